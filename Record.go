@@ -2,6 +2,10 @@ package customstore
 
 import (
 	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/dracory/neat/database/orm"
 	"github.com/dracory/neat/database/soft_delete"
@@ -22,6 +26,15 @@ type RecordInterface interface {
 	CreatedAtCarbon() *carbon.Carbon
 	SetCreatedAt(createdAt string)
 
+	CreatedBy() string
+	SetCreatedBy(actor string) error
+
+	PayloadVersion() int
+	SetPayloadVersion(version int) error
+
+	IsProtected() bool
+	SetProtected(protected bool) error
+
 	ID() string
 	SetID(id string)
 
@@ -31,28 +44,99 @@ type RecordInterface interface {
 	Meta(name string) string
 	SetMeta(name, value string) error
 
+	// Typed meta accessors, returning ErrMetaNotFound when the meta is
+	// missing and the parse error when it does not hold the type. Times
+	// are stored as RFC 3339 in UTC.
+	MetaInt(name string) (int, error)
+	MetaBool(name string) (bool, error)
+	MetaFloat(name string) (float64, error)
+	MetaTime(name string) (time.Time, error)
+	SetMetaInt(name string, value int) error
+	SetMetaBool(name string, value bool) error
+	SetMetaFloat(name string, value float64) error
+	SetMetaTime(name string, value time.Time) error
+
 	Metas() (map[string]string, error)
 	SetMetas(metas map[string]string) error
 	UpsertMetas(metas map[string]string) error
 
+	// DeleteMeta removes the meta, doing nothing if it is not set. MetaKeys
+	// returns the sorted names of the metas.
+	DeleteMeta(name string) error
+	HasMeta(name string) bool
+	MetaKeys() []string
+
 	Memo() string
 	SetMemo(memo string)
 
 	Payload() string
 	SetPayload(payload string)
 
+	// IsPayloadLoaded returns false for records listed with the payload
+	// excluded (RecordQuery.SetExcludePayload), see Store.RecordLoadPayload
+	IsPayloadLoaded() bool
+
+	// IsMetasLoaded returns false for records listed with the metas
+	// excluded (RecordQuery.SetExcludeMetas), see Store.RecordLoadPayload
+	IsMetasLoaded() bool
+
 	PayloadMap() (map[string]any, error)
 	SetPayloadMap(payloadMap map[string]any) error
+
+	// PayloadStruct decodes the payload into dest (a pointer), leaving it
+	// unchanged if the payload is empty. SetPayloadStruct encodes src as
+	// the payload.
+	PayloadStruct(dest any) error
+	SetPayloadStruct(src any) error
+
+	// PatchPayload applies a JSON merge patch (RFC 7386) to the payload,
+	// i.e. `{"status":"paid","draft":null}` sets status and removes draft
+	PatchPayload(jsonPatch string) error
+
+	// PayloadMapKey and SetPayloadMapKey accept paths of nested keys and
+	// array indexes, i.e. "customer.address.city" or "items.0.sku", the
+	// setter creating the missing intermediate objects
 	PayloadMapKey(key string) (any, error)
 	SetPayloadMapKey(key string, value any) error
+	PayloadHasKey(key string) bool
+	PayloadSubset(keys []string) (map[string]any, error)
+
+	// Array helpers for the payload array at the path. PayloadAppend
+	// creates the array if the path is missing, PayloadArrayLen returns 0
+	// for a missing path. A value at the path which is not an array
+	// returns an error.
+	PayloadAppend(path string, value any) error
+	PayloadRemoveAt(path string, index int) error
+	PayloadArrayLen(path string) (int, error)
+
+	// Payload accessors returning the default when the key is missing,
+	// null, not convertible or the payload cannot be decoded
+	PayloadMapKeyOr(key string, def any) any
+	PayloadString(key string, def string) string
+	PayloadInt(key string, def int) int
+	PayloadBool(key string, def bool) bool
 
 	SoftDeletedAt() string
 	SoftDeletedAtCarbon() *carbon.Carbon
 	SetSoftDeletedAt(softDeletedAt string)
 
+	// ExpiresAt is MAX_DATETIME for records which never expire. Expired
+	// records are excluded from queries, see RecordQuery.SetExpiredIncluded
+	IsExpired() bool
+	ExpiresAt() string
+	ExpiresAtCarbon() *carbon.Carbon
+	SetExpiresAt(expiresAt string)
+
 	UpdatedAt() string
 	UpdatedAtCarbon() *carbon.Carbon
 	SetUpdatedAt(updatedAt string)
+
+	// Touch sets the updated at to the current time, see
+	// StoreInterface.RecordTouch to store it
+	Touch()
+
+	Version() int64
+	SetVersion(version int64)
 }
 
 // ============================================================================
@@ -62,23 +146,49 @@ type RecordInterface interface {
 var _ RecordInterface = (*recordImplementation)(nil)
 
 type recordImplementation struct {
-	IDField        string `db:"id"`
-	TypeField      string `db:"record_type"`
-	PayloadField   string `db:"payload"`
-	MetasField     string `db:"metas"`
-	MemoField      string `db:"memo"`
+	IDField        string    `db:"id"`
+	TypeField      string    `db:"record_type"`
+	PayloadField   string    `db:"payload"`
+	MetasField     string    `db:"metas"`
+	MemoField      string    `db:"memo"`
+	VersionField   int64     `db:"version"`
+	ExpiresAtField time.Time `db:"expires_at"`
 	CreatedAtField orm.CreatedAt
 	UpdatedAtField orm.UpdatedAt
 	soft_delete.SoftDeletesMaxDate
+
+	// payloadExcluded and metasExcluded mark the columns not selected when
+	// the record was listed, which are then not written back on update
+	payloadExcluded bool
+	metasExcluded   bool
+
+	// idGenerated marks the default ID assigned by NewRecord, replaced by
+	// the IDGenerator of the store on create
+	idGenerated bool
+
+	// ttl is the time to live of WithTTL, counted from the time of the
+	// store on create
+	ttl time.Duration
 }
 
 // ============================================================================
 // == CONSTRUCTORS
 // ============================================================================
 
+// NewRecord creates a new record of the given type, applying the options.
+// Option errors are ignored, use NewRecordE to have them returned.
 func NewRecord(recordType string, opts ...RecordOption) RecordInterface {
+	record, _ := NewRecordE(recordType, opts...)
+	return record
+}
+
+// NewRecordE creates a new record of the given type, applying the options
+// and returning the errors of all failing options joined together.
+// The record is returned even on error, with the successful options applied.
+func NewRecordE(recordType string, opts ...RecordOption) (RecordInterface, error) {
 	record := &recordImplementation{}
 	record.SetID(neatuid.GenerateShortID())
+	record.idGenerated = true
 	record.SetType(recordType)
 	record.SetMemo("")
 	record.SetMetas(map[string]string{})
@@ -86,17 +196,24 @@ func NewRecord(recordType string, opts ...RecordOption) RecordInterface {
 	record.SetCreatedAt(carbon.Now(carbon.UTC).ToDateTimeString())
 	record.SetUpdatedAt(carbon.Now(carbon.UTC).ToDateTimeString())
 	record.SetSoftDeletedAt(MAX_DATETIME)
+	record.SetExpiresAt(MAX_DATETIME)
+	record.SetVersion(1)
 
-	// Apply functional options, ignore errors to keep constructor signature simple
+	errs := []error{}
 	for _, opt := range opts {
-		_ = opt(record)
+		if err := opt(record); err != nil {
+			errs = append(errs, err)
+		}
 	}
 
-	return record
+	return record, errors.Join(errs...)
 }
 
 func NewRecordFromExistingData(data map[string]string) RecordInterface {
+	// Not soft deleted and never expiring unless given, as NewRecord
 	o := &recordImplementation{}
+	o.SetSoftDeletedAt(MAX_DATETIME)
+	o.SetExpiresAt(MAX_DATETIME)
 	if v, ok := data[COLUMN_ID]; ok {
 		o.SetID(v)
 	}
@@ -121,6 +238,12 @@ func NewRecordFromExistingData(data map[string]string) RecordInterface {
 	if v, ok := data[COLUMN_SOFT_DELETED_AT]; ok {
 		o.SetSoftDeletedAt(v)
 	}
+	if v, ok := data[COLUMN_EXPIRES_AT]; ok {
+		o.SetExpiresAt(v)
+	}
+	if v, ok := data[COLUMN_VERSION]; ok {
+		o.SetVersion(cast.ToInt64(v))
+	}
 	return o
 }
 
@@ -128,8 +251,10 @@ func NewRecordFromExistingData(data map[string]string) RecordInterface {
 // == METHODS
 // ============================================================================
 
+// IsSoftDeleted returns true once the soft deleted at is reached, as the
+// queries of the store, which only list records soft deleted in the future
 func (o *recordImplementation) IsSoftDeleted() bool {
-	return o.SoftDeletesMaxDate.SoftDeletedAt.Before(carbon.Now(carbon.UTC).StdTime())
+	return !o.SoftDeletesMaxDate.SoftDeletedAt.After(carbon.Now(carbon.UTC).StdTime())
 }
 
 // ============================================================================
@@ -154,6 +279,57 @@ func (o *recordImplementation) SetCreatedAt(createdAt string) {
 	o.CreatedAtField.CreatedAt = carbon.Parse(createdAt, carbon.UTC).StdTime()
 }
 
+// CreatedBy returns the actor who created the record, if recorded
+func (o *recordImplementation) CreatedBy() string {
+	return o.Meta(META_CREATED_BY)
+}
+
+// SetCreatedBy records the actor who created the record (stored as a reserved meta)
+func (o *recordImplementation) SetCreatedBy(actor string) error {
+	return o.SetMeta(META_CREATED_BY, actor)
+}
+
+// PayloadVersion returns the payload schema version, 1 if not recorded
+func (o *recordImplementation) PayloadVersion() int {
+	version := cast.ToInt(o.Meta(META_PAYLOAD_VERSION))
+	if version < 1 {
+		return 1
+	}
+	return version
+}
+
+// SetPayloadVersion records the payload schema version (stored as a reserved meta)
+func (o *recordImplementation) SetPayloadVersion(version int) error {
+	if version < 1 {
+		return errors.New("payload version must be at least 1")
+	}
+	return o.SetMeta(META_PAYLOAD_VERSION, cast.ToString(version))
+}
+
+// IsProtected returns true if the record is protected from deletion
+func (o *recordImplementation) IsProtected() bool {
+	return o.Meta(META_PROTECTED) == "1"
+}
+
+// SetProtected protects the record from soft deletion, hard deletion and
+// purging, unless forced (stored as a reserved meta)
+func (o *recordImplementation) SetProtected(protected bool) error {
+	if protected {
+		return o.SetMeta(META_PROTECTED, "1")
+	}
+
+	if o.metasExcluded {
+		return ErrNotLoaded
+	}
+
+	metas, err := o.Metas()
+	if err != nil {
+		return err
+	}
+	delete(metas, META_PROTECTED)
+	return o.SetMetas(metas)
+}
+
 func (o *recordImplementation) Type() string {
 	return o.TypeField
 }
@@ -168,6 +344,11 @@ func (o *recordImplementation) ID() string {
 
 func (o *recordImplementation) SetID(id string) {
 	o.IDField = id
+	o.idGenerated = false
+}
+
+func (o *recordImplementation) isIDGenerated() bool {
+	return o.idGenerated
 }
 
 func (o *recordImplementation) Memo() string {
@@ -216,6 +397,79 @@ func (o *recordImplementation) SetMeta(name, value string) error {
 	return o.UpsertMetas(map[string]string{name: value})
 }
 
+// metaValue returns the meta, or ErrMetaNotFound if it is not set
+func (o *recordImplementation) metaValue(name string) (string, error) {
+	metas, err := o.Metas()
+	if err != nil {
+		return "", err
+	}
+
+	value, exists := metas[name]
+	if !exists {
+		return "", ErrMetaNotFound
+	}
+	return value, nil
+}
+
+func (o *recordImplementation) MetaInt(name string) (int, error) {
+	value, err := o.metaValue(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+func (o *recordImplementation) MetaBool(name string) (bool, error) {
+	value, err := o.metaValue(name)
+	if err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(value)
+}
+
+func (o *recordImplementation) MetaFloat(name string) (float64, error) {
+	value, err := o.metaValue(name)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// MetaTime parses the meta as RFC 3339, or as a date time in UTC as
+// formatted by MAX_DATETIME
+func (o *recordImplementation) MetaTime(name string) (time.Time, error) {
+	value, err := o.metaValue(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		datetime, datetimeErr := time.Parse(time.DateTime, value)
+		if datetimeErr != nil {
+			return time.Time{}, err
+		}
+		t = datetime
+	}
+	return t.UTC(), nil
+}
+
+func (o *recordImplementation) SetMetaInt(name string, value int) error {
+	return o.SetMeta(name, strconv.Itoa(value))
+}
+
+func (o *recordImplementation) SetMetaBool(name string, value bool) error {
+	return o.SetMeta(name, strconv.FormatBool(value))
+}
+
+func (o *recordImplementation) SetMetaFloat(name string, value float64) error {
+	return o.SetMeta(name, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (o *recordImplementation) SetMetaTime(name string, value time.Time) error {
+	return o.SetMeta(name, value.UTC().Format(time.RFC3339Nano))
+}
+
 // SetMetas stores metas as json string
 // Warning: it overwrites any existing metas
 func (o *recordImplementation) SetMetas(metas map[string]string) error {
@@ -224,15 +478,25 @@ func (o *recordImplementation) SetMetas(metas map[string]string) error {
 		return err
 	}
 	o.MetasField = string(mapString)
+	o.metasExcluded = false
 	return nil
 }
 
 // SetMetasRaw sets the metas field directly from a raw JSON string
 func (o *recordImplementation) SetMetasRaw(metasStr string) {
 	o.MetasField = metasStr
+	o.metasExcluded = false
+}
+
+func (o *recordImplementation) IsMetasLoaded() bool {
+	return !o.metasExcluded
 }
 
 func (o *recordImplementation) UpsertMetas(metas map[string]string) error {
+	if o.metasExcluded {
+		return ErrNotLoaded
+	}
+
 	currentMetas, err := o.Metas()
 
 	if err != nil {
@@ -246,12 +510,60 @@ func (o *recordImplementation) UpsertMetas(metas map[string]string) error {
 	return o.SetMetas(currentMetas)
 }
 
+func (o *recordImplementation) DeleteMeta(name string) error {
+	if o.metasExcluded {
+		return ErrNotLoaded
+	}
+
+	metas, err := o.Metas()
+	if err != nil {
+		return err
+	}
+
+	if _, exists := metas[name]; !exists {
+		return nil
+	}
+
+	delete(metas, name)
+	return o.SetMetas(metas)
+}
+
+// HasMeta returns true if the meta is set (even if empty)
+func (o *recordImplementation) HasMeta(name string) bool {
+	metas, err := o.Metas()
+	if err != nil {
+		return false
+	}
+
+	_, exists := metas[name]
+	return exists
+}
+
+func (o *recordImplementation) MetaKeys() []string {
+	metas, err := o.Metas()
+	if err != nil {
+		return []string{}
+	}
+
+	keys := make([]string, 0, len(metas))
+	for key := range metas {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 func (o *recordImplementation) Payload() string {
 	return o.PayloadField
 }
 
 func (o *recordImplementation) SetPayload(payload string) {
 	o.PayloadField = payload
+	o.payloadExcluded = false
+}
+
+func (o *recordImplementation) IsPayloadLoaded() bool {
+	return !o.payloadExcluded
 }
 
 func (r *recordImplementation) PayloadMap() (map[string]any, error) {
@@ -280,13 +592,56 @@ func (record *recordImplementation) SetPayloadMap(metas map[string]any) error {
 	return nil
 }
 
+func (record *recordImplementation) PayloadStruct(dest any) error {
+	if record.Payload() == "" {
+		return nil
+	}
+
+	return json.Unmarshal([]byte(record.Payload()), dest)
+}
+
+func (record *recordImplementation) SetPayloadStruct(src any) error {
+	jsonBytes, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	record.SetPayload(string(jsonBytes))
+	return nil
+}
+
+func (record *recordImplementation) PatchPayload(jsonPatch string) error {
+	if record.payloadExcluded {
+		return ErrNotLoaded
+	}
+
+	patch, err := decodeJSONValue(jsonPatch)
+	if err != nil {
+		return err
+	}
+
+	var target any
+	if record.Payload() != "" {
+		target, err = decodeJSONValue(record.Payload())
+		if err != nil {
+			return err
+		}
+	}
+
+	jsonBytes, err := json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return err
+	}
+	record.SetPayload(string(jsonBytes))
+	return nil
+}
+
 func (record *recordImplementation) PayloadMapKey(key string) (any, error) {
 	data, err := record.PayloadMap()
 	if err != nil {
 		return nil, err
 	}
 
-	value, exists := data[key]
+	value, exists := payloadPathLookup(data, key)
 	if !exists {
 		return nil, nil
 	}
@@ -295,16 +650,146 @@ func (record *recordImplementation) PayloadMapKey(key string) (any, error) {
 }
 
 func (record *recordImplementation) SetPayloadMapKey(key string, value any) error {
+	if record.payloadExcluded {
+		return ErrNotLoaded
+	}
+
+	data, err := record.PayloadMap()
+	if err != nil {
+		return err
+	}
+
+	if err := payloadPathSet(data, key, value); err != nil {
+		return err
+	}
+
+	return record.SetPayloadMap(data)
+}
+
+// PayloadHasKey returns true if the payload contains the key or path (even
+// if null)
+func (record *recordImplementation) PayloadHasKey(key string) bool {
+	data, err := record.PayloadMap()
+	if err != nil {
+		return false
+	}
+
+	_, exists := payloadPathLookup(data, key)
+	return exists
+}
+
+// PayloadSubset returns only the requested payload keys, keys missing from
+// the payload are omitted
+func (record *recordImplementation) PayloadSubset(keys []string) (map[string]any, error) {
+	data, err := record.PayloadMap()
+	if err != nil {
+		return nil, err
+	}
+
+	subset := make(map[string]any, len(keys))
+	for _, key := range keys {
+		if value, exists := data[key]; exists {
+			subset[key] = value
+		}
+	}
+
+	return subset, nil
+}
+
+func (record *recordImplementation) PayloadAppend(path string, value any) error {
+	if record.payloadExcluded {
+		return ErrNotLoaded
+	}
+
+	data, err := record.PayloadMap()
+	if err != nil {
+		return err
+	}
+
+	array, err := payloadPathArray(data, path)
+	if err != nil {
+		return err
+	}
+
+	if err := payloadPathSet(data, path, append(array, value)); err != nil {
+		return err
+	}
+
+	return record.SetPayloadMap(data)
+}
+
+func (record *recordImplementation) PayloadRemoveAt(path string, index int) error {
+	if record.payloadExcluded {
+		return ErrNotLoaded
+	}
+
 	data, err := record.PayloadMap()
 	if err != nil {
 		return err
 	}
 
-	data[key] = value
+	array, err := payloadPathArray(data, path)
+	if err != nil {
+		return err
+	}
+
+	if index < 0 || index >= len(array) {
+		return errors.New("payload path " + path + ": no index " + strconv.Itoa(index) + " in the array")
+	}
+
+	if err := payloadPathSet(data, path, append(array[:index:index], array[index+1:]...)); err != nil {
+		return err
+	}
 
 	return record.SetPayloadMap(data)
 }
 
+func (record *recordImplementation) PayloadArrayLen(path string) (int, error) {
+	data, err := record.PayloadMap()
+	if err != nil {
+		return 0, err
+	}
+
+	array, err := payloadPathArray(data, path)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(array), nil
+}
+
+func (record *recordImplementation) PayloadMapKeyOr(key string, def any) any {
+	value, err := record.PayloadMapKey(key)
+	if err != nil || value == nil {
+		return def
+	}
+	return value
+}
+
+func (record *recordImplementation) PayloadString(key string, def string) string {
+	value, err := cast.ToStringE(record.PayloadMapKeyOr(key, def))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+func (record *recordImplementation) PayloadInt(key string, def int) int {
+	value, err := cast.ToIntE(record.PayloadMapKeyOr(key, def))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+func (record *recordImplementation) PayloadBool(key string, def bool) bool {
+	value, err := cast.ToBoolE(record.PayloadMapKeyOr(key, def))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
 func (o *recordImplementation) SoftDeletedAt() string {
 	if o.SoftDeletesMaxDate.SoftDeletedAt.IsZero() {
 		return ""
@@ -323,6 +808,46 @@ func (o *recordImplementation) SetSoftDeletedAt(softDeletedAt string) {
 	o.SoftDeletesMaxDate.SoftDeletedAt = carbon.Parse(softDeletedAt, carbon.UTC).StdTime()
 }
 
+func (o *recordImplementation) IsExpired() bool {
+	return !o.ExpiresAtField.IsZero() && !o.ExpiresAtField.After(carbon.Now(carbon.UTC).StdTime())
+}
+
+func (o *recordImplementation) ExpiresAt() string {
+	if o.ExpiresAtField.IsZero() {
+		return ""
+	}
+	return carbon.CreateFromStdTime(o.ExpiresAtField).ToDateTimeString()
+}
+
+func (o *recordImplementation) ExpiresAtCarbon() *carbon.Carbon {
+	return carbon.CreateFromStdTime(o.ExpiresAtField)
+}
+
+func (o *recordImplementation) SetExpiresAt(expiresAt string) {
+	if expiresAt == "" {
+		return
+	}
+	o.ExpiresAtField = carbon.Parse(expiresAt, carbon.UTC).StdTime()
+	o.ttl = 0
+}
+
+func (o *recordImplementation) setTTL(ttl time.Duration) {
+	o.SetExpiresAt(time.Now().UTC().Add(ttl).Format(time.DateTime))
+	o.ttl = ttl
+}
+
+func (o *recordImplementation) pendingTTL() time.Duration {
+	return o.ttl
+}
+
+func (o *recordImplementation) Version() int64 {
+	return o.VersionField
+}
+
+func (o *recordImplementation) SetVersion(version int64) {
+	o.VersionField = version
+}
+
 func (o *recordImplementation) UpdatedAt() string {
 	if o.UpdatedAtField.UpdatedAt.IsZero() {
 		return ""
@@ -340,3 +865,7 @@ func (o *recordImplementation) SetUpdatedAt(updatedAt string) {
 	}
 	o.UpdatedAtField.UpdatedAt = carbon.Parse(updatedAt, carbon.UTC).StdTime()
 }
+
+func (o *recordImplementation) Touch() {
+	o.UpdatedAtField.UpdatedAt = carbon.Now(carbon.UTC).StdTime()
+}
@@ -0,0 +1,27 @@
+package customstore
+
+import "time"
+
+// Clock provides the current time used by the store for timestamps and
+// soft delete comparisons, i.e. to make time dependent behaviour
+// deterministic in tests
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the default clock, returning the system time
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// now returns the current store time in UTC
+func (st *storeImplementation) now() time.Time {
+	return st.clock.Now().UTC()
+}
+
+// nowDateTime returns the current store time formatted as stored
+func (st *storeImplementation) nowDateTime() string {
+	return st.now().Format(time.DateTime)
+}
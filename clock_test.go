@@ -0,0 +1,76 @@
+package customstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+func TestStoreClock(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	clock := &fixedClock{now: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_clock",
+		AutomigrateEnabled: true,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person", customstore.WithSoftDeletedAt(clock.now.Add(time.Hour)))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if record.CreatedAt() != "2030-01-02 03:04:05" {
+		t.Fatalf("Expected created at from the clock, but got %q", record.CreatedAt())
+	}
+
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found == nil {
+		t.Fatalf("Expected record to be active before its soft deleted at")
+	}
+	if found.UpdatedAt() != "2030-01-02 03:04:05" {
+		t.Fatalf("Expected updated at from the clock, but got %q", found.UpdatedAt())
+	}
+
+	clock.now = clock.now.Add(2 * time.Hour)
+
+	found, err = store.RecordFindByID(record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatalf("Expected record to be soft deleted once the clock passes its soft deleted at")
+	}
+
+	clock.now = time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+	if err := store.RecordSoftDeleteByID(record.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetID(record.ID()).SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 || list[0].SoftDeletedAt() != "2030-06-01 00:00:00" {
+		t.Fatalf("Expected soft deleted at from the clock, but got %v", list)
+	}
+}
@@ -1,13 +1,36 @@
 package customstore
 
 const COLUMN_CREATED_AT = "created_at"
+const COLUMN_EXPIRES_AT = "expires_at"
 const COLUMN_ID = "id"
 const COLUMN_MEMO = "memo"
 const COLUMN_METAS = "metas"
 const COLUMN_PAYLOAD = "payload"
 const COLUMN_RECORD_TYPE = "record_type"
 const COLUMN_SOFT_DELETED_AT = "soft_deleted_at"
+const COLUMN_TENANT_ID = "tenant_id"
 const COLUMN_UPDATED_AT = "updated_at"
+const COLUMN_VERSION = "version"
 
-// MAX_DATETIME is a far-future datetime used as the default soft-delete sentinel.
+// ORDER_ASC and ORDER_DESC are the directions of RecordQuery.AddOrderBy
+const ORDER_ASC = "asc"
+const ORDER_DESC = "desc"
+
+// MAX_DATETIME is a far-future datetime used as the default soft-delete and
+// expiry sentinel.
 const MAX_DATETIME = "9999-12-31 23:59:59"
+
+// RESERVED_META_PREFIX marks meta names reserved for internal use by the store.
+const RESERVED_META_PREFIX = "_"
+
+// META_CREATED_BY is the reserved meta holding the actor who created the record.
+const META_CREATED_BY = RESERVED_META_PREFIX + "created_by"
+
+// META_PAYLOAD_VERSION is the reserved meta holding the payload schema version.
+const META_PAYLOAD_VERSION = RESERVED_META_PREFIX + "payload_version"
+
+// META_PROTECTED is the reserved meta marking a record protected from deletion.
+const META_PROTECTED = RESERVED_META_PREFIX + "protected"
+
+// PAYLOAD_KEY_PREFIX prefixes a payload key selected as a column, i.e. "payload.name".
+const PAYLOAD_KEY_PREFIX = COLUMN_PAYLOAD + "."
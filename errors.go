@@ -0,0 +1,160 @@
+package customstore
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrTypeFilterRequired is returned when the store requires a type filter
+// (NewStoreOptions.RequireTypeFilter) and the query has none
+var ErrTypeFilterRequired = errors.New("customstore: query requires a type filter")
+
+// ErrReadOnly is returned by the mutating methods of a read only store
+var ErrReadOnly = errors.New("customstore: store is read only")
+
+// ErrProtectedRecord is returned when deleting a protected record without
+// the WithForceDelete option
+var ErrProtectedRecord = errors.New("customstore: record is protected")
+
+// ErrStaleRecord is returned by RecordUpdateVersioned when the stored record
+// was updated since the record was read
+var ErrStaleRecord = errors.New("customstore: record was modified concurrently")
+
+// ErrNotFound is returned when updating, patching, incrementing, touching,
+// cloning, restoring, rolling back or linking a record which is not stored
+var ErrNotFound = errors.New("customstore: record not found")
+
+// ErrFullTextSearchDisabled is returned when a query uses full text search
+// on a store without NewStoreOptions.FullTextSearchEnabled
+var ErrFullTextSearchDisabled = errors.New("customstore: full text search is not enabled")
+
+// ErrRegexUnsupported is returned when a query matches the payload by
+// regular expression on a database without regular expression support
+var ErrRegexUnsupported = errors.New("customstore: regular expressions are not supported by the database")
+
+// ErrUnhealthy is matched by the errors of Healthy when the database is
+// reachable but the table or its schema is not ready
+var ErrUnhealthy = errors.New("customstore: store is unhealthy")
+
+// ErrDuplicate is matched by the errors of the writes giving a record the
+// value of a unique key of another record of its type, see
+// EnsureUniquePayloadKey
+var ErrDuplicate = errors.New("customstore: duplicate value of a unique key")
+
+// ErrNotLoaded is returned when modifying part of a payload or metas which
+// were excluded when the record was listed
+var ErrNotLoaded = errors.New("customstore: column is not loaded")
+
+// ErrMetaNotFound is returned by the typed meta accessors of the records,
+// i.e. MetaInt, when the meta is not set
+var ErrMetaNotFound = errors.New("customstore: meta not found")
+
+// ErrUnknownRecordType is returned when creating a record of a type which
+// is not registered, see RegisterRecordType
+var ErrUnknownRecordType = errors.New("customstore: unknown record type")
+
+// ErrRecordConstraint is returned when creating a record which does not
+// satisfy the definition of its type, see RegisterRecordType
+var ErrRecordConstraint = errors.New("customstore: record violates the constraints of its type")
+
+// OperationError wraps an error returned by the database while executing a
+// store operation, recording where the failure happened.
+//
+// Use errors.As to inspect it:
+//
+//	var opErr *customstore.OperationError
+//	if errors.As(err, &opErr) {
+//		log.Println(opErr.Op, opErr.RecordID, opErr.SQL)
+//	}
+type OperationError struct {
+	// Op is the name of the store operation, i.e. "RecordCreate"
+	Op string
+
+	// RecordID is the ID of the record involved, if known
+	RecordID string
+
+	// RecordType is the type of the record involved, if known
+	RecordType string
+
+	// SQL is the statement that failed, only populated in debug mode
+	SQL string
+
+	// Err is the underlying error
+	Err error
+}
+
+// Error returns the error message prefixed with the operation context
+func (e *OperationError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("customstore: ")
+	sb.WriteString(e.Op)
+
+	details := []string{}
+	if e.RecordID != "" {
+		details = append(details, "id="+e.RecordID)
+	}
+	if e.RecordType != "" {
+		details = append(details, "type="+e.RecordType)
+	}
+	if len(details) > 0 {
+		sb.WriteString(" (" + strings.Join(details, ", ") + ")")
+	}
+
+	if e.Err != nil {
+		sb.WriteString(": ")
+		sb.WriteString(e.Err.Error())
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the underlying error
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// wrapError wraps err in an OperationError for the given operation. The sql
+// function is only called in debug mode. Returns nil if err is nil.
+func (st *storeImplementation) wrapError(err error, op string, recordID string, recordType string, sql func() string) error {
+	if err == nil {
+		return nil
+	}
+
+	var existing *OperationError
+	if errors.As(err, &existing) {
+		return err
+	}
+
+	opErr := &OperationError{
+		Op:         op,
+		RecordID:   recordID,
+		RecordType: recordType,
+		Err:        err,
+	}
+
+	if st.debugEnabled && sql != nil {
+		opErr.SQL = sql()
+	}
+
+	if st.debugEnabled {
+		st.logger.Error("Store operation failed", "op", op, "id", recordID, "type", recordType, "sql", opErr.SQL, "error", err)
+	}
+
+	return opErr
+}
+
+// queryRecordID returns the record ID filtered by the query, if any
+func queryRecordID(query RecordQueryInterface) string {
+	if query != nil && query.IsIDSet() {
+		return query.GetID()
+	}
+	return ""
+}
+
+// queryRecordType returns the record type filtered by the query, if any
+func queryRecordType(query RecordQueryInterface) string {
+	if query != nil && query.IsTypeSet() {
+		return query.GetType()
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package customstore_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestOperationErrorOnCreate(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_operation_error_create",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person")
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	store.EnableDebug(true)
+	err = store.RecordCreate(customstore.NewRecord("person", customstore.WithID(record.ID())))
+	store.EnableDebug(false)
+	if err == nil {
+		t.Fatalf("Expected error when creating a record with a duplicate ID, but got nil")
+	}
+
+	var opErr *customstore.OperationError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("Expected an OperationError, but got %T: %v", err, err)
+	}
+	if opErr.Op != "RecordCreate" {
+		t.Errorf("Expected Op %q, but got %q", "RecordCreate", opErr.Op)
+	}
+	if opErr.RecordID != record.ID() {
+		t.Errorf("Expected RecordID %q, but got %q", record.ID(), opErr.RecordID)
+	}
+	if opErr.RecordType != "person" {
+		t.Errorf("Expected RecordType %q, but got %q", "person", opErr.RecordType)
+	}
+	if !strings.Contains(strings.ToUpper(opErr.SQL), "INSERT") {
+		t.Errorf("Expected SQL to contain the INSERT statement in debug mode, but got %q", opErr.SQL)
+	}
+	if opErr.Unwrap() == nil {
+		t.Errorf("Expected the underlying error to be wrapped")
+	}
+	if !strings.HasPrefix(err.Error(), "customstore: RecordCreate (id="+record.ID()+", type=person): ") {
+		t.Errorf("Unexpected error message %q", err.Error())
+	}
+}
+
+func TestOperationErrorOnList(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_operation_error_list",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if _, err := db.Exec("DROP TABLE data_operation_error_list"); err != nil {
+		t.Fatalf("Dropping the table failed: %v", err)
+	}
+
+	_, err = store.RecordList(customstore.RecordQuery().SetType("person"))
+	if err == nil {
+		t.Fatalf("Expected error when listing from a missing table, but got nil")
+	}
+
+	var opErr *customstore.OperationError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("Expected an OperationError, but got %T: %v", err, err)
+	}
+	if opErr.Op != "RecordList" {
+		t.Errorf("Expected Op %q, but got %q", "RecordList", opErr.Op)
+	}
+	if opErr.SQL != "" {
+		t.Errorf("Expected SQL to be empty outside debug mode, but got %q", opErr.SQL)
+	}
+
+	_, err = store.RecordCount(customstore.RecordQuery().SetType("person"))
+	if !errors.As(err, &opErr) || opErr.Op != "RecordCount" || opErr.RecordType != "person" {
+		t.Errorf("Expected RecordCount OperationError for type person, but got %v", err)
+	}
+}
+
+func TestErrNotFound(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_error_not_found",
+		AutomigrateEnabled: true,
+		RevisionsEnabled:   true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	missing := customstore.NewRecord("person", customstore.WithPayload(`{"name":"Ann"}`))
+	if err := store.RecordCreate(missing); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	listed, err := store.RecordList(customstore.RecordQuery().SetID(missing.ID()).SetExcludePayload(true))
+	if err != nil || len(listed) != 1 {
+		t.Fatalf("RecordList failed: %v, %v", listed, err)
+	}
+	if err := store.RecordDeleteByID(missing.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+
+	_, cloneErr := store.RecordClone(missing.ID())
+	for name, err := range map[string]error{
+		"RecordUpdate":              store.RecordUpdate(missing),
+		"RecordPatchPayloadByID":    store.RecordPatchPayloadByID(missing.ID(), `{"age":30}`),
+		"RecordIncrementPayloadKey": store.RecordIncrementPayloadKey(missing.ID(), "visits", 1),
+		"RecordTouch":               store.RecordTouch(missing.ID()),
+		"RecordLoadPayload":         store.RecordLoadPayload(listed[0]),
+		"RecordClone":               cloneErr,
+		"RecordRollback":            store.RecordRollback(missing.ID(), 1),
+		"RecordRestoreByID":         store.RecordRestoreByID(missing.ID()),
+	} {
+		if !errors.Is(err, customstore.ErrNotFound) {
+			t.Fatalf("Expected ErrNotFound from %s, but got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package customstore
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// GenerateUUIDv4 returns a random UUID (version 4, RFC 9562) in its 36
+// characters text form
+func GenerateUUIDv4() string {
+	var uuid [16]byte
+	rand.Read(uuid[:])
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return formatUUID(uuid)
+}
+
+// GenerateUUIDv7 returns a UUID (version 7, RFC 9562) leading with the
+// milliseconds since the Unix epoch, so the IDs sort by creation time to
+// the millisecond
+func GenerateUUIDv7() string {
+	var uuid [16]byte
+	rand.Read(uuid[6:])
+	putMilliseconds(uuid[:6], time.Now())
+	uuid[6] = uuid[6]&0x0f | 0x70
+	uuid[8] = uuid[8]&0x3f | 0x80
+	return formatUUID(uuid)
+}
+
+// formatUUID returns the text form of the UUID, the hexadecimal digits in
+// groups of 8-4-4-4-12
+func formatUUID(uuid [16]byte) string {
+	encoded := hex.EncodeToString(uuid[:])
+	return encoded[:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" + encoded[16:20] + "-" + encoded[20:]
+}
+
+// crockfordAlphabet is the base 32 alphabet of ULIDs
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// GenerateULID returns a ULID: 26 characters of Crockford's base 32
+// encoding the milliseconds since the Unix epoch followed by 80 random
+// bits, so the IDs sort by creation time to the millisecond
+func GenerateULID() string {
+	var ulid [16]byte
+	putMilliseconds(ulid[:6], time.Now())
+	rand.Read(ulid[6:])
+
+	// The 128 bits are encoded 5 at a time from the last, the first of
+	// the 26 characters holding the 3 leading bits
+	hi := binary.BigEndian.Uint64(ulid[:8])
+	lo := binary.BigEndian.Uint64(ulid[8:])
+	encoded := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		encoded[i] = crockfordAlphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(encoded)
+}
+
+// putMilliseconds writes the milliseconds since the Unix epoch of the time
+// as a 48 bit big endian integer
+func putMilliseconds(dst []byte, t time.Time) {
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	copy(dst, ms[2:])
+}
+
+// ksuidEpoch is the epoch of the timestamps of KSUIDs, in Unix seconds
+const ksuidEpoch = 1400000000
+
+// base62Alphabet is the alphabet of KSUIDs
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// GenerateKSUID returns a KSUID: 27 characters of base 62 encoding the
+// seconds since the KSUID epoch followed by 128 random bits, so the IDs
+// sort by creation time to the second
+func GenerateKSUID() string {
+	var ksuid [20]byte
+	binary.BigEndian.PutUint32(ksuid[:4], uint32(time.Now().Unix()-ksuidEpoch))
+	rand.Read(ksuid[4:])
+
+	// Padded with zeros to the 27 characters of the largest value, so the
+	// text sorts as the bytes
+	n := new(big.Int).SetBytes(ksuid[:])
+	base := big.NewInt(62)
+	digit := new(big.Int)
+	var encoded strings.Builder
+	digits := make([]byte, 0, 27)
+	for n.Sign() > 0 {
+		n.DivMod(n, base, digit)
+		digits = append(digits, base62Alphabet[digit.Int64()])
+	}
+	for range 27 - len(digits) {
+		encoded.WriteByte('0')
+	}
+	for i := len(digits) - 1; i >= 0; i-- {
+		encoded.WriteByte(digits[i])
+	}
+	return encoded.String()
+}
+
+// generatedIDRecord is implemented by the records able to report whether
+// their ID is the default one assigned by NewRecord, which the IDGenerator
+// of the store replaces
+type generatedIDRecord interface {
+	isIDGenerated() bool
+}
+
+// assignID replaces the default ID of the record with one of the
+// IDGenerator of the store, prefixed for the record types with an ID
+// prefix, if any
+func (st *storeImplementation) assignID(record RecordInterface) {
+	if generated, ok := record.(generatedIDRecord); !ok || !generated.isIDGenerated() {
+		return
+	}
+
+	prefix, prefixed := st.idPrefixes.prefix(record.Type())
+	if st.idGenerator == nil && !prefixed {
+		return
+	}
+
+	generate := st.idGenerator
+	if generate == nil {
+		generate = GenerateULID
+	}
+
+	id := generate()
+	if prefixed {
+		id = prefix + idPrefixSeparator + id
+	}
+	record.SetID(id)
+}
@@ -0,0 +1,106 @@
+package customstore_test
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestIDGenerators(t *testing.T) {
+	formats := map[string]struct {
+		generate func() string
+		pattern  *regexp.Regexp
+	}{
+		"uuidv4": {customstore.GenerateUUIDv4, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		"uuidv7": {customstore.GenerateUUIDv7, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		"ulid":   {customstore.GenerateULID, regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)},
+		"ksuid":  {customstore.GenerateKSUID, regexp.MustCompile(`^[0-9A-Za-z]{27}$`)},
+	}
+
+	for name, format := range formats {
+		seen := map[string]bool{}
+		for range 100 {
+			id := format.generate()
+			if !format.pattern.MatchString(id) {
+				t.Fatalf("Unexpected %s format: %q", name, id)
+			}
+			if seen[id] {
+				t.Fatalf("Duplicate %s: %q", name, id)
+			}
+			seen[id] = true
+		}
+	}
+
+	// The time sortable IDs sort by creation time, to the millisecond
+	// (the second for KSUIDs)
+	sortable := map[string]func() string{
+		"uuidv7": customstore.GenerateUUIDv7,
+		"ulid":   customstore.GenerateULID,
+	}
+	for name, generate := range sortable {
+		ids := []string{}
+		for range 3 {
+			ids = append(ids, generate())
+			time.Sleep(2 * time.Millisecond)
+		}
+		if !slices.IsSorted(ids) {
+			t.Fatalf("Expected %s IDs to sort by creation time: %v", name, ids)
+		}
+	}
+	first := customstore.GenerateKSUID()
+	time.Sleep(1100 * time.Millisecond)
+	if second := customstore.GenerateKSUID(); second <= first {
+		t.Fatalf("Expected KSUIDs to sort by creation time: %q, %q", first, second)
+	}
+}
+
+func TestStoreIDGenerator(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_id_generator",
+		AutomigrateEnabled: true,
+		IDGenerator:        func() string { return "generated-" + customstore.GenerateULID() },
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("post")
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if !regexp.MustCompile(`^generated-`).MatchString(record.ID()) {
+		t.Fatalf("Expected the ID of the generator, but got %q", record.ID())
+	}
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("Expected the record to be stored with the generated ID, but got %v, %v", found, err)
+	}
+
+	// Set IDs are kept
+	explicit := customstore.NewRecord("post", customstore.WithID("explicit"))
+	if err := store.RecordCreate(explicit); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if explicit.ID() != "explicit" {
+		t.Fatalf("Expected the set ID to be kept, but got %q", explicit.ID())
+	}
+
+	upserted := customstore.NewRecord("post")
+	if err := store.RecordUpsert(upserted); err != nil {
+		t.Fatalf("RecordUpsert failed: %v", err)
+	}
+	if !regexp.MustCompile(`^generated-`).MatchString(upserted.ID()) {
+		t.Fatalf("Expected the ID of the generator on upsert, but got %q", upserted.ID())
+	}
+	id := upserted.ID()
+	if err := store.RecordUpsert(upserted); err != nil || upserted.ID() != id {
+		t.Fatalf("Expected a second upsert to keep the ID, but got %q, %v", upserted.ID(), err)
+	}
+}
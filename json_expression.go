@@ -0,0 +1,146 @@
+package customstore
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// jsonPath builds a JSON path selecting a single top level key, quoting the
+// key so that dots and other special characters are taken literally. Quotes
+// and backslashes are escaped as in a JSON string, as expected by the path
+// syntax of SQLite, MySQL 5.7+ and SQL Server.
+func jsonPath(key string) string {
+	return `$."` + jsonPathEscaper.Replace(key) + `"`
+}
+
+var jsonPathEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// jsonColumn returns the column as a JSON document, treating an empty
+// string (i.e. a record without payload) as SQL NULL
+func jsonColumn(column string) string {
+	return "NULLIF(" + column + ", '')"
+}
+
+// jsonExtractText returns a SQL expression extracting the value at the JSON
+// path (bound as the single placeholder) of column as text, using the JSON
+// functions of the given driver. Numbers are returned in their text form.
+func jsonExtractText(driver string, column string) string {
+	switch driver {
+	case "mysql":
+		return "JSON_UNQUOTE(JSON_EXTRACT(" + jsonColumn(column) + ", ?))"
+	case "postgres":
+		return "(" + jsonColumn(column) + "::jsonb ->> ?)"
+	case "sqlserver":
+		return "JSON_VALUE(" + jsonColumn(column) + ", ?)"
+	default:
+		return "CAST(json_extract(" + jsonColumn(column) + ", ?) AS TEXT)"
+	}
+}
+
+// jsonExtractJSON returns a SQL expression extracting the value at the JSON
+// path (bound as the single placeholder) of column, encoded as JSON text.
+//
+// SQL Server returns objects and arrays with JSON_QUERY, which is NULL for
+// scalars. These fall back to the top level member matching the path (see
+// jsonPath), which OPENJSON returns with its type so strings are quoted,
+// as JSON_VALUE returns them unquoted like numbers.
+func jsonExtractJSON(driver string, column string) string {
+	switch driver {
+	case "mysql":
+		return "JSON_EXTRACT(" + jsonColumn(column) + ", ?)"
+	case "postgres":
+		return "(" + jsonColumn(column) + "::jsonb -> ?)::text"
+	case "sqlserver":
+		document := jsonColumn(column)
+		scalar := "SELECT CASE m.[type] WHEN 0 THEN NULL WHEN 1 THEN '\"' + STRING_ESCAPE(m.[value], 'json') + '\"' ELSE m.[value] END" +
+			" FROM OPENJSON(" + document + ") AS m" +
+			" WHERE " + sqlServerMemberPath("m") + " = p.path"
+		return "(SELECT COALESCE(JSON_QUERY(" + document + ", p.path), (" + scalar + ")) FROM (SELECT ? AS path) AS p)"
+	default:
+		return "json_quote(json_extract(" + jsonColumn(column) + ", ?))"
+	}
+}
+
+// jsonExtractNumber returns a SQL expression extracting the value at the
+// JSON path (bound as the single placeholder) of column cast to a floating
+// point number.
+func jsonExtractNumber(driver string, column string) string {
+	switch driver {
+	case "mysql":
+		return "CAST(" + jsonExtractText(driver, column) + " AS DOUBLE)"
+	case "postgres":
+		return "CAST(" + jsonExtractText(driver, column) + " AS DOUBLE PRECISION)"
+	case "sqlserver":
+		return "CAST(" + jsonExtractText(driver, column) + " AS FLOAT)"
+	default:
+		return "CAST(" + jsonExtractText(driver, column) + " AS REAL)"
+	}
+}
+
+// jsonExtractNumeric returns a SQL expression extracting the value at the
+// JSON path (bound as each of the placeholders) of column as a floating
+// point number, or NULL if the value is not a JSON number, i.e. a string,
+// so non numeric values are skipped by the aggregate functions. SQL Server
+// does not allow the subquery of OPENJSON, the only way to get the type,
+// in aggregate functions, so numeric strings are converted there.
+func jsonExtractNumeric(driver string, column string) string {
+	document := jsonColumn(column)
+
+	switch driver {
+	case "mysql":
+		return "CASE WHEN JSON_TYPE(JSON_EXTRACT(" + document + ", ?)) IN ('INTEGER', 'UNSIGNED INTEGER', 'DOUBLE', 'DECIMAL') THEN " + jsonExtractNumber(driver, column) + " END"
+	case "postgres":
+		return "CASE WHEN jsonb_typeof(" + document + "::jsonb -> ?) = 'number' THEN " + jsonExtractNumber(driver, column) + " END"
+	case "sqlserver":
+		return "TRY_CAST(" + jsonExtractText(driver, column) + " AS FLOAT)"
+	default:
+		return "CASE WHEN json_type(" + document + ", ?) IN ('integer', 'real') THEN " + jsonExtractNumber(driver, column) + " END"
+	}
+}
+
+// sqlServerMemberPath returns a SQL Server expression of the JSON path of
+// the top level member of a row of OPENJSON, as built by jsonPath
+func sqlServerMemberPath(alias string) string {
+	return "'$.\"' + REPLACE(REPLACE(" + alias + ".[key], '\\', '\\\\'), '\"', '\\\"') + '\"'"
+}
+
+// jsonPathArg returns the bound value for the path placeholder produced by
+// the jsonExtract expressions for the given driver.
+func jsonPathArg(driver string, key string) any {
+	if driver == "postgres" {
+		return key
+	}
+	return jsonPath(key)
+}
+
+// jsonContainsCondition returns the PostgreSQL condition matching the
+// documents of column whose top level key has the text value, as a jsonb
+// containment (@>) which a GIN index on the column can serve. The key may
+// hold the value as a string or, for a number or boolean, as the JSON
+// scalar, as the text comparison of jsonExtractText matches both.
+func jsonContainsCondition(column string, key string, value string) sqlCondition {
+	documents := []any{}
+	if encoded, err := json.Marshal(map[string]string{key: value}); err == nil {
+		documents = append(documents, string(encoded))
+	}
+	if jsonScalar(value) {
+		if encoded, err := json.Marshal(map[string]json.RawMessage{key: json.RawMessage(value)}); err == nil {
+			documents = append(documents, string(encoded))
+		}
+	}
+
+	contains := make([]string, len(documents))
+	for i := range documents {
+		contains[i] = jsonColumn(column) + "::jsonb @> ?::jsonb"
+	}
+	return sqlCondition{sql: comparedToTrue(strings.Join(contains, " OR ")), args: documents}
+}
+
+// jsonScalar returns whether the text is a JSON number or boolean, without
+// surrounding white space
+func jsonScalar(text string) bool {
+	if text == "" || strings.TrimSpace(text) != text || !json.Valid([]byte(text)) {
+		return false
+	}
+	return text == "true" || text == "false" || text[0] == '-' || (text[0] >= '0' && text[0] <= '9')
+}
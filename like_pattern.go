@@ -0,0 +1,19 @@
+package customstore
+
+import "strings"
+
+// likeEscapeChar is the escape character of the LIKE patterns built by
+// likeEscape, declared with an ESCAPE clause as the default differs between
+// databases
+const likeEscapeChar = "!"
+
+// likeEscape escapes the LIKE wildcards in value, so that it is matched
+// literally
+func likeEscape(value string) string {
+	return strings.NewReplacer(
+		likeEscapeChar, likeEscapeChar+likeEscapeChar,
+		"%", likeEscapeChar+"%",
+		"_", likeEscapeChar+"_",
+		"[", likeEscapeChar+"[",
+	).Replace(value)
+}
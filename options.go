@@ -1,5 +1,10 @@
 package customstore
 
+import (
+	"errors"
+	"time"
+)
+
 // RecordOption represents a functional option that mutates a RecordInterface
 // instance during construction or afterwards.
 type RecordOption func(RecordInterface) error
@@ -41,3 +46,111 @@ func WithPayloadMap(payloadMap map[string]any) RecordOption {
 		return r.SetPayloadMap(payloadMap)
 	}
 }
+
+// WithPayloadStruct sets the record payload from a struct (will be marshaled to JSON).
+func WithPayloadStruct(src any) RecordOption {
+	return func(r RecordInterface) error {
+		return r.SetPayloadStruct(src)
+	}
+}
+
+// WithSoftDeletedAt sets the record soft deleted at timestamp, i.e. to
+// reconstruct a record exactly as it was at the source.
+func WithSoftDeletedAt(softDeletedAt time.Time) RecordOption {
+	return func(r RecordInterface) error {
+		r.SetSoftDeletedAt(softDeletedAt.UTC().Format(time.DateTime))
+		return nil
+	}
+}
+
+// WithProtected protects the record from deletion.
+func WithProtected() RecordOption {
+	return func(r RecordInterface) error {
+		return r.SetProtected(true)
+	}
+}
+
+// DeleteOption represents a functional option of the record delete methods.
+type DeleteOption func(*deleteOptions)
+
+type deleteOptions struct {
+	force bool
+}
+
+// WithForceDelete deletes the record even if it is protected.
+func WithForceDelete() DeleteOption {
+	return func(o *deleteOptions) {
+		o.force = true
+	}
+}
+
+func newDeleteOptions(opts []DeleteOption) deleteOptions {
+	options := deleteOptions{}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&options)
+		}
+	}
+	return options
+}
+
+// WithExpiresAt sets the time the record expires at, after which it is
+// excluded from queries and purged by Store.RecordPurgeExpired.
+func WithExpiresAt(expiresAt time.Time) RecordOption {
+	return func(r RecordInterface) error {
+		r.SetExpiresAt(expiresAt.UTC().Format(time.DateTime))
+		return nil
+	}
+}
+
+// WithTTL makes the record expire the given duration from now, counted
+// again from the time of the store (see NewStoreOptions.Clock) when the
+// record is created or upserted.
+func WithTTL(ttl time.Duration) RecordOption {
+	return func(r RecordInterface) error {
+		if ttl <= 0 {
+			return errors.New("ttl must be positive")
+		}
+		if record, ok := r.(ttlRecord); ok {
+			record.setTTL(ttl)
+			return nil
+		}
+		r.SetExpiresAt(time.Now().UTC().Add(ttl).Format(time.DateTime))
+		return nil
+	}
+}
+
+// WithCreatedBy sets the actor who created the record.
+func WithCreatedBy(actor string) RecordOption {
+	return func(r RecordInterface) error {
+		return r.SetCreatedBy(actor)
+	}
+}
+
+// WithVersion sets the record version.
+func WithVersion(version int64) RecordOption {
+	return func(r RecordInterface) error {
+		r.SetVersion(version)
+		return nil
+	}
+}
+
+// WithPayloadVersion sets the record payload schema version.
+func WithPayloadVersion(version int) RecordOption {
+	return func(r RecordInterface) error {
+		return r.SetPayloadVersion(version)
+	}
+}
+
+// WithSoftDeleted creates the record directly in soft deleted state (soft
+// deleted at is set to now), or not soft deleted when false.
+func WithSoftDeleted(softDeleted bool) RecordOption {
+	return func(r RecordInterface) error {
+		if softDeleted {
+			r.SetSoftDeletedAt(time.Now().UTC().Format(time.DateTime))
+		} else {
+			r.SetSoftDeletedAt(MAX_DATETIME)
+		}
+		return nil
+	}
+}
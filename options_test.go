@@ -4,6 +4,7 @@ package customstore_test
 import (
     "reflect"
     "testing"
+    "time"
 
     "github.com/dracory/customstore"
 )
@@ -53,3 +54,76 @@ func TestWithMetas(t *testing.T) {
         t.Fatalf("WithMetas not applied, expected %v, got %v", metas, got)
     }
 }
+
+func TestNewRecordEReturnsOptionErrors(t *testing.T) {
+    r, err := customstore.NewRecordE("test",
+        customstore.WithMemo("kept"),
+        customstore.WithPayloadMap(map[string]any{"bad": make(chan int)}),
+        customstore.WithPayloadMap(map[string]any{"worse": func() {}}),
+    )
+    if err == nil {
+        t.Fatalf("Expected error from invalid payload map options, got nil")
+    }
+    if r == nil {
+        t.Fatalf("Expected record to be returned alongside the error")
+    }
+    if r.Memo() != "kept" {
+        t.Fatalf("Expected successful options to be applied, got memo %q", r.Memo())
+    }
+}
+
+func TestNewRecordEWithoutErrors(t *testing.T) {
+    r, err := customstore.NewRecordE("test", customstore.WithID("id-1"))
+    if err != nil {
+        t.Fatalf("Expected no error, got %v", err)
+    }
+    if r.ID() != "id-1" {
+        t.Fatalf("WithID not applied, expected id-1, got %s", r.ID())
+    }
+}
+
+func TestWithSoftDeletedAt(t *testing.T) {
+    deletedAt := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+    r := customstore.NewRecord("test", customstore.WithSoftDeletedAt(deletedAt))
+    if r.SoftDeletedAt() != "2024-05-01 10:30:00" {
+        t.Fatalf("WithSoftDeletedAt not applied, expected 2024-05-01 10:30:00, got %s", r.SoftDeletedAt())
+    }
+    if !r.IsSoftDeleted() {
+        t.Fatalf("Expected record with past soft deleted at to be soft deleted")
+    }
+}
+
+func TestWithCreatedBy(t *testing.T) {
+    r := customstore.NewRecord("test", customstore.WithCreatedBy("user_1"))
+    if r.CreatedBy() != "user_1" {
+        t.Fatalf("WithCreatedBy not applied, expected user_1, got %s", r.CreatedBy())
+    }
+    if r.Meta(customstore.META_CREATED_BY) != "user_1" {
+        t.Fatalf("Expected created by to be stored as meta %s", customstore.META_CREATED_BY)
+    }
+}
+
+func TestWithVersion(t *testing.T) {
+    if v := customstore.NewRecord("test").Version(); v != 1 {
+        t.Fatalf("Expected new record version 1, got %d", v)
+    }
+    r := customstore.NewRecord("test", customstore.WithVersion(7))
+    if r.Version() != 7 {
+        t.Fatalf("WithVersion not applied, expected 7, got %d", r.Version())
+    }
+}
+
+func TestWithSoftDeleted(t *testing.T) {
+    r := customstore.NewRecord("test", customstore.WithSoftDeleted(true))
+    if !r.IsSoftDeleted() {
+        t.Fatalf("WithSoftDeleted(true) not applied, record is not soft deleted")
+    }
+
+    r = customstore.NewRecord("test", customstore.WithSoftDeletedAt(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)), customstore.WithSoftDeleted(false))
+    if r.IsSoftDeleted() {
+        t.Fatalf("WithSoftDeleted(false) not applied, record is soft deleted")
+    }
+    if r.SoftDeletedAt() != customstore.MAX_DATETIME {
+        t.Fatalf("Expected SoftDeletedAt %s, got %s", customstore.MAX_DATETIME, r.SoftDeletedAt())
+    }
+}
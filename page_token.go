@@ -0,0 +1,118 @@
+package customstore
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// PageToken is a keyset pagination cursor pointing just past the last
+// record of a page, ordered descending by OrderBy with the record ID as the
+// tie breaker.
+//
+// Pass it around in its opaque encoded form (see Encode and DecodePageToken),
+// and continue listing with RecordQuery().SetPageToken(token).
+type PageToken struct {
+	// OrderBy is the column the pages are ordered by
+	OrderBy string `json:"o"`
+
+	// Value is the OrderBy column value of the last record
+	Value string `json:"v"`
+
+	// ID is the ID of the last record
+	ID string `json:"i"`
+}
+
+// pageTokenColumns lists the columns a page token can be ordered by
+var pageTokenColumns = map[string]bool{
+	COLUMN_ID:          true,
+	COLUMN_RECORD_TYPE: true,
+	COLUMN_CREATED_AT:  true,
+	COLUMN_UPDATED_AT:  true,
+}
+
+// NewPageToken returns the token continuing after the given record, which
+// should be the last record of the current page
+func NewPageToken(orderBy string, record RecordInterface) (PageToken, error) {
+	if record == nil {
+		return PageToken{}, errors.New("page token: record is nil")
+	}
+
+	token := PageToken{OrderBy: orderBy, ID: record.ID()}
+
+	switch orderBy {
+	case COLUMN_ID:
+		token.Value = record.ID()
+	case COLUMN_RECORD_TYPE:
+		token.Value = record.Type()
+	case COLUMN_CREATED_AT:
+		token.Value = record.CreatedAtCarbon().StdTime().UTC().Format(time.RFC3339Nano)
+	case COLUMN_UPDATED_AT:
+		token.Value = record.UpdatedAtCarbon().StdTime().UTC().Format(time.RFC3339Nano)
+	}
+
+	return token, token.Validate()
+}
+
+// DecodePageToken decodes and validates an encoded page token
+func DecodePageToken(encoded string) (PageToken, error) {
+	if encoded == "" {
+		return PageToken{}, errors.New("page token: token is empty")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return PageToken{}, errors.New("page token: token is malformed")
+	}
+
+	var token PageToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return PageToken{}, errors.New("page token: token is malformed")
+	}
+
+	return token, token.Validate()
+}
+
+// Encode returns the opaque, URL safe form of the token
+func (t PageToken) Encode() (string, error) {
+	if err := t.Validate(); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Validate checks the token orders by a supported column and points to a record
+func (t PageToken) Validate() error {
+	if !pageTokenColumns[t.OrderBy] {
+		return errors.New("page token: unsupported order by column " + t.OrderBy)
+	}
+	if t.ID == "" {
+		return errors.New("page token: id is required")
+	}
+	if t.Value == "" {
+		return errors.New("page token: value is required")
+	}
+	if t.OrderBy == COLUMN_CREATED_AT || t.OrderBy == COLUMN_UPDATED_AT {
+		if _, err := time.Parse(time.RFC3339Nano, t.Value); err != nil {
+			return errors.New("page token: value is not a valid time")
+		}
+	}
+	return nil
+}
+
+// whereValue returns the value to compare the OrderBy column against,
+// formatting times like the stored values with timestamp
+func (t PageToken) whereValue(timestamp func(time.Time) any) any {
+	if t.OrderBy == COLUMN_CREATED_AT || t.OrderBy == COLUMN_UPDATED_AT {
+		value, _ := time.Parse(time.RFC3339Nano, t.Value)
+		return timestamp(value)
+	}
+	return t.Value
+}
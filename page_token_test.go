@@ -0,0 +1,120 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestPageTokenEncodeDecode(t *testing.T) {
+	token := customstore.PageToken{
+		OrderBy: customstore.COLUMN_CREATED_AT,
+		Value:   "2026-01-02T03:04:05.123456Z",
+		ID:      "rec_1",
+	}
+
+	encoded, err := token.Encode()
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	decoded, err := customstore.DecodePageToken(encoded)
+	if err != nil {
+		t.Fatalf("DecodePageToken() error = %v", err)
+	}
+	if decoded != token {
+		t.Fatalf("DecodePageToken() = %+v, expected %+v", decoded, token)
+	}
+
+	if _, err := customstore.DecodePageToken("not a token!"); err == nil {
+		t.Fatalf("DecodePageToken() with malformed token: expected error, got nil")
+	}
+	if _, err := customstore.DecodePageToken(""); err == nil {
+		t.Fatalf("DecodePageToken() with empty token: expected error, got nil")
+	}
+}
+
+func TestPageTokenValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		token customstore.PageToken
+	}{
+		{"unsupported column", customstore.PageToken{OrderBy: "payload", Value: "x", ID: "rec_1"}},
+		{"missing id", customstore.PageToken{OrderBy: customstore.COLUMN_ID, Value: "rec_1"}},
+		{"missing value", customstore.PageToken{OrderBy: customstore.COLUMN_RECORD_TYPE, ID: "rec_1"}},
+		{"invalid time", customstore.PageToken{OrderBy: customstore.COLUMN_UPDATED_AT, Value: "yesterday", ID: "rec_1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.token.Validate(); err == nil {
+				t.Fatalf("Validate() expected error, got nil")
+			}
+			if _, err := tt.token.Encode(); err == nil {
+				t.Fatalf("Encode() expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestRecordListPageToken(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_page_token",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := store.RecordCreate(customstore.NewRecord("invoice")); err != nil {
+			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
+		}
+	}
+
+	seen := map[string]bool{}
+	query := customstore.RecordQuery().SetType("invoice").SetOrderBy(customstore.COLUMN_CREATED_AT).SetLimit(2)
+	for page := 0; page < 5; page++ {
+		list, err := store.RecordList(query)
+		if err != nil {
+			t.Fatalf("RecordList page %d failed: %v", page+1, err)
+		}
+		if len(list) == 0 {
+			break
+		}
+
+		for _, rec := range list {
+			if seen[rec.ID()] {
+				t.Fatalf("Record %s returned on more than one page", rec.ID())
+			}
+			seen[rec.ID()] = true
+		}
+
+		token, err := customstore.NewPageToken(customstore.COLUMN_CREATED_AT, list[len(list)-1])
+		if err != nil {
+			t.Fatalf("NewPageToken failed: %v", err)
+		}
+		encoded, err := token.Encode()
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		decoded, err := customstore.DecodePageToken(encoded)
+		if err != nil {
+			t.Fatalf("DecodePageToken failed: %v", err)
+		}
+		query.SetPageToken(decoded)
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("Expected to page through 5 records, but got %d", len(seen))
+	}
+
+	invalid := customstore.RecordQuery().SetPageToken(customstore.PageToken{OrderBy: "payload; DROP TABLE x", Value: "x", ID: "y"})
+	if _, err := store.RecordList(invalid); err == nil {
+		t.Fatalf("Expected error listing with an invalid page token, but got nil")
+	}
+}
@@ -0,0 +1,106 @@
+package customstore
+
+import (
+	"reflect"
+	"sort"
+)
+
+// PayloadChange is a payload key which differs between two records, by its
+// path as accepted by PayloadMapKey, i.e. "customer.address.city"
+type PayloadChange struct {
+	Key string
+
+	// Old is the value in the first record, nil for an added key
+	Old any
+
+	// New is the value in the second record, nil for a removed key
+	New any
+}
+
+// PayloadChanges is the difference of the payloads of two records, each
+// list sorted by key
+type PayloadChanges struct {
+	// Added lists the keys only in the second record
+	Added []PayloadChange
+
+	// Removed lists the keys only in the first record
+	Removed []PayloadChange
+
+	// Changed lists the keys with different values
+	Changed []PayloadChange
+}
+
+// Empty returns whether the payloads are equal
+func (c PayloadChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// PayloadDiff compares the payloads of the records, i.e. the record before
+// and after an update for an audit log. Objects are compared key by key,
+// down to the nested keys, while any other value, arrays included, is
+// compared as a whole. A nil record has an empty payload.
+func PayloadDiff(a, b RecordInterface) (PayloadChanges, error) {
+	before, err := payloadDiffMap(a)
+	if err != nil {
+		return PayloadChanges{}, err
+	}
+
+	after, err := payloadDiffMap(b)
+	if err != nil {
+		return PayloadChanges{}, err
+	}
+
+	changes := PayloadChanges{}
+	diffPayloadObjects("", before, after, &changes)
+
+	for _, list := range [][]PayloadChange{changes.Added, changes.Removed, changes.Changed} {
+		sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	}
+
+	return changes, nil
+}
+
+// payloadDiffMap returns the decoded payload of the record compared by
+// PayloadDiff
+func payloadDiffMap(record RecordInterface) (map[string]any, error) {
+	if record == nil {
+		return map[string]any{}, nil
+	}
+
+	if !record.IsPayloadLoaded() {
+		return nil, ErrNotLoaded
+	}
+
+	return record.PayloadMap()
+}
+
+// diffPayloadObjects adds the differences of the objects at the path to
+// the changes
+func diffPayloadObjects(path string, before, after map[string]any, changes *PayloadChanges) {
+	for key, old := range before {
+		keyPath := path + key
+
+		value, exists := after[key]
+		if !exists {
+			changes.Removed = append(changes.Removed, PayloadChange{Key: keyPath, Old: old})
+			continue
+		}
+
+		oldObject, oldIsObject := old.(map[string]any)
+		object, isObject := value.(map[string]any)
+		if oldIsObject && isObject {
+			diffPayloadObjects(keyPath+payloadPathSeparator, oldObject, object, changes)
+			continue
+		}
+
+		if !reflect.DeepEqual(old, value) {
+			changes.Changed = append(changes.Changed, PayloadChange{Key: keyPath, Old: old, New: value})
+		}
+	}
+
+	for key, value := range after {
+		if _, exists := before[key]; !exists {
+			changes.Added = append(changes.Added, PayloadChange{Key: path + key, New: value})
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package customstore_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestPayloadDiff(t *testing.T) {
+	before := customstore.NewRecord("order", customstore.WithPayload(`{"status":"draft","total":10,"note":"call first","customer":{"name":"Ann","address":{"city":"Paris","zip":"75001"}},"tags":["a"]}`))
+	after := customstore.NewRecord("order", customstore.WithPayload(`{"status":"paid","total":10,"paid_at":"2030-01-02","customer":{"name":"Ann","address":{"city":"Lyon"}},"tags":["a","b"]}`))
+
+	changes, err := customstore.PayloadDiff(before, after)
+	if err != nil {
+		t.Fatalf("PayloadDiff failed: %v", err)
+	}
+
+	expected := customstore.PayloadChanges{
+		Added: []customstore.PayloadChange{
+			{Key: "paid_at", New: "2030-01-02"},
+		},
+		Removed: []customstore.PayloadChange{
+			{Key: "customer.address.zip", Old: "75001"},
+			{Key: "note", Old: "call first"},
+		},
+		Changed: []customstore.PayloadChange{
+			{Key: "customer.address.city", Old: "Paris", New: "Lyon"},
+			{Key: "status", Old: "draft", New: "paid"},
+			{Key: "tags", Old: []any{"a"}, New: []any{"a", "b"}},
+		},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatalf("Expected %+v, but got %+v", expected, changes)
+	}
+	if changes.Empty() {
+		t.Fatal("Expected the changes not to be empty")
+	}
+
+	// Equal payloads, key order and number formatting aside
+	changes, err = customstore.PayloadDiff(
+		customstore.NewRecord("order", customstore.WithPayload(`{"a":1,"b":{"c":2}}`)),
+		customstore.NewRecord("order", customstore.WithPayload(`{"b":{"c":2.0},"a":1}`)))
+	if err != nil || !changes.Empty() {
+		t.Fatalf("Expected no changes, but got %+v, %v", changes, err)
+	}
+
+	// A nil record has an empty payload
+	changes, err = customstore.PayloadDiff(nil, after)
+	if err != nil || len(changes.Added) != 5 || len(changes.Removed) != 0 || len(changes.Changed) != 0 {
+		t.Fatalf("Expected every key to be added, but got %+v, %v", changes, err)
+	}
+
+	invalid := customstore.NewRecordFromExistingData(map[string]string{
+		customstore.COLUMN_ID:      "invalid-payload",
+		customstore.COLUMN_PAYLOAD: `{"invalid"`,
+	})
+	if _, err := customstore.PayloadDiff(before, invalid); err == nil {
+		t.Fatal("Expected an error for an invalid payload")
+	}
+
+	// Records listed without the payload cannot be compared
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_payload_diff",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+	if err := store.RecordCreate(after); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	list, err := store.RecordList(customstore.RecordQuery().SetID(after.ID()).SetExcludePayload(true))
+	if err != nil || len(list) != 1 {
+		t.Fatalf("RecordList failed: %v, %v", list, err)
+	}
+	if _, err := customstore.PayloadDiff(before, list[0]); !errors.Is(err, customstore.ErrNotLoaded) {
+		t.Fatalf("Expected ErrNotLoaded, but got %v", err)
+	}
+}
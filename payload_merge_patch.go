@@ -0,0 +1,51 @@
+package customstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// decodeJSONValue decodes a JSON document keeping numbers as json.Number,
+// so they are written back unchanged
+func decodeJSONValue(document string) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(document)))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	if decoder.More() {
+		return nil, errors.New("invalid JSON: unexpected data after the document")
+	}
+
+	return value, nil
+}
+
+// mergePatch applies the JSON merge patch to the target as specified by
+// RFC 7386: object members of the patch are merged recursively into the
+// target, null members remove the key and any other patch replaces the
+// target as a whole
+func mergePatch(target any, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+
+	return targetObject
+}
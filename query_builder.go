@@ -0,0 +1,219 @@
+package customstore
+
+import "context"
+
+// ============================================================================
+// == INTERFACE
+// ============================================================================
+
+// QueryBuilderInterface defines a fluent query bound to a store, which both
+// constructs the record query and executes it
+//
+// Example:
+//
+//	list, err := store.Query().
+//		Type("invoice").
+//		MetaEquals("status", "open").
+//		Limit(20).
+//		List(ctx)
+type QueryBuilderInterface interface {
+	// RecordQuery returns the underlying record query
+	RecordQuery() RecordQueryInterface
+
+	ID(id string) QueryBuilderInterface
+	IDList(ids []string) QueryBuilderInterface
+	IDPrefix(prefix string) QueryBuilderInterface
+	Type(recordType string) QueryBuilderInterface
+	TypeIn(types []string) QueryBuilderInterface
+	TypePrefix(prefix string) QueryBuilderInterface
+	TypeNot(recordType string) QueryBuilderInterface
+	TypeNotIn(types []string) QueryBuilderInterface
+	MetaEquals(name string, value string) QueryBuilderInterface
+	MetaIn(name string, values []string) QueryBuilderInterface
+	PayloadSearch(needle string) QueryBuilderInterface
+	PayloadSearchNot(needle string) QueryBuilderInterface
+	FullTextSearch(query string) QueryBuilderInterface
+	PayloadKeyEquals(key string, value string) QueryBuilderInterface
+	PayloadKeyLike(key string, pattern string) QueryBuilderInterface
+	MemoEquals(value string) QueryBuilderInterface
+	MemoLike(pattern string) QueryBuilderInterface
+	Limit(limit int) QueryBuilderInterface
+	Offset(offset int) QueryBuilderInterface
+	OrderBy(orderBy string) QueryBuilderInterface
+	SoftDeletedIncluded(softDeletedIncluded bool) QueryBuilderInterface
+	OnlySoftDeleted(onlySoftDeleted bool) QueryBuilderInterface
+
+	// Count returns the number of records matching the query
+	Count(ctx context.Context) (int64, error)
+
+	// First returns the first record matching the query, or nil if none found
+	First(ctx context.Context) (RecordInterface, error)
+
+	// List returns the records matching the query
+	List(ctx context.Context) ([]RecordInterface, error)
+}
+
+// ============================================================================
+// == TYPE
+// ============================================================================
+
+var _ QueryBuilderInterface = (*queryBuilderImplementation)(nil)
+
+type queryBuilderImplementation struct {
+	store *storeImplementation
+	query RecordQueryInterface
+}
+
+// ============================================================================
+// == CONSTRUCTOR
+// ============================================================================
+
+// Query returns a fluent query builder executing against the store
+func (st *storeImplementation) Query() QueryBuilderInterface {
+	return &queryBuilderImplementation{
+		store: st,
+		query: NewRecordQuery(),
+	}
+}
+
+// ============================================================================
+// == METHODS
+// ============================================================================
+
+func (b *queryBuilderImplementation) RecordQuery() RecordQueryInterface {
+	return b.query
+}
+
+func (b *queryBuilderImplementation) ID(id string) QueryBuilderInterface {
+	b.query.SetID(id)
+	return b
+}
+
+func (b *queryBuilderImplementation) IDList(ids []string) QueryBuilderInterface {
+	b.query.SetIDList(ids)
+	return b
+}
+
+func (b *queryBuilderImplementation) IDPrefix(prefix string) QueryBuilderInterface {
+	b.query.SetIDPrefix(prefix)
+	return b
+}
+
+func (b *queryBuilderImplementation) Type(recordType string) QueryBuilderInterface {
+	b.query.SetType(recordType)
+	return b
+}
+
+func (b *queryBuilderImplementation) TypeIn(types []string) QueryBuilderInterface {
+	b.query.SetTypeIn(types)
+	return b
+}
+
+func (b *queryBuilderImplementation) TypePrefix(prefix string) QueryBuilderInterface {
+	b.query.SetTypePrefix(prefix)
+	return b
+}
+
+func (b *queryBuilderImplementation) TypeNot(recordType string) QueryBuilderInterface {
+	b.query.SetTypeNot(recordType)
+	return b
+}
+
+func (b *queryBuilderImplementation) TypeNotIn(types []string) QueryBuilderInterface {
+	b.query.SetTypeNotIn(types)
+	return b
+}
+
+func (b *queryBuilderImplementation) MetaEquals(name string, value string) QueryBuilderInterface {
+	b.query.AddMetaEquals(name, value)
+	return b
+}
+
+func (b *queryBuilderImplementation) MetaIn(name string, values []string) QueryBuilderInterface {
+	b.query.AddMetaIn(name, values)
+	return b
+}
+
+func (b *queryBuilderImplementation) PayloadSearch(needle string) QueryBuilderInterface {
+	b.query.AddPayloadSearch(needle)
+	return b
+}
+
+func (b *queryBuilderImplementation) PayloadSearchNot(needle string) QueryBuilderInterface {
+	b.query.AddPayloadSearchNot(needle)
+	return b
+}
+
+func (b *queryBuilderImplementation) FullTextSearch(query string) QueryBuilderInterface {
+	b.query.SetFullTextSearch(query)
+	return b
+}
+
+func (b *queryBuilderImplementation) PayloadKeyEquals(key string, value string) QueryBuilderInterface {
+	b.query.AddPayloadKeyEquals(key, value)
+	return b
+}
+
+func (b *queryBuilderImplementation) PayloadKeyLike(key string, pattern string) QueryBuilderInterface {
+	b.query.AddPayloadKeyLike(key, pattern)
+	return b
+}
+
+func (b *queryBuilderImplementation) MemoEquals(value string) QueryBuilderInterface {
+	b.query.SetMemoEquals(value)
+	return b
+}
+
+func (b *queryBuilderImplementation) MemoLike(pattern string) QueryBuilderInterface {
+	b.query.SetMemoLike(pattern)
+	return b
+}
+
+func (b *queryBuilderImplementation) Limit(limit int) QueryBuilderInterface {
+	b.query.SetLimit(limit)
+	return b
+}
+
+func (b *queryBuilderImplementation) Offset(offset int) QueryBuilderInterface {
+	b.query.SetOffset(offset)
+	return b
+}
+
+func (b *queryBuilderImplementation) OrderBy(orderBy string) QueryBuilderInterface {
+	b.query.SetOrderBy(orderBy)
+	return b
+}
+
+func (b *queryBuilderImplementation) SoftDeletedIncluded(softDeletedIncluded bool) QueryBuilderInterface {
+	b.query.SetSoftDeletedIncluded(softDeletedIncluded)
+	return b
+}
+
+func (b *queryBuilderImplementation) OnlySoftDeleted(onlySoftDeleted bool) QueryBuilderInterface {
+	b.query.SetOnlySoftDeleted(onlySoftDeleted)
+	return b
+}
+
+// == EXECUTION ==
+
+func (b *queryBuilderImplementation) Count(ctx context.Context) (int64, error) {
+	if err := b.query.Validate(); err != nil {
+		return 0, err
+	}
+	return b.store.recordCount(ctx, b.query)
+}
+
+func (b *queryBuilderImplementation) First(ctx context.Context) (RecordInterface, error) {
+	if err := b.query.Validate(); err != nil {
+		return nil, err
+	}
+
+	return b.store.RecordFindFirstContext(ctx, b.query)
+}
+
+func (b *queryBuilderImplementation) List(ctx context.Context) ([]RecordInterface, error) {
+	if err := b.query.Validate(); err != nil {
+		return nil, err
+	}
+	return b.store.recordList(ctx, b.query)
+}
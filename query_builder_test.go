@@ -0,0 +1,99 @@
+package customstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_query_builder",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	recordsData := []struct {
+		recordType string
+		status     string
+	}{
+		{"invoice", "open"},
+		{"invoice", "open"},
+		{"invoice", "paid"},
+		{"order", "open"},
+	}
+
+	for i, data := range recordsData {
+		rec := customstore.NewRecord(data.recordType, customstore.WithMetas(map[string]string{"status": data.status}))
+		if err := store.RecordCreate(rec); err != nil {
+			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
+		}
+	}
+
+	ctx := context.Background()
+
+	list, err := store.Query().Type("invoice").MetaEquals("status", "open").Limit(20).List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 open invoices, but got %d", len(list))
+	}
+	for _, rec := range list {
+		if rec.Type() != "invoice" || rec.Meta("status") != "open" {
+			t.Fatalf("Unexpected record type %q with status %q", rec.Type(), rec.Meta("status"))
+		}
+	}
+
+	count, err := store.Query().MetaEquals("status", "open").Count(ctx)
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected count 3, but got %d", count)
+	}
+
+	first, err := store.Query().Type("invoice").MetaEquals("status", "paid").First(ctx)
+	if err != nil {
+		t.Fatalf("First failed: %v", err)
+	}
+	if first == nil || first.Meta("status") != "paid" {
+		t.Fatalf("Expected to find the paid invoice, but got %v", first)
+	}
+
+	missing, err := store.Query().Type("order").MetaEquals("status", "paid").First(ctx)
+	if err != nil {
+		t.Fatalf("First for missing record failed: %v", err)
+	}
+	if missing != nil {
+		t.Fatalf("Expected nil when no record matches, but got record with ID %s", missing.ID())
+	}
+}
+
+func TestQueryBuilderCancelledContext(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_query_builder_cancelled",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := store.Query().Type("invoice").List(ctx); err == nil {
+		t.Fatalf("Expected error when listing with a cancelled context, but got nil")
+	}
+}
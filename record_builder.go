@@ -0,0 +1,140 @@
+package customstore
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ============================================================================
+// == INTERFACE
+// ============================================================================
+
+// RecordBuilderInterface defines a chainable record builder, which collects
+// validation problems and reports them all at once on Build
+//
+// Example:
+//
+//	record, err := customstore.NewRecordBuilder("invoice").
+//		Payload(`{"total":10}`).
+//		Meta("status", "open").
+//		Build()
+type RecordBuilderInterface interface {
+	ID(id string) RecordBuilderInterface
+	Memo(memo string) RecordBuilderInterface
+	Meta(name string, value string) RecordBuilderInterface
+	Metas(metas map[string]string) RecordBuilderInterface
+	Payload(payload string) RecordBuilderInterface
+	PayloadMap(payloadMap map[string]any) RecordBuilderInterface
+
+	// Build returns the record, or the accumulated validation errors
+	Build() (RecordInterface, error)
+}
+
+// ============================================================================
+// == TYPE
+// ============================================================================
+
+var _ RecordBuilderInterface = (*recordBuilderImplementation)(nil)
+
+type recordBuilderImplementation struct {
+	record RecordInterface
+	errs   []error
+}
+
+// ============================================================================
+// == CONSTRUCTOR
+// ============================================================================
+
+// NewRecordBuilder creates a new record builder for the given record type
+func NewRecordBuilder(recordType string) RecordBuilderInterface {
+	builder := &recordBuilderImplementation{
+		record: NewRecord(recordType),
+	}
+
+	if strings.TrimSpace(recordType) == "" {
+		builder.errs = append(builder.errs, errors.New("record builder: type is required"))
+	}
+
+	return builder
+}
+
+// ============================================================================
+// == METHODS
+// ============================================================================
+
+func (b *recordBuilderImplementation) ID(id string) RecordBuilderInterface {
+	if id == "" {
+		b.errs = append(b.errs, errors.New("record builder: id cannot be empty"))
+		return b
+	}
+	b.record.SetID(id)
+	return b
+}
+
+func (b *recordBuilderImplementation) Memo(memo string) RecordBuilderInterface {
+	b.record.SetMemo(memo)
+	return b
+}
+
+func (b *recordBuilderImplementation) Meta(name string, value string) RecordBuilderInterface {
+	if err := validateMetaName(name); err != nil {
+		b.errs = append(b.errs, err)
+		return b
+	}
+	if err := b.record.SetMeta(name, value); err != nil {
+		b.errs = append(b.errs, err)
+	}
+	return b
+}
+
+func (b *recordBuilderImplementation) Metas(metas map[string]string) RecordBuilderInterface {
+	valid := true
+	for name := range metas {
+		if err := validateMetaName(name); err != nil {
+			b.errs = append(b.errs, err)
+			valid = false
+		}
+	}
+	if !valid {
+		return b
+	}
+	if err := b.record.SetMetas(metas); err != nil {
+		b.errs = append(b.errs, err)
+	}
+	return b
+}
+
+func (b *recordBuilderImplementation) Payload(payload string) RecordBuilderInterface {
+	if payload != "" && !json.Valid([]byte(payload)) {
+		b.errs = append(b.errs, errors.New("record builder: payload is not valid JSON"))
+		return b
+	}
+	b.record.SetPayload(payload)
+	return b
+}
+
+func (b *recordBuilderImplementation) PayloadMap(payloadMap map[string]any) RecordBuilderInterface {
+	if err := b.record.SetPayloadMap(payloadMap); err != nil {
+		b.errs = append(b.errs, errors.New("record builder: payload map cannot be marshaled: "+err.Error()))
+	}
+	return b
+}
+
+func (b *recordBuilderImplementation) Build() (RecordInterface, error) {
+	if len(b.errs) > 0 {
+		return nil, errors.Join(b.errs...)
+	}
+	return b.record, nil
+}
+
+// validateMetaName checks that the meta name is not empty and not reserved
+func validateMetaName(name string) error {
+	if name == "" {
+		return errors.New("record builder: meta name cannot be empty")
+	}
+	if strings.HasPrefix(name, RESERVED_META_PREFIX) {
+		return errors.New("record builder: meta name " + name + " is reserved")
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package customstore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordBuilder(t *testing.T) {
+	record, err := customstore.NewRecordBuilder("invoice").
+		ID("inv-1").
+		Memo("first invoice").
+		Payload(`{"total":10}`).
+		Meta("status", "open").
+		Metas(map[string]string{"status": "open", "currency": "EUR"}).
+		Build()
+
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if record.ID() != "inv-1" {
+		t.Errorf("Expected ID %q, but got %q", "inv-1", record.ID())
+	}
+	if record.Type() != "invoice" {
+		t.Errorf("Expected type %q, but got %q", "invoice", record.Type())
+	}
+	if record.Memo() != "first invoice" {
+		t.Errorf("Expected memo %q, but got %q", "first invoice", record.Memo())
+	}
+	if record.Payload() != `{"total":10}` {
+		t.Errorf("Expected payload %q, but got %q", `{"total":10}`, record.Payload())
+	}
+	if record.Meta("currency") != "EUR" {
+		t.Errorf("Expected meta currency %q, but got %q", "EUR", record.Meta("currency"))
+	}
+}
+
+func TestRecordBuilderAccumulatesErrors(t *testing.T) {
+	record, err := customstore.NewRecordBuilder("").
+		Payload(`{invalid`).
+		Meta(customstore.RESERVED_META_PREFIX+"version", "1").
+		Meta("", "empty").
+		Build()
+
+	if err == nil {
+		t.Fatalf("Expected validation errors, but got nil")
+	}
+	if record != nil {
+		t.Fatalf("Expected nil record on validation errors")
+	}
+
+	for _, expected := range []string{"type is required", "payload is not valid JSON", "is reserved", "meta name cannot be empty"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, but got %q", expected, err.Error())
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package customstore
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// payloadPathSeparator separates the keys of a payload path, i.e.
+// "customer.address.city", with array indexes as keys, i.e. "items.0.sku"
+const payloadPathSeparator = "."
+
+// payloadPathLookup returns the value at the path of the decoded payload,
+// and whether it exists. A top level key equal to the path, dots included,
+// takes precedence so payloads with such keys keep working.
+func payloadPathLookup(data map[string]any, path string) (any, bool) {
+	if value, exists := data[path]; exists {
+		return value, true
+	}
+
+	var current any = data
+	for _, key := range strings.Split(path, payloadPathSeparator) {
+		switch container := current.(type) {
+		case map[string]any:
+			value, exists := container[key]
+			if !exists {
+				return nil, false
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(container) {
+				return nil, false
+			}
+			current = container[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// payloadPathSet sets the value at the path of the decoded payload,
+// creating the missing intermediate objects. Array indexes must exist, and
+// a path through a value which is neither an object nor an array returns
+// an error. A top level key equal to the path is set as is, see
+// payloadPathLookup.
+func payloadPathSet(data map[string]any, path string, value any) error {
+	if _, exists := data[path]; exists || !strings.Contains(path, payloadPathSeparator) {
+		data[path] = value
+		return nil
+	}
+
+	keys := strings.Split(path, payloadPathSeparator)
+	var current any = data
+	for i, key := range keys {
+		last := i == len(keys)-1
+
+		switch container := current.(type) {
+		case map[string]any:
+			if last {
+				container[key] = value
+				return nil
+			}
+			next, exists := container[key]
+			if !exists || next == nil {
+				next = map[string]any{}
+				container[key] = next
+			}
+			current = next
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(container) {
+				return errors.New("payload path " + path + ": no index " + key + " in the array")
+			}
+			if last {
+				container[index] = value
+				return nil
+			}
+			next := container[index]
+			if next == nil {
+				next = map[string]any{}
+				container[index] = next
+			}
+			current = next
+		default:
+			return errors.New("payload path " + path + ": " + strings.Join(keys[:i], payloadPathSeparator) + " is not an object or array")
+		}
+	}
+
+	return nil
+}
+
+// payloadPathArray returns the array at the path of the decoded payload,
+// nil if the path is missing or null, or an error if it holds another value
+func payloadPathArray(data map[string]any, path string) ([]any, error) {
+	value, exists := payloadPathLookup(data, path)
+	if !exists || value == nil {
+		return nil, nil
+	}
+
+	array, ok := value.([]any)
+	if !ok {
+		return nil, errors.New("payload path " + path + " is not an array")
+	}
+	return array, nil
+}
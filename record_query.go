@@ -1,6 +1,11 @@
 package customstore
 
-import "errors"
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
 
 // ============================================================================
 // == INTERFACE
@@ -10,9 +15,29 @@ import "errors"
 type RecordQueryInterface interface {
 	Validate() error
 
+	// ToSQL returns the SQL and arguments the store would execute for the
+	// query, without a database
+	ToSQL(driver string, table string) (string, []any, error)
+
 	IsSoftDeletedIncluded() bool
 	SetSoftDeletedIncluded(softDeletedIncluded bool) RecordQueryInterface
 
+	// Lists only the soft deleted records, i.e. for a trash bin, taking
+	// precedence over SetSoftDeletedIncluded
+	IsOnlySoftDeleted() bool
+	SetOnlySoftDeleted(onlySoftDeleted bool) RecordQueryInterface
+
+	// Expired records (see WithExpiresAt) are excluded unless included
+	IsExpiredIncluded() bool
+	SetExpiredIncluded(expiredIncluded bool) RecordQueryInterface
+
+	// Lists partially hydrated records without the payload or metas, load
+	// them on demand with Store.RecordLoadPayload
+	IsExcludePayload() bool
+	SetExcludePayload(excludePayload bool) RecordQueryInterface
+	IsExcludeMetas() bool
+	SetExcludeMetas(excludeMetas bool) RecordQueryInterface
+
 	SetColumns(columns []string) RecordQueryInterface
 	GetColumns() []string
 
@@ -28,10 +53,38 @@ type RecordQueryInterface interface {
 	GetIDList() []string
 	SetIDList(ids []string) RecordQueryInterface
 
+	// Records with an ID starting with the prefix, i.e. "usr_" for type
+	// prefixed IDs. Wildcards in the prefix are matched literally.
+	IsIDPrefixSet() bool
+	GetIDPrefix() string
+	SetIDPrefix(prefix string) RecordQueryInterface
+
 	IsTypeSet() bool
 	GetType() string
 	SetType(recordType string) RecordQueryInterface
 
+	// Records with any of the given types
+	IsTypeInSet() bool
+	GetTypeIn() []string
+	SetTypeIn(types []string) RecordQueryInterface
+
+	// Records with a type starting with the prefix, i.e. "shop." for the
+	// "shop.order" and "shop.customer" namespaced types
+	IsTypePrefixSet() bool
+	GetTypePrefix() string
+	SetTypePrefix(prefix string) RecordQueryInterface
+
+	// Records with a type other than the given type, i.e. everything but
+	// the internal "audit" records
+	IsTypeNotSet() bool
+	GetTypeNot() string
+	SetTypeNot(recordType string) RecordQueryInterface
+
+	// Records with none of the given types
+	IsTypeNotInSet() bool
+	GetTypeNotIn() []string
+	SetTypeNotIn(types []string) RecordQueryInterface
+
 	IsLimitSet() bool
 	GetLimit() int
 	SetLimit(limit int) RecordQueryInterface
@@ -44,11 +97,103 @@ type RecordQueryInterface interface {
 	GetOrderBy() string
 	SetOrderBy(orderBy string) RecordQueryInterface
 
+	// Orders by several columns, applied in the order added (after the
+	// SetOrderBy column). Direction is ORDER_ASC or ORDER_DESC.
+	AddOrderBy(column string, direction string) RecordQueryInterface
+	GetOrderByList() []OrderByColumn
+
+	// Collation applied to the order by column, i.e. "NOCASE" (SQLite),
+	// "utf8mb4_german2_ci" (MySQL) or "de-DE-x-icu" (PostgreSQL)
+	IsOrderByCollationSet() bool
+	GetOrderByCollation() string
+	SetOrderByCollation(collation string) RecordQueryInterface
+
+	// Records created at or after / at or before the given datetime (UTC,
+	// "2006-01-02 15:04:05")
+	IsCreatedAtGteSet() bool
+	GetCreatedAtGte() string
+	SetCreatedAtGte(createdAt string) RecordQueryInterface
+	IsCreatedAtLteSet() bool
+	GetCreatedAtLte() string
+	SetCreatedAtLte(createdAt string) RecordQueryInterface
+
+	// Records updated at or after / at or before the given datetime (UTC,
+	// "2006-01-02 15:04:05")
+	IsUpdatedAtGteSet() bool
+	GetUpdatedAtGte() string
+	SetUpdatedAtGte(updatedAt string) RecordQueryInterface
+	IsUpdatedAtLteSet() bool
+	GetUpdatedAtLte() string
+	SetUpdatedAtLte(updatedAt string) RecordQueryInterface
+
+	// Keyset pagination, continuing after the record the token points to
+	IsPageTokenSet() bool
+	GetPageToken() PageToken
+	SetPageToken(token PageToken) RecordQueryInterface
+
 	// Payload search methods
 	AddPayloadSearch(needle string) RecordQueryInterface
 	GetPayloadSearch() []string
 	AddPayloadSearchNot(needle string) RecordQueryInterface
 	GetPayloadSearchNot() []string
+
+	// Matches the payloads matching every regular expression (~ on
+	// PostgreSQL, REGEXP on MySQL and on SQLite with a regexp function)
+	AddPayloadSearchRegex(pattern string) RecordQueryInterface
+	GetPayloadSearchRegex() []string
+
+	// Matches the payload search terms regardless of case, i.e. "John"
+	// finding "john" (ILIKE on PostgreSQL, LOWER elsewhere)
+	IsPayloadSearchCaseInsensitive() bool
+	SetPayloadSearchCaseInsensitive(caseInsensitive bool) RecordQueryInterface
+
+	// Full text search of the payload, matching records containing every
+	// word of the query. Requires NewStoreOptions.FullTextSearchEnabled.
+	IsFullTextSearchSet() bool
+	GetFullTextSearch() string
+	SetFullTextSearch(query string) RecordQueryInterface
+
+	// Payload key filter methods, comparing the text of top level payload
+	// values (AND between keys)
+	AddPayloadKeyEquals(key string, value string) RecordQueryInterface
+	GetPayloadKeyEquals() map[string]string
+	AddPayloadKeyLike(key string, pattern string) RecordQueryInterface
+	GetPayloadKeyLike() map[string]string
+
+	// Memo filter methods, matching the memo by its exact text or by a SQL
+	// LIKE pattern
+	IsMemoEqualsSet() bool
+	GetMemoEquals() string
+	SetMemoEquals(value string) RecordQueryInterface
+	IsMemoLikeSet() bool
+	GetMemoLike() string
+	SetMemoLike(pattern string) RecordQueryInterface
+
+	// Meta filter methods
+	AddMetaEquals(name string, value string) RecordQueryInterface
+	GetMetaEquals() map[string]string
+	AddMetaIn(name string, values []string) RecordQueryInterface
+	GetMetaIn() map[string][]string
+
+	// Condition groups, matching the records matching any of the
+	// alternatives (OR), ANDed with the other filters and groups. Only the
+	// filters of the alternatives are used, not their limit, order or soft
+	// delete options. Alternatives may have groups of their own.
+	AddOrGroup(alternatives ...RecordQueryInterface) RecordQueryInterface
+	GetOrGroups() [][]RecordQueryInterface
+
+	// Raw conditions, ANDed with the other filters, for what the query does
+	// not cover (i.e. driver specific JSON functions). The SQL is used as
+	// given, with "?" placeholders for the arguments: never build it from
+	// user input.
+	AddRawWhere(sql string, args ...any) RecordQueryInterface
+	GetRawWheres() []RawWhere
+}
+
+// RawWhere is a raw SQL condition of a record query, see AddRawWhere
+type RawWhere struct {
+	SQL  string
+	Args []any
 }
 
 // ============================================================================
@@ -57,6 +202,24 @@ type RecordQueryInterface interface {
 
 var _ RecordQueryInterface = (*recordQueryImplementation)(nil)
 
+// OrderByColumn is a column of the record query order, see AddOrderBy
+type OrderByColumn struct {
+	Column    string
+	Direction string
+}
+
+// orderByColumns lists the columns AddOrderBy can order by
+var orderByColumns = map[string]bool{
+	COLUMN_ID:              true,
+	COLUMN_RECORD_TYPE:     true,
+	COLUMN_MEMO:            true,
+	COLUMN_CREATED_AT:      true,
+	COLUMN_UPDATED_AT:      true,
+	COLUMN_SOFT_DELETED_AT: true,
+	COLUMN_EXPIRES_AT:      true,
+	COLUMN_VERSION:         true,
+}
+
 // ============================================================================
 // == CONSTRUCTORS
 // ============================================================================
@@ -80,6 +243,22 @@ type recordQueryImplementation struct {
 	properties map[string]interface{}
 }
 
+// cloneRecordQuery returns a copy of a query built by NewRecordQuery, for
+// paging through its records without changing the query of the caller.
+// Returns false for other implementations.
+func cloneRecordQuery(query RecordQueryInterface) (RecordQueryInterface, bool) {
+	implementation, ok := query.(*recordQueryImplementation)
+	if !ok {
+		return nil, false
+	}
+
+	properties := make(map[string]interface{}, len(implementation.properties))
+	for key, value := range implementation.properties {
+		properties[key] = value
+	}
+	return &recordQueryImplementation{properties: properties}, true
+}
+
 // ============================================================================
 // == METHODS
 // ============================================================================
@@ -94,12 +273,138 @@ func (o *recordQueryImplementation) Validate() error {
 	if o.IsTypeSet() && o.GetType() == "" {
 		return errors.New("record query: type cannot be empty")
 	}
+	if o.IsTypeInSet() {
+		if len(o.GetTypeIn()) == 0 {
+			return errors.New("record query: type in list cannot be empty")
+		}
+		for _, recordType := range o.GetTypeIn() {
+			if recordType == "" {
+				return errors.New("record query: type in list cannot contain an empty type")
+			}
+		}
+	}
+	if o.IsTypeNotSet() && o.GetTypeNot() == "" {
+		return errors.New("record query: type not cannot be empty")
+	}
+	if o.IsTypeNotInSet() {
+		if len(o.GetTypeNotIn()) == 0 {
+			return errors.New("record query: type not in list cannot be empty")
+		}
+		for _, recordType := range o.GetTypeNotIn() {
+			if recordType == "" {
+				return errors.New("record query: type not in list cannot contain an empty type")
+			}
+		}
+	}
+	if o.IsFullTextSearchSet() && strings.TrimSpace(o.GetFullTextSearch()) == "" {
+		return errors.New("record query: full text search cannot be empty")
+	}
 	if o.IsLimitSet() && o.GetLimit() < 0 {
 		return errors.New("record query: limit cannot be negative")
 	}
 	if o.IsOffsetSet() && o.GetOffset() < 0 {
 		return errors.New("record query: offset cannot be negative")
 	}
+	for _, datetime := range []struct {
+		name  string
+		isSet bool
+		get   func() string
+	}{
+		{"created at gte", o.IsCreatedAtGteSet(), o.GetCreatedAtGte},
+		{"created at lte", o.IsCreatedAtLteSet(), o.GetCreatedAtLte},
+		{"updated at gte", o.IsUpdatedAtGteSet(), o.GetUpdatedAtGte},
+		{"updated at lte", o.IsUpdatedAtLteSet(), o.GetUpdatedAtLte},
+	} {
+		if !datetime.isSet {
+			continue
+		}
+		if _, err := time.Parse(time.DateTime, datetime.get()); err != nil {
+			return errors.New("record query: " + datetime.name + " must be a datetime formatted as 2006-01-02 15:04:05")
+		}
+	}
+	if o.IsOrderByCollationSet() {
+		if err := validateOrderByCollation(o.GetOrderByCollation()); err != nil {
+			return errors.New("record query: " + err.Error())
+		}
+		if !o.IsOrderBySet() {
+			return errors.New("record query: order by collation requires an order by")
+		}
+		if o.IsPageTokenSet() {
+			return errors.New("record query: page token cannot be combined with an order by collation")
+		}
+	}
+	for _, orderBy := range o.GetOrderByList() {
+		if !orderByColumns[orderBy.Column] {
+			return errors.New("record query: unsupported order by column " + orderBy.Column)
+		}
+		if orderBy.Direction != ORDER_ASC && orderBy.Direction != ORDER_DESC {
+			return errors.New("record query: order by direction must be asc or desc")
+		}
+	}
+	if o.IsPageTokenSet() && len(o.GetOrderByList()) > 0 {
+		return errors.New("record query: page token cannot be combined with multiple order by columns")
+	}
+	if o.IsPageTokenSet() {
+		token := o.GetPageToken()
+		if err := token.Validate(); err != nil {
+			return errors.New("record query: " + err.Error())
+		}
+		if o.IsOrderBySet() && o.GetOrderBy() != token.OrderBy {
+			return errors.New("record query: page token order by does not match the query order by")
+		}
+		if o.IsOffsetSet() && o.GetOffset() > 0 {
+			return errors.New("record query: page token cannot be combined with an offset")
+		}
+	}
+	for key := range o.GetPayloadKeyEquals() {
+		if key == "" {
+			return errors.New("record query: payload key cannot be empty")
+		}
+	}
+	for key := range o.GetPayloadKeyLike() {
+		if key == "" {
+			return errors.New("record query: payload key cannot be empty")
+		}
+	}
+	for _, pattern := range o.GetPayloadSearchRegex() {
+		if pattern == "" {
+			return errors.New("record query: payload search regex cannot be empty")
+		}
+	}
+	if o.IsMemoLikeSet() && o.GetMemoLike() == "" {
+		return errors.New("record query: memo like pattern cannot be empty")
+	}
+	for name := range o.GetMetaEquals() {
+		if name == "" {
+			return errors.New("record query: meta name cannot be empty")
+		}
+	}
+	for name, values := range o.GetMetaIn() {
+		if name == "" {
+			return errors.New("record query: meta name cannot be empty")
+		}
+		if len(values) == 0 {
+			return errors.New("record query: meta in values cannot be empty")
+		}
+	}
+	for _, raw := range o.GetRawWheres() {
+		if strings.TrimSpace(raw.SQL) == "" {
+			return errors.New("record query: raw where cannot be empty")
+		}
+	}
+	for _, group := range o.GetOrGroups() {
+		if len(group) == 0 {
+			return errors.New("record query: or group cannot be empty")
+		}
+		for _, alternative := range group {
+			if alternative == nil {
+				return errors.New("record query: or group cannot contain a nil query")
+			}
+			if err := alternative.Validate(); err != nil {
+				return errors.New("record query: or group: " + err.Error())
+			}
+		}
+	}
 	return nil
 }
 
@@ -167,6 +472,25 @@ func (o *recordQueryImplementation) SetIDList(ids []string) RecordQueryInterface
 	return o
 }
 
+// == ID PREFIX ==
+
+func (o *recordQueryImplementation) IsIDPrefixSet() bool {
+	return o.hasProperty("id_prefix")
+}
+
+func (o *recordQueryImplementation) GetIDPrefix() string {
+	return o.properties["id_prefix"].(string)
+}
+
+func (o *recordQueryImplementation) SetIDPrefix(prefix string) RecordQueryInterface {
+	if prefix == "" {
+		delete(o.properties, "id_prefix")
+	} else {
+		o.properties["id_prefix"] = prefix
+	}
+	return o
+}
+
 // == TYPE ==
 
 func (o *recordQueryImplementation) IsTypeSet() bool {
@@ -186,6 +510,74 @@ func (o *recordQueryImplementation) SetType(recordType string) RecordQueryInterf
 	return o
 }
 
+// == TYPE IN ==
+
+func (o *recordQueryImplementation) IsTypeInSet() bool {
+	return o.hasProperty("type_in")
+}
+
+func (o *recordQueryImplementation) GetTypeIn() []string {
+	return o.properties["type_in"].([]string)
+}
+
+func (o *recordQueryImplementation) SetTypeIn(types []string) RecordQueryInterface {
+	o.properties["type_in"] = types
+	return o
+}
+
+// == TYPE PREFIX ==
+
+func (o *recordQueryImplementation) IsTypePrefixSet() bool {
+	return o.hasProperty("type_prefix")
+}
+
+func (o *recordQueryImplementation) GetTypePrefix() string {
+	return o.properties["type_prefix"].(string)
+}
+
+func (o *recordQueryImplementation) SetTypePrefix(prefix string) RecordQueryInterface {
+	if prefix == "" {
+		delete(o.properties, "type_prefix")
+	} else {
+		o.properties["type_prefix"] = prefix
+	}
+	return o
+}
+
+// == TYPE NOT ==
+
+func (o *recordQueryImplementation) IsTypeNotSet() bool {
+	return o.hasProperty("type_not")
+}
+
+func (o *recordQueryImplementation) GetTypeNot() string {
+	return o.properties["type_not"].(string)
+}
+
+func (o *recordQueryImplementation) SetTypeNot(recordType string) RecordQueryInterface {
+	if recordType == "" {
+		delete(o.properties, "type_not")
+	} else {
+		o.properties["type_not"] = recordType
+	}
+	return o
+}
+
+// == TYPE NOT IN ==
+
+func (o *recordQueryImplementation) IsTypeNotInSet() bool {
+	return o.hasProperty("type_not_in")
+}
+
+func (o *recordQueryImplementation) GetTypeNotIn() []string {
+	return o.properties["type_not_in"].([]string)
+}
+
+func (o *recordQueryImplementation) SetTypeNotIn(types []string) RecordQueryInterface {
+	o.properties["type_not_in"] = types
+	return o
+}
+
 // == LIMIT ==
 
 func (o *recordQueryImplementation) IsLimitSet() bool {
@@ -243,10 +635,146 @@ func (o *recordQueryImplementation) SetOrderBy(orderBy string) RecordQueryInterf
 	return o
 }
 
+// == ORDER BY LIST ==
+
+func (o *recordQueryImplementation) AddOrderBy(column string, direction string) RecordQueryInterface {
+	if !o.hasProperty("order_by_list") {
+		o.properties["order_by_list"] = []OrderByColumn{}
+	}
+	o.properties["order_by_list"] = append(o.properties["order_by_list"].([]OrderByColumn), OrderByColumn{
+		Column:    column,
+		Direction: strings.ToLower(direction),
+	})
+	return o
+}
+
+func (o *recordQueryImplementation) GetOrderByList() []OrderByColumn {
+	if v, ok := o.properties["order_by_list"].([]OrderByColumn); ok {
+		return v
+	}
+	return []OrderByColumn{}
+}
+
+// == ORDER BY COLLATION ==
+
+func (o *recordQueryImplementation) IsOrderByCollationSet() bool {
+	return o.hasProperty("order_by_collation")
+}
+
+func (o *recordQueryImplementation) GetOrderByCollation() string {
+	return o.properties["order_by_collation"].(string)
+}
+
+func (o *recordQueryImplementation) SetOrderByCollation(collation string) RecordQueryInterface {
+	if collation == "" {
+		delete(o.properties, "order_by_collation")
+	} else {
+		o.properties["order_by_collation"] = collation
+	}
+	return o
+}
+
+// == CREATED AT GTE ==
+
+func (o *recordQueryImplementation) IsCreatedAtGteSet() bool {
+	return o.hasProperty("created_at_gte")
+}
+
+func (o *recordQueryImplementation) GetCreatedAtGte() string {
+	return o.properties["created_at_gte"].(string)
+}
+
+func (o *recordQueryImplementation) SetCreatedAtGte(createdAt string) RecordQueryInterface {
+	if createdAt == "" {
+		delete(o.properties, "created_at_gte")
+	} else {
+		o.properties["created_at_gte"] = createdAt
+	}
+	return o
+}
+
+// == CREATED AT LTE ==
+
+func (o *recordQueryImplementation) IsCreatedAtLteSet() bool {
+	return o.hasProperty("created_at_lte")
+}
+
+func (o *recordQueryImplementation) GetCreatedAtLte() string {
+	return o.properties["created_at_lte"].(string)
+}
+
+func (o *recordQueryImplementation) SetCreatedAtLte(createdAt string) RecordQueryInterface {
+	if createdAt == "" {
+		delete(o.properties, "created_at_lte")
+	} else {
+		o.properties["created_at_lte"] = createdAt
+	}
+	return o
+}
+
+// == UPDATED AT GTE ==
+
+func (o *recordQueryImplementation) IsUpdatedAtGteSet() bool {
+	return o.hasProperty("updated_at_gte")
+}
+
+func (o *recordQueryImplementation) GetUpdatedAtGte() string {
+	return o.properties["updated_at_gte"].(string)
+}
+
+func (o *recordQueryImplementation) SetUpdatedAtGte(updatedAt string) RecordQueryInterface {
+	if updatedAt == "" {
+		delete(o.properties, "updated_at_gte")
+	} else {
+		o.properties["updated_at_gte"] = updatedAt
+	}
+	return o
+}
+
+// == UPDATED AT LTE ==
+
+func (o *recordQueryImplementation) IsUpdatedAtLteSet() bool {
+	return o.hasProperty("updated_at_lte")
+}
+
+func (o *recordQueryImplementation) GetUpdatedAtLte() string {
+	return o.properties["updated_at_lte"].(string)
+}
+
+func (o *recordQueryImplementation) SetUpdatedAtLte(updatedAt string) RecordQueryInterface {
+	if updatedAt == "" {
+		delete(o.properties, "updated_at_lte")
+	} else {
+		o.properties["updated_at_lte"] = updatedAt
+	}
+	return o
+}
+
+// == PAGE TOKEN ==
+
+func (o *recordQueryImplementation) IsPageTokenSet() bool {
+	return o.hasProperty("page_token")
+}
+
+func (o *recordQueryImplementation) GetPageToken() PageToken {
+	if v, ok := o.properties["page_token"].(PageToken); ok {
+		return v
+	}
+	return PageToken{}
+}
+
+func (o *recordQueryImplementation) SetPageToken(token PageToken) RecordQueryInterface {
+	o.properties["page_token"] = token
+	return o
+}
+
 // == SOFT DELETED INCLUDED ==
 
 func (o *recordQueryImplementation) IsSoftDeletedIncluded() bool {
-	return o.hasProperty("soft_deleted_included")
+	if v, ok := o.properties["soft_deleted_included"].(bool); ok {
+		return v
+	}
+	return false
 }
 
 func (o *recordQueryImplementation) SetSoftDeletedIncluded(softDeletedIncluded bool) RecordQueryInterface {
@@ -254,6 +782,62 @@ func (o *recordQueryImplementation) SetSoftDeletedIncluded(softDeletedIncluded b
 	return o
 }
 
+// == ONLY SOFT DELETED ==
+
+func (o *recordQueryImplementation) IsOnlySoftDeleted() bool {
+	if v, ok := o.properties["only_soft_deleted"].(bool); ok {
+		return v
+	}
+	return false
+}
+
+func (o *recordQueryImplementation) SetOnlySoftDeleted(onlySoftDeleted bool) RecordQueryInterface {
+	o.properties["only_soft_deleted"] = onlySoftDeleted
+	return o
+}
+
+// == EXPIRED INCLUDED ==
+
+func (o *recordQueryImplementation) IsExpiredIncluded() bool {
+	if v, ok := o.properties["expired_included"].(bool); ok {
+		return v
+	}
+	return false
+}
+
+func (o *recordQueryImplementation) SetExpiredIncluded(expiredIncluded bool) RecordQueryInterface {
+	o.properties["expired_included"] = expiredIncluded
+	return o
+}
+
+// == EXCLUDE PAYLOAD ==
+
+func (o *recordQueryImplementation) IsExcludePayload() bool {
+	if v, ok := o.properties["exclude_payload"].(bool); ok {
+		return v
+	}
+	return false
+}
+
+func (o *recordQueryImplementation) SetExcludePayload(excludePayload bool) RecordQueryInterface {
+	o.properties["exclude_payload"] = excludePayload
+	return o
+}
+
+// == EXCLUDE METAS ==
+
+func (o *recordQueryImplementation) IsExcludeMetas() bool {
+	if v, ok := o.properties["exclude_metas"].(bool); ok {
+		return v
+	}
+	return false
+}
+
+func (o *recordQueryImplementation) SetExcludeMetas(excludeMetas bool) RecordQueryInterface {
+	o.properties["exclude_metas"] = excludeMetas
+	return o
+}
+
 // == PAYLOAD SEARCH ==
 
 func (o *recordQueryImplementation) AddPayloadSearch(needle string) RecordQueryInterface {
@@ -287,3 +871,177 @@ func (o *recordQueryImplementation) GetPayloadSearchNot() []string {
 	}
 	return []string{}
 }
+
+// == PAYLOAD SEARCH REGEX ==
+
+func (o *recordQueryImplementation) AddPayloadSearchRegex(pattern string) RecordQueryInterface {
+	if !o.hasProperty("payload_search_regex") {
+		o.properties["payload_search_regex"] = []string{}
+	}
+	o.properties["payload_search_regex"] = append(o.properties["payload_search_regex"].([]string), pattern)
+	return o
+}
+
+func (o *recordQueryImplementation) GetPayloadSearchRegex() []string {
+	if v, ok := o.properties["payload_search_regex"].([]string); ok {
+		return v
+	}
+	return []string{}
+}
+
+// == PAYLOAD SEARCH CASE INSENSITIVE ==
+
+func (o *recordQueryImplementation) IsPayloadSearchCaseInsensitive() bool {
+	if v, ok := o.properties["payload_search_case_insensitive"].(bool); ok {
+		return v
+	}
+	return false
+}
+
+func (o *recordQueryImplementation) SetPayloadSearchCaseInsensitive(caseInsensitive bool) RecordQueryInterface {
+	o.properties["payload_search_case_insensitive"] = caseInsensitive
+	return o
+}
+
+// == FULL TEXT SEARCH ==
+
+func (o *recordQueryImplementation) IsFullTextSearchSet() bool {
+	return o.hasProperty("full_text_search")
+}
+
+func (o *recordQueryImplementation) GetFullTextSearch() string {
+	return o.properties["full_text_search"].(string)
+}
+
+func (o *recordQueryImplementation) SetFullTextSearch(query string) RecordQueryInterface {
+	o.properties["full_text_search"] = query
+	return o
+}
+
+// == PAYLOAD KEY EQUALS ==
+
+func (o *recordQueryImplementation) AddPayloadKeyEquals(key string, value string) RecordQueryInterface {
+	if !o.hasProperty("payload_key_equals") {
+		o.properties["payload_key_equals"] = map[string]string{}
+	}
+	o.properties["payload_key_equals"].(map[string]string)[key] = value
+	return o
+}
+
+func (o *recordQueryImplementation) GetPayloadKeyEquals() map[string]string {
+	if v, ok := o.properties["payload_key_equals"].(map[string]string); ok {
+		return v
+	}
+	return map[string]string{}
+}
+
+// == PAYLOAD KEY LIKE ==
+
+func (o *recordQueryImplementation) AddPayloadKeyLike(key string, pattern string) RecordQueryInterface {
+	if !o.hasProperty("payload_key_like") {
+		o.properties["payload_key_like"] = map[string]string{}
+	}
+	o.properties["payload_key_like"].(map[string]string)[key] = pattern
+	return o
+}
+
+func (o *recordQueryImplementation) GetPayloadKeyLike() map[string]string {
+	if v, ok := o.properties["payload_key_like"].(map[string]string); ok {
+		return v
+	}
+	return map[string]string{}
+}
+
+// == MEMO EQUALS ==
+
+func (o *recordQueryImplementation) IsMemoEqualsSet() bool {
+	return o.hasProperty("memo_equals")
+}
+
+func (o *recordQueryImplementation) GetMemoEquals() string {
+	return o.properties["memo_equals"].(string)
+}
+
+func (o *recordQueryImplementation) SetMemoEquals(value string) RecordQueryInterface {
+	o.properties["memo_equals"] = value
+	return o
+}
+
+// == MEMO LIKE ==
+
+func (o *recordQueryImplementation) IsMemoLikeSet() bool {
+	return o.hasProperty("memo_like")
+}
+
+func (o *recordQueryImplementation) GetMemoLike() string {
+	return o.properties["memo_like"].(string)
+}
+
+func (o *recordQueryImplementation) SetMemoLike(pattern string) RecordQueryInterface {
+	o.properties["memo_like"] = pattern
+	return o
+}
+
+// == META EQUALS ==
+
+func (o *recordQueryImplementation) AddMetaEquals(name string, value string) RecordQueryInterface {
+	if !o.hasProperty("meta_equals") {
+		o.properties["meta_equals"] = map[string]string{}
+	}
+	o.properties["meta_equals"].(map[string]string)[name] = value
+	return o
+}
+
+func (o *recordQueryImplementation) GetMetaEquals() map[string]string {
+	if v, ok := o.properties["meta_equals"].(map[string]string); ok {
+		return v
+	}
+	return map[string]string{}
+}
+
+// == META IN ==
+
+func (o *recordQueryImplementation) AddMetaIn(name string, values []string) RecordQueryInterface {
+	if !o.hasProperty("meta_in") {
+		o.properties["meta_in"] = map[string][]string{}
+	}
+	o.properties["meta_in"].(map[string][]string)[name] = values
+	return o
+}
+
+func (o *recordQueryImplementation) GetMetaIn() map[string][]string {
+	if v, ok := o.properties["meta_in"].(map[string][]string); ok {
+		return v
+	}
+	return map[string][]string{}
+}
+
+// == OR GROUPS ==
+
+func (o *recordQueryImplementation) AddOrGroup(alternatives ...RecordQueryInterface) RecordQueryInterface {
+	// Clipped, so a cloned query appends to a copy
+	o.properties["or_groups"] = append(slices.Clip(o.GetOrGroups()), alternatives)
+	return o
+}
+
+func (o *recordQueryImplementation) GetOrGroups() [][]RecordQueryInterface {
+	if v, ok := o.properties["or_groups"].([][]RecordQueryInterface); ok {
+		return v
+	}
+	return nil
+}
+
+// == RAW WHERES ==
+
+func (o *recordQueryImplementation) AddRawWhere(sql string, args ...any) RecordQueryInterface {
+	// Clipped, so a cloned query appends to a copy
+	o.properties["raw_wheres"] = append(slices.Clip(o.GetRawWheres()), RawWhere{SQL: sql, Args: args})
+	return o
+}
+
+func (o *recordQueryImplementation) GetRawWheres() []RawWhere {
+	if v, ok := o.properties["raw_wheres"].([]RawWhere); ok {
+		return v
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package customstore_test // Changed package name
 
 import (
 	"encoding/json"
+	"errors"
 	"reflect" // Import reflect for DeepEqual
 	"testing"
 	"time"
@@ -320,6 +321,94 @@ func TestMetas(t *testing.T) {
 	}
 }
 
+func TestDeleteMeta(t *testing.T) {
+	record := customstore.NewRecord("test", customstore.WithMetas(map[string]string{"status": "open", "owner": "alice", "empty": ""}))
+
+	if !record.HasMeta("status") || !record.HasMeta("empty") || record.HasMeta("missing") {
+		t.Fatal("Expected HasMeta to report the set metas, including empty ones")
+	}
+	if keys := record.MetaKeys(); !reflect.DeepEqual(keys, []string{"empty", "owner", "status"}) {
+		t.Fatalf("Expected the sorted meta keys, but got %v", keys)
+	}
+
+	if err := record.DeleteMeta("status"); err != nil {
+		t.Fatalf("DeleteMeta failed: %v", err)
+	}
+	if err := record.DeleteMeta("missing"); err != nil {
+		t.Fatalf("DeleteMeta of a missing meta failed: %v", err)
+	}
+	if record.HasMeta("status") {
+		t.Fatal("Expected the meta to be deleted")
+	}
+	if keys := record.MetaKeys(); !reflect.DeepEqual(keys, []string{"empty", "owner"}) {
+		t.Fatalf("Expected the remaining meta keys, but got %v", keys)
+	}
+
+	empty := customstore.NewRecord("test")
+	if keys := empty.MetaKeys(); keys == nil || len(keys) != 0 {
+		t.Fatalf("Expected no meta keys, but got %v", keys)
+	}
+}
+
+func TestTypedMetas(t *testing.T) {
+	record := customstore.NewRecord("test")
+	at := time.Date(2030, 1, 2, 3, 4, 5, 6, time.FixedZone("+02:00", 2*60*60))
+
+	if err := record.SetMetaInt("count", 42); err != nil {
+		t.Fatalf("SetMetaInt failed: %v", err)
+	}
+	if err := record.SetMetaBool("active", true); err != nil {
+		t.Fatalf("SetMetaBool failed: %v", err)
+	}
+	if err := record.SetMetaFloat("ratio", 0.25); err != nil {
+		t.Fatalf("SetMetaFloat failed: %v", err)
+	}
+	if err := record.SetMetaTime("seen_at", at); err != nil {
+		t.Fatalf("SetMetaTime failed: %v", err)
+	}
+
+	if record.Meta("count") != "42" || record.Meta("active") != "true" || record.Meta("ratio") != "0.25" || record.Meta("seen_at") != "2030-01-02T01:04:05.000000006Z" {
+		t.Fatalf("Expected the metas to be stored as strings, but got %q", record.Meta("seen_at"))
+	}
+
+	if value, err := record.MetaInt("count"); err != nil || value != 42 {
+		t.Fatalf("Expected MetaInt to be 42, but got %d, %v", value, err)
+	}
+	if value, err := record.MetaBool("active"); err != nil || !value {
+		t.Fatalf("Expected MetaBool to be true, but got %v, %v", value, err)
+	}
+	if value, err := record.MetaFloat("ratio"); err != nil || value != 0.25 {
+		t.Fatalf("Expected MetaFloat to be 0.25, but got %v, %v", value, err)
+	}
+	if value, err := record.MetaTime("seen_at"); err != nil || !value.Equal(at) || value.Location() != time.UTC {
+		t.Fatalf("Expected MetaTime to be %v in UTC, but got %v, %v", at, value, err)
+	}
+
+	// Date times as formatted by the store and "1" for true are parsed too
+	record.SetMeta("created", "2030-01-02 03:04:05")
+	if value, err := record.MetaTime("created"); err != nil || !value.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Fatalf("Expected the date time to be parsed, but got %v, %v", value, err)
+	}
+	record.SetMeta("flag", "1")
+	if value, err := record.MetaBool("flag"); err != nil || !value {
+		t.Fatalf("Expected 1 to be true, but got %v, %v", value, err)
+	}
+
+	if _, err := record.MetaInt("missing"); !errors.Is(err, customstore.ErrMetaNotFound) {
+		t.Fatalf("Expected ErrMetaNotFound, but got %v", err)
+	}
+	record.SetMeta("count", "many")
+	if value, err := record.MetaInt("count"); err == nil || value != 0 {
+		t.Fatalf("Expected a parse error, but got %d, %v", value, err)
+	}
+	if _, err := record.MetaFloat("active"); err == nil {
+		t.Fatal("Expected a parse error for a bool as float")
+	}
+	if _, err := record.MetaTime("count"); err == nil {
+		t.Fatal("Expected a parse error for a string as time")
+	}
+}
+
 func TestPayload(t *testing.T) {
 	record := customstore.NewRecord("test")
 	payloadStr := `{"product":"widget","price":19.99}`
@@ -388,6 +477,104 @@ func TestPayloadMap(t *testing.T) {
 	}
 }
 
+func TestPayloadStruct(t *testing.T) {
+	type person struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	record := customstore.NewRecord("test")
+
+	// Empty payload leaves the destination unchanged
+	initial := person{Name: "unchanged"}
+	if err := record.PayloadStruct(&initial); err != nil {
+		t.Errorf("PayloadStruct() with empty payload: unexpected error: %v", err)
+	}
+	if initial.Name != "unchanged" {
+		t.Errorf("PayloadStruct() with empty payload: expected destination unchanged, got %+v", initial)
+	}
+
+	if err := record.SetPayloadStruct(person{Name: "Alice", Age: 25}); err != nil {
+		t.Errorf("SetPayloadStruct(): unexpected error: %v", err)
+	}
+	if record.Payload() != `{"name":"Alice","age":25}` {
+		t.Errorf("SetPayloadStruct(): expected JSON payload, got %s", record.Payload())
+	}
+
+	var retrieved person
+	if err := record.PayloadStruct(&retrieved); err != nil {
+		t.Errorf("PayloadStruct(): unexpected error: %v", err)
+	}
+	if retrieved.Name != "Alice" || retrieved.Age != 25 {
+		t.Errorf("PayloadStruct(): expected Alice aged 25, got %+v", retrieved)
+	}
+
+	withOption := customstore.NewRecord("test", customstore.WithPayloadStruct(person{Name: "Bob"}))
+	if withOption.PayloadString("name", "") != "Bob" {
+		t.Errorf("WithPayloadStruct not applied, got payload %s", withOption.Payload())
+	}
+
+	if err := record.SetPayloadStruct(make(chan int)); err == nil {
+		t.Error("SetPayloadStruct() with an unsupported value: expected an error, but got nil")
+	}
+
+	record.SetPayload(`{"invalid"`)
+	if err := record.PayloadStruct(&retrieved); err == nil {
+		t.Error("PayloadStruct() with invalid JSON: expected an error, but got nil")
+	}
+}
+
+func TestPatchPayload(t *testing.T) {
+	// Examples from RFC 7386, appendix A
+	testCases := []struct {
+		payload  string
+		patch    string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{``, `{"a":1}`, `{"a":1}`},
+		{`{"big":12345678901234567890}`, `{"a":1.50}`, `{"a":1.50,"big":12345678901234567890}`},
+	}
+
+	for _, tc := range testCases {
+		record := customstore.NewRecord("test", customstore.WithPayload(tc.payload))
+		if err := record.PatchPayload(tc.patch); err != nil {
+			t.Errorf("PatchPayload(%s) on %s: unexpected error: %v", tc.patch, tc.payload, err)
+			continue
+		}
+		if record.Payload() != tc.expected {
+			t.Errorf("PatchPayload(%s) on %s: expected %s, got %s", tc.patch, tc.payload, tc.expected, record.Payload())
+		}
+	}
+
+	record := customstore.NewRecord("test", customstore.WithPayload(`{"a":1}`))
+	if err := record.PatchPayload(`{"a":`); err == nil {
+		t.Error("PatchPayload() with an invalid patch: expected an error, but got nil")
+	}
+	if record.Payload() != `{"a":1}` {
+		t.Errorf("PatchPayload() with an invalid patch: expected payload unchanged, got %s", record.Payload())
+	}
+
+	record.SetPayload(`{"invalid"`)
+	if err := record.PatchPayload(`{"a":1}`); err == nil {
+		t.Error("PatchPayload() on invalid JSON: expected an error, but got nil")
+	}
+}
+
 func TestPayloadMapKey(t *testing.T) {
 	record := customstore.NewRecord("test")
 
@@ -524,6 +711,174 @@ func TestPayloadMapKey(t *testing.T) {
 	}
 }
 
+func TestPayloadMapKeyPath(t *testing.T) {
+	record := customstore.NewRecord("order", customstore.WithPayload(`{"customer":{"address":{"city":"Paris"}},"items":[{"sku":"A1"},{"sku":"B2"}],"a.b":"dotted"}`))
+
+	for path, expected := range map[string]any{
+		"customer.address.city":      "Paris",
+		"items.1.sku":                "B2",
+		"a.b":                        "dotted",
+		"customer.phone":             nil,
+		"items.2.sku":                nil,
+		"items.x":                    nil,
+		"customer.address.city.name": nil,
+	} {
+		value, err := record.PayloadMapKey(path)
+		if err != nil || value != expected {
+			t.Fatalf("PayloadMapKey(%q): expected %v, got %v, %v", path, expected, value, err)
+		}
+	}
+	if !record.PayloadHasKey("items.0.sku") || record.PayloadHasKey("items.0.qty") {
+		t.Fatal("Expected PayloadHasKey to follow the paths")
+	}
+	if record.PayloadString("customer.address.city", "") != "Paris" {
+		t.Fatal("Expected the typed accessors to follow the paths")
+	}
+
+	if err := record.SetPayloadMapKey("customer.address.city", "Lyon"); err != nil {
+		t.Fatalf("SetPayloadMapKey failed: %v", err)
+	}
+	if err := record.SetPayloadMapKey("items.0.sku", "C3"); err != nil {
+		t.Fatalf("SetPayloadMapKey failed: %v", err)
+	}
+	if err := record.SetPayloadMapKey("shipping.carrier.name", "ups"); err != nil {
+		t.Fatalf("SetPayloadMapKey failed: %v", err)
+	}
+	if err := record.SetPayloadMapKey("a.b", "still dotted"); err != nil {
+		t.Fatalf("SetPayloadMapKey failed: %v", err)
+	}
+	expected := `{"a.b":"still dotted","customer":{"address":{"city":"Lyon"}},"items":[{"sku":"C3"},{"sku":"B2"}],"shipping":{"carrier":{"name":"ups"}}}`
+	if record.Payload() != expected {
+		t.Fatalf("Expected %s, got %s", expected, record.Payload())
+	}
+
+	for _, path := range []string{"items.5.sku", "items.first", "customer.address.city.name"} {
+		if err := record.SetPayloadMapKey(path, "x"); err == nil {
+			t.Fatalf("SetPayloadMapKey(%q): expected an error", path)
+		}
+	}
+	if record.Payload() != expected {
+		t.Fatalf("Expected the payload unchanged after the errors, got %s", record.Payload())
+	}
+}
+
+func TestPayloadArrays(t *testing.T) {
+	record := customstore.NewRecord("order", customstore.WithPayload(`{"items":[{"sku":"A1"}],"total":10}`))
+
+	if err := record.PayloadAppend("items", map[string]any{"sku": "B2"}); err != nil {
+		t.Fatalf("PayloadAppend failed: %v", err)
+	}
+	if err := record.PayloadAppend("shipping.tracking", "1Z999"); err != nil {
+		t.Fatalf("PayloadAppend to a missing path failed: %v", err)
+	}
+	if length, err := record.PayloadArrayLen("items"); err != nil || length != 2 {
+		t.Fatalf("Expected 2 items, but got %d, %v", length, err)
+	}
+	if length, err := record.PayloadArrayLen("missing"); err != nil || length != 0 {
+		t.Fatalf("Expected 0 for a missing path, but got %d, %v", length, err)
+	}
+
+	if err := record.PayloadRemoveAt("items", 0); err != nil {
+		t.Fatalf("PayloadRemoveAt failed: %v", err)
+	}
+	expected := `{"items":[{"sku":"B2"}],"shipping":{"tracking":["1Z999"]},"total":10}`
+	if record.Payload() != expected {
+		t.Fatalf("Expected %s, got %s", expected, record.Payload())
+	}
+
+	if err := record.PayloadRemoveAt("items", 1); err == nil {
+		t.Fatal("PayloadRemoveAt: expected an error for an index out of range")
+	}
+	if err := record.PayloadRemoveAt("missing", 0); err == nil {
+		t.Fatal("PayloadRemoveAt: expected an error for a missing path")
+	}
+	if err := record.PayloadAppend("total", 1); err == nil {
+		t.Fatal("PayloadAppend: expected an error for a value which is not an array")
+	}
+	if _, err := record.PayloadArrayLen("total"); err == nil {
+		t.Fatal("PayloadArrayLen: expected an error for a value which is not an array")
+	}
+	if record.Payload() != expected {
+		t.Fatalf("Expected the payload unchanged after the errors, got %s", record.Payload())
+	}
+}
+
+func TestPayloadMapKeyOr(t *testing.T) {
+	record := customstore.NewRecord("test", customstore.WithPayload(`{"name":"John","age":30,"active":true,"nickname":null,"count":"7"}`))
+
+	if value := record.PayloadMapKeyOr("name", "default"); value != "John" {
+		t.Errorf("PayloadMapKeyOr('name'): expected %q, got %v", "John", value)
+	}
+	if value := record.PayloadMapKeyOr("missing", "default"); value != "default" {
+		t.Errorf("PayloadMapKeyOr('missing'): expected %q, got %v", "default", value)
+	}
+	if value := record.PayloadMapKeyOr("nickname", "default"); value != "default" {
+		t.Errorf("PayloadMapKeyOr('nickname') with null value: expected %q, got %v", "default", value)
+	}
+
+	if value := record.PayloadString("name", "default"); value != "John" {
+		t.Errorf("PayloadString('name'): expected %q, got %q", "John", value)
+	}
+	if value := record.PayloadString("missing", "default"); value != "default" {
+		t.Errorf("PayloadString('missing'): expected %q, got %q", "default", value)
+	}
+
+	if value := record.PayloadInt("age", 0); value != 30 {
+		t.Errorf("PayloadInt('age'): expected 30, got %d", value)
+	}
+	if value := record.PayloadInt("count", 0); value != 7 {
+		t.Errorf("PayloadInt('count') from string: expected 7, got %d", value)
+	}
+	if value := record.PayloadInt("name", 5); value != 5 {
+		t.Errorf("PayloadInt('name') not convertible: expected default 5, got %d", value)
+	}
+
+	if value := record.PayloadBool("active", false); !value {
+		t.Errorf("PayloadBool('active'): expected true, got false")
+	}
+	if value := record.PayloadBool("missing", true); !value {
+		t.Errorf("PayloadBool('missing'): expected default true, got false")
+	}
+
+	invalidRecord := customstore.NewRecord("test", customstore.WithPayload(`{invalid`))
+	if value := invalidRecord.PayloadString("name", "default"); value != "default" {
+		t.Errorf("PayloadString() with invalid JSON: expected %q, got %q", "default", value)
+	}
+}
+
+func TestPayloadSubset(t *testing.T) {
+	record := customstore.NewRecord("test", customstore.WithPayload(`{"name":"John","age":30,"nickname":null}`))
+
+	if !record.PayloadHasKey("name") {
+		t.Errorf("PayloadHasKey('name'): expected true, got false")
+	}
+	if !record.PayloadHasKey("nickname") {
+		t.Errorf("PayloadHasKey('nickname') with null value: expected true, got false")
+	}
+	if record.PayloadHasKey("missing") {
+		t.Errorf("PayloadHasKey('missing'): expected false, got true")
+	}
+
+	subset, err := record.PayloadSubset([]string{"name", "nickname", "missing"})
+	if err != nil {
+		t.Fatalf("PayloadSubset() error = %v", err)
+	}
+	if len(subset) != 2 {
+		t.Fatalf("PayloadSubset(): expected 2 keys, got %d: %v", len(subset), subset)
+	}
+	if subset["name"] != "John" {
+		t.Errorf("PayloadSubset()['name']: expected %q, got %v", "John", subset["name"])
+	}
+	if value, exists := subset["nickname"]; !exists || value != nil {
+		t.Errorf("PayloadSubset()['nickname']: expected present nil, got %v (exists=%v)", value, exists)
+	}
+
+	invalidRecord := customstore.NewRecord("test", customstore.WithPayload(`{invalid`))
+	if _, err := invalidRecord.PayloadSubset([]string{"name"}); err == nil {
+		t.Errorf("PayloadSubset() with invalid JSON: expected error, got nil")
+	}
+}
+
 // Helper function to introduce a small delay (renamed)
 // Note: Consider if this sleep is truly necessary for the test logic.
 func sleepForTest(duration time.Duration) {
@@ -0,0 +1,419 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// == INTERFACE
+// ============================================================================
+
+// ReplicatorInterface continuously copies the changes of a source store to
+// a target store, i.e. for moving to another database without downtime
+type ReplicatorInterface interface {
+	// Run replicates every interval until the context is cancelled
+	Run(ctx context.Context) error
+
+	// SyncOnce replicates the changes since the last sync, returning the
+	// number of records applied to the target
+	SyncOnce(ctx context.Context) (int, error)
+
+	// Stats returns the replication counters and lag
+	Stats() ReplicatorStats
+}
+
+// ReplicatorStats holds the replication metrics
+type ReplicatorStats struct {
+	// Created is the number of records created in the target
+	Created int64
+
+	// Updated is the number of records updated in the target
+	Updated int64
+
+	// SoftDeleted is the number of records soft deleted in the target
+	SoftDeleted int64
+
+	// Restored is the number of soft deleted records restored in the target
+	Restored int64
+
+	// Skipped is the number of changes already present in the target, or
+	// skipped by the conflict handler
+	Skipped int64
+
+	// Conflicts is the number of records changed in the target after the source
+	Conflicts int64
+
+	// Errors is the number of failed syncs
+	Errors int64
+
+	// Checkpoint is the updated at of the newest replicated source change
+	Checkpoint string
+
+	// LastSyncAt is the time of the last successful sync
+	LastSyncAt time.Time
+
+	// Lag is the time since the last successful sync
+	Lag time.Duration
+}
+
+// ============================================================================
+// == TYPE
+// ============================================================================
+
+var _ ReplicatorInterface = (*replicatorImplementation)(nil)
+
+type replicatorImplementation struct {
+	source  StoreInterface
+	target  StoreInterface
+	options ReplicatorOptions
+
+	mu    sync.Mutex
+	stats ReplicatorStats
+}
+
+// ============================================================================
+// == CONSTRUCTOR
+// ============================================================================
+
+// ReplicatorOptions define the options for creating a new replicator
+type ReplicatorOptions struct {
+	// RecordType limits replication to a record type, all types if empty
+	RecordType string
+
+	// Interval between syncs in Run, defaults to 5 seconds
+	Interval time.Duration
+
+	// BatchSize is the number of source records read at once, defaults to 100
+	BatchSize int
+
+	// Since is the updated at (UTC, "2006-01-02 15:04:05") to start
+	// replicating from, all records if empty
+	Since string
+
+	// OnConflict resolves a target record changed after the source record.
+	// It returns the record to write to the target, or nil to keep the
+	// target as is. Defaults to the source record winning.
+	OnConflict func(source RecordInterface, target RecordInterface) (RecordInterface, error)
+
+	Logger *slog.Logger
+}
+
+// NewReplicator creates a replicator copying the changes in source to target.
+//
+// Changes are found by polling the source updated at column, so creates,
+// updates, soft deletes and restores are replicated, hard deletes are not.
+// The replicated records keep the created and updated at of the source.
+func NewReplicator(source StoreInterface, target StoreInterface, opts ReplicatorOptions) (ReplicatorInterface, error) {
+	if source == nil {
+		return nil, errors.New("customstore replicator: source is required")
+	}
+
+	if target == nil {
+		return nil, errors.New("customstore replicator: target is required")
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+	}
+
+	return &replicatorImplementation{
+		source:  source,
+		target:  target,
+		options: opts,
+		stats:   ReplicatorStats{Checkpoint: opts.Since},
+	}, nil
+}
+
+// ============================================================================
+// == METHODS
+// ============================================================================
+
+func (r *replicatorImplementation) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.SyncOnce(ctx); err != nil && ctx.Err() == nil {
+			r.options.Logger.Error("Replication sync failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *replicatorImplementation) SyncOnce(ctx context.Context) (int, error) {
+	applied, err := r.sync(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		r.stats.Errors++
+		return applied, err
+	}
+
+	r.stats.LastSyncAt = time.Now()
+	return applied, nil
+}
+
+func (r *replicatorImplementation) Stats() ReplicatorStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := r.stats
+	if !stats.LastSyncAt.IsZero() {
+		stats.Lag = time.Since(stats.LastSyncAt)
+	}
+	return stats
+}
+
+// sync pages through the source changes since the checkpoint (inclusive,
+// as updated at has second precision), newest first, and applies them
+func (r *replicatorImplementation) sync(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	checkpoint := r.stats.Checkpoint
+	r.mu.Unlock()
+
+	query := NewRecordQuery().
+		SetType(r.options.RecordType).
+		SetUpdatedAtGte(checkpoint).
+		SetOrderBy(COLUMN_UPDATED_AT).
+		SetLimit(r.options.BatchSize).
+		SetSoftDeletedIncluded(true).
+		SetExpiredIncluded(true)
+
+	newest := checkpoint
+	applied := 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return applied, err
+		}
+
+		list, err := r.source.RecordListContext(ctx, query)
+		if err != nil {
+			return applied, err
+		}
+
+		if len(list) == 0 {
+			break
+		}
+
+		for _, record := range list {
+			if record.UpdatedAt() > newest {
+				newest = record.UpdatedAt()
+			}
+		}
+
+		token, err := NewPageToken(COLUMN_UPDATED_AT, list[len(list)-1])
+		if err != nil {
+			return applied, err
+		}
+
+		for _, record := range list {
+			changed, err := r.apply(ctx, record)
+			if err != nil {
+				return applied, err
+			}
+			if changed {
+				applied++
+			}
+		}
+
+		// Continue until an empty page, the source may cap the batch size
+		query.SetPageToken(token)
+	}
+
+	r.mu.Lock()
+	r.stats.Checkpoint = newest
+	r.mu.Unlock()
+
+	return applied, nil
+}
+
+// apply writes the source record to the target, returning whether the
+// target was changed
+func (r *replicatorImplementation) apply(ctx context.Context, source RecordInterface) (bool, error) {
+	ctx = withSourceTimestamps(ctx)
+
+	existing, err := r.target.RecordListContext(ctx, NewRecordQuery().
+		SetID(source.ID()).
+		SetSoftDeletedIncluded(true).
+		SetExpiredIncluded(true).
+		SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	if len(existing) == 0 {
+		copied, err := copyRecord(source)
+		if err != nil {
+			return false, err
+		}
+		if err := r.target.RecordCreateContext(ctx, copied); err != nil {
+			return false, err
+		}
+		r.count(func(s *ReplicatorStats) { s.Created++ })
+		return true, nil
+	}
+
+	target := existing[0]
+	if sameRecordContent(source, target) {
+		r.count(func(s *ReplicatorStats) { s.Skipped++ })
+		return false, nil
+	}
+
+	winner := source
+	if target.UpdatedAt() > source.UpdatedAt() {
+		r.count(func(s *ReplicatorStats) { s.Conflicts++ })
+		if r.options.OnConflict != nil {
+			winner, err = r.options.OnConflict(source, target)
+			if err != nil {
+				return false, err
+			}
+			if winner == nil {
+				r.count(func(s *ReplicatorStats) { s.Skipped++ })
+				return false, nil
+			}
+		}
+	}
+
+	// Soft deleted or restored first, then updated to the content and
+	// updated at of the winner
+	counted := false
+	switch {
+	case winner.IsSoftDeleted() && !target.IsSoftDeleted():
+		softDeleteCtx := withSourceSoftDeletedAt(ctx, winner.SoftDeletedAtCarbon().StdTime())
+		if err := r.target.RecordSoftDeleteByIDContext(softDeleteCtx, target.ID(), WithForceDelete()); err != nil {
+			return false, err
+		}
+		r.count(func(s *ReplicatorStats) { s.SoftDeleted++ })
+		counted = true
+	case !winner.IsSoftDeleted() && target.IsSoftDeleted():
+		if err := r.target.RecordRestoreContext(ctx, target); err != nil {
+			return false, err
+		}
+		r.count(func(s *ReplicatorStats) { s.Restored++ })
+		counted = true
+	}
+
+	metas, err := winner.Metas()
+	if err != nil {
+		return false, err
+	}
+
+	target.SetType(winner.Type())
+	target.SetPayload(winner.Payload())
+	target.SetMemo(winner.Memo())
+	target.SetExpiresAt(winner.ExpiresAt())
+	target.SetUpdatedAt(winner.UpdatedAt())
+	if err := target.SetMetas(metas); err != nil {
+		return false, err
+	}
+
+	if err := r.target.RecordUpdateContext(ctx, target); err != nil {
+		return false, err
+	}
+	if !counted {
+		r.count(func(s *ReplicatorStats) { s.Updated++ })
+	}
+	return true, nil
+}
+
+func (r *replicatorImplementation) count(update func(stats *ReplicatorStats)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	update(&r.stats)
+}
+
+// copyRecord returns a copy of the record, so creating it in the target
+// does not touch the source record
+func copyRecord(record RecordInterface) (RecordInterface, error) {
+	metas, err := record.Metas()
+	if err != nil {
+		return nil, err
+	}
+
+	copied, err := NewRecordE(record.Type(),
+		WithID(record.ID()),
+		WithPayload(record.Payload()),
+		WithMemo(record.Memo()),
+		WithMetas(metas),
+		WithVersion(record.Version()))
+	if err != nil {
+		return nil, err
+	}
+
+	copied.SetCreatedAt(record.CreatedAt())
+	copied.SetUpdatedAt(record.UpdatedAt())
+	copied.SetSoftDeletedAt(record.SoftDeletedAt())
+	copied.SetExpiresAt(record.ExpiresAt())
+	return copied, nil
+}
+
+// sourceTimestampsKey is the context key of withSourceTimestamps
+type sourceTimestampsKey struct{}
+
+// withSourceTimestamps returns the context with the records created and
+// updated keeping their created and updated at as set, i.e. to replicate
+// them with the timestamps of the source
+func withSourceTimestamps(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sourceTimestampsKey{}, true)
+}
+
+// sourceTimestamps returns whether the context keeps the timestamps of the
+// records written, see withSourceTimestamps
+func sourceTimestamps(ctx context.Context) bool {
+	kept, _ := ctx.Value(sourceTimestampsKey{}).(bool)
+	return kept
+}
+
+// sourceSoftDeletedAtKey is the context key of withSourceSoftDeletedAt
+type sourceSoftDeletedAtKey struct{}
+
+// withSourceSoftDeletedAt returns the context with the records soft
+// deleted stamped with the soft deleted at instead of now, i.e. to
+// replicate the soft deletes of the source
+func withSourceSoftDeletedAt(ctx context.Context, softDeletedAt time.Time) context.Context {
+	return context.WithValue(ctx, sourceSoftDeletedAtKey{}, softDeletedAt)
+}
+
+// sourceSoftDeletedAt returns the soft deleted at of the records soft
+// deleted, see withSourceSoftDeletedAt, and false if they are stamped now
+func sourceSoftDeletedAt(ctx context.Context) (time.Time, bool) {
+	softDeletedAt, ok := ctx.Value(sourceSoftDeletedAtKey{}).(time.Time)
+	return softDeletedAt, ok
+}
+
+// sameRecordContent returns whether both records hold the same data
+func sameRecordContent(a RecordInterface, b RecordInterface) bool {
+	if a.Type() != b.Type() || a.Payload() != b.Payload() || a.Memo() != b.Memo() || a.IsSoftDeleted() != b.IsSoftDeleted() || a.ExpiresAt() != b.ExpiresAt() {
+		return false
+	}
+
+	metasA, errA := a.Metas()
+	metasB, errB := b.Metas()
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(metasA, metasB)
+}
@@ -0,0 +1,305 @@
+package customstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestReplicatorSyncOnce(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	source, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_replicator_source",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Source store could not be created: %v", err)
+	}
+
+	target, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_replicator_target",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Target store could not be created: %v", err)
+	}
+
+	first := customstore.NewRecord("person", customstore.WithPayload(`{"name":"John"}`), customstore.WithMetas(map[string]string{"status": "active"}))
+	second := customstore.NewRecord("person", customstore.WithPayload(`{"name":"Jane"}`))
+	deleted := customstore.NewRecord("person", customstore.WithSoftDeleted(true))
+	for _, rec := range []customstore.RecordInterface{first, second, deleted} {
+		if err := source.RecordCreate(rec); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	replicator, err := customstore.NewReplicator(source, target, customstore.ReplicatorOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("NewReplicator failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	applied, err := replicator.SyncOnce(ctx)
+	if err != nil {
+		t.Fatalf("SyncOnce failed: %v", err)
+	}
+	if applied != 3 {
+		t.Fatalf("Expected 3 records applied, but got %d", applied)
+	}
+
+	replicated, err := target.RecordFindByID(first.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if replicated == nil || replicated.Payload() != first.Payload() || replicated.Meta("status") != "active" {
+		t.Fatalf("Expected first record to be replicated, but got %v", replicated)
+	}
+
+	count, err := target.RecordCount(customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 active records in the target, but got %d", count)
+	}
+
+	applied, err = replicator.SyncOnce(ctx)
+	if err != nil {
+		t.Fatalf("Second SyncOnce failed: %v", err)
+	}
+	if applied != 0 {
+		t.Fatalf("Expected no changes applied on second sync, but got %d", applied)
+	}
+
+	first.SetPayload(`{"name":"John Smith"}`)
+	if err := source.RecordUpdate(first); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+	if err := source.RecordSoftDelete(second); err != nil {
+		t.Fatalf("RecordSoftDelete failed: %v", err)
+	}
+
+	applied, err = replicator.SyncOnce(ctx)
+	if err != nil {
+		t.Fatalf("Third SyncOnce failed: %v", err)
+	}
+	if applied != 2 {
+		t.Fatalf("Expected 2 changes applied, but got %d", applied)
+	}
+
+	replicated, err = target.RecordFindByID(first.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if replicated == nil || replicated.Payload() != `{"name":"John Smith"}` {
+		t.Fatalf("Expected updated payload to be replicated, but got %v", replicated)
+	}
+
+	replicated, err = target.RecordFindByID(second.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if replicated != nil {
+		t.Fatalf("Expected second record to be soft deleted in the target")
+	}
+
+	stats := replicator.Stats()
+	if stats.Created != 3 || stats.Updated != 1 || stats.SoftDeleted != 1 {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+	if stats.Checkpoint == "" || stats.LastSyncAt.IsZero() {
+		t.Fatalf("Expected checkpoint and last sync to be set, but got %+v", stats)
+	}
+}
+
+func TestReplicatorConflict(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	source, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_replicator_conflict_source",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Source store could not be created: %v", err)
+	}
+
+	target, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_replicator_conflict_target",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Target store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person", customstore.WithPayload(`{"name":"John"}`))
+	if err := source.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	conflicts := 0
+	replicator, err := customstore.NewReplicator(source, target, customstore.ReplicatorOptions{
+		OnConflict: func(source customstore.RecordInterface, target customstore.RecordInterface) (customstore.RecordInterface, error) {
+			conflicts++
+			return nil, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewReplicator failed: %v", err)
+	}
+
+	if _, err := replicator.SyncOnce(context.Background()); err != nil {
+		t.Fatalf("SyncOnce failed: %v", err)
+	}
+
+	record.SetPayload(`{"name":"From source"}`)
+	if err := source.RecordUpdate(record); err != nil {
+		t.Fatalf("Source RecordUpdate failed: %v", err)
+	}
+
+	// updated at has second precision
+	sleepForTest(1100 * time.Millisecond)
+
+	targetRecord, err := target.RecordFindByID(record.ID())
+	if err != nil || targetRecord == nil {
+		t.Fatalf("Target RecordFindByID failed: %v", err)
+	}
+	targetRecord.SetPayload(`{"name":"From target"}`)
+	if err := target.RecordUpdate(targetRecord); err != nil {
+		t.Fatalf("Target RecordUpdate failed: %v", err)
+	}
+
+	if _, err := replicator.SyncOnce(context.Background()); err != nil {
+		t.Fatalf("SyncOnce failed: %v", err)
+	}
+
+	if conflicts != 1 {
+		t.Fatalf("Expected 1 conflict, but got %d", conflicts)
+	}
+
+	targetRecord, err = target.RecordFindByID(record.ID())
+	if err != nil || targetRecord == nil {
+		t.Fatalf("Target RecordFindByID failed: %v", err)
+	}
+	if targetRecord.Payload() != `{"name":"From target"}` {
+		t.Fatalf("Expected target record to be kept, but got payload %s", targetRecord.Payload())
+	}
+	if replicator.Stats().Conflicts != 1 {
+		t.Fatalf("Expected 1 conflict in stats, but got %d", replicator.Stats().Conflicts)
+	}
+}
+
+func TestNewReplicatorRequiresStores(t *testing.T) {
+	if _, err := customstore.NewReplicator(nil, nil, customstore.ReplicatorOptions{}); err == nil {
+		t.Fatalf("Expected error when source and target are nil, but got nil")
+	}
+}
+
+func TestReplicatorRestoreAndTimestamps(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	sourceClock := &fixedClock{now: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)}
+	source, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_replicator_restore_source",
+		AutomigrateEnabled: true,
+		Clock:              sourceClock,
+	})
+	if err != nil {
+		t.Fatalf("Source store could not be created: %v", err)
+	}
+
+	// The target clock is ahead, so records stamped by the target would
+	// look changed after the source
+	target, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_replicator_restore_target",
+		AutomigrateEnabled: true,
+		Clock:              &fixedClock{now: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)},
+	})
+	if err != nil {
+		t.Fatalf("Target store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person", customstore.WithPayload(`{"name":"John"}`))
+	if err := source.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	replicator, err := customstore.NewReplicator(source, target, customstore.ReplicatorOptions{})
+	if err != nil {
+		t.Fatalf("NewReplicator failed: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := replicator.SyncOnce(ctx); err != nil {
+		t.Fatalf("SyncOnce failed: %v", err)
+	}
+
+	replicated, err := target.RecordFindByID(record.ID())
+	if err != nil || replicated == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if replicated.CreatedAt() != record.CreatedAt() || replicated.UpdatedAt() != record.UpdatedAt() {
+		t.Fatalf("Expected the source timestamps, but got %s and %s", replicated.CreatedAt(), replicated.UpdatedAt())
+	}
+
+	sourceClock.now = sourceClock.now.Add(time.Minute)
+	if err := source.RecordSoftDelete(record); err != nil {
+		t.Fatalf("RecordSoftDelete failed: %v", err)
+	}
+	if _, err := replicator.SyncOnce(ctx); err != nil {
+		t.Fatalf("SyncOnce failed: %v", err)
+	}
+
+	// The soft deleted at is the one of the source, not the target clock
+	softDeletedQuery := customstore.NewRecordQuery().SetID(record.ID()).SetSoftDeletedIncluded(true)
+	sourceDeleted, err := source.RecordList(softDeletedQuery)
+	if err != nil || len(sourceDeleted) != 1 {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	replicatedDeleted, err := target.RecordList(softDeletedQuery)
+	if err != nil || len(replicatedDeleted) != 1 {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if !replicatedDeleted[0].IsSoftDeleted() || replicatedDeleted[0].SoftDeletedAt() != sourceDeleted[0].SoftDeletedAt() {
+		t.Fatalf("Expected the soft deleted at %s of the source, but got %s", sourceDeleted[0].SoftDeletedAt(), replicatedDeleted[0].SoftDeletedAt())
+	}
+
+	// An update after a restore restores and updates the target
+	sourceClock.now = sourceClock.now.Add(time.Minute)
+	if err := source.RecordRestore(record); err != nil {
+		t.Fatalf("RecordRestore failed: %v", err)
+	}
+	sourceClock.now = sourceClock.now.Add(time.Minute)
+	record.SetPayload(`{"name":"John Smith"}`)
+	if err := source.RecordUpdate(record); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+	if _, err := replicator.SyncOnce(ctx); err != nil {
+		t.Fatalf("SyncOnce failed: %v", err)
+	}
+
+	replicated, err = target.RecordFindByID(record.ID())
+	if err != nil || replicated == nil {
+		t.Fatalf("Expected the record to be restored in the target: %v", err)
+	}
+	if replicated.Payload() != `{"name":"John Smith"}` || replicated.UpdatedAt() != record.UpdatedAt() {
+		t.Fatalf("Expected the update to be replicated, but got %s at %s", replicated.Payload(), replicated.UpdatedAt())
+	}
+
+	stats := replicator.Stats()
+	if stats.Created != 1 || stats.SoftDeleted != 1 || stats.Restored != 1 || stats.Conflicts != 0 {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+}
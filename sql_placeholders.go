@@ -0,0 +1,32 @@
+package customstore
+
+import (
+	"strconv"
+	"strings"
+)
+
+// rebindPlaceholders rewrites the "?" placeholders of a raw statement to the
+// style of the driver. The query builder does this itself, raw statements
+// passed to Raw and Exec are sent as is.
+func rebindPlaceholders(driver string, sqlStr string) string {
+	if driver != "postgres" {
+		return sqlStr
+	}
+
+	var sb strings.Builder
+	n := 0
+	for _, r := range sqlStr {
+		if r == '?' {
+			n++
+			sb.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// placeholders returns n comma separated "?" placeholders
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
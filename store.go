@@ -5,15 +5,16 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"io"
 	"log/slog"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/dracory/neat"
 	contractsorm "github.com/dracory/neat/contracts/database/orm"
 	contractsschema "github.com/dracory/neat/contracts/database/schema"
-	"github.com/dromara/carbon/v2"
 )
 
 // ============================================================================
@@ -25,41 +26,332 @@ type StoreInterface interface {
 	// MigrateDown drops the table
 	MigrateDown(ctx context.Context, tx ...*sql.Tx) error
 
-	// MigrateUp creates the table
+	// MigrateUp creates the table, or upgrades an existing table
 	MigrateUp(ctx context.Context, tx ...*sql.Tx) error
 
+	// MigrateUpReport is MigrateUp reporting the tables, columns and indexes it created
+	MigrateUpReport(ctx context.Context) (MigrateReport, error)
+
+	// EnsurePayloadIndex creates an index on a top level payload key, used by the payload key filters of queries with the type
+	EnsurePayloadIndex(recordType string, key string) error
+
+	// EnsurePayloadIndexContext is EnsurePayloadIndex using the given context
+	EnsurePayloadIndexContext(ctx context.Context, recordType string, key string) error
+
+	// EnsureUniquePayloadKey makes a top level payload key unique among the records of the type
+	EnsureUniquePayloadKey(recordType string, key string) error
+
+	// EnsureUniquePayloadKeyContext is EnsureUniquePayloadKey using the given context
+	EnsureUniquePayloadKeyContext(ctx context.Context, recordType string, key string) error
+
+	// EnsureUniqueMeta makes a meta unique among the records of the type
+	EnsureUniqueMeta(recordType string, name string) error
+
+	// EnsureUniqueMetaContext is EnsureUniqueMeta using the given context
+	EnsureUniqueMetaContext(ctx context.Context, recordType string, name string) error
+
+	// RebuildFullTextIndex rebuilds the full text index from the stored payloads
+	RebuildFullTextIndex(ctx context.Context) error
+
 	// EnableDebug - enables the debug option
 	EnableDebug(debug bool)
 
 	// GetDB returns the underlying *sql.DB
 	GetDB() *sql.DB
 
+	// Ping verifies the connection to the database, and to the reader database if any
+	Ping(ctx context.Context) error
+
+	// Healthy verifies the connection, the tables and their schema, returning an error matching ErrUnhealthy if they are not ready
+	Healthy(ctx context.Context) error
+
+	// RunMaintenance runs the enabled maintenance tasks once
+	RunMaintenance(ctx context.Context, config MaintenanceConfig) []MaintenanceResult
+
+	// StartMaintenance runs the enabled maintenance tasks periodically in the background
+	StartMaintenance(ctx context.Context, config MaintenanceConfig) error
+
+	// Changes returns a channel receiving the record changes made through the store until the context is done
+	Changes(ctx context.Context, bufferSize int) <-chan ChangeEvent
+
+	// On registers a hook running on a record lifecycle event
+	On(event HookEvent, fn HookFunc) error
+
+	// RunInTransaction runs fn with a store bound to a new transaction, committed if fn returns nil
+	RunInTransaction(ctx context.Context, fn func(tx TransactionInterface) error) error
+
+	// ReadOnlyView returns a view of the store where every mutating method returns ErrReadOnly
+	ReadOnlyView() StoreInterface
+
+	// ForTenant returns a view of the store filtering every query and stamping every created record with the tenant
+	ForTenant(tenantID string) (StoreInterface, error)
+
+	// Query returns a fluent query builder executing against this store
+	Query() QueryBuilderInterface
+
 	// RecordCount returns the count of records based on a query
 	RecordCount(query RecordQueryInterface) (int64, error)
 
+	// RecordCountContext is RecordCount using the context for cancellation and deadlines
+	RecordCountContext(ctx context.Context, query RecordQueryInterface) (int64, error)
+
+	// RecordCountFast returns the count of the records of the type from the counters table, see CountersEnabled
+	RecordCountFast(recordType string) (int64, error)
+
+	// RecordCountFastContext is RecordCountFast using the given context
+	RecordCountFastContext(ctx context.Context, recordType string) (int64, error)
+
+	// RebuildCounters recounts the records of every type, replacing the counters table
+	RebuildCounters(ctx context.Context) error
+
+	// RecordCountByType returns the count of the records matching the query per record type
+	RecordCountByType(query RecordQueryInterface) (map[string]int64, error)
+
+	// RecordCountByTypeContext is RecordCountByType using the given context
+	RecordCountByTypeContext(ctx context.Context, query RecordQueryInterface) (map[string]int64, error)
+
+	// RecordExists returns whether any record matches the query, selecting a single row
+	RecordExists(query RecordQueryInterface) (bool, error)
+
+	// RecordExistsContext is RecordExists using the given context
+	RecordExistsContext(ctx context.Context, query RecordQueryInterface) (bool, error)
+
 	// RecordCreate creates a new record
 	RecordCreate(record RecordInterface) error
 
-	// RecordDelete deletes a record
-	RecordDelete(record RecordInterface) error
+	// RecordCreateContext is RecordCreate using the given context
+	RecordCreateContext(ctx context.Context, record RecordInterface) error
+
+	// RecordClone creates a copy of a record under a new ID, applying the options to the copy
+	RecordClone(id string, opts ...RecordOption) (RecordInterface, error)
+
+	// RecordCloneContext is RecordClone using the given context
+	RecordCloneContext(ctx context.Context, id string, opts ...RecordOption) (RecordInterface, error)
+
+	// RecordDelete deletes a record, returning ErrProtectedRecord for protected records unless forced
+	RecordDelete(record RecordInterface, opts ...DeleteOption) error
+
+	// RecordDeleteContext is RecordDelete using the given context
+	RecordDeleteContext(ctx context.Context, record RecordInterface, opts ...DeleteOption) error
+
+	// RecordDeleteByID deletes a record by ID, returning ErrProtectedRecord for protected records unless forced
+	RecordDeleteByID(id string, opts ...DeleteOption) error
 
-	// RecordDeleteByID deletes a record by ID
-	RecordDeleteByID(id string) error
+	// RecordDeleteByIDContext is RecordDeleteByID using the given context
+	RecordDeleteByIDContext(ctx context.Context, id string, opts ...DeleteOption) error
 
 	// RecordFindByID finds a record by ID
 	RecordFindByID(id string) (RecordInterface, error)
 
+	// RecordFindByIDContext is RecordFindByID using the given context
+	RecordFindByIDContext(ctx context.Context, id string) (RecordInterface, error)
+
+	// RecordLoadPayload loads the payload and metas of a record listed with them excluded
+	RecordLoadPayload(record RecordInterface) error
+
+	// RecordFindOrCreate finds the first record matching the query, or creates one
+	RecordFindOrCreate(query RecordQueryInterface, create func() RecordInterface) (record RecordInterface, created bool, err error)
+
+	// RecordFindFirst returns the first record matching the query, nil if none matches
+	RecordFindFirst(query RecordQueryInterface) (RecordInterface, error)
+
+	// RecordFindFirstContext is RecordFindFirst using the given context
+	RecordFindFirstContext(ctx context.Context, query RecordQueryInterface) (RecordInterface, error)
+
+	// RecordsFromSQL maps the rows of a raw SELECT over the store table to records
+	RecordsFromSQL(ctx context.Context, sqlStr string, args ...any) ([]RecordInterface, error)
+
 	// RecordList returns a list of records
 	RecordList(query RecordQueryInterface) ([]RecordInterface, error)
 
-	// RecordSoftDelete soft deletes a record
-	RecordSoftDelete(record RecordInterface) error
+	// RecordListContext is RecordList using the given context
+	RecordListContext(ctx context.Context, query RecordQueryInterface) ([]RecordInterface, error)
+
+	// Export writes the records matching the query as newline delimited JSON
+	Export(w io.Writer, query RecordQueryInterface) (int, error)
+
+	// ExportContext is Export using the given context
+	ExportContext(ctx context.Context, w io.Writer, query RecordQueryInterface) (int, error)
+
+	// ExportCSV writes the given columns and payload keys of the records matching the query as CSV
+	ExportCSV(w io.Writer, query RecordQueryInterface, columns []string) (int, error)
+
+	// ExportCSVContext is ExportCSV using the given context
+	ExportCSVContext(ctx context.Context, w io.Writer, query RecordQueryInterface, columns []string) (int, error)
+
+	// Import creates or replaces the records read from newline delimited JSON written by Export
+	Import(r io.Reader) (int, error)
+
+	// ImportContext is Import using the given context
+	ImportContext(ctx context.Context, r io.Reader) (int, error)
+
+	// RecordAuditHistory returns the audit entries of a record, oldest first
+	RecordAuditHistory(id string) ([]AuditEntry, error)
+
+	// RecordAuditHistoryContext is RecordAuditHistory using the given context
+	RecordAuditHistoryContext(ctx context.Context, id string) ([]AuditEntry, error)
+
+	// RecordRevisions returns the saved revisions of a record, oldest first
+	RecordRevisions(id string) ([]RecordRevision, error)
+
+	// RecordRevisionsContext is RecordRevisions using the given context
+	RecordRevisionsContext(ctx context.Context, id string) ([]RecordRevision, error)
+
+	// RecordRollback restores the payload and metas of a record to a saved revision
+	RecordRollback(id string, revision int64) error
+
+	// RecordRollbackContext is RecordRollback using the given context
+	RecordRollbackContext(ctx context.Context, id string, revision int64) error
+
+	// RecordLink links a record to another with a link type, see LinksEnabled
+	RecordLink(fromID string, toID string, linkType string) error
+
+	// RecordLinkContext is RecordLink using the given context
+	RecordLinkContext(ctx context.Context, fromID string, toID string, linkType string) error
+
+	// RecordUnlink removes the link of a type from a record to another
+	RecordUnlink(fromID string, toID string, linkType string) error
+
+	// RecordUnlinkContext is RecordUnlink using the given context
+	RecordUnlinkContext(ctx context.Context, fromID string, toID string, linkType string) error
+
+	// RecordListLinked returns the records matching the query linked to or from a record
+	RecordListLinked(id string, linkType string, direction LinkDirection, query RecordQueryInterface) ([]RecordInterface, error)
+
+	// RecordListLinkedContext is RecordListLinked using the given context
+	RecordListLinkedContext(ctx context.Context, id string, linkType string, direction LinkDirection, query RecordQueryInterface) ([]RecordInterface, error)
+
+	// Backup writes a consistent snapshot of the table, or of the given record types, in a versioned format
+	Backup(ctx context.Context, w io.Writer, recordTypes ...string) (int, error)
+
+	// Restore replaces the records in the scope of a backup with the backed up records, in one transaction
+	Restore(ctx context.Context, r io.Reader) (int, error)
+
+	// RecordListPaged returns a page of records with the total count and pagination details
+	RecordListPaged(query RecordQueryInterface) (PageResult, error)
+
+	// RecordListWithTotal returns a page of records together with the total number of matching records
+	RecordListWithTotal(query RecordQueryInterface) (records []RecordInterface, total int64, err error)
+
+	// AggregatePayload returns the sum, average, minimum and maximum of a numeric payload key
+	AggregatePayload(query RecordQueryInterface, path string) (sum float64, avg float64, min float64, max float64, err error)
+
+	// AggregatePayloadContext is AggregatePayload using the given context
+	AggregatePayloadContext(ctx context.Context, query RecordQueryInterface, path string) (sum float64, avg float64, min float64, max float64, err error)
+
+	// MetasForRecords returns the requested metas of many records in one query, keyed by ID
+	MetasForRecords(ids []string, keys []string) (map[string]map[string]string, error)
 
-	// RecordSoftDeleteByID soft deletes a record by ID
-	RecordSoftDeleteByID(id string) error
+	// MetaKeys returns the distinct meta keys in use by the records of a type
+	MetaKeys(recordType string) ([]string, error)
+
+	// RecordDistinctPayloadKey returns the sorted distinct values of a payload key over the matching records
+	RecordDistinctPayloadKey(query RecordQueryInterface, key string) ([]string, error)
+
+	// RecordDistinctMeta returns the sorted distinct values of a meta over the matching records
+	RecordDistinctMeta(query RecordQueryInterface, name string) ([]string, error)
+
+	// QueryBatch executes several record queries over one connection, returning the lists in query order
+	QueryBatch(queries []RecordQueryInterface) ([][]RecordInterface, error)
+
+	// RecordRows returns the matching records as lightweight rows holding only the selected columns
+	RecordRows(query RecordQueryInterface) ([]RecordRow, error)
+
+	// RecordListPayloadSubset returns the requested payload keys of the matching records, keyed by ID
+	RecordListPayloadSubset(query RecordQueryInterface, keys []string) (map[string]map[string]any, error)
+
+	// TransformPayloads applies the transform to the payloads of a record type, writing back changed records in batches
+	TransformPayloads(recordType string, fn PayloadTransformFunc, opts TransformPayloadsOptions) (TransformPayloadsProgress, error)
+
+	// MigrateToTable copies the records to a new table while double writing, then switches the store to it
+	MigrateToTable(ctx context.Context, newTable string, opts MigrateToTableOptions) (MigrateToTableProgress, error)
+
+	// RegisterRecordType declares an allowed record type with its required payload keys and allowed metas
+	RegisterRecordType(definition RecordTypeDefinition) error
+
+	// RegisterPayloadMigration registers the function upgrading payloads of a type between versions
+	RegisterPayloadMigration(recordType string, fromVersion int, toVersion int, fn PayloadMigrationFunc) error
+
+	// MigratePayloads upgrades the stored payloads of a type to the latest registered version
+	MigratePayloads(recordType string) (int, error)
+
+	// RecordSoftDelete soft deletes a record, returning ErrProtectedRecord for protected records unless forced
+	RecordSoftDelete(record RecordInterface, opts ...DeleteOption) error
+
+	// RecordSoftDeleteContext is RecordSoftDelete using the given context
+	RecordSoftDeleteContext(ctx context.Context, record RecordInterface, opts ...DeleteOption) error
+
+	// RecordSoftDeleteByID soft deletes a record by ID, returning ErrProtectedRecord for protected records unless forced
+	RecordSoftDeleteByID(id string, opts ...DeleteOption) error
+
+	// RecordSoftDeleteByIDContext is RecordSoftDeleteByID using the given context
+	RecordSoftDeleteByIDContext(ctx context.Context, id string, opts ...DeleteOption) error
+
+	// RecordPurgeSoftDeleted hard deletes the records soft deleted more than olderThan ago, optionally of the given types only
+	RecordPurgeSoftDeleted(olderThan time.Duration, recordTypes ...string) (int64, error)
+
+	// RecordPurgeSoftDeletedContext is RecordPurgeSoftDeleted using the given context
+	RecordPurgeSoftDeletedContext(ctx context.Context, olderThan time.Duration, recordTypes ...string) (int64, error)
+
+	// RecordIncrementPayloadKey atomically adds delta to a numeric payload key of the record with the ID
+	RecordIncrementPayloadKey(id string, key string, delta float64) error
+
+	// RecordIncrementPayloadKeyContext is RecordIncrementPayloadKey using the given context
+	RecordIncrementPayloadKeyContext(ctx context.Context, id string, key string, delta float64) error
+
+	// RecordPatchPayloadByID applies a JSON merge patch (RFC 7386) to the payload of the record with the ID
+	RecordPatchPayloadByID(id string, patch string) error
+
+	// RecordPatchPayloadByIDContext is RecordPatchPayloadByID using the given context
+	RecordPatchPayloadByIDContext(ctx context.Context, id string, patch string) error
+
+	// RecordPurgeExpired hard deletes the expired records
+	RecordPurgeExpired() (int64, error)
+
+	// RecordPurgeExpiredContext is RecordPurgeExpired using the given context
+	RecordPurgeExpiredContext(ctx context.Context) (int64, error)
+
+	// RecordRestore restores a soft deleted record
+	RecordRestore(record RecordInterface) error
+
+	// RecordRestoreContext is RecordRestore using the given context
+	RecordRestoreContext(ctx context.Context, record RecordInterface) error
+
+	// RecordRestoreByID restores a soft deleted record by ID
+	RecordRestoreByID(id string) error
+
+	// RecordRestoreByIDContext is RecordRestoreByID using the given context
+	RecordRestoreByIDContext(ctx context.Context, id string) error
+
+	// RecordTouch sets the updated at of the record with the ID to the current time, leaving the rest of the record unchanged
+	RecordTouch(id string) error
+
+	// RecordTouchContext is RecordTouch using the given context
+	RecordTouchContext(ctx context.Context, id string) error
+
+	// RecordUpsert creates the record, or updates it if a record with the same ID exists
+	RecordUpsert(record RecordInterface) error
+
+	// RecordUpsertContext is RecordUpsert using the given context
+	RecordUpsertContext(ctx context.Context, record RecordInterface) error
 
 	// RecordUpdate updates a record
 	RecordUpdate(record RecordInterface) error
+
+	// RecordUpdateContext is RecordUpdate using the given context
+	RecordUpdateContext(ctx context.Context, record RecordInterface) error
+
+	// RecordUpdateMany updates many records in one transaction, reporting the records skipped
+	RecordUpdateMany(records []RecordInterface) (UpdateManyResult, error)
+
+	// RecordUpdateManyContext is RecordUpdateMany using the given context
+	RecordUpdateManyContext(ctx context.Context, records []RecordInterface) (UpdateManyResult, error)
+
+	// RecordUpdateVersioned updates a record, returning ErrStaleRecord if it was updated since it was read
+	RecordUpdateVersioned(record RecordInterface) error
+
+	// RecordUpdateVersionedContext is RecordUpdateVersioned using the given context
+	RecordUpdateVersionedContext(ctx context.Context, record RecordInterface) error
 }
 
 // ============================================================================
@@ -70,11 +362,51 @@ var _ StoreInterface = (*storeImplementation)(nil)
 
 // Store defines a custom store
 type storeImplementation struct {
-	tableName          string
+	tables             *tableState
 	db                 *neat.Database
+	readDB             *neat.Database
+	retryPolicy        RetryPolicy
+	txRetryPolicy      RetryPolicy
 	automigrateEnabled bool
 	debugEnabled       bool
 	logger             *slog.Logger
+	loggerInjected     bool
+	slowQueryThreshold time.Duration
+	clock              Clock
+	timestampFormat    TimestampFormat
+	idGenerator        func() string
+	idPrefixes         *idPrefixes
+	maxListLimit       int
+	requireTypeFilter  bool
+	readOnly           bool
+	debugSQL           bool
+	fullTextSearch     bool
+	payloadMigrations  *payloadMigrationRegistry
+	payloadIndexes     *payloadIndexRegistry
+	uniqueKeys         *uniqueKeyRegistry
+	regex              *regexSupport
+	recordTypes        *recordTypeRegistry
+	hooks              *hookRegistry
+	changes            *changeFeed
+	auditTable         string
+	revisionsTable     string
+	countersTable      string
+	linksTable         string
+	tenancy            bool
+
+	// typeTables holds the tables of the record types routed to their own
+	// table, see NewStoreOptions.TypeTableMap, shardTables the distinct ones
+	typeTables  map[string]*tableState
+	shardTables []*tableState
+
+	// tenantID is the tenant the store is scoped to, see ForTenant
+	tenantID string
+
+	// tx is the transaction the store is bound to, see RunInTransaction
+	tx contractsorm.Query
+
+	// txChanges collects the changes made in the transaction, see Changes
+	txChanges *changeBuffer
 }
 
 // ============================================================================
@@ -89,7 +421,143 @@ type NewStoreOptions struct {
 	TimeoutSeconds     int64
 	AutomigrateEnabled bool
 	DebugEnabled       bool
-	Logger             *slog.Logger
+
+	// ReaderDB is a replica of DB serving RecordList, RecordCount and
+	// RecordFindByID (with their Context variants and the methods built on
+	// them), i.e. a read replica. Writes, transactions and the other reads
+	// use DB. See WithPrimaryReads to read from DB after a write. Must use
+	// the driver of DB.
+	ReaderDB *sql.DB
+
+	// Logger receives the store logs, defaults to a text logger writing to
+	// stdout. With DebugEnabled every executed statement is logged at debug
+	// level, if the logger handles it.
+	Logger *slog.Logger
+
+	// SlowQueryThreshold logs a warning with the SQL, arguments and
+	// duration of every statement running for at least the threshold. No
+	// slow query log if zero.
+	SlowQueryThreshold time.Duration
+
+	// MaxOpenConns sets the maximum number of open connections of DB (and
+	// ReaderDB), see sql.DB.SetMaxOpenConns. Left unchanged if zero.
+	MaxOpenConns int
+
+	// MaxIdleConns sets the maximum number of idle connections of DB (and
+	// ReaderDB), see sql.DB.SetMaxIdleConns. Left unchanged if zero.
+	MaxIdleConns int
+
+	// ConnMaxLifetime sets the maximum amount of time a connection of DB
+	// (and ReaderDB) may be reused, see sql.DB.SetConnMaxLifetime. Left
+	// unchanged if zero.
+	ConnMaxLifetime time.Duration
+
+	// RetryPolicy retries the statements failing with transient errors,
+	// i.e. a connection reset or a busy SQLite database. No retry if zero.
+	RetryPolicy RetryPolicy
+
+	// TransactionRetryPolicy runs the function of RunInTransaction again
+	// when the transaction fails with a deadlock or serialization failure,
+	// see IsTransactionConflict. No retry if zero.
+	TransactionRetryPolicy RetryPolicy
+
+	// Clock provides the current time, defaults to the system clock
+	Clock Clock
+
+	// IDGenerator returns the IDs of the created (or upserted) records
+	// whose ID was left as assigned by NewRecord, i.e. GenerateULID for
+	// IDs sorting by creation time. Defaults to keeping that ID.
+	IDGenerator func() string
+
+	// IDPrefixes prefixes the IDs generated for the records of the mapped
+	// types, keyed by record type, i.e. "user": "usr" for IDs such as
+	// "usr_01H...", generated by IDGenerator or GenerateULID. IDs carrying
+	// a prefix identify records of its type: queries by ID only match
+	// records of that type, and are routed to its table.
+	IDPrefixes map[string]string
+
+	// TimestampFormat is how the timestamps of the records are stored,
+	// i.e. TimestampRFC3339 or TimestampNative to work with an existing
+	// table. Defaults to UTC "2006-01-02 15:04:05" strings.
+	TimestampFormat TimestampFormat
+
+	// MaxListLimit caps the number of records a query returns, applied to
+	// queries without a limit or with a higher one. No cap if zero.
+	MaxListLimit int
+
+	// RequireTypeFilter rejects queries without a type (or ID) filter with
+	// ErrTypeFilterRequired, protecting against accidental full table scans.
+	// An empty type, ID or list filters nothing and is rejected too.
+	RequireTypeFilter bool
+
+	// ReadOnly makes every mutating method return ErrReadOnly, i.e. for
+	// reporting services. Cannot be combined with AutomigrateEnabled.
+	ReadOnly bool
+
+	// DebugSQL logs the SQL and arguments of the record queries (RecordList,
+	// RecordCount, RecordFindByID, ...) instead of executing them, returning
+	// no records, i.e. to debug payload filters. The store is read only, see
+	// RecordQuery.ToSQL to build the SQL without a store.
+	DebugSQL bool
+
+	// FullTextSearchEnabled maintains a full text index of the payloads
+	// (SQLite and PostgreSQL only), see RecordQuery.SetFullTextSearch
+	FullTextSearchEnabled bool
+
+	// AuditEnabled writes an entry to the audit table for every change
+	// reported by Changes, see RecordAuditHistory. Every write then reads
+	// the record before and after writing it, and is committed with its
+	// entry in a single transaction. The audit table is created by
+	// MigrateUp.
+	AuditEnabled bool
+
+	// AuditTableName is the name of the audit table, defaults to the table
+	// name with an "_audit" suffix
+	AuditTableName string
+
+	// RevisionsEnabled saves a snapshot of the payload and metas of every
+	// version of the records to the revisions table, see RecordRevisions
+	// and RecordRollback. Every write then reads the record before and
+	// after writing it, and is committed with its revision in a single
+	// transaction. The revisions table is created by MigrateUp.
+	RevisionsEnabled bool
+
+	// RevisionsTableName is the name of the revisions table, defaults to
+	// the table name with a "_revisions" suffix
+	RevisionsTableName string
+
+	// CountersEnabled maintains the number of records of every type (and
+	// tenant) which are not soft deleted in the counters table, see
+	// RecordCountFast. Every write then reads the record before and after
+	// writing it, and is committed with its count in a single transaction.
+	// The counters table is created by MigrateUp.
+	CountersEnabled bool
+
+	// CountersTableName is the name of the counters table, defaults to the
+	// table name with a "_counts" suffix
+	CountersTableName string
+
+	// LinksEnabled stores directed, typed links between records in the links
+	// table, see RecordLink and RecordListLinked. The links of a deleted
+	// or purged record are removed. The links table is created by
+	// MigrateUp.
+	LinksEnabled bool
+
+	// LinksTableName is the name of the links table, defaults to the table
+	// name with a "_links" suffix
+	LinksTableName string
+
+	// TenancyEnabled adds an indexed tenant_id column to the table (by
+	// MigrateUp) for the tenant scoped views returned by ForTenant
+	TenancyEnabled bool
+
+	// TypeTableMap stores the records of the mapped types in their own
+	// tables, keyed by record type, i.e. to keep a type dwarfing the others
+	// apart. The tables are created by MigrateUp and the records are read
+	// and written in the table of their type. Queries without a type filter
+	// read the default table only, queries of types stored in different
+	// tables fail. Cannot be combined with MigrateToTable.
+	TypeTableMap map[string]string
 }
 
 // ============================================================================
@@ -106,22 +574,131 @@ func NewStore(opts NewStoreOptions) (StoreInterface, error) {
 		return nil, errors.New("customstore store: tableName is required")
 	}
 
-	neatDB, err := neat.NewFromSQLDB(opts.DB)
+	if (opts.ReadOnly || opts.DebugSQL) && opts.AutomigrateEnabled {
+		return nil, errors.New("customstore store: automigrate cannot be enabled on a read only store")
+	}
+
+	neatDB, err := neat.NewFromSQLDB(opts.DB, driverErrorOptions(opts.RetryPolicy, opts.TransactionRetryPolicy)...)
 	if err != nil {
 		return nil, err
 	}
 
+	var readDB *neat.Database
+	if opts.ReaderDB != nil {
+		readDB, err = neat.NewFromSQLDB(opts.ReaderDB, driverErrorOptions(opts.RetryPolicy, opts.TransactionRetryPolicy)...)
+		if err != nil {
+			return nil, err
+		}
+		if readDB.Query().Driver().String() != neatDB.Query().Driver().String() {
+			return nil, errors.New("customstore store: ReaderDB must use the driver of DB")
+		}
+	}
+
+	for _, db := range []*sql.DB{opts.DB, opts.ReaderDB} {
+		if db == nil {
+			continue
+		}
+		if opts.MaxOpenConns > 0 {
+			db.SetMaxOpenConns(opts.MaxOpenConns)
+		}
+		if opts.MaxIdleConns > 0 {
+			db.SetMaxIdleConns(opts.MaxIdleConns)
+		}
+		if opts.ConnMaxLifetime > 0 {
+			db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+		}
+	}
+
+	if opts.FullTextSearchEnabled && !fullTextSearchSupported(neatDB.Query().Driver().String()) {
+		return nil, errors.New("customstore store: full text search requires SQLite or PostgreSQL")
+	}
+
 	logger := opts.Logger
 	if logger == nil {
-		logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+		logger = defaultLogger(opts.DebugEnabled)
+	}
+
+	clock := opts.Clock
+	if clock == nil {
+		clock = systemClock{}
+	}
+
+	auditTable := ""
+	if opts.AuditEnabled {
+		auditTable = opts.AuditTableName
+		if auditTable == "" {
+			auditTable = auditTableName(opts.TableName)
+		}
+	}
+
+	revisionsTable := ""
+	if opts.RevisionsEnabled {
+		revisionsTable = opts.RevisionsTableName
+		if revisionsTable == "" {
+			revisionsTable = revisionsTableName(opts.TableName)
+		}
+	}
+
+	countersTable := ""
+	if opts.CountersEnabled {
+		countersTable = opts.CountersTableName
+		if countersTable == "" {
+			countersTable = countersTableName(opts.TableName)
+		}
+	}
+
+	linksTable := ""
+	if opts.LinksEnabled {
+		linksTable = opts.LinksTableName
+		if linksTable == "" {
+			linksTable = linksTableName(opts.TableName)
+		}
+	}
+
+	typeTables, shardTables, err := newTypeTables(opts.TableName, opts.TypeTableMap)
+	if err != nil {
+		return nil, err
+	}
+
+	prefixes, err := newIDPrefixes(opts.IDPrefixes)
+	if err != nil {
+		return nil, err
 	}
 
 	store := &storeImplementation{
-		tableName:          opts.TableName,
+		tables:             &tableState{name: opts.TableName},
 		automigrateEnabled: opts.AutomigrateEnabled,
 		db:                 neatDB,
+		readDB:             readDB,
+		retryPolicy:        opts.RetryPolicy,
+		txRetryPolicy:      opts.TransactionRetryPolicy,
 		debugEnabled:       opts.DebugEnabled,
 		logger:             logger,
+		loggerInjected:     opts.Logger != nil,
+		slowQueryThreshold: opts.SlowQueryThreshold,
+		clock:              clock,
+		timestampFormat:    opts.TimestampFormat,
+		idGenerator:        opts.IDGenerator,
+		idPrefixes:         prefixes,
+		maxListLimit:       opts.MaxListLimit,
+		requireTypeFilter:  opts.RequireTypeFilter,
+		readOnly:           opts.ReadOnly || opts.DebugSQL,
+		debugSQL:           opts.DebugSQL,
+		fullTextSearch:     opts.FullTextSearchEnabled,
+		payloadMigrations:  &payloadMigrationRegistry{migrations: map[string]map[int]payloadMigration{}},
+		payloadIndexes:     &payloadIndexRegistry{keys: map[string]map[string]bool{}},
+		uniqueKeys:         &uniqueKeyRegistry{keys: map[string][]uniqueKey{}},
+		regex:              &regexSupport{},
+		recordTypes:        &recordTypeRegistry{types: map[string]RecordTypeDefinition{}},
+		hooks:              &hookRegistry{hooks: map[HookEvent][]HookFunc{}},
+		changes:            &changeFeed{subscribers: map[int]*changeSubscriber{}},
+		auditTable:         auditTable,
+		revisionsTable:     revisionsTable,
+		countersTable:      countersTable,
+		linksTable:         linksTable,
+		tenancy:            opts.TenancyEnabled,
+		typeTables:         typeTables,
+		shardTables:        shardTables,
 	}
 
 	if store.automigrateEnabled {
@@ -137,52 +714,177 @@ func NewStore(opts NewStoreOptions) (StoreInterface, error) {
 // == MIGRATE
 // ============================================================================
 
-// MigrateUp creates the table
+// MigrateUp creates the table, or upgrades an existing table, see
+// MigrateUpReport
 func (st *storeImplementation) MigrateUp(ctx context.Context, tx ...*sql.Tx) error {
-	if st.db.Schema().HasTable(st.tableName) {
+	_, err := st.MigrateUpReport(ctx)
+	return err
+}
+
+// MigrateUpReport creates the table, or upgrades an existing table by
+// adding the columns and indexes introduced by newer versions, reporting
+// the changes made. Existing columns, indexes and rows are left untouched.
+func (st *storeImplementation) MigrateUpReport(ctx context.Context) (MigrateReport, error) {
+	report := MigrateReport{}
+
+	if err := st.checkWritable(); err != nil {
+		return report, err
+	}
+
+	if err := st.checkUnscoped("MigrateUp"); err != nil {
+		return report, err
+	}
+
+	for _, view := range st.tableViews() {
+		if err := view.migrateUp(ctx, &report); err != nil {
+			return report, err
+		}
+	}
+
+	if st.debugEnabled && report.Changed() {
+		st.logger.Info("MigrateUp: schema changed", "table", st.tableName(), "created_tables", report.CreatedTables, "added_columns", report.AddedColumns, "created_indexes", report.CreatedIndexes)
+	}
+
+	return report, nil
+}
+
+// migrateUp creates or upgrades the table of the store, adding the changes
+// made to the report
+func (st *storeImplementation) migrateUp(ctx context.Context, report *MigrateReport) error {
+	if st.db.Schema().HasTable(st.tableName()) {
 		if st.debugEnabled {
-			st.logger.Info("MigrateUp: table already exists", "table", st.tableName)
+			st.logger.Info("MigrateUp: table already exists", "table", st.tableName())
 		}
-		return nil
+		if err := st.migrateSchema(ctx, st.tableName(), report); err != nil {
+			return st.wrapError(err, "MigrateUp", "", "", nil)
+		}
+		if err := st.addOptionalSchema(st.tableName(), report); err != nil {
+			return st.wrapError(err, "MigrateUp", "", "", nil)
+		}
+		if err := st.createAuditTable(); err != nil {
+			return st.wrapError(err, "MigrateUp", "", "", nil)
+		}
+		if err := st.createRevisionsTable(); err != nil {
+			return st.wrapError(err, "MigrateUp", "", "", nil)
+		}
+		if err := st.createCountersTable(); err != nil {
+			return st.wrapError(err, "MigrateUp", "", "", nil)
+		}
+		if err := st.createLinksTable(); err != nil {
+			return st.wrapError(err, "MigrateUp", "", "", nil)
+		}
+		return st.wrapError(st.createFullTextIndex(ctx, st.tableName()), "MigrateUp", "", "", nil)
+	}
+
+	err := st.createTable(st.tableName())
+	if err == nil {
+		report.CreatedTables = append(report.CreatedTables, st.tableName())
+		err = st.createSchemaTable(st.tableName())
+	}
+	if err == nil {
+		err = st.saveSchemaVersion(ctx, st.tableName(), latestSchemaVersion())
+	}
+	if err == nil {
+		err = st.createFullTextIndex(ctx, st.tableName())
+	}
+	if err == nil {
+		err = st.createAuditTable()
+	}
+	if err == nil {
+		err = st.createRevisionsTable()
+	}
+	if err == nil {
+		err = st.createCountersTable()
+	}
+	if err == nil {
+		err = st.createLinksTable()
+	}
+	if err != nil {
+		if st.debugEnabled {
+			st.logger.Error("MigrateUp failed", "error", err)
+		}
+		return st.wrapError(err, "MigrateUp", "", "", nil)
 	}
 
-	err := st.db.Schema().Create(st.tableName, func(table contractsschema.Blueprint) {
+	return nil
+}
+
+// createTable creates a store table with the given name, with its indexes
+func (st *storeImplementation) createTable(tableName string) error {
+	return st.db.Schema().Create(tableName, func(table contractsschema.Blueprint) {
 		table.String(COLUMN_ID, 40)
 		table.Primary(COLUMN_ID)
 		table.String(COLUMN_RECORD_TYPE, 100)
 		table.Text(COLUMN_PAYLOAD)
 		table.Text(COLUMN_METAS)
 		table.Text(COLUMN_MEMO)
-		table.DateTime(COLUMN_CREATED_AT)
-		table.DateTime(COLUMN_UPDATED_AT)
-		table.DateTime(COLUMN_SOFT_DELETED_AT)
+		st.timestampColumn(table, COLUMN_CREATED_AT)
+		st.timestampColumn(table, COLUMN_UPDATED_AT)
+		st.timestampColumn(table, COLUMN_SOFT_DELETED_AT)
+		table.BigInteger(COLUMN_VERSION).Default(1)
+		st.timestampColumn(table, COLUMN_EXPIRES_AT).Default(st.maxTimestampDefault())
+		if st.tenancy {
+			table.String(COLUMN_TENANT_ID, 100).Default("")
+		}
+		for _, columns := range st.tableIndexes() {
+			table.Index(columns...).Name(tableIndexName(tableName, columns))
+		}
 	})
+}
 
-	if err != nil {
-		if st.debugEnabled {
-			st.logger.Error("MigrateUp failed", "error", err)
-		}
+// MigrateDown drops the table
+func (st *storeImplementation) MigrateDown(ctx context.Context, tx ...*sql.Tx) error {
+	if err := st.checkWritable(); err != nil {
 		return err
 	}
 
-	return nil
-}
+	if err := st.checkUnscoped("MigrateDown"); err != nil {
+		return err
+	}
 
-// MigrateDown drops the table
-func (st *storeImplementation) MigrateDown(ctx context.Context, tx ...*sql.Tx) error {
-	if !st.db.Schema().HasTable(st.tableName) {
+	if st.sharded() {
+		for _, view := range st.tableViews() {
+			if err := view.MigrateDown(ctx, tx...); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !st.db.Schema().HasTable(st.tableName()) {
 		if st.debugEnabled {
-			st.logger.Info("MigrateDown: table does not exist", "table", st.tableName)
+			st.logger.Info("MigrateDown: table does not exist", "table", st.tableName())
 		}
 		return nil
 	}
 
-	err := st.db.Schema().Drop(st.tableName)
+	err := st.dropFullTextIndex(ctx, st.tableName())
+	if err == nil {
+		err = st.db.Schema().Drop(st.tableName())
+	}
+	if err == nil {
+		err = st.dropSchemaTable(st.tableName())
+	}
+	if err == nil {
+		err = st.dropAuditTable()
+	}
+	if err == nil {
+		err = st.dropRevisionsTable()
+	}
+	if err == nil {
+		err = st.dropCountersTable()
+	}
+	if err == nil {
+		err = st.dropLinksTable()
+	}
+	if err == nil {
+		err = st.dropUniqueTable()
+	}
 	if err != nil {
 		if st.debugEnabled {
 			st.logger.Error("MigrateDown failed", "error", err)
 		}
-		return err
+		return st.wrapError(err, "MigrateDown", "", "", nil)
 	}
 	return nil
 }
@@ -194,13 +896,31 @@ func (st *storeImplementation) MigrateDown(ctx context.Context, tx ...*sql.Tx) e
 // EnableDebug - enables the debug option
 func (st *storeImplementation) EnableDebug(debugEnabled bool) {
 	st.debugEnabled = debugEnabled
+	for _, db := range []*neat.Database{st.db, st.readDB} {
+		if db == nil {
+			continue
+		}
+		if debugEnabled {
+			db.EnableDebug()
+		} else if !st.retryPolicy.retries() && !st.txRetryPolicy.retries() {
+			// The retry policies need the errors of the driver
+			db.DisableDebug()
+		}
+	}
+
+	// An injected logger is kept, its handler decides what is logged
+	if !st.loggerInjected {
+		st.logger = defaultLogger(debugEnabled)
+	}
+}
+
+// defaultLogger returns the stdout logger used when none is injected,
+// logging debug messages when debug is enabled
+func defaultLogger(debugEnabled bool) *slog.Logger {
 	if debugEnabled {
-		st.db.EnableDebug()
-		st.logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	} else {
-		st.db.DisableDebug()
-		st.logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+		return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 	}
+	return slog.New(slog.NewTextHandler(os.Stdout, nil))
 }
 
 // ============================================================================
@@ -213,35 +933,145 @@ func (st *storeImplementation) GetDB() *sql.DB {
 	return db
 }
 
+// newQuery returns a new neat query bound to the given context
+func (st *storeImplementation) newQuery(ctx context.Context) contractsorm.Query {
+	if st.tx != nil {
+		return cloneQuery(ctx, st.tx)
+	}
+	return cloneQuery(ctx, st.db.Query())
+}
+
+// cloneQuery returns a copy of q bound to the given context. Neat builder
+// methods mutate the query in place, so a transaction query must be cloned
+// for every statement to stop conditions leaking into the next one.
+func cloneQuery(ctx context.Context, q contractsorm.Query) contractsorm.Query {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if queryWithContext, ok := q.(contractsorm.QueryWithContext); ok {
+		return queryWithContext.WithContext(ctx)
+	}
+	return q
+}
+
+// driverName returns the name of the database driver (i.e. "sqlite", "mysql", "postgres")
+func (st *storeImplementation) driverName() string {
+	return st.db.Query().Driver().String()
+}
+
 // ============================================================================
 // == RECORD CRUD
 // ============================================================================
 
 // RecordCount counts the number of records that match the query
 func (st *storeImplementation) RecordCount(query RecordQueryInterface) (int64, error) {
+	return st.recordCount(context.Background(), query)
+}
+
+// RecordCountContext counts the number of records that match the query,
+// using the context for cancellation and deadlines
+func (st *storeImplementation) RecordCountContext(ctx context.Context, query RecordQueryInterface) (int64, error) {
+	return st.recordCount(ctx, query)
+}
+
+// recordCount counts the records matching the query using the given context
+func (st *storeImplementation) recordCount(ctx context.Context, query RecordQueryInterface) (int64, error) {
+	if st.sharded() {
+		view, err := st.forQuery(query)
+		if err != nil {
+			return 0, err
+		}
+		return view.recordCount(ctx, query)
+	}
+
 	if st.db == nil {
 		return 0, errors.New("database is not initialized")
 	}
 
-	q := st.buildQuery(query)
+	if err := st.checkQueryGuards(query); err != nil {
+		return 0, err
+	}
+
+	q := st.buildReadQuery(ctx, query).Table(st.tableName())
+	if st.dryRun("RecordCount", func() (string, []any) { return countSQL(q) }) {
+		return 0, nil
+	}
 
 	var count int64
-	err := q.Table(st.tableName).Count(&count)
-	return count, err
+	start := time.Now()
+	err := st.retry(ctx, "RecordCount", func() error {
+		return q.Count(&count)
+	})
+	st.logQuery("RecordCount", start, func() (string, []any) {
+		return q.ToRawSql().Count(), nil
+	})
+	return count, st.wrapError(err, "RecordCount", queryRecordID(query), queryRecordType(query), func() string {
+		return q.ToSql().Count()
+	})
 }
 
 // RecordCreate creates a new record
 func (st *storeImplementation) RecordCreate(record RecordInterface) error {
+	return st.RecordCreateContext(context.Background(), record)
+}
+
+// RecordCreateContext creates a new record using the given context
+func (st *storeImplementation) RecordCreateContext(ctx context.Context, record RecordInterface) error {
+	if st.sharded() && record != nil {
+		return st.forType(record.Type()).RecordCreateContext(ctx, record)
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
 	if st.db == nil {
 		return errors.New("database is not initialized")
 	}
 
+	return st.insertRecord(ctx, record, "RecordCreate")
+}
+
+// insertRecord inserts the record using the given context, wrapping
+// failures as the given operation
+func (st *storeImplementation) insertRecord(ctx context.Context, record RecordInterface, op string) error {
+	st.assignID(record)
+	st.applyTTL(record)
+
 	if record.ID() == "" {
 		return errors.New("record ID is required")
 	}
 
-	record.SetCreatedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC))
-	record.SetUpdatedAt(carbon.Now(carbon.UTC).ToDateTimeString(carbon.UTC))
+	if err := st.runHooks(ctx, EventBeforeCreate, record.ID(), record); err != nil {
+		return err
+	}
+
+	if err := st.checkRecordType(record); err != nil {
+		return err
+	}
+
+	err := st.trackChanges(ctx, ChangeCreate, []string{record.ID()}, func(st *storeImplementation) error {
+		return st.insertRow(ctx, record, op)
+	})
+	if err != nil {
+		return err
+	}
+
+	return st.runHooks(ctx, EventAfterCreate, record.ID(), record)
+}
+
+// insertRow inserts the row of the record, stamping its timestamps
+func (st *storeImplementation) insertRow(ctx context.Context, record RecordInterface, op string) error {
+	if !sourceTimestamps(ctx) {
+		record.SetCreatedAt(st.nowDateTime())
+		record.SetUpdatedAt(st.nowDateTime())
+	}
+
+	if latest := st.latestPayloadVersion(record.Type()); latest > 0 && record.Meta(META_PAYLOAD_VERSION) == "" {
+		if err := record.SetPayloadVersion(latest); err != nil {
+			return err
+		}
+	}
 
 	metas, err := record.Metas()
 	if err != nil {
@@ -258,29 +1088,84 @@ func (st *storeImplementation) RecordCreate(record RecordInterface) error {
 		COLUMN_PAYLOAD:         record.Payload(),
 		COLUMN_METAS:           string(metasJSON),
 		COLUMN_MEMO:            record.Memo(),
-		COLUMN_CREATED_AT:      record.CreatedAtCarbon().StdTime(),
-		COLUMN_UPDATED_AT:      record.UpdatedAtCarbon().StdTime(),
-		COLUMN_SOFT_DELETED_AT: record.SoftDeletedAtCarbon().StdTime(),
+		COLUMN_CREATED_AT:      st.timestamp(record.CreatedAtCarbon().StdTime()),
+		COLUMN_UPDATED_AT:      st.timestamp(record.UpdatedAtCarbon().StdTime()),
+		COLUMN_SOFT_DELETED_AT: st.timestamp(record.SoftDeletedAtCarbon().StdTime()),
+		COLUMN_EXPIRES_AT:      st.expiresAtTimestamp(record),
+		COLUMN_VERSION:         record.Version(),
+	}
+
+	if st.tenantID != "" {
+		row[COLUMN_TENANT_ID] = st.tenantID
 	}
 
 	if st.debugEnabled {
 		st.logger.Debug("Record create", "row", row)
 	}
 
-	return st.db.Query().Table(st.tableName).Create(row)
+	unlock := st.lockWrite()
+	defer unlock()
+
+	q := st.newQuery(ctx).Table(st.tableName())
+	start := time.Now()
+	err = st.retry(ctx, op, func() error {
+		return q.Create(row)
+	})
+	st.logQuery(op, start, func() (string, []any) {
+		return q.ToRawSql().Create(row), nil
+	})
+	if err != nil {
+		return st.duplicateOf(ctx, op, st.wrapError(err, op, record.ID(), record.Type(), func() string {
+			return q.ToSql().Create(row)
+		}), record.ID(), record.Type(), record.Payload(), string(metasJSON))
+	}
+
+	return st.copyToMigrationTarget(ctx, []string{record.ID()})
 }
 
 // RecordDelete permanently deletes a record
-func (st *storeImplementation) RecordDelete(record RecordInterface) error {
+func (st *storeImplementation) RecordDelete(record RecordInterface, opts ...DeleteOption) error {
+	return st.RecordDeleteContext(context.Background(), record, opts...)
+}
+
+// RecordDeleteContext permanently deletes a record using the given context
+func (st *storeImplementation) RecordDeleteContext(ctx context.Context, record RecordInterface, opts ...DeleteOption) error {
+	if st.sharded() && record != nil {
+		return st.forType(record.Type()).RecordDeleteContext(ctx, record, opts...)
+	}
+
 	if record == nil {
 		return errors.New("record is nil")
 	}
 
-	return st.RecordDeleteByID(record.ID())
+	return st.deleteRecord(ctx, record.ID(), record, opts)
 }
 
 // RecordDeleteByID permanently deletes a record by ID
-func (st *storeImplementation) RecordDeleteByID(id string) error {
+func (st *storeImplementation) RecordDeleteByID(id string, opts ...DeleteOption) error {
+	return st.RecordDeleteByIDContext(context.Background(), id, opts...)
+}
+
+// RecordDeleteByIDContext permanently deletes a record by ID using the given context
+func (st *storeImplementation) RecordDeleteByIDContext(ctx context.Context, id string, opts ...DeleteOption) error {
+	if st.sharded() {
+		view, err := st.forID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return view.RecordDeleteByIDContext(ctx, id, opts...)
+	}
+
+	return st.deleteRecord(ctx, id, nil, opts)
+}
+
+// deleteRecord permanently deletes the record with the ID, running the
+// delete hooks with the record if known
+func (st *storeImplementation) deleteRecord(ctx context.Context, id string, record RecordInterface, opts []DeleteOption) error {
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
 	if st.db == nil {
 		return errors.New("database is not initialized")
 	}
@@ -289,16 +1174,89 @@ func (st *storeImplementation) RecordDeleteByID(id string) error {
 		return errors.New("record id is empty")
 	}
 
-	_, err := st.db.Query().
-		Table(st.tableName).
-		Where(COLUMN_ID+" = ?", id).
-		Delete()
+	if err := st.runHooks(ctx, EventBeforeDelete, id, record); err != nil {
+		return err
+	}
 
-	return err
+	deleted := false
+	deleteTracked := func(st *storeImplementation) error {
+		return st.trackChanges(ctx, ChangeDelete, []string{id}, func(st *storeImplementation) error {
+			var err error
+			deleted, err = st.deleteRow(ctx, id, opts)
+			if err != nil || !deleted {
+				return err
+			}
+			return st.deleteLinks(ctx, st.newQuery(ctx), []any{id}, "RecordDeleteByID")
+		})
+	}
+
+	// The links are deleted in the transaction of the record
+	var err error
+	if st.linksTable != "" {
+		err = st.inTransaction(deleteTracked)
+	} else {
+		err = deleteTracked(st)
+	}
+	if err != nil || !deleted {
+		return err
+	}
+
+	return st.runHooks(ctx, EventAfterDelete, id, record)
+}
+
+// deleteRow deletes the row with the ID, returning whether it existed
+func (st *storeImplementation) deleteRow(ctx context.Context, id string, opts []DeleteOption) (bool, error) {
+	options := newDeleteOptions(opts)
+
+	unlock := st.lockWrite()
+	defer unlock()
+
+	q := st.whereTenant(st.newQuery(ctx).
+		Table(st.tableName()).
+		Where(COLUMN_ID+" = ?", id))
+
+	if !options.force {
+		q = st.whereNotProtected(q)
+	}
+
+	start := time.Now()
+	result, err := st.retryResult(ctx, "RecordDeleteByID", func() (*contractsorm.Result, error) {
+		return q.Delete()
+	})
+	st.logQuery("RecordDeleteByID", start, func() (string, []any) {
+		return q.ToRawSql().Delete(), nil
+	})
+	if err != nil {
+		return false, st.wrapError(err, "RecordDeleteByID", id, "", func() string {
+			return q.ToSql().Delete()
+		})
+	}
+
+	if result.RowsAffected == 0 {
+		if options.force {
+			return false, nil
+		}
+		return false, st.checkNotProtected(ctx, id, "RecordDeleteByID")
+	}
+
+	return true, st.copyToMigrationTarget(ctx, []string{id})
 }
 
 // RecordFindByID returns a record by ID
 func (st *storeImplementation) RecordFindByID(id string) (record RecordInterface, err error) {
+	return st.RecordFindByIDContext(context.Background(), id)
+}
+
+// RecordFindByIDContext returns a record by ID using the given context
+func (st *storeImplementation) RecordFindByIDContext(ctx context.Context, id string) (record RecordInterface, err error) {
+	if st.sharded() {
+		view, err := st.forID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		return view.RecordFindByIDContext(ctx, id)
+	}
+
 	if st.db == nil {
 		return nil, errors.New("database is not initialized")
 	}
@@ -307,9 +1265,11 @@ func (st *storeImplementation) RecordFindByID(id string) (record RecordInterface
 		return nil, errors.New("record id is empty")
 	}
 
-	list, err := st.RecordList(RecordQuery().
+	query := RecordQuery().
 		SetID(id).
-		SetLimit(1))
+		SetLimit(1)
+
+	list, err := st.selectRecords(ctx, st.buildReadQuery(ctx, query), query, "RecordFindByID")
 
 	if err != nil {
 		return nil, err
@@ -324,28 +1284,154 @@ func (st *storeImplementation) RecordFindByID(id string) (record RecordInterface
 
 // RecordList returns a list of records
 func (st *storeImplementation) RecordList(query RecordQueryInterface) ([]RecordInterface, error) {
+	return st.recordList(context.Background(), query)
+}
+
+// RecordListContext returns a list of records using the given context
+func (st *storeImplementation) RecordListContext(ctx context.Context, query RecordQueryInterface) ([]RecordInterface, error) {
+	return st.recordList(ctx, query)
+}
+
+// recordList returns the records matching the query using the given context
+func (st *storeImplementation) recordList(ctx context.Context, query RecordQueryInterface) ([]RecordInterface, error) {
+	return st.listRecords(ctx, query, "RecordList")
+}
+
+// listRecords returns the records matching the query, wrapping failures as
+// the given operation
+func (st *storeImplementation) listRecords(ctx context.Context, query RecordQueryInterface, op string) ([]RecordInterface, error) {
+	if st.sharded() {
+		view, err := st.forQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		return view.listRecords(ctx, query, op)
+	}
+
 	if st.db == nil {
 		return nil, errors.New("database is not initialized")
 	}
 
-	type recordRow struct {
-		ID            string    `db:"id"`
-		Type          string    `db:"record_type"`
-		Payload       string    `db:"payload"`
-		Metas         string    `db:"metas"`
-		Memo          string    `db:"memo"`
-		CreatedAt     time.Time `db:"created_at"`
-		UpdatedAt     time.Time `db:"updated_at"`
-		SoftDeletedAt time.Time `db:"soft_deleted_at"`
+	if err := st.checkQueryGuards(query); err != nil {
+		return nil, err
+	}
+
+	if query != nil && query.IsPageTokenSet() {
+		if err := query.GetPageToken().Validate(); err != nil {
+			return nil, err
+		}
 	}
 
-	q := st.buildQuery(query)
+	if query != nil && query.IsOrderByCollationSet() {
+		if err := validateOrderByCollation(query.GetOrderByCollation()); err != nil {
+			return nil, err
+		}
+	}
+
+	return st.selectRecords(ctx, st.buildReadQuery(ctx, query), query, op)
+}
+
+// selectRecords executes the built query and maps the rows to records,
+// wrapping failures as the given operation
+func (st *storeImplementation) selectRecords(ctx context.Context, q contractsorm.Query, query RecordQueryInterface, op string) ([]RecordInterface, error) {
+	q = st.selectQuery(q, query)
+	if st.dryRun(op, func() (string, []any) { return selectSQL(q) }) {
+		return []RecordInterface{}, nil
+	}
 
 	var rows []recordRow
-	if err := q.Table(st.tableName).Get(&rows); err != nil {
-		return []RecordInterface{}, err
+	start := time.Now()
+	err := st.retry(ctx, op, func() error {
+		return q.Get(&rows)
+	})
+	st.logQuery(op, start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return []RecordInterface{}, st.wrapError(err, op, "", "", func() string {
+			return q.ToSql().Get(&rows)
+		})
 	}
 
+	return markExcludedColumns(st.recordRowsToRecords(rows), query), nil
+}
+
+// selectQuery selects the record columns of the query from the table
+func (st *storeImplementation) selectQuery(q contractsorm.Query, query RecordQueryInterface) contractsorm.Query {
+	return q.Table(st.tableName()).Select(recordColumnsFor(query) + st.orderKeySelect(query))
+}
+
+// recordColumns lists the columns selected into a recordRow. They are listed
+// explicitly, as the column list neat derives from the model misses the
+// timestamp fields.
+var recordColumns = strings.Join([]string{
+	COLUMN_ID,
+	COLUMN_RECORD_TYPE,
+	COLUMN_PAYLOAD,
+	COLUMN_METAS,
+	COLUMN_MEMO,
+	COLUMN_CREATED_AT,
+	COLUMN_UPDATED_AT,
+	COLUMN_SOFT_DELETED_AT,
+	COLUMN_VERSION,
+	COLUMN_EXPIRES_AT,
+}, ", ")
+
+// recordColumnsFor returns the columns selected for the query, leaving out
+// the payload and metas when excluded
+func recordColumnsFor(query RecordQueryInterface) string {
+	if query == nil || !(query.IsExcludePayload() || query.IsExcludeMetas()) {
+		return recordColumns
+	}
+
+	columns := []string{}
+	for _, column := range strings.Split(recordColumns, ", ") {
+		if column == COLUMN_PAYLOAD && query.IsExcludePayload() {
+			continue
+		}
+		if column == COLUMN_METAS && query.IsExcludeMetas() {
+			continue
+		}
+		columns = append(columns, column)
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+// markExcludedColumns flags the records listed without payload or metas, so
+// they are not written back empty on update
+func markExcludedColumns(records []RecordInterface, query RecordQueryInterface) []RecordInterface {
+	if query == nil || !(query.IsExcludePayload() || query.IsExcludeMetas()) {
+		return records
+	}
+
+	for _, record := range records {
+		if r, ok := record.(*recordImplementation); ok {
+			r.payloadExcluded = query.IsExcludePayload()
+			r.metasExcluded = query.IsExcludeMetas()
+		}
+	}
+
+	return records
+}
+
+// recordRow is a row of the store table as selected from the database
+type recordRow struct {
+	ID            string           `db:"id"`
+	Type          string           `db:"record_type"`
+	Payload       string           `db:"payload"`
+	Metas         string           `db:"metas"`
+	Memo          string           `db:"memo"`
+	CreatedAt     scannedTimestamp `db:"created_at"`
+	UpdatedAt     scannedTimestamp `db:"updated_at"`
+	SoftDeletedAt scannedTimestamp `db:"soft_deleted_at"`
+	Version       int64            `db:"version"`
+	ExpiresAt     scannedTimestamp `db:"expires_at"`
+}
+
+// recordRowsToRecords converts the selected rows, parsing their timestamps
+// in the format of the store
+func (st *storeImplementation) recordRowsToRecords(rows []recordRow) []RecordInterface {
 	list := make([]RecordInterface, 0, len(rows))
 	for _, r := range rows {
 		record := &recordImplementation{}
@@ -354,40 +1440,180 @@ func (st *storeImplementation) RecordList(query RecordQueryInterface) ([]RecordI
 		record.SetPayload(r.Payload)
 		record.SetMetasRaw(r.Metas)
 		record.SetMemo(r.Memo)
-		record.CreatedAtField.CreatedAt = r.CreatedAt
-		record.UpdatedAtField.UpdatedAt = r.UpdatedAt
-		record.SoftDeletesMaxDate.SoftDeletedAt = r.SoftDeletedAt
+		record.CreatedAtField.CreatedAt = st.parseTimestamp(r.CreatedAt.value)
+		record.UpdatedAtField.UpdatedAt = st.parseTimestamp(r.UpdatedAt.value)
+		record.SoftDeletesMaxDate.SoftDeletedAt = st.parseTimestamp(r.SoftDeletedAt.value)
+		record.SetVersion(r.Version)
+		record.ExpiresAtField = st.parseTimestamp(r.ExpiresAt.value)
 		list = append(list, record)
 	}
+	return list
+}
 
-	return list, nil
+func (st *storeImplementation) RecordSoftDelete(record RecordInterface, opts ...DeleteOption) error {
+	return st.RecordSoftDeleteContext(context.Background(), record, opts...)
 }
 
-func (st *storeImplementation) RecordSoftDelete(record RecordInterface) error {
+// RecordSoftDeleteContext soft deletes a record using the given context
+func (st *storeImplementation) RecordSoftDeleteContext(ctx context.Context, record RecordInterface, opts ...DeleteOption) error {
 	if record == nil {
 		return errors.New("record is nil")
 	}
 
-	return st.RecordSoftDeleteByID(record.ID())
+	return st.RecordSoftDeleteByIDContext(ctx, record.ID(), opts...)
 }
 
 // RecordSoftDeleteByID soft deletes a record by ID
-func (st *storeImplementation) RecordSoftDeleteByID(id string) error {
+func (st *storeImplementation) RecordSoftDeleteByID(id string, opts ...DeleteOption) error {
+	return st.RecordSoftDeleteByIDContext(context.Background(), id, opts...)
+}
+
+// RecordSoftDeleteByIDContext soft deletes a record by ID using the given context
+func (st *storeImplementation) RecordSoftDeleteByIDContext(ctx context.Context, id string, opts ...DeleteOption) error {
+	if st.sharded() {
+		view, err := st.forID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return view.RecordSoftDeleteByIDContext(ctx, id, opts...)
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
 	if id == "" {
 		return errors.New("record id is empty")
 	}
 
+	if err := st.runHooks(ctx, EventBeforeSoftDelete, id, nil); err != nil {
+		return err
+	}
+
+	err := st.trackChanges(ctx, ChangeSoftDelete, []string{id}, func(st *storeImplementation) error {
+		return st.softDeleteRow(ctx, id, opts)
+	})
+	if err != nil {
+		return err
+	}
+
+	return st.runHooks(ctx, EventAfterSoftDelete, id, nil)
+}
+
+// softDeleteRow soft deletes the row with the ID, see RecordSoftDeleteByID
+func (st *storeImplementation) softDeleteRow(ctx context.Context, id string, opts []DeleteOption) error {
 	row := map[string]any{
-		COLUMN_SOFT_DELETED_AT: carbon.Now(carbon.UTC).StdTime(),
-		COLUMN_UPDATED_AT:      carbon.Now(carbon.UTC).StdTime(),
+		COLUMN_SOFT_DELETED_AT: st.nowTimestamp(),
+		COLUMN_UPDATED_AT:      st.nowTimestamp(),
+	}
+	if softDeletedAt, ok := sourceSoftDeletedAt(ctx); ok {
+		row[COLUMN_SOFT_DELETED_AT] = st.timestamp(softDeletedAt)
 	}
 
-	_, err := st.db.Query().Table(st.tableName).Where(COLUMN_ID+" = ?", id).Update(row)
-	return err
+	options := newDeleteOptions(opts)
+
+	unlock := st.lockWrite()
+	defer unlock()
+
+	q := st.whereTenant(st.newQuery(ctx).Table(st.tableName()).Where(COLUMN_ID+" = ?", id))
+	if !options.force {
+		q = st.whereNotProtected(q)
+	}
+
+	start := time.Now()
+	result, err := st.retryResult(ctx, "RecordSoftDeleteByID", func() (*contractsorm.Result, error) {
+		return q.Update(row)
+	})
+	st.logQuery("RecordSoftDeleteByID", start, func() (string, []any) {
+		return q.ToRawSql().Update(row), nil
+	})
+	if err != nil {
+		return st.wrapError(err, "RecordSoftDeleteByID", id, "", func() string {
+			return q.ToSql().Update(row)
+		})
+	}
+
+	if !options.force && result.RowsAffected == 0 {
+		return st.checkNotProtected(ctx, id, "RecordSoftDeleteByID")
+	}
+
+	return st.copyToMigrationTarget(ctx, []string{id})
+}
+
+// whereNotProtected excludes the protected records from the query
+func (st *storeImplementation) whereNotProtected(q contractsorm.Query) contractsorm.Query {
+	driver := st.driverName()
+	return q.Where("COALESCE("+jsonExtractText(driver, COLUMN_METAS)+", '') <> ?", jsonPathArg(driver, META_PROTECTED), "1")
+}
+
+// checkNotProtected returns ErrProtectedRecord if the record with the ID
+// is protected, called when a delete affected no rows
+func (st *storeImplementation) checkNotProtected(ctx context.Context, id string, op string) error {
+	driver := st.driverName()
+	q := st.whereTenant(st.newQuery(ctx).
+		Table(st.tableName()).
+		Where(COLUMN_ID+" = ?", id).
+		Where(jsonExtractText(driver, COLUMN_METAS)+" = ?", jsonPathArg(driver, META_PROTECTED), "1"))
+
+	var count int64
+	start := time.Now()
+	err := st.retry(ctx, op, func() error {
+		return q.Count(&count)
+	})
+	st.logQuery(op, start, func() (string, []any) {
+		return q.ToRawSql().Count(), nil
+	})
+	if err != nil {
+		return st.wrapError(err, op, id, "", func() string {
+			return q.ToSql().Count()
+		})
+	}
+
+	if count > 0 {
+		return ErrProtectedRecord
+	}
+
+	return nil
 }
 
 // RecordUpdate updates a record
 func (st *storeImplementation) RecordUpdate(record RecordInterface) error {
+	return st.RecordUpdateContext(context.Background(), record)
+}
+
+// RecordUpdateContext updates a record using the given context
+func (st *storeImplementation) RecordUpdateContext(ctx context.Context, record RecordInterface) error {
+	return st.updateRecord(ctx, record, false, "RecordUpdate")
+}
+
+// RecordUpdateVersioned updates a record using optimistic locking
+func (st *storeImplementation) RecordUpdateVersioned(record RecordInterface) error {
+	return st.RecordUpdateVersionedContext(context.Background(), record)
+}
+
+// RecordUpdateVersionedContext updates a record using optimistic locking and
+// the given context. It returns ErrStaleRecord, leaving the stored record
+// unchanged, if the version stored no longer matches the version of the
+// record, i.e. because it was updated by someone else since it was read.
+func (st *storeImplementation) RecordUpdateVersionedContext(ctx context.Context, record RecordInterface) error {
+	return st.updateRecord(ctx, record, true, "RecordUpdateVersioned")
+}
+
+// updateRecord updates the record, incrementing its version. With
+// checkVersion only a stored record with the same version is updated.
+func (st *storeImplementation) updateRecord(ctx context.Context, record RecordInterface, checkVersion bool, op string) error {
+	if st.sharded() && record != nil && record.ID() != "" {
+		view, err := st.forUpdate(ctx, record)
+		if err != nil {
+			return err
+		}
+		return view.updateRecord(ctx, record, checkVersion, op)
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
 	if st.db == nil {
 		return errors.New("database is not initialized")
 	}
@@ -400,97 +1626,240 @@ func (st *storeImplementation) RecordUpdate(record RecordInterface) error {
 		return errors.New("record id is required")
 	}
 
-	record.SetUpdatedAt(carbon.Now(carbon.UTC).ToDateTimeString())
-
-	metas, err := record.Metas()
-	if err != nil {
+	if err := st.runHooks(ctx, EventBeforeUpdate, record.ID(), record); err != nil {
 		return err
 	}
-	metasJSON, err := json.Marshal(metas)
+
+	err := st.trackChanges(ctx, ChangeUpdate, []string{record.ID()}, func(st *storeImplementation) error {
+		return st.updateRow(ctx, record, checkVersion, op)
+	})
 	if err != nil {
 		return err
 	}
 
+	return st.runHooks(ctx, EventAfterUpdate, record.ID(), record)
+}
+
+// updateRow updates the row of the record, see updateRecord
+func (st *storeImplementation) updateRow(ctx context.Context, record RecordInterface, checkVersion bool, op string) error {
+	if !sourceTimestamps(ctx) {
+		record.SetUpdatedAt(st.nowDateTime())
+	}
+
+	version := record.Version()
+
 	row := map[string]any{
 		COLUMN_RECORD_TYPE: record.Type(),
-		COLUMN_PAYLOAD:     record.Payload(),
-		COLUMN_METAS:       string(metasJSON),
 		COLUMN_MEMO:        record.Memo(),
-		COLUMN_UPDATED_AT:  record.UpdatedAtCarbon().StdTime(),
+		COLUMN_UPDATED_AT:  st.timestamp(record.UpdatedAtCarbon().StdTime()),
+		COLUMN_EXPIRES_AT:  st.expiresAtTimestamp(record),
+		COLUMN_VERSION:     version + 1,
+	}
+
+	// Columns excluded when listing are left as stored
+	if record.IsPayloadLoaded() {
+		row[COLUMN_PAYLOAD] = record.Payload()
+	}
+
+	if record.IsMetasLoaded() {
+		metas, err := record.Metas()
+		if err != nil {
+			return err
+		}
+		metasJSON, err := json.Marshal(metas)
+		if err != nil {
+			return err
+		}
+		row[COLUMN_METAS] = string(metasJSON)
 	}
 
 	if st.debugEnabled {
 		st.logger.Debug("Record update", "row", row)
 	}
 
-	_, err = st.db.Query().Table(st.tableName).Where(COLUMN_ID+" = ?", record.ID()).Update(row)
-	return err
+	unlock := st.lockWrite()
+	defer unlock()
+
+	q := st.whereTenant(st.newQuery(ctx).Table(st.tableName()).Where(COLUMN_ID+" = ?", record.ID()))
+	if checkVersion {
+		q = q.Where(COLUMN_VERSION+" = ?", version)
+	}
+
+	start := time.Now()
+	result, err := st.retryResult(ctx, op, func() (*contractsorm.Result, error) {
+		return q.Update(row)
+	})
+	st.logQuery(op, start, func() (string, []any) {
+		return q.ToRawSql().Update(row), nil
+	})
+	if err != nil {
+		payload, _ := row[COLUMN_PAYLOAD].(string)
+		metas, _ := row[COLUMN_METAS].(string)
+		return st.duplicateOf(ctx, op, st.wrapError(err, op, record.ID(), record.Type(), func() string {
+			return q.ToSql().Update(row)
+		}), record.ID(), record.Type(), payload, metas)
+	}
+
+	if result.RowsAffected == 0 {
+		if checkVersion {
+			return ErrStaleRecord
+		}
+		return ErrNotFound
+	}
+
+	record.SetVersion(version + 1)
+
+	return st.copyToMigrationTarget(ctx, []string{record.ID()})
 }
 
 // ============================================================================
 // == QUERY BUILDER
 // ============================================================================
 
+// checkQueryGuards enforces the store guardrails on a caller supplied query
+func (st *storeImplementation) checkQueryGuards(query RecordQueryInterface) error {
+	if query != nil && queryHasFullTextSearch(query) && !st.fullTextSearch {
+		return ErrFullTextSearchDisabled
+	}
+
+	if query != nil && queryHasPayloadSearchRegex(query) && !st.regexSupported() {
+		return ErrRegexUnsupported
+	}
+
+	if err := st.checkOrderByCollation(query); err != nil {
+		return err
+	}
+
+	if !st.requireTypeFilter {
+		return nil
+	}
+
+	if query == nil || !queryHasTypeFilter(query) {
+		return ErrTypeFilterRequired
+	}
+
+	return nil
+}
+
 // buildQuery builds a neat query from the record query interface.
-func (st *storeImplementation) buildQuery(query RecordQueryInterface) contractsorm.Query {
-	// Use Model() to enable neat's automatic soft delete handling via SoftDeletesMaxDate
-	q := st.db.Query().Model(&recordImplementation{})
+func (st *storeImplementation) buildQuery(ctx context.Context, query RecordQueryInterface) contractsorm.Query {
+	return st.applyQuery(st.newQuery(ctx), query)
+}
+
+// buildFilterQuery builds a neat query selecting the records matching the
+// record query, without its limit, offset, page token or order (nor the cap
+// of MaxListLimit), i.e. for aggregates over every matching record.
+func (st *storeImplementation) buildFilterQuery(ctx context.Context, query RecordQueryInterface) contractsorm.Query {
+	return st.applyFilters(st.newQuery(ctx), query)
+}
+
+// applyFilters applies the record query filters to the given (fresh) base
+// query, see buildFilterQuery
+func (st *storeImplementation) applyFilters(base contractsorm.Query, query RecordQueryInterface) contractsorm.Query {
+	q := st.whereTenant(base)
+
+	if query != nil && query.IsOnlySoftDeleted() {
+		q = q.Where(COLUMN_SOFT_DELETED_AT+" <= ?", st.nowTimestamp())
+	} else if query == nil || !query.IsSoftDeletedIncluded() {
+		// Active records have a soft deleted at in the future (MAX_DATETIME
+		// unless scheduled), compared against the store clock
+		q = q.Where(COLUMN_SOFT_DELETED_AT+" > ?", st.nowTimestamp())
+	}
+
+	if query == nil || !query.IsExpiredIncluded() {
+		// Records without an expiry expire at MAX_DATETIME
+		q = q.Where(COLUMN_EXPIRES_AT+" > ?", st.nowTimestamp())
+	}
 
 	if query == nil {
 		return q
 	}
 
-	if query.IsIDSet() && query.GetID() != "" {
-		q = q.Where(COLUMN_ID+" = ?", query.GetID())
+	for _, condition := range st.queryConditions(query) {
+		q = q.Where(condition.sql, condition.args...)
 	}
 
-	if query.IsIDListSet() && len(query.GetIDList()) > 0 {
-		idList := query.GetIDList()
-		anyList := make([]any, len(idList))
-		for i, v := range idList {
-			anyList[i] = v
+	return q
+}
+
+// applyQuery applies the record query filters, paging and order to the
+// given (fresh) base query.
+func (st *storeImplementation) applyQuery(base contractsorm.Query, query RecordQueryInterface) contractsorm.Query {
+	q := st.applyFilters(base, query)
+
+	if query == nil {
+		if st.maxListLimit > 0 {
+			q = q.Limit(st.maxListLimit)
 		}
-		q = q.WhereIn(COLUMN_ID, anyList)
+		return q
 	}
 
-	if query.IsTypeSet() && query.GetType() != "" {
-		q = q.Where(COLUMN_RECORD_TYPE+" = ?", query.GetType())
+	limit := 0
+	if query.IsLimitSet() {
+		limit = query.GetLimit()
 	}
-
-	if query.IsLimitSet() && query.GetLimit() > 0 {
-		q = q.Limit(query.GetLimit())
+	if st.maxListLimit > 0 && (limit <= 0 || limit > st.maxListLimit) {
+		limit = st.maxListLimit
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
 	}
 
 	if query.IsOffsetSet() && query.GetOffset() > 0 {
 		q = q.Offset(query.GetOffset())
 	}
 
-	if query.IsOrderBySet() && query.GetOrderBy() != "" {
-		q = q.OrderByDesc(query.GetOrderBy())
-	}
+	if query.IsPageTokenSet() && query.GetPageToken().Validate() == nil {
+		// Keyset pagination, descending by the token column then by ID
+		token := query.GetPageToken()
+		if token.OrderBy == COLUMN_ID {
+			q = q.Where(COLUMN_ID+" < ?", token.ID).OrderByDesc(COLUMN_ID)
+		} else {
+			q = q.Where("("+token.OrderBy+" < ? OR ("+token.OrderBy+" = ? AND "+COLUMN_ID+" < ?))", token.whereValue(st.timestamp), token.whereValue(st.timestamp), token.ID).
+				OrderByDesc(token.OrderBy).
+				OrderByDesc(COLUMN_ID)
+		}
+	} else if (query.IsOrderBySet() && query.GetOrderBy() != "") || len(query.GetOrderByList()) > 0 {
+		orderedByID := false
+
+		if query.IsOrderBySet() && query.GetOrderBy() != "" {
+			if st.orderKeySelect(query) != "" {
+				// Selected by the caller, see orderKeySelect
+				q = q.OrderByDesc(ORDER_KEY_ALIAS)
+			} else {
+				q = q.OrderByDesc(query.GetOrderBy())
+			}
+			orderedByID = query.GetOrderBy() == COLUMN_ID
+		}
 
-	// Payload search (OR within positive searches, AND for negative)
-	searchTerms := query.GetPayloadSearch()
-	if len(searchTerms) > 0 {
-		var searchQuery strings.Builder
-		searchArgs := make([]any, 0, len(searchTerms))
-		for i, needle := range searchTerms {
-			if i > 0 {
-				searchQuery.WriteString(" OR ")
+		for _, orderBy := range query.GetOrderByList() {
+			if !orderByColumns[orderBy.Column] {
+				continue
 			}
-			searchQuery.WriteString(COLUMN_PAYLOAD + " LIKE ?")
-			searchArgs = append(searchArgs, "%"+needle+"%")
+			if orderBy.Direction == ORDER_ASC {
+				q = q.OrderBy(orderBy.Column)
+			} else {
+				q = q.OrderByDesc(orderBy.Column)
+			}
+			orderedByID = orderedByID || orderBy.Column == COLUMN_ID
 		}
-		q = q.Where("("+searchQuery.String()+")", searchArgs...)
-	}
-	for _, needle := range query.GetPayloadSearchNot() {
-		q = q.Where(COLUMN_PAYLOAD+" NOT LIKE ?", "%"+needle+"%")
-	}
 
-	// Handle soft delete filtering via neat's automatic handling (SoftDeletesMaxDate)
-	if query.IsSoftDeletedIncluded() {
-		q = q.WithSoftDeleted()
+		if !orderedByID {
+			// Tie breaker, so pages continued with a page token are stable
+			q = q.OrderByDesc(COLUMN_ID)
+		}
 	}
 
 	return q
 }
+
+// sortedKeys returns the keys of the filter map sorted, so that the built
+// statements are deterministic
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
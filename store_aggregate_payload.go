@@ -0,0 +1,85 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// AggregatePayload computes the sum, average, minimum and maximum of the
+// numeric payload value at path (a top level payload key) over the records
+// matching the query.
+//
+// The aggregation runs in SQL, extracting the value from the JSON payload
+// and casting it to a floating point number of the driver. Records missing
+// the key or holding another value than a JSON number, numeric strings
+// included (except on SQL Server), are ignored. All results are zero when
+// no record matches. The limit, offset and order of the query are ignored.
+func (st *storeImplementation) AggregatePayload(query RecordQueryInterface, path string) (sum float64, avg float64, min float64, max float64, err error) {
+	return st.AggregatePayloadContext(context.Background(), query, path)
+}
+
+// AggregatePayloadContext is AggregatePayload using the given context
+func (st *storeImplementation) AggregatePayloadContext(ctx context.Context, query RecordQueryInterface, path string) (sum float64, avg float64, min float64, max float64, err error) {
+	if st.sharded() {
+		view, err := st.forQuery(query)
+		if err != nil {
+			return 0, 0, 0, 0, err
+		}
+		return view.AggregatePayloadContext(ctx, query, path)
+	}
+
+	if st.db == nil {
+		return 0, 0, 0, 0, errors.New("database is not initialized")
+	}
+
+	if err := st.checkQueryGuards(query); err != nil {
+		return 0, 0, 0, 0, err
+	}
+
+	if path == "" {
+		return 0, 0, 0, 0, errors.New("path is required")
+	}
+
+	driver := st.driverName()
+	value := jsonExtractNumeric(driver, COLUMN_PAYLOAD)
+	arg := jsonPathArg(driver, path)
+	args := make([]any, 4*strings.Count(value, "?"))
+	for i := range args {
+		args[i] = arg
+	}
+
+	// Ordering has no meaning for the single aggregate row, and every
+	// matching record is aggregated
+	q := st.buildFilterQuery(ctx, query).
+		Table(st.tableName()).
+		Select("SUM("+value+") AS agg_sum, AVG("+value+") AS agg_avg, MIN("+value+") AS agg_min, MAX("+value+") AS agg_max", args...)
+
+	if st.dryRun("AggregatePayload", func() (string, []any) { return selectSQL(q) }) {
+		return 0, 0, 0, 0, nil
+	}
+
+	var rows []map[string]any
+	start := time.Now()
+	err = st.retry(ctx, "AggregatePayload", func() error {
+		return q.Get(&rows)
+	})
+	st.logQuery("AggregatePayload", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return 0, 0, 0, 0, st.wrapError(err, "AggregatePayload", queryRecordID(query), queryRecordType(query), func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	if len(rows) == 0 {
+		return 0, 0, 0, 0, nil
+	}
+
+	row := rows[0]
+	return cast.ToFloat64(row["agg_sum"]), cast.ToFloat64(row["agg_avg"]), cast.ToFloat64(row["agg_min"]), cast.ToFloat64(row["agg_max"]), nil
+}
@@ -0,0 +1,84 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestAggregatePayload(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_aggregate_payload",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	payloads := []string{
+		`{"amount":10}`,
+		`{"amount":2.5}`,
+		`{"amount":"30"}`,
+		`{"amount":"n/a"}`,
+		`{"amount":true}`,
+		`{"amount":{"value":5}}`,
+		`{"amount":30}`,
+		`{"other":1}`,
+		``,
+	}
+
+	for i, payload := range payloads {
+		rec := customstore.NewRecord("invoice", customstore.WithPayload(payload))
+		if err := store.RecordCreate(rec); err != nil {
+			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
+		}
+	}
+
+	if err := store.RecordCreate(customstore.NewRecord("order", customstore.WithPayload(`{"amount":1000}`))); err != nil {
+		t.Fatalf("RecordCreate order failed: %v", err)
+	}
+
+	sum, avg, min, max, err := store.AggregatePayload(customstore.RecordQuery().SetType("invoice"), "amount")
+	if err != nil {
+		t.Fatalf("AggregatePayload failed: %v", err)
+	}
+	if sum != 42.5 {
+		t.Fatalf("Expected sum 42.5, but got %v", sum)
+	}
+	// Values which are not JSON numbers, numeric strings included, are not
+	// counted
+	if avg != 42.5/3 {
+		t.Fatalf("Expected avg %v, but got %v", 42.5/3, avg)
+	}
+	if min != 2.5 {
+		t.Fatalf("Expected min 2.5, but got %v", min)
+	}
+	if max != 30 {
+		t.Fatalf("Expected max 30, but got %v", max)
+	}
+
+	sum, avg, min, max, err = store.AggregatePayload(customstore.RecordQuery().SetType("unknown"), "amount")
+	if err != nil {
+		t.Fatalf("AggregatePayload for unknown type failed: %v", err)
+	}
+	if sum != 0 || avg != 0 || min != 0 || max != 0 {
+		t.Fatalf("Expected zero results when no record matches, but got %v %v %v %v", sum, avg, min, max)
+	}
+
+	// Every matching record is aggregated, whatever the page of the query
+	sum, _, _, _, err = store.AggregatePayloadContext(t.Context(), customstore.RecordQuery().SetType("invoice").SetLimit(1).SetOffset(1), "amount")
+	if err != nil {
+		t.Fatalf("AggregatePayloadContext failed: %v", err)
+	}
+	if sum != 42.5 {
+		t.Fatalf("Expected the limit and offset to be ignored, but got sum %v", sum)
+	}
+
+	if _, _, _, _, err := store.AggregatePayload(customstore.RecordQuery(), ""); err == nil {
+		t.Fatalf("Expected error for empty path, but got nil")
+	}
+}
@@ -0,0 +1,321 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"time"
+
+	contractsschema "github.com/dracory/neat/contracts/database/schema"
+	neatuid "github.com/dracory/neat/support/uid"
+)
+
+// Columns of the audit table
+const (
+	auditColumnRecordID   = "record_id"
+	auditColumnAction     = "action"
+	auditColumnActor      = "actor"
+	auditColumnChanged    = "changed"
+	auditColumnOldPayload = "old_payload"
+	auditColumnNewPayload = "new_payload"
+)
+
+// AuditEntry is a change of a record written to the audit table, see
+// RecordAuditHistory
+type AuditEntry struct {
+	// ID is the ID of the entry
+	ID string
+
+	// RecordID is the ID of the changed record
+	RecordID string
+
+	// RecordType is the type of the record after the change (before, for
+	// deletes)
+	RecordType string
+
+	// Action is the kind of change
+	Action ChangeType
+
+	// Actor is who made the change, see WithAuditActor. Empty if unknown.
+	Actor string
+
+	// Changed lists the changed columns, and the changed top level payload
+	// keys prefixed with "payload."
+	Changed []string
+
+	// OldPayload is the payload before the change, empty for creates
+	OldPayload string
+
+	// NewPayload is the payload after the change, empty for deletes
+	NewPayload string
+
+	// CreatedAt is when the change was made
+	CreatedAt string
+}
+
+// auditRow is a row of the audit table
+type auditRow struct {
+	ID         string    `db:"id"`
+	RecordID   string    `db:"record_id"`
+	RecordType string    `db:"record_type"`
+	Action     string    `db:"action"`
+	Actor      string    `db:"actor"`
+	Changed    string    `db:"changed"`
+	OldPayload string    `db:"old_payload"`
+	NewPayload string    `db:"new_payload"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// auditActorKey is the context key of the audit actor
+type auditActorKey struct{}
+
+// WithAuditActor returns a copy of the context recording the actor (i.e. a
+// user ID) as who made the changes written with it to the audit table
+func WithAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActor returns the actor of the context, see WithAuditActor
+func AuditActor(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	actor, _ := ctx.Value(auditActorKey{}).(string)
+	return actor
+}
+
+// auditTableName returns the name of the audit table of a store table
+func auditTableName(tableName string) string {
+	return tableName + "_audit"
+}
+
+// RecordAuditHistory returns the audit entries of the record with the ID,
+// oldest first. Requires AuditEnabled. A tenant scoped view only returns
+// the entries written for its tenant.
+func (st *storeImplementation) RecordAuditHistory(id string) ([]AuditEntry, error) {
+	return st.RecordAuditHistoryContext(context.Background(), id)
+}
+
+// RecordAuditHistoryContext is RecordAuditHistory using the given context
+func (st *storeImplementation) RecordAuditHistoryContext(ctx context.Context, id string) ([]AuditEntry, error) {
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	if st.auditTable == "" {
+		return nil, errors.New("audit is not enabled")
+	}
+
+	if id == "" {
+		return nil, errors.New("record id is required")
+	}
+
+	q := st.newQuery(ctx).
+		Table(st.auditTable).
+		Where(auditColumnRecordID+" = ?", id)
+	q = st.whereTenant(q).
+		OrderBy(COLUMN_CREATED_AT).
+		OrderBy(COLUMN_ID)
+
+	var rows []auditRow
+	start := time.Now()
+	err := q.Get(&rows)
+	st.logQuery("RecordAuditHistory", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return nil, st.wrapError(err, "RecordAuditHistory", id, "", func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	entries := make([]AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		changed := []string{}
+		if row.Changed != "" {
+			if err := json.Unmarshal([]byte(row.Changed), &changed); err != nil {
+				return nil, err
+			}
+		}
+
+		entries = append(entries, AuditEntry{
+			ID:         row.ID,
+			RecordID:   row.RecordID,
+			RecordType: row.RecordType,
+			Action:     ChangeType(row.Action),
+			Actor:      row.Actor,
+			Changed:    changed,
+			OldPayload: row.OldPayload,
+			NewPayload: row.NewPayload,
+			CreatedAt:  row.CreatedAt.Format(time.DateTime),
+		})
+	}
+
+	return entries, nil
+}
+
+// createAuditTable creates the audit table if audit is enabled. Safe to
+// call when it exists.
+func (st *storeImplementation) createAuditTable() error {
+	if st.auditTable == "" || st.db.Schema().HasTable(st.auditTable) {
+		return nil
+	}
+
+	return st.db.Schema().Create(st.auditTable, func(table contractsschema.Blueprint) {
+		table.String(COLUMN_ID, 40)
+		table.Primary(COLUMN_ID)
+		table.String(auditColumnRecordID, 40)
+		table.String(COLUMN_RECORD_TYPE, 100)
+		table.String(auditColumnAction, 20)
+		table.String(auditColumnActor, 100)
+		table.String(COLUMN_TENANT_ID, 100).Default("")
+		table.Text(auditColumnChanged)
+		table.Text(auditColumnOldPayload)
+		table.Text(auditColumnNewPayload)
+		table.DateTime(COLUMN_CREATED_AT)
+		table.Index(auditColumnRecordID, COLUMN_CREATED_AT)
+	})
+}
+
+// dropAuditTable drops the audit table if audit is enabled
+func (st *storeImplementation) dropAuditTable() error {
+	if st.auditTable == "" || !st.db.Schema().HasTable(st.auditTable) {
+		return nil
+	}
+
+	return st.db.Schema().Drop(st.auditTable)
+}
+
+// writeAudit writes an audit entry per change event, in the transaction the
+// store is bound to if any
+func (st *storeImplementation) writeAudit(ctx context.Context, events []ChangeEvent) error {
+	actor := AuditActor(ctx)
+	now := st.now()
+
+	for _, event := range events {
+		changed, err := json.Marshal(changedFields(event.Old, event.New))
+		if err != nil {
+			return err
+		}
+
+		row := map[string]any{
+			COLUMN_ID:             neatuid.GenerateShortID(),
+			auditColumnRecordID:   event.RecordID,
+			auditColumnAction:     string(event.Type),
+			auditColumnActor:      actor,
+			auditColumnChanged:    string(changed),
+			auditColumnOldPayload: "",
+			auditColumnNewPayload: "",
+			COLUMN_CREATED_AT:     now,
+		}
+		if st.tenancy {
+			row[COLUMN_TENANT_ID] = event.TenantID
+		}
+		if event.Old != nil {
+			row[COLUMN_RECORD_TYPE] = event.Old.Type()
+			row[auditColumnOldPayload] = event.Old.Payload()
+		}
+		if event.New != nil {
+			row[COLUMN_RECORD_TYPE] = event.New.Type()
+			row[auditColumnNewPayload] = event.New.Payload()
+		}
+
+		q := st.newQuery(ctx).Table(st.auditTable)
+		start := time.Now()
+		err = q.Create(row)
+		st.logQuery("Audit", start, func() (string, []any) {
+			return q.ToRawSql().Create(row), nil
+		})
+		if err != nil {
+			return st.wrapError(err, "Audit", event.RecordID, "", func() string {
+				return q.ToSql().Create(row)
+			})
+		}
+	}
+
+	return nil
+}
+
+// changedFields lists the columns differing between the snapshots, and the
+// differing top level keys of JSON object payloads prefixed with "payload."
+// (or "payload" if either is not an object). Creates and deletes list the
+// columns and keys set on the record.
+func changedFields(before RecordInterface, after RecordInterface) []string {
+	column := func(record RecordInterface, name string) string {
+		if record == nil {
+			// The soft delete and expiration of a missing record are
+			// unset, as the defaults of a new record
+			if name == COLUMN_SOFT_DELETED_AT || name == COLUMN_EXPIRES_AT {
+				return MAX_DATETIME
+			}
+			return ""
+		}
+		switch name {
+		case COLUMN_RECORD_TYPE:
+			return record.Type()
+		case COLUMN_METAS:
+			metas, _ := record.Metas()
+			if len(metas) == 0 {
+				return ""
+			}
+			metasJSON, _ := json.Marshal(metas)
+			return string(metasJSON)
+		case COLUMN_MEMO:
+			return record.Memo()
+		case COLUMN_SOFT_DELETED_AT:
+			return record.SoftDeletedAt()
+		case COLUMN_EXPIRES_AT:
+			return record.ExpiresAt()
+		}
+		return ""
+	}
+
+	changed := []string{}
+	for _, name := range []string{COLUMN_RECORD_TYPE, COLUMN_METAS, COLUMN_MEMO, COLUMN_SOFT_DELETED_AT, COLUMN_EXPIRES_AT} {
+		if column(before, name) != column(after, name) {
+			changed = append(changed, name)
+		}
+	}
+
+	payload := func(record RecordInterface) (string, map[string]any) {
+		if record == nil {
+			return "", map[string]any{}
+		}
+		data, err := record.PayloadMap()
+		if err != nil {
+			return record.Payload(), nil
+		}
+		return record.Payload(), data
+	}
+
+	beforePayload, beforeData := payload(before)
+	afterPayload, afterData := payload(after)
+	if beforePayload == afterPayload {
+		return changed
+	}
+
+	if beforeData == nil || afterData == nil {
+		return append(changed, COLUMN_PAYLOAD)
+	}
+
+	keys := []string{}
+	for key, value := range beforeData {
+		if other, ok := afterData[key]; !ok || !reflect.DeepEqual(value, other) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range afterData {
+		if _, ok := beforeData[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		changed = append(changed, PAYLOAD_KEY_PREFIX+key)
+	}
+
+	return changed
+}
@@ -0,0 +1,260 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreAuditHistory(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_audit",
+		AutomigrateEnabled: true,
+		AuditEnabled:       true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := customstore.WithAuditActor(context.Background(), "user-1")
+
+	record := customstore.NewRecord("person", customstore.WithPayload(`{"name":"Ann","age":30}`))
+	if err := store.RecordCreateContext(ctx, record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	record.SetPayload(`{"name":"Ann","age":31}`)
+	record.SetMemo("birthday")
+	if err := store.RecordUpdateContext(ctx, record); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	// Without an actor
+	if err := store.RecordSoftDeleteByID(record.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+
+	if err := store.RecordDeleteByIDContext(ctx, record.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+
+	history, err := store.RecordAuditHistory(record.ID())
+	if err != nil {
+		t.Fatalf("RecordAuditHistory failed: %v", err)
+	}
+
+	actions := []customstore.ChangeType{}
+	for _, entry := range history {
+		actions = append(actions, entry.Action)
+	}
+	expected := []customstore.ChangeType{customstore.ChangeCreate, customstore.ChangeUpdate, customstore.ChangeSoftDelete, customstore.ChangeDelete}
+	if !slices.Equal(actions, expected) {
+		t.Fatalf("Expected actions %v, got %v", expected, actions)
+	}
+
+	created := history[0]
+	if created.Actor != "user-1" || created.RecordType != "person" {
+		t.Fatalf("Unexpected create entry: %+v", created)
+	}
+	if created.OldPayload != "" || created.NewPayload != `{"name":"Ann","age":30}` {
+		t.Fatalf("Unexpected create payloads: %+v", created)
+	}
+	if created.CreatedAt == "" {
+		t.Fatal("Expected the entry to have a creation time")
+	}
+
+	updated := history[1]
+	if !slices.Equal(updated.Changed, []string{"memo", "payload.age"}) {
+		t.Fatalf("Expected memo and payload.age changed, got %v", updated.Changed)
+	}
+	if updated.OldPayload != `{"name":"Ann","age":30}` || updated.NewPayload != `{"name":"Ann","age":31}` {
+		t.Fatalf("Unexpected update payloads: %+v", updated)
+	}
+
+	softDeleted := history[2]
+	if softDeleted.Actor != "" || !slices.Equal(softDeleted.Changed, []string{"soft_deleted_at"}) {
+		t.Fatalf("Unexpected soft delete entry: %+v", softDeleted)
+	}
+
+	deleted := history[3]
+	if deleted.NewPayload != "" || deleted.RecordType != "person" {
+		t.Fatalf("Unexpected delete entry: %+v", deleted)
+	}
+}
+
+func TestStoreAuditTransaction(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_audit_tx",
+		AutomigrateEnabled: true,
+		AuditEnabled:       true,
+		AuditTableName:     "data_audit_tx_history",
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	committed := customstore.NewRecord("person")
+	rolledBack := customstore.NewRecord("person")
+
+	err = store.RunInTransaction(context.Background(), func(tx customstore.TransactionInterface) error {
+		return tx.RecordCreate(committed)
+	})
+	if err != nil {
+		t.Fatalf("RunInTransaction failed: %v", err)
+	}
+
+	errRollback := errors.New("rollback")
+	err = store.RunInTransaction(context.Background(), func(tx customstore.TransactionInterface) error {
+		if err := tx.RecordCreate(rolledBack); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("Expected the rollback error, got %v", err)
+	}
+
+	history, err := store.RecordAuditHistory(committed.ID())
+	if err != nil {
+		t.Fatalf("RecordAuditHistory failed: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("Expected 1 entry for the committed record, got %d", len(history))
+	}
+
+	history, err = store.RecordAuditHistory(rolledBack.ID())
+	if err != nil {
+		t.Fatalf("RecordAuditHistory failed: %v", err)
+	}
+	if len(history) != 0 {
+		t.Fatalf("Expected the entry of the rolled back record to be rolled back, got %d", len(history))
+	}
+}
+
+func TestStoreAuditDisabled(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_audit_disabled",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if _, err := store.RecordAuditHistory("id"); err == nil {
+		t.Fatal("Expected an error when audit is disabled")
+	}
+}
+
+func TestStoreAuditFailure(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_audit_failure",
+		AutomigrateEnabled: true,
+		AuditEnabled:       true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person", customstore.WithPayload(`{"name":"Ann"}`))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if _, err := db.Exec("DROP TABLE data_audit_failure_audit"); err != nil {
+		t.Fatalf("Drop failed: %v", err)
+	}
+
+	// A failed audit entry fails and rolls back the write
+	record.SetPayload(`{"name":"Bob"}`)
+	if err := store.RecordUpdate(record); err == nil {
+		t.Fatal("Expected the update to fail with the audit")
+	}
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil || found == nil || found.Payload() != `{"name":"Ann"}` {
+		t.Fatalf("Expected the update to be rolled back: %v", err)
+	}
+
+	created := customstore.NewRecord("person")
+	if err := store.RecordCreate(created); err == nil {
+		t.Fatal("Expected the create to fail with the audit")
+	}
+	if found, err := store.RecordFindByID(created.ID()); err != nil || found != nil {
+		t.Fatalf("Expected the create to be rolled back: %v", err)
+	}
+
+	if err := store.RecordDeleteByID(record.ID()); err == nil {
+		t.Fatal("Expected the delete to fail with the audit")
+	}
+	if found, err := store.RecordFindByID(record.ID()); err != nil || found == nil {
+		t.Fatalf("Expected the delete to be rolled back: %v", err)
+	}
+}
+
+func TestStoreAuditTenancy(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_audit_tenancy",
+		AutomigrateEnabled: true,
+		AuditEnabled:       true,
+		TenancyEnabled:     true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	acme, err := store.ForTenant("acme")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+	globex, err := store.ForTenant("globex")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+
+	record := customstore.NewRecord("person", customstore.WithPayload(`{"name":"Ann"}`))
+	if err := acme.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	// Written through the unscoped store, the entry keeps the tenant of
+	// the record
+	record.SetPayload(`{"name":"Ann Lee"}`)
+	if err := store.RecordUpdate(record); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		store    customstore.StoreInterface
+		expected int
+	}{{acme, 2}, {globex, 0}, {store, 2}} {
+		history, err := tc.store.RecordAuditHistory(record.ID())
+		if err != nil {
+			t.Fatalf("RecordAuditHistory failed: %v", err)
+		}
+		if len(history) != tc.expected {
+			t.Fatalf("Expected %d entries, got %d", tc.expected, len(history))
+		}
+	}
+}
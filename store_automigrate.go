@@ -0,0 +1,116 @@
+package customstore
+
+import (
+	"strings"
+
+	contractsschema "github.com/dracory/neat/contracts/database/schema"
+)
+
+// MigrateReport lists the changes made to the schema by MigrateUpReport
+type MigrateReport struct {
+	// CreatedTables lists the tables created
+	CreatedTables []string
+
+	// AddedColumns lists the columns added to existing tables, as
+	// table.column
+	AddedColumns []string
+
+	// CreatedIndexes lists the indexes added to existing tables. The
+	// indexes of created tables are not listed.
+	CreatedIndexes []string
+
+	// AppliedMigrations lists the schema migrations applied to existing
+	// tables, in order
+	AppliedMigrations []string
+}
+
+// Changed returns whether the schema was changed
+func (r MigrateReport) Changed() bool {
+	return len(r.CreatedTables) > 0 || len(r.AddedColumns) > 0 || len(r.CreatedIndexes) > 0 || len(r.AppliedMigrations) > 0
+}
+
+// addColumn adds the column to the table with the definition, unless the
+// table has it. The added columns have defaults, so the existing rows stay
+// valid.
+func (st *storeImplementation) addColumn(tableName string, column string, define func(table contractsschema.Blueprint), report *MigrateReport) error {
+	if st.db.Schema().HasColumn(tableName, column) {
+		return nil
+	}
+
+	if err := st.db.Schema().Table(tableName, define); err != nil {
+		return err
+	}
+	report.AddedColumns = append(report.AddedColumns, tableName+"."+column)
+	return nil
+}
+
+// addIndex creates the index of the table on the columns, unless the table
+// has it
+func (st *storeImplementation) addIndex(tableName string, columns []string, report *MigrateReport) error {
+	indexName := tableIndexName(tableName, columns)
+	if st.db.Schema().HasIndex(tableName, indexName) {
+		return nil
+	}
+
+	err := st.db.Schema().Table(tableName, func(table contractsschema.Blueprint) {
+		table.Index(columns...).Name(indexName)
+	})
+	if err != nil {
+		return err
+	}
+	report.CreatedIndexes = append(report.CreatedIndexes, indexName)
+	return nil
+}
+
+// addOptionalSchema adds the columns and indexes of the enabled options
+// missing from the table. They depend on the options of the store, not on
+// the schema version, so they are checked on every MigrateUp.
+func (st *storeImplementation) addOptionalSchema(tableName string, report *MigrateReport) error {
+	if !st.tenancy {
+		return nil
+	}
+
+	err := st.addColumn(tableName, COLUMN_TENANT_ID, func(table contractsschema.Blueprint) {
+		table.String(COLUMN_TENANT_ID, 100).Default("")
+	}, report)
+	if err != nil {
+		return err
+	}
+
+	// The audit and revisions tables created before the tenant was stored
+	// with their rows
+	for _, sideTable := range []string{st.auditTable, st.revisionsTable} {
+		if sideTable == "" || !st.db.Schema().HasTable(sideTable) {
+			continue
+		}
+		err := st.addColumn(sideTable, COLUMN_TENANT_ID, func(table contractsschema.Blueprint) {
+			table.String(COLUMN_TENANT_ID, 100).Default("")
+		}, report)
+		if err != nil {
+			return err
+		}
+	}
+
+	return st.addIndex(tableName, []string{COLUMN_TENANT_ID, COLUMN_RECORD_TYPE}, report)
+}
+
+// tableIndexes returns the columns of the indexes of the table
+func (st *storeImplementation) tableIndexes() [][]string {
+	indexes := [][]string{
+		{COLUMN_RECORD_TYPE},
+		{COLUMN_CREATED_AT},
+		{COLUMN_SOFT_DELETED_AT},
+	}
+
+	if st.tenancy {
+		indexes = append(indexes, []string{COLUMN_TENANT_ID, COLUMN_RECORD_TYPE})
+	}
+
+	return indexes
+}
+
+// tableIndexName returns the name of the index of the table on the columns
+func tableIndexName(tableName string, columns []string) string {
+	name := strings.ToLower(tableName + "_" + strings.Join(columns, "_") + "_index")
+	return strings.NewReplacer("-", "_", ".", "_").Replace(name)
+}
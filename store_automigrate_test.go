@@ -0,0 +1,110 @@
+package customstore_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreMigrateUpReportUpgradesTable(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	// A table created by the first release, without the later columns
+	_, err := db.Exec(`CREATE TABLE data_automigrate (
+		id VARCHAR(40) PRIMARY KEY,
+		record_type VARCHAR(100),
+		payload TEXT,
+		metas TEXT,
+		memo TEXT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		soft_deleted_at DATETIME
+	)`)
+	if err != nil {
+		t.Fatalf("Create table failed: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO data_automigrate VALUES ('legacy', 'order', '{}', '{}', '', '2024-01-01 00:00:00', '2024-01-01 00:00:00', '9999-12-31 23:59:59')`)
+	if err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:        db,
+		TableName: "data_automigrate",
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	report, err := store.MigrateUpReport(t.Context())
+	if err != nil {
+		t.Fatalf("MigrateUpReport failed: %v", err)
+	}
+
+	if len(report.CreatedTables) != 0 {
+		t.Fatalf("Expected no table to be created, got %v", report.CreatedTables)
+	}
+	if !slices.Equal(report.AddedColumns, []string{"data_automigrate.version", "data_automigrate.expires_at"}) {
+		t.Fatalf("Unexpected added columns: %v", report.AddedColumns)
+	}
+	expectedIndexes := []string{
+		"data_automigrate_record_type_index",
+		"data_automigrate_created_at_index",
+		"data_automigrate_soft_deleted_at_index",
+	}
+	if !slices.Equal(report.CreatedIndexes, expectedIndexes) {
+		t.Fatalf("Unexpected created indexes: %v", report.CreatedIndexes)
+	}
+
+	record, err := store.RecordFindByID("legacy")
+	if err != nil || record == nil {
+		t.Fatalf("Expected the legacy record to be kept: %v", err)
+	}
+	// At the version of the records created by NewRecord
+	if record.Version() != 1 {
+		t.Fatalf("Expected the legacy record at version 1, got %d", record.Version())
+	}
+
+	// Upgrading again changes nothing
+	report, err = store.MigrateUpReport(t.Context())
+	if err != nil {
+		t.Fatalf("MigrateUpReport failed: %v", err)
+	}
+	if report.Changed() {
+		t.Fatalf("Expected no changes, got %+v", report)
+	}
+}
+
+func TestStoreMigrateUpReportCreatesTable(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:        db,
+		TableName: "data_automigrate_new",
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	report, err := store.MigrateUpReport(t.Context())
+	if err != nil {
+		t.Fatalf("MigrateUpReport failed: %v", err)
+	}
+	if !slices.Equal(report.CreatedTables, []string{"data_automigrate_new"}) {
+		t.Fatalf("Unexpected created tables: %v", report.CreatedTables)
+	}
+	if len(report.AddedColumns) != 0 || len(report.CreatedIndexes) != 0 {
+		t.Fatalf("Expected only the table to be reported, got %+v", report)
+	}
+
+	var indexes int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = 'data_automigrate_new' AND name LIKE '%_index'").Scan(&indexes); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if indexes != 3 {
+		t.Fatalf("Expected the table to be created with 3 indexes, got %d", indexes)
+	}
+}
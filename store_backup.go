@@ -0,0 +1,238 @@
+package customstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// backupFormat identifies the files written by Backup
+const backupFormat = "customstore-backup"
+
+// backupFormatVersion is the version of the file format written by Backup.
+// Restore reads this and every earlier version.
+const backupFormatVersion = 1
+
+// backupHeader is the first line of a backup
+type backupHeader struct {
+	Format    string   `json:"format"`
+	Version   int      `json:"version"`
+	Table     string   `json:"table"`
+	Types     []string `json:"types,omitempty"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// backupFooter is the last line of a backup, telling a complete backup from
+// a truncated one
+type backupFooter struct {
+	Count int `json:"count"`
+}
+
+// backupLine is a record line or the footer line of a backup
+type backupLine struct {
+	exportedRecord
+	End *backupFooter `json:"end"`
+}
+
+// Backup writes a point in time snapshot of the table to w, of the records
+// of the given types or of every record if none are given, returning the
+// number of records written. Soft deleted and expired records are included.
+//
+// The records are read in a single repeatable read transaction, so the
+// snapshot is consistent while the table is written to. The backup is
+// newline delimited JSON: a header line with the format version, a line per
+// record as written by Export and a footer line with the record count.
+func (st *storeImplementation) Backup(ctx context.Context, w io.Writer, recordTypes ...string) (int, error) {
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if w == nil {
+		return 0, errors.New("writer is nil")
+	}
+
+	header := backupHeader{
+		Format:    backupFormat,
+		Version:   backupFormatVersion,
+		Table:     st.tableName(),
+		Types:     recordTypes,
+		CreatedAt: st.nowDateTime(),
+	}
+
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(header); err != nil {
+		return 0, err
+	}
+
+	query := NewRecordQuery().
+		SetSoftDeletedIncluded(true).
+		SetExpiredIncluded(true)
+	if len(recordTypes) > 0 {
+		query.SetTypeIn(recordTypes)
+	}
+
+	exported := 0
+	snapshot := func(tx contractsorm.Query) error {
+		// The records of every table are read in the snapshot
+		for _, view := range st.tableViews() {
+			bound := *view
+			bound.tx = tx
+			// The whole table is backed up by design
+			bound.requireTypeFilter = false
+
+			count, err := bound.ExportContext(ctx, w, query)
+			exported += count
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if st.tx != nil {
+		err = snapshot(st.tx)
+	} else {
+		err = st.db.Transaction(snapshot, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	}
+	if err != nil {
+		return exported, st.wrapError(err, "Backup", "", "", nil)
+	}
+
+	footer := struct {
+		End backupFooter `json:"end"`
+	}{End: backupFooter{Count: exported}}
+	if err := encoder.Encode(footer); err != nil {
+		return exported, err
+	}
+
+	return exported, nil
+}
+
+// Restore replaces the records in the scope of a backup written by Backup
+// (the backed up types, or the whole table) with the records read from r,
+// returning the number of records restored.
+//
+// The records in scope are deleted and the backed up records written in a
+// single transaction, so the table is left untouched if the backup is
+// invalid, truncated or fails to be written. Hooks do not run and the writes
+// are not streamed by Changes. Restoring while MigrateToTable runs is not
+// supported.
+func (st *storeImplementation) Restore(ctx context.Context, r io.Reader) (int, error) {
+	if err := st.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if r == nil {
+		return 0, errors.New("reader is nil")
+	}
+
+	if st.migrationTarget() != "" {
+		return 0, errors.New("restore is not supported while migrating to a new table")
+	}
+
+	decoder := json.NewDecoder(r)
+
+	header := backupHeader{}
+	if err := decoder.Decode(&header); err != nil {
+		return 0, errors.New("restore header: " + err.Error())
+	}
+
+	if header.Format != backupFormat {
+		return 0, errors.New("restore header: not a backup")
+	}
+
+	if header.Version < 1 || header.Version > backupFormatVersion {
+		return 0, errors.New("restore header: unsupported backup version " + strconv.Itoa(header.Version))
+	}
+
+	unlock := st.lockWrite()
+	defer unlock()
+
+	restored := 0
+	err := st.transaction(func(tx contractsorm.Query) error {
+		for _, view := range st.tableViews() {
+			q := view.whereTenant(cloneQuery(ctx, tx).Table(view.tableName()))
+			if len(header.Types) > 0 {
+				anyList := make([]any, len(header.Types))
+				for i, recordType := range header.Types {
+					anyList[i] = recordType
+				}
+				q = q.WhereIn(COLUMN_RECORD_TYPE, anyList)
+			}
+
+			start := time.Now()
+			_, err := q.Delete()
+			view.logQuery("Restore", start, func() (string, []any) {
+				return q.ToRawSql().Delete(), nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		batch := []RecordInterface{}
+		for {
+			line := backupLine{}
+			err := decoder.Decode(&line)
+			if errors.Is(err, io.EOF) {
+				return errors.New("restore: backup is truncated, the end line is missing")
+			}
+			if err != nil {
+				return errors.New("restore record " + strconv.Itoa(restored+len(batch)+1) + ": " + err.Error())
+			}
+
+			if line.End != nil {
+				if line.End.Count != restored+len(batch) {
+					return errors.New("restore: backup has " + strconv.Itoa(restored+len(batch)) + " records, the end line counts " + strconv.Itoa(line.End.Count))
+				}
+				break
+			}
+
+			record, err := line.record()
+			if err != nil {
+				return errors.New("restore record " + strconv.Itoa(restored+len(batch)+1) + ": " + err.Error())
+			}
+
+			if len(header.Types) > 0 && !slices.Contains(header.Types, record.Type()) {
+				return errors.New("restore record " + strconv.Itoa(restored+len(batch)+1) + ": record type " + record.Type() + " is not in the backup")
+			}
+
+			batch = append(batch, record)
+			if len(batch) < importBatchSize {
+				continue
+			}
+
+			if err := st.writeImported(ctx, tx, batch, "Restore"); err != nil {
+				return err
+			}
+			restored += len(batch)
+			batch = batch[:0]
+		}
+
+		if len(batch) > 0 {
+			if err := st.writeImported(ctx, tx, batch, "Restore"); err != nil {
+				return err
+			}
+			restored += len(batch)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, st.wrapError(err, "Restore", "", "", nil)
+	}
+
+	return restored, nil
+}
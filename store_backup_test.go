@@ -0,0 +1,204 @@
+package customstore_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreBackupRestore(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_backup",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	person := customstore.NewRecord("person", customstore.WithPayload(`{"name":"Ann"}`))
+	if err := store.RecordCreate(person); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	softDeleted := customstore.NewRecord("person")
+	if err := store.RecordCreate(softDeleted); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordSoftDeleteByID(softDeleted.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	invoice := customstore.NewRecord("invoice")
+	if err := store.RecordCreate(invoice); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	backedUp, err := store.Backup(ctx, &buffer)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if backedUp != 3 {
+		t.Fatalf("Expected 3 records backed up, got %d", backedUp)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected a header, 3 records and an end line, got %d lines", len(lines))
+	}
+	if !strings.Contains(lines[0], `"format":"customstore-backup"`) || !strings.Contains(lines[0], `"version":1`) {
+		t.Fatalf("Unexpected header: %s", lines[0])
+	}
+	if lines[4] != `{"end":{"count":3}}` {
+		t.Fatalf("Unexpected end line: %s", lines[4])
+	}
+
+	// Changes after the backup are rolled back by the restore
+	person.SetPayload(`{"name":"Bob"}`)
+	if err := store.RecordUpdate(person); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+	if err := store.RecordCreate(customstore.NewRecord("person")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	restored, err := store.Restore(ctx, bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored != 3 {
+		t.Fatalf("Expected 3 records restored, got %d", restored)
+	}
+
+	count, err := store.RecordCount(customstore.NewRecordQuery().SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 records after the restore, got %d", count)
+	}
+
+	found, err := store.RecordFindByID(person.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found.Payload() != `{"name":"Ann"}` {
+		t.Fatalf("Expected the backed up payload, got %s", found.Payload())
+	}
+	if found.Version() != 1 {
+		t.Fatalf("Expected the backed up version, got %d", found.Version())
+	}
+}
+
+func TestStoreBackupRestoreTypes(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_backup_types",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := store.RecordCreate(customstore.NewRecord("person")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	if _, err := store.Backup(ctx, &buffer, "person"); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+
+	// Records of other types are left as they are
+	if err := store.RecordCreate(customstore.NewRecord("invoice")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordCreate(customstore.NewRecord("person")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if _, err := store.Restore(ctx, bytes.NewReader(buffer.Bytes())); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	people, err := store.RecordCount(customstore.NewRecordQuery().SetType("person"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if people != 1 {
+		t.Fatalf("Expected 1 person after the restore, got %d", people)
+	}
+
+	invoices, err := store.RecordCount(customstore.NewRecordQuery().SetType("invoice"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if invoices != 1 {
+		t.Fatalf("Expected the invoice to be kept, got %d", invoices)
+	}
+}
+
+func TestStoreRestoreInvalid(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_restore_invalid",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if err := store.RecordCreate(customstore.NewRecord("person")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	if _, err := store.Backup(ctx, &buffer); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+
+	if err := store.RecordCreate(customstore.NewRecord("person")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	cases := map[string]string{
+		"not a backup":        lines[1] + "\n",
+		"future version":      strings.Replace(lines[0], `"version":1`, `"version":99`, 1) + "\n" + lines[1] + "\n" + lines[2] + "\n",
+		"truncated":           lines[0] + "\n" + lines[1] + "\n",
+		"count mismatch":      lines[0] + "\n" + lines[1] + "\n" + `{"end":{"count":2}}` + "\n",
+		"invalid record line": lines[0] + "\n" + `{"id":""}` + "\n" + lines[2] + "\n",
+	}
+
+	for name, backup := range cases {
+		if _, err := store.Restore(ctx, strings.NewReader(backup)); err == nil {
+			t.Fatalf("Expected an error for %s", name)
+		}
+	}
+
+	// Failed restores are rolled back
+	count, err := store.RecordCount(customstore.NewRecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected the 2 records to be kept, got %d", count)
+	}
+}
@@ -0,0 +1,310 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// CacheInterface is the key value cache used by NewCachedStore, i.e. a thin
+// adapter over a Redis client:
+//
+//	func (c redisCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+//		value, err := c.client.Get(ctx, key).Bytes()
+//		if errors.Is(err, redis.Nil) {
+//			return nil, false, nil
+//		}
+//		return value, err == nil, err
+//	}
+type CacheInterface interface {
+	// Get returns the cached value, and false if the key is not cached
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set caches the value for the ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes the key from the cache
+	Delete(ctx context.Context, key string) error
+}
+
+var _ StoreInterface = (*cachedStoreImplementation)(nil)
+
+// cachedStoreImplementation is a read through cache for RecordFindByID over
+// a store, invalidated by the record methods writing by ID
+type cachedStoreImplementation struct {
+	StoreInterface
+	cache     CacheInterface
+	ttl       time.Duration
+	keyPrefix string
+}
+
+// NewCachedStore returns the store with RecordFindByID results cached for
+// the ttl, i.e. in Redis. The cached record is removed when it is updated,
+// upserted, patched, incremented, touched, rolled back, deleted, soft
+// deleted or restored through the returned store.
+//
+// Writes bypassing the returned store (other instances, transactions,
+// purges, imports, restores, TransformPayloads and payload migrations) are
+// not invalidated, and are only seen once the cached record expires. Use a
+// ttl matching the staleness acceptable for the records.
+//
+// A tenant scoped view (see ForTenant) caches its records apart from the
+// other tenants, so a cache shared by every view never returns a record of
+// another tenant.
+//
+// Cache failures are not fatal: a failing Get or Set falls back to the
+// store, a failing invalidation is returned after the write succeeded.
+func NewCachedStore(store StoreInterface, cache CacheInterface, ttl time.Duration) (StoreInterface, error) {
+	if store == nil {
+		return nil, errors.New("store is nil")
+	}
+
+	if cache == nil {
+		return nil, errors.New("cache is nil")
+	}
+
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+
+	keyPrefix := "customstore:"
+	if st, ok := store.(*storeImplementation); ok {
+		keyPrefix += st.tableName() + ":"
+		if st.tenantID != "" {
+			// Views of different tenants do not see the records cached
+			// for each other
+			keyPrefix += "tenant:" + st.tenantID + ":"
+		}
+	}
+
+	return &cachedStoreImplementation{
+		StoreInterface: store,
+		cache:          cache,
+		ttl:            ttl,
+		keyPrefix:      keyPrefix,
+	}, nil
+}
+
+func (c *cachedStoreImplementation) cacheKey(id string) string {
+	return c.keyPrefix + id
+}
+
+// invalidate removes the cached record after a successful write
+func (c *cachedStoreImplementation) invalidate(ctx context.Context, id string, err error) error {
+	if err != nil {
+		return err
+	}
+	return c.cache.Delete(ctx, c.cacheKey(id))
+}
+
+// == READ ==
+
+func (c *cachedStoreImplementation) RecordFindByID(id string) (RecordInterface, error) {
+	return c.RecordFindByIDContext(context.Background(), id)
+}
+
+func (c *cachedStoreImplementation) RecordFindByIDContext(ctx context.Context, id string) (RecordInterface, error) {
+	if id == "" {
+		return nil, errors.New("record id is empty")
+	}
+
+	if data, found, err := c.cache.Get(ctx, c.cacheKey(id)); err == nil && found {
+		record, err := decodeCachedRecord(data)
+		if err == nil && !record.IsSoftDeleted() && !record.IsExpired() {
+			return record, nil
+		}
+	}
+
+	record, err := c.StoreInterface.RecordFindByIDContext(ctx, id)
+	if err != nil || record == nil {
+		return record, err
+	}
+
+	if data, err := encodeCachedRecord(record); err == nil {
+		_ = c.cache.Set(ctx, c.cacheKey(id), data, c.ttl)
+	}
+
+	return record, nil
+}
+
+// == WRITE ==
+
+func (c *cachedStoreImplementation) RecordUpdate(record RecordInterface) error {
+	return c.RecordUpdateContext(context.Background(), record)
+}
+
+func (c *cachedStoreImplementation) RecordUpdateContext(ctx context.Context, record RecordInterface) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.invalidate(ctx, record.ID(), c.StoreInterface.RecordUpdateContext(ctx, record))
+}
+
+func (c *cachedStoreImplementation) RecordUpdateVersioned(record RecordInterface) error {
+	return c.RecordUpdateVersionedContext(context.Background(), record)
+}
+
+func (c *cachedStoreImplementation) RecordUpdateVersionedContext(ctx context.Context, record RecordInterface) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.invalidate(ctx, record.ID(), c.StoreInterface.RecordUpdateVersionedContext(ctx, record))
+}
+
+func (c *cachedStoreImplementation) RecordUpdateMany(records []RecordInterface) (UpdateManyResult, error) {
+	return c.RecordUpdateManyContext(context.Background(), records)
+}
+
+func (c *cachedStoreImplementation) RecordUpdateManyContext(ctx context.Context, records []RecordInterface) (UpdateManyResult, error) {
+	result, err := c.StoreInterface.RecordUpdateManyContext(ctx, records)
+	if err != nil {
+		return result, err
+	}
+	for _, record := range records {
+		if record == nil || record.ID() == "" {
+			continue
+		}
+		if err := c.cache.Delete(ctx, c.cacheKey(record.ID())); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (c *cachedStoreImplementation) RecordUpsert(record RecordInterface) error {
+	return c.RecordUpsertContext(context.Background(), record)
+}
+
+func (c *cachedStoreImplementation) RecordUpsertContext(ctx context.Context, record RecordInterface) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.invalidate(ctx, record.ID(), c.StoreInterface.RecordUpsertContext(ctx, record))
+}
+
+func (c *cachedStoreImplementation) RecordPatchPayloadByID(id string, patch string) error {
+	return c.RecordPatchPayloadByIDContext(context.Background(), id, patch)
+}
+
+func (c *cachedStoreImplementation) RecordPatchPayloadByIDContext(ctx context.Context, id string, patch string) error {
+	return c.invalidate(ctx, id, c.StoreInterface.RecordPatchPayloadByIDContext(ctx, id, patch))
+}
+
+func (c *cachedStoreImplementation) RecordIncrementPayloadKey(id string, key string, delta float64) error {
+	return c.RecordIncrementPayloadKeyContext(context.Background(), id, key, delta)
+}
+
+func (c *cachedStoreImplementation) RecordIncrementPayloadKeyContext(ctx context.Context, id string, key string, delta float64) error {
+	return c.invalidate(ctx, id, c.StoreInterface.RecordIncrementPayloadKeyContext(ctx, id, key, delta))
+}
+
+func (c *cachedStoreImplementation) RecordDelete(record RecordInterface, opts ...DeleteOption) error {
+	return c.RecordDeleteContext(context.Background(), record, opts...)
+}
+
+func (c *cachedStoreImplementation) RecordDeleteContext(ctx context.Context, record RecordInterface, opts ...DeleteOption) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.RecordDeleteByIDContext(ctx, record.ID(), opts...)
+}
+
+func (c *cachedStoreImplementation) RecordDeleteByID(id string, opts ...DeleteOption) error {
+	return c.RecordDeleteByIDContext(context.Background(), id, opts...)
+}
+
+func (c *cachedStoreImplementation) RecordDeleteByIDContext(ctx context.Context, id string, opts ...DeleteOption) error {
+	return c.invalidate(ctx, id, c.StoreInterface.RecordDeleteByIDContext(ctx, id, opts...))
+}
+
+func (c *cachedStoreImplementation) RecordSoftDelete(record RecordInterface, opts ...DeleteOption) error {
+	return c.RecordSoftDeleteContext(context.Background(), record, opts...)
+}
+
+func (c *cachedStoreImplementation) RecordSoftDeleteContext(ctx context.Context, record RecordInterface, opts ...DeleteOption) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.RecordSoftDeleteByIDContext(ctx, record.ID(), opts...)
+}
+
+func (c *cachedStoreImplementation) RecordSoftDeleteByID(id string, opts ...DeleteOption) error {
+	return c.RecordSoftDeleteByIDContext(context.Background(), id, opts...)
+}
+
+func (c *cachedStoreImplementation) RecordSoftDeleteByIDContext(ctx context.Context, id string, opts ...DeleteOption) error {
+	return c.invalidate(ctx, id, c.StoreInterface.RecordSoftDeleteByIDContext(ctx, id, opts...))
+}
+
+func (c *cachedStoreImplementation) RecordRestore(record RecordInterface) error {
+	return c.RecordRestoreContext(context.Background(), record)
+}
+
+func (c *cachedStoreImplementation) RecordRestoreContext(ctx context.Context, record RecordInterface) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.invalidate(ctx, record.ID(), c.StoreInterface.RecordRestoreContext(ctx, record))
+}
+
+func (c *cachedStoreImplementation) RecordRestoreByID(id string) error {
+	return c.RecordRestoreByIDContext(context.Background(), id)
+}
+
+func (c *cachedStoreImplementation) RecordRestoreByIDContext(ctx context.Context, id string) error {
+	return c.invalidate(ctx, id, c.StoreInterface.RecordRestoreByIDContext(ctx, id))
+}
+
+func (c *cachedStoreImplementation) RecordTouch(id string) error {
+	return c.RecordTouchContext(context.Background(), id)
+}
+
+func (c *cachedStoreImplementation) RecordTouchContext(ctx context.Context, id string) error {
+	return c.invalidate(ctx, id, c.StoreInterface.RecordTouchContext(ctx, id))
+}
+
+func (c *cachedStoreImplementation) RecordRollback(id string, revision int64) error {
+	return c.RecordRollbackContext(context.Background(), id, revision)
+}
+
+func (c *cachedStoreImplementation) RecordRollbackContext(ctx context.Context, id string, revision int64) error {
+	return c.invalidate(ctx, id, c.StoreInterface.RecordRollbackContext(ctx, id, revision))
+}
+
+// == ENCODING ==
+
+// encodeCachedRecord encodes the record columns as cached
+func encodeCachedRecord(record RecordInterface) ([]byte, error) {
+	metas, err := record.Metas()
+	if err != nil {
+		return nil, err
+	}
+	metasJSON, err := json.Marshal(metas)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(map[string]string{
+		COLUMN_ID:              record.ID(),
+		COLUMN_RECORD_TYPE:     record.Type(),
+		COLUMN_PAYLOAD:         record.Payload(),
+		COLUMN_METAS:           string(metasJSON),
+		COLUMN_MEMO:            record.Memo(),
+		COLUMN_CREATED_AT:      record.CreatedAt(),
+		COLUMN_UPDATED_AT:      record.UpdatedAt(),
+		COLUMN_SOFT_DELETED_AT: record.SoftDeletedAt(),
+		COLUMN_EXPIRES_AT:      record.ExpiresAt(),
+		COLUMN_VERSION:         strconv.FormatInt(record.Version(), 10),
+	})
+}
+
+// decodeCachedRecord decodes a record encoded by encodeCachedRecord
+func decodeCachedRecord(data []byte) (RecordInterface, error) {
+	columns := map[string]string{}
+	if err := json.Unmarshal(data, &columns); err != nil {
+		return nil, err
+	}
+	return NewRecordFromExistingData(columns), nil
+}
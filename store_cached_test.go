@@ -0,0 +1,229 @@
+package customstore_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+// memoryCache is an in memory CacheInterface counting the cache hits
+type memoryCache struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	hits   int
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: map[string][]byte{}}
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, found := c.values[key]
+	if found {
+		c.hits++
+	}
+	return value, found, nil
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+	return nil
+}
+
+func (c *memoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.values, key)
+	return nil
+}
+
+func TestCachedStore(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_cached",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	cache := newMemoryCache()
+	cached, err := customstore.NewCachedStore(store, cache, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCachedStore failed: %v", err)
+	}
+
+	record := customstore.NewRecord("person",
+		customstore.WithPayload(`{"name":"John"}`),
+		customstore.WithMetas(map[string]string{"role": "admin"}))
+	if err := cached.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	first, err := cached.RecordFindByID(record.ID())
+	if err != nil || first == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if cache.hits != 0 {
+		t.Fatalf("Expected the first find to miss the cache, but got %d hits", cache.hits)
+	}
+
+	second, err := cached.RecordFindByID(record.ID())
+	if err != nil || second == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if cache.hits != 1 {
+		t.Fatalf("Expected the second find to hit the cache, but got %d hits", cache.hits)
+	}
+	if second.Payload() != first.Payload() || second.Meta("role") != "admin" || second.Version() != first.Version() || second.CreatedAt() != first.CreatedAt() {
+		t.Fatalf("Expected the cached record to match the stored one, but got payload %q", second.Payload())
+	}
+
+	second.SetPayload(`{"name":"Johnny"}`)
+	if err := cached.RecordUpdate(second); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	updated, err := cached.RecordFindByID(record.ID())
+	if err != nil || updated == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if updated.Payload() != `{"name":"Johnny"}` {
+		t.Fatalf("Expected the update to invalidate the cache, but got payload %q", updated.Payload())
+	}
+
+	if err := cached.RecordIncrementPayloadKey(record.ID(), "visits", 1); err != nil {
+		t.Fatalf("RecordIncrementPayloadKey failed: %v", err)
+	}
+	incremented, err := cached.RecordFindByID(record.ID())
+	if err != nil || incremented == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if incremented.PayloadInt("visits", 0) != 1 {
+		t.Fatalf("Expected the increment to invalidate the cache, but got payload %q", incremented.Payload())
+	}
+
+	if err := cached.RecordSoftDeleteByID(record.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	deleted, err := cached.RecordFindByID(record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if deleted != nil {
+		t.Fatalf("Expected the soft deleted record not to be served from the cache")
+	}
+
+	if _, err := customstore.NewCachedStore(store, nil, time.Minute); err == nil {
+		t.Fatalf("Expected error for a nil cache, but got nil")
+	}
+	if _, err := customstore.NewCachedStore(store, cache, 0); err == nil {
+		t.Fatalf("Expected error for a zero ttl, but got nil")
+	}
+}
+
+func TestCachedStoreTenancy(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_cached_tenancy",
+		AutomigrateEnabled: true,
+		TenancyEnabled:     true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	acme, err := store.ForTenant("acme")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+	globex, err := store.ForTenant("globex")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+
+	// One cache shared by the views
+	cache := newMemoryCache()
+	cachedAcme, err := customstore.NewCachedStore(acme, cache, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCachedStore failed: %v", err)
+	}
+	cachedGlobex, err := customstore.NewCachedStore(globex, cache, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCachedStore failed: %v", err)
+	}
+
+	record := customstore.NewRecord("order", customstore.WithPayload(`{"total":10}`))
+	if err := cachedAcme.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	found, err := cachedAcme.RecordFindByID(record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("Expected the record to be found, got %v: %v", found, err)
+	}
+
+	found, err = cachedGlobex.RecordFindByID(record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatal("Expected the record cached for another tenant not to be found")
+	}
+}
+
+func TestCachedStoreRollback(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_cached_rollback",
+		AutomigrateEnabled: true,
+		RevisionsEnabled:   true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	cached, err := customstore.NewCachedStore(store, newMemoryCache(), time.Minute)
+	if err != nil {
+		t.Fatalf("NewCachedStore failed: %v", err)
+	}
+
+	record := customstore.NewRecord("document", customstore.WithPayload(`{"title":"Draft"}`))
+	if err := cached.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	record.SetPayload(`{"title":"Final"}`)
+	if err := cached.RecordUpdate(record); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+	if found, err := cached.RecordFindByID(record.ID()); err != nil || found == nil || found.Payload() != `{"title":"Final"}` {
+		t.Fatalf("Expected the updated record to be cached, got %v: %v", found, err)
+	}
+
+	if err := cached.RecordRollback(record.ID(), 1); err != nil {
+		t.Fatalf("RecordRollback failed: %v", err)
+	}
+
+	found, err := cached.RecordFindByID(record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v, %v", found, err)
+	}
+	if found.Payload() != `{"title":"Draft"}` {
+		t.Fatalf("Expected the rolled back payload, but got %s", found.Payload())
+	}
+}
@@ -0,0 +1,346 @@
+package customstore
+
+import (
+	"context"
+	"sync"
+)
+
+// ChangeType is the kind of mutation of a ChangeEvent
+type ChangeType string
+
+const (
+	// ChangeCreate is a created record, or an upsert inserting one
+	ChangeCreate ChangeType = "create"
+
+	// ChangeUpdate is an updated (or restored) record
+	ChangeUpdate ChangeType = "update"
+
+	// ChangeSoftDelete is a soft deleted record
+	ChangeSoftDelete ChangeType = "soft_delete"
+
+	// ChangeDelete is a hard deleted record
+	ChangeDelete ChangeType = "delete"
+)
+
+// ChangeEvent is a mutation of a record, see Changes
+type ChangeEvent struct {
+	// Type is the kind of mutation
+	Type ChangeType
+
+	// RecordID is the ID of the record
+	RecordID string
+
+	// Old is the record before the mutation, nil for creates
+	Old RecordInterface
+
+	// New is the record after the mutation, nil for deletes
+	New RecordInterface
+
+	// TenantID is the tenant of the record, empty unless tenancy is
+	// enabled
+	TenantID string
+}
+
+// changeFeed holds the subscribers of the changes of a store
+type changeFeed struct {
+	mu          sync.RWMutex
+	nextID      int
+	subscribers map[int]*changeSubscriber
+}
+
+type changeSubscriber struct {
+	ch   chan ChangeEvent
+	done <-chan struct{}
+
+	// tenantID is the tenant of the view subscribing, receiving only the
+	// changes of its records, empty for every change
+	tenantID string
+}
+
+// changeBuffer collects the changes made in a transaction, published once
+// it is committed
+type changeBuffer struct {
+	events []ChangeEvent
+
+	// savepoints holds the number of events collected when each savepoint
+	// was marked, to drop the events rolled back with it
+	savepoints map[string]int
+}
+
+// savepoint marks the events collected so far as kept by a rollback to the
+// savepoint with the name
+func (b *changeBuffer) savepoint(name string) {
+	if b.savepoints == nil {
+		b.savepoints = map[string]int{}
+	}
+	b.savepoints[name] = len(b.events)
+}
+
+// rollbackTo drops the events collected since the savepoint with the name
+func (b *changeBuffer) rollbackTo(name string) {
+	if length, exists := b.savepoints[name]; exists && length <= len(b.events) {
+		b.events = b.events[:length]
+	}
+}
+
+// changeCapture holds the snapshots of the records taken before a write,
+// see captureChanges
+type changeCapture struct {
+	st         *storeImplementation
+	ctx        context.Context
+	changeType ChangeType
+	ids        []string
+	old        map[string]RecordInterface
+	oldTenants map[string]string
+}
+
+// Changes returns a channel receiving a ChangeEvent, with snapshots of the
+// record before and after, for every create, update, upsert, restore,
+// payload patch or increment, soft delete and delete made through the
+// store, its views and transactions. The channel is closed when the
+// context is done. On a tenant scoped view (see ForTenant), only the
+// changes of the records of the tenant are received.
+//
+// Changes made in a transaction are received once it is committed. Bulk
+// purges (RecordPurgeSoftDeleted, RecordPurgeExpired and the maintenance
+// tasks) are not reported.
+//
+// Events are delivered in order and none is dropped: a write waits until
+// every subscriber has room for its events, so keep up with the channel
+// or give it a buffer. While anyone subscribes, every write reads the
+// record before and after writing it. The snapshots are shared between the
+// subscribers, treat them as read only.
+func (st *storeImplementation) Changes(ctx context.Context, bufferSize int) <-chan ChangeEvent {
+	if bufferSize < 0 {
+		bufferSize = 0
+	}
+
+	subscriber := &changeSubscriber{
+		ch:       make(chan ChangeEvent, bufferSize),
+		done:     ctx.Done(),
+		tenantID: st.tenantID,
+	}
+
+	st.changes.mu.Lock()
+	id := st.changes.nextID
+	st.changes.nextID++
+	st.changes.subscribers[id] = subscriber
+	st.changes.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		// Removed before closing, so no event is sent on the closed channel
+		st.changes.mu.Lock()
+		delete(st.changes.subscribers, id)
+		st.changes.mu.Unlock()
+
+		close(subscriber.ch)
+	}()
+
+	return subscriber.ch
+}
+
+// hasSubscribers returns whether anyone subscribed to the changes
+func (f *changeFeed) hasSubscribers() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.subscribers) > 0
+}
+
+// deliver sends the events to every subscriber, waiting for room in their
+// channels unless they unsubscribe. Subscribers scoped to a tenant only
+// receive the events of its records.
+func (f *changeFeed) deliver(events []ChangeEvent) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, subscriber := range f.subscribers {
+		for _, event := range events {
+			if subscriber.tenantID != "" && event.TenantID != subscriber.tenantID {
+				continue
+			}
+			select {
+			case subscriber.ch <- event:
+			case <-subscriber.done:
+			}
+		}
+	}
+}
+
+// tracksChanges returns whether the changes are written to the audit,
+// revisions, counters or unique values tables
+func (st *storeImplementation) tracksChanges() bool {
+	return st.auditTable != "" || st.revisionsTable != "" || st.countersTable != "" || st.usesUniqueTable()
+}
+
+// trackChanges runs the write of the records with the IDs, capturing their
+// changes before and publishing them after it (see captureChanges). With
+// audit, revisions or counters enabled, the write and their rows are
+// committed in a single transaction, so a failure of either rolls back
+// both and is returned.
+func (st *storeImplementation) trackChanges(ctx context.Context, changeType ChangeType, ids []string, write func(st *storeImplementation) error) error {
+	if st.tx == nil && st.tracksChanges() {
+		return st.inTransaction(func(st *storeImplementation) error {
+			return st.trackChanges(ctx, changeType, ids, write)
+		})
+	}
+
+	change, err := st.captureChanges(ctx, changeType, ids...)
+	if err != nil {
+		return err
+	}
+
+	if err := write(st); err != nil {
+		return err
+	}
+
+	return change.publish()
+}
+
+// captureChanges snapshots the records with the IDs before a write, for
+// publish to report the changes once written. Returns nil, capturing
+// nothing, if nobody subscribed to the changes and neither audit, revisions
+// nor counters are enabled. A failed snapshot is only returned with audit,
+// revisions or counters enabled, subscribers missing the change otherwise.
+func (st *storeImplementation) captureChanges(ctx context.Context, changeType ChangeType, ids ...string) (*changeCapture, error) {
+	if (!st.changes.hasSubscribers() && !st.tracksChanges()) || len(ids) == 0 {
+		return nil, nil
+	}
+
+	old, err := st.changeSnapshots(ctx, ids)
+	if err != nil {
+		if st.tracksChanges() {
+			return nil, err
+		}
+		st.logger.Error("Change capture failed", "ids", ids, "error", err)
+		return nil, nil
+	}
+
+	oldTenants, err := st.recordTenants(ctx, ids)
+	if err != nil {
+		if st.tracksChanges() {
+			return nil, err
+		}
+		st.logger.Error("Change capture failed", "ids", ids, "error", err)
+		return nil, nil
+	}
+
+	return &changeCapture{
+		st:         st,
+		ctx:        ctx,
+		changeType: changeType,
+		ids:        ids,
+		old:        old,
+		oldTenants: oldTenants,
+	}, nil
+}
+
+// publish reports the changes of the captured records once written, and
+// writes them to the audit, revisions and counters tables if enabled.
+// Records which did not change are skipped.
+func (c *changeCapture) publish() error {
+	if c == nil {
+		return nil
+	}
+
+	st := c.st
+
+	current, err := st.changeSnapshots(c.ctx, c.ids)
+	var tenants map[string]string
+	if err == nil {
+		tenants, err = st.recordTenants(c.ctx, c.ids)
+	}
+	if err != nil {
+		if st.tracksChanges() {
+			return err
+		}
+		st.logger.Error("Change capture failed", "ids", c.ids, "error", err)
+		return nil
+	}
+
+	events := []ChangeEvent{}
+	for _, id := range c.ids {
+		before, after := c.old[id], current[id]
+
+		event := ChangeEvent{Type: c.changeType, RecordID: id, Old: before, New: after, TenantID: st.tenantID}
+		if st.tenantID == "" {
+			// The tenant of the record, the one it had if deleted
+			event.TenantID = tenants[id]
+			if after == nil {
+				event.TenantID = c.oldTenants[id]
+			}
+		}
+		switch {
+		case before == nil && after == nil:
+			continue
+		case before == nil:
+			event.Type = ChangeCreate
+		case after == nil:
+			event.Type = ChangeDelete
+		case before.Version() == after.Version() && before.SoftDeletedAt() == after.SoftDeletedAt():
+			// Not written, i.e. a protected record or a restore of an
+			// active record
+			continue
+		}
+
+		events = append(events, event)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	if st.auditTable != "" {
+		if err := st.writeAudit(c.ctx, events); err != nil {
+			return err
+		}
+	}
+
+	if st.revisionsTable != "" {
+		if err := st.writeRevisions(c.ctx, events); err != nil {
+			return err
+		}
+	}
+
+	if st.countersTable != "" {
+		if err := st.writeCounters(c.ctx, events); err != nil {
+			return err
+		}
+	}
+
+	if st.usesUniqueTable() {
+		if err := st.writeUniqueValues(c.ctx, events); err != nil {
+			return err
+		}
+	}
+
+	if st.txChanges != nil {
+		st.txChanges.events = append(st.txChanges.events, events...)
+		return nil
+	}
+
+	st.changes.deliver(events)
+
+	return nil
+}
+
+// changeSnapshots reads the records with the IDs, soft deleted and expired
+// included, keyed by ID
+func (st *storeImplementation) changeSnapshots(ctx context.Context, ids []string) (map[string]RecordInterface, error) {
+	anyIDs := make([]any, len(ids))
+	for i, id := range ids {
+		anyIDs[i] = id
+	}
+
+	list, err := st.selectRecords(ctx, st.newQuery(ctx).WhereIn(COLUMN_ID, anyIDs), nil, "Changes")
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make(map[string]RecordInterface, len(list))
+	for _, record := range list {
+		snapshots[record.ID()] = record
+	}
+	return snapshots, nil
+}
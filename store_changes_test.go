@@ -0,0 +1,329 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestChanges(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_changes",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := store.Changes(ctx, 20)
+
+	next := func(expected customstore.ChangeType) customstore.ChangeEvent {
+		t.Helper()
+		select {
+		case event := <-changes:
+			if event.Type != expected {
+				t.Fatalf("Expected a %s change, but got %s", expected, event.Type)
+			}
+			return event
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a %s change, but got none", expected)
+		}
+		return customstore.ChangeEvent{}
+	}
+
+	record := customstore.NewRecord("person", customstore.WithPayload(`{"name":"Ada"}`))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	event := next(customstore.ChangeCreate)
+	if event.RecordID != record.ID() || event.Old != nil || event.New == nil || event.New.Payload() != `{"name":"Ada"}` {
+		t.Fatalf("Unexpected create event %+v", event)
+	}
+
+	record.SetPayload(`{"name":"Ada Lovelace"}`)
+	if err := store.RecordUpdate(record); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+	event = next(customstore.ChangeUpdate)
+	if event.Old.Payload() != `{"name":"Ada"}` || event.New.Payload() != `{"name":"Ada Lovelace"}` {
+		t.Fatalf("Expected the old and new payloads, but got %q and %q", event.Old.Payload(), event.New.Payload())
+	}
+
+	if err := store.RecordIncrementPayloadKey(record.ID(), "visits", 1); err != nil {
+		t.Fatalf("RecordIncrementPayloadKey failed: %v", err)
+	}
+	event = next(customstore.ChangeUpdate)
+	if event.New.Version() != event.Old.Version()+1 {
+		t.Fatalf("Expected the increment to bump the version, but got %d and %d", event.Old.Version(), event.New.Version())
+	}
+
+	if err := store.RecordSoftDeleteByID(record.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	event = next(customstore.ChangeSoftDelete)
+	if event.Old.IsSoftDeleted() || !event.New.IsSoftDeleted() {
+		t.Fatalf("Expected the record to be soft deleted by the change")
+	}
+
+	if err := store.RecordRestoreByID(record.ID()); err != nil {
+		t.Fatalf("RecordRestoreByID failed: %v", err)
+	}
+	next(customstore.ChangeUpdate)
+
+	// Restoring an active record changes nothing
+	if err := store.RecordRestoreByID(record.ID()); err != nil {
+		t.Fatalf("RecordRestoreByID failed: %v", err)
+	}
+
+	upserted := customstore.NewRecord("person", customstore.WithPayload(`{"name":"Grace"}`))
+	if err := store.RecordUpsert(upserted); err != nil {
+		t.Fatalf("RecordUpsert failed: %v", err)
+	}
+	next(customstore.ChangeCreate)
+	if err := store.RecordUpsert(upserted); err != nil {
+		t.Fatalf("RecordUpsert failed: %v", err)
+	}
+	next(customstore.ChangeUpdate)
+
+	if err := store.RecordDeleteByID(record.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+	event = next(customstore.ChangeDelete)
+	if event.Old == nil || event.New != nil {
+		t.Fatalf("Expected only the old snapshot of a delete, but got %+v", event)
+	}
+
+	// Deleting a missing record changes nothing
+	if err := store.RecordDeleteByID(record.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+
+	select {
+	case event := <-changes:
+		t.Fatalf("Expected no change for the no-op writes, but got %s", event.Type)
+	default:
+	}
+
+	cancel()
+	select {
+	case _, ok := <-changes:
+		if ok {
+			t.Fatalf("Expected no more changes after the context is done")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected the changes channel to be closed")
+	}
+}
+
+func TestChangesTransaction(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_changes_transaction",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := store.Changes(ctx, 10)
+
+	first := customstore.NewRecord("person")
+	second := customstore.NewRecord("person")
+	errAbort := errors.New("abort")
+	err = store.RunInTransaction(ctx, func(tx customstore.TransactionInterface) error {
+		if err := tx.RecordCreate(first); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Expected the transaction to be rolled back, but got %v", err)
+	}
+
+	err = store.RunInTransaction(ctx, func(tx customstore.TransactionInterface) error {
+		if err := tx.RecordCreate(second); err != nil {
+			return err
+		}
+
+		select {
+		case event := <-changes:
+			t.Fatalf("Expected no change before the commit, but got %s", event.Type)
+		default:
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunInTransaction failed: %v", err)
+	}
+
+	select {
+	case event := <-changes:
+		if event.Type != customstore.ChangeCreate || event.RecordID != second.ID() {
+			t.Fatalf("Expected the create of the committed record, but got %s of %s", event.Type, event.RecordID)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected a change once committed, but got none")
+	}
+
+	select {
+	case event := <-changes:
+		t.Fatalf("Expected no change for the rolled back record, but got %s of %s", event.Type, event.RecordID)
+	default:
+	}
+
+	second.SetMemo("bulk")
+	if _, err := store.RecordUpdateMany([]customstore.RecordInterface{second}); err != nil {
+		t.Fatalf("RecordUpdateMany failed: %v", err)
+	}
+	select {
+	case event := <-changes:
+		if event.Type != customstore.ChangeUpdate || event.New.Memo() != "bulk" {
+			t.Fatalf("Expected the update of the bulk updated record, but got %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected a change for the bulk update, but got none")
+	}
+}
+
+func TestChangesTenancy(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_changes_tenancy",
+		AutomigrateEnabled: true,
+		TenancyEnabled:     true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	acme, err := store.ForTenant("acme")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+	globex, err := store.ForTenant("globex")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	acmeChanges := acme.Changes(ctx, 10)
+	allChanges := store.Changes(ctx, 10)
+
+	if err := globex.RecordCreate(customstore.NewRecord("order")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	record := customstore.NewRecord("order")
+	if err := acme.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	// Written through the unscoped store, the change is of the tenant of
+	// the record
+	if err := store.RecordDeleteByID(record.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+
+	for _, expected := range []customstore.ChangeType{customstore.ChangeCreate, customstore.ChangeDelete} {
+		select {
+		case event := <-acmeChanges:
+			if event.Type != expected || event.RecordID != record.ID() || event.TenantID != "acme" {
+				t.Fatalf("Expected a %s change of the acme record, but got %+v", expected, event)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a %s change, but got none", expected)
+		}
+	}
+	select {
+	case event := <-acmeChanges:
+		t.Fatalf("Expected no change of another tenant, but got %+v", event)
+	default:
+	}
+
+	tenants := []string{}
+	for range 3 {
+		select {
+		case event := <-allChanges:
+			tenants = append(tenants, event.TenantID)
+		case <-time.After(time.Second):
+			t.Fatal("Expected every change on the unscoped store")
+		}
+	}
+	if !slices.Equal(tenants, []string{"globex", "acme", "acme"}) {
+		t.Fatalf("Expected the tenants of the changes, but got %v", tenants)
+	}
+}
+
+func TestChangesTransactionSavepoint(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_changes_savepoint",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := store.Changes(ctx, 10)
+
+	kept := customstore.NewRecord("person")
+	undone := customstore.NewRecord("person")
+	err = store.RunInTransaction(ctx, func(tx customstore.TransactionInterface) error {
+		if err := tx.RecordCreate(kept); err != nil {
+			return err
+		}
+		if err := tx.Savepoint("item"); err != nil {
+			return err
+		}
+		if err := tx.RecordCreate(undone); err != nil {
+			return err
+		}
+		return tx.RollbackTo("item")
+	})
+	if err != nil {
+		t.Fatalf("RunInTransaction failed: %v", err)
+	}
+
+	select {
+	case event := <-changes:
+		if event.RecordID != kept.ID() {
+			t.Fatalf("Expected the change of the kept record, but got %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the change of the kept record")
+	}
+
+	select {
+	case event := <-changes:
+		t.Fatalf("Expected no change for the rolled back write, but got %s %s", event.Type, event.RecordID)
+	default:
+	}
+}
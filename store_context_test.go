@@ -0,0 +1,82 @@
+package customstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreContextMethods(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_context",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ctx := context.Background()
+
+	record := customstore.NewRecord("person", customstore.WithMemo("John"))
+	if err := store.RecordCreateContext(ctx, record); err != nil {
+		t.Fatalf("RecordCreateContext failed: %v", err)
+	}
+
+	found, err := store.RecordFindByIDContext(ctx, record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByIDContext failed: %v, %v", found, err)
+	}
+
+	found.SetMemo("Johnny")
+	if err := store.RecordUpdateContext(ctx, found); err != nil {
+		t.Fatalf("RecordUpdateContext failed: %v", err)
+	}
+
+	list, err := store.RecordListContext(ctx, customstore.RecordQuery().SetType("person"))
+	if err != nil || len(list) != 1 || list[0].Memo() != "Johnny" {
+		t.Fatalf("RecordListContext failed: %v, %v", list, err)
+	}
+
+	if err := store.RecordSoftDeleteContext(ctx, found); err != nil {
+		t.Fatalf("RecordSoftDeleteContext failed: %v", err)
+	}
+
+	count, err := store.RecordCountContext(ctx, customstore.RecordQuery().SetSoftDeletedIncluded(true))
+	if err != nil || count != 1 {
+		t.Fatalf("RecordCountContext failed: %d, %v", count, err)
+	}
+
+	if err := store.RecordDeleteContext(ctx, found); err != nil {
+		t.Fatalf("RecordDeleteContext failed: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := store.RecordCreateContext(cancelled, customstore.NewRecord("person")); err == nil {
+		t.Fatalf("Expected RecordCreateContext to fail with a cancelled context")
+	}
+	if _, err := store.RecordFindByIDContext(cancelled, record.ID()); err == nil {
+		t.Fatalf("Expected RecordFindByIDContext to fail with a cancelled context")
+	}
+	if _, err := store.RecordListContext(cancelled, customstore.RecordQuery()); err == nil {
+		t.Fatalf("Expected RecordListContext to fail with a cancelled context")
+	}
+	if _, err := store.RecordCountContext(cancelled, customstore.RecordQuery()); err == nil {
+		t.Fatalf("Expected RecordCountContext to fail with a cancelled context")
+	}
+	if err := store.RecordUpdateContext(cancelled, record); err == nil {
+		t.Fatalf("Expected RecordUpdateContext to fail with a cancelled context")
+	}
+	if err := store.RecordSoftDeleteByIDContext(cancelled, record.ID(), customstore.WithForceDelete()); err == nil {
+		t.Fatalf("Expected RecordSoftDeleteByIDContext to fail with a cancelled context")
+	}
+	if err := store.RecordDeleteByIDContext(cancelled, record.ID(), customstore.WithForceDelete()); err == nil {
+		t.Fatalf("Expected RecordDeleteByIDContext to fail with a cancelled context")
+	}
+}
@@ -0,0 +1,67 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// RecordCountByType counts the records matching the query per record type
+func (st *storeImplementation) RecordCountByType(query RecordQueryInterface) (map[string]int64, error) {
+	return st.RecordCountByTypeContext(context.Background(), query)
+}
+
+// RecordCountByTypeContext counts the records matching the query per record
+// type in a single GROUP BY query, i.e. for dashboards needing the count of
+// every type. Types without matching records are left out. The limit,
+// offset and order of the query are ignored.
+func (st *storeImplementation) RecordCountByTypeContext(ctx context.Context, query RecordQueryInterface) (map[string]int64, error) {
+	if st.sharded() {
+		view, err := st.forQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		return view.RecordCountByTypeContext(ctx, query)
+	}
+
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	if err := st.checkQueryGuards(query); err != nil {
+		return nil, err
+	}
+
+	// Every group is counted, in no particular order
+	q := st.buildFilterQuery(ctx, query).
+		Table(st.tableName()).
+		Select(COLUMN_RECORD_TYPE + ", COUNT(*) AS type_count").
+		Group(COLUMN_RECORD_TYPE)
+
+	counts := map[string]int64{}
+	if st.dryRun("RecordCountByType", func() (string, []any) { return selectSQL(q) }) {
+		return counts, nil
+	}
+
+	var rows []map[string]any
+	start := time.Now()
+	err := st.retry(ctx, "RecordCountByType", func() error {
+		return q.Get(&rows)
+	})
+	st.logQuery("RecordCountByType", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return nil, st.wrapError(err, "RecordCountByType", queryRecordID(query), queryRecordType(query), func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	for _, row := range rows {
+		counts[cast.ToString(row[COLUMN_RECORD_TYPE])] = cast.ToInt64(row["type_count"])
+	}
+
+	return counts, nil
+}
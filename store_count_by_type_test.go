@@ -0,0 +1,59 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRecordCountByType(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_count_by_type",
+		AutomigrateEnabled: true,
+		MaxListLimit:       1,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, recordType := range []string{"order", "order", "invoice", "refund"} {
+		if err := store.RecordCreate(customstore.NewRecord(recordType)); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	deleted := customstore.NewRecord("order")
+	if err := store.RecordCreate(deleted); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordSoftDelete(deleted); err != nil {
+		t.Fatalf("RecordSoftDelete failed: %v", err)
+	}
+
+	counts, err := store.RecordCountByType(customstore.NewRecordQuery().
+		SetTypeIn([]string{"order", "invoice", "missing"}).
+		SetLimit(1).
+		SetOrderBy(customstore.COLUMN_CREATED_AT))
+	if err != nil {
+		t.Fatalf("RecordCountByType failed: %v", err)
+	}
+
+	if len(counts) != 2 {
+		t.Fatalf("Expected 2 types, got %v", counts)
+	}
+	if counts["order"] != 2 || counts["invoice"] != 1 {
+		t.Fatalf("Unexpected counts: %v", counts)
+	}
+
+	counts, err = store.RecordCountByType(customstore.NewRecordQuery().SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCountByType failed: %v", err)
+	}
+	if counts["order"] != 3 || counts["refund"] != 1 {
+		t.Fatalf("Unexpected counts: %v", counts)
+	}
+}
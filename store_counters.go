@@ -0,0 +1,266 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+	contractsschema "github.com/dracory/neat/contracts/database/schema"
+	"github.com/spf13/cast"
+)
+
+// countersColumnCount is the column of the counters table holding the count
+const countersColumnCount = "record_count"
+
+// countersTableName returns the name of the counters table of a store table
+func countersTableName(tableName string) string {
+	return tableName + "_counts"
+}
+
+// RecordCountFast returns the number of records of the type which are not
+// soft deleted, read from the counters table maintained on every write
+// instead of counting the rows. A tenant view returns the count of its
+// tenant. Requires CountersEnabled.
+func (st *storeImplementation) RecordCountFast(recordType string) (int64, error) {
+	return st.RecordCountFastContext(context.Background(), recordType)
+}
+
+// RecordCountFastContext is RecordCountFast using the given context
+func (st *storeImplementation) RecordCountFastContext(ctx context.Context, recordType string) (int64, error) {
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if st.countersTable == "" {
+		return 0, errors.New("counters are not enabled")
+	}
+
+	if recordType == "" {
+		return 0, errors.New("record type is required")
+	}
+
+	q := st.newQuery(ctx).
+		Table(st.countersTable).
+		Select("SUM("+countersColumnCount+") AS total").
+		Where(COLUMN_RECORD_TYPE+" = ?", recordType)
+	if st.tenantID != "" {
+		q = q.Where(COLUMN_TENANT_ID+" = ?", st.tenantID)
+	}
+
+	if st.dryRun("RecordCountFast", func() (string, []any) { return selectSQL(q) }) {
+		return 0, nil
+	}
+
+	var rows []map[string]any
+	start := time.Now()
+	err := q.Get(&rows)
+	st.logQuery("RecordCountFast", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return 0, st.wrapError(err, "RecordCountFast", "", recordType, func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	return cast.ToInt64(rows[0]["total"]), nil
+}
+
+// RebuildCounters recounts the records of every type (and tenant) which are
+// not soft deleted, replacing the counters table. Run it to reconcile the
+// counters after writes they do not see: imports, backup restores, purges
+// of expired records, scheduled soft deletes coming due and writes outside
+// the store.
+func (st *storeImplementation) RebuildCounters(ctx context.Context) error {
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
+	if err := st.checkUnscoped("RebuildCounters"); err != nil {
+		return err
+	}
+
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if st.countersTable == "" {
+		return errors.New("counters are not enabled")
+	}
+
+	groups := []string{COLUMN_RECORD_TYPE}
+	if st.tenancy {
+		groups = append(groups, COLUMN_TENANT_ID)
+	}
+
+	type counter struct {
+		recordType string
+		tenantID   string
+	}
+	counts := map[counter]int64{}
+
+	for _, view := range st.tableViews() {
+		q := view.newQuery(ctx).
+			Table(view.tableName()).
+			Select(strings.Join(groups, ", ")+", COUNT(*) AS type_count").
+			Where(COLUMN_SOFT_DELETED_AT+" > ?", view.nowTimestamp())
+		for _, group := range groups {
+			q = q.Group(group)
+		}
+
+		var rows []map[string]any
+		start := time.Now()
+		err := q.Get(&rows)
+		view.logQuery("RebuildCounters", start, func() (string, []any) {
+			return q.ToRawSql().Get(&rows), nil
+		})
+		if err != nil {
+			return view.wrapError(err, "RebuildCounters", "", "", func() string {
+				return q.ToSql().Get(&rows)
+			})
+		}
+
+		for _, row := range rows {
+			key := counter{recordType: cast.ToString(row[COLUMN_RECORD_TYPE]), tenantID: cast.ToString(row[COLUMN_TENANT_ID])}
+			counts[key] += cast.ToInt64(row["type_count"])
+		}
+	}
+
+	return st.transaction(func(tx contractsorm.Query) error {
+		sqlStr := "DELETE FROM " + st.countersTable
+		if _, err := st.exec(cloneQuery(ctx, tx), "RebuildCounters", sqlStr); err != nil {
+			return st.wrapError(err, "RebuildCounters", "", "", func() string { return sqlStr })
+		}
+
+		for key, count := range counts {
+			row := map[string]any{
+				COLUMN_RECORD_TYPE:  key.recordType,
+				COLUMN_TENANT_ID:    key.tenantID,
+				countersColumnCount: count,
+			}
+			q := cloneQuery(ctx, tx).Table(st.countersTable)
+			start := time.Now()
+			err := q.Create(row)
+			st.logQuery("RebuildCounters", start, func() (string, []any) {
+				return q.ToRawSql().Create(row), nil
+			})
+			if err != nil {
+				return st.wrapError(err, "RebuildCounters", "", key.recordType, func() string {
+					return q.ToSql().Create(row)
+				})
+			}
+		}
+		return nil
+	})
+}
+
+// createCountersTable creates the counters table if counters are enabled.
+// Safe to call when it exists.
+func (st *storeImplementation) createCountersTable() error {
+	if st.countersTable == "" || st.db.Schema().HasTable(st.countersTable) {
+		return nil
+	}
+
+	return st.db.Schema().Create(st.countersTable, func(table contractsschema.Blueprint) {
+		table.String(COLUMN_RECORD_TYPE, 100)
+		table.String(COLUMN_TENANT_ID, 100).Default("")
+		table.BigInteger(countersColumnCount).Default(0)
+		table.Primary(COLUMN_RECORD_TYPE, COLUMN_TENANT_ID)
+	})
+}
+
+// dropCountersTable drops the counters table if counters are enabled
+func (st *storeImplementation) dropCountersTable() error {
+	if st.countersTable == "" || !st.db.Schema().HasTable(st.countersTable) {
+		return nil
+	}
+
+	return st.db.Schema().Drop(st.countersTable)
+}
+
+// writeCounters adjusts the counters of the types and tenants of the records
+// gaining or losing a record which is not soft deleted by the change events,
+// in the
+// transaction the store is bound to if any
+func (st *storeImplementation) writeCounters(ctx context.Context, events []ChangeEvent) error {
+	now := st.nowDateTime()
+	counted := func(record RecordInterface) bool {
+		return record != nil && record.SoftDeletedAt() > now
+	}
+
+	type counter struct {
+		recordType string
+		tenantID   string
+	}
+	deltas := map[counter]int64{}
+	for _, event := range events {
+		if counted(event.Old) {
+			deltas[counter{recordType: event.Old.Type(), tenantID: event.TenantID}]--
+		}
+		if counted(event.New) {
+			deltas[counter{recordType: event.New.Type(), tenantID: event.TenantID}]++
+		}
+	}
+
+	keys := slices.SortedFunc(maps.Keys(deltas), func(a, b counter) int {
+		return strings.Compare(a.recordType+"\x00"+a.tenantID, b.recordType+"\x00"+b.tenantID)
+	})
+	for _, key := range keys {
+		if deltas[key] == 0 {
+			continue
+		}
+		if err := st.addToCounter(ctx, key.recordType, key.tenantID, deltas[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addToCounter adds the delta to the counter of the type for the tenant,
+// creating the counter if missing, in a single statement so a counter
+// created concurrently does not fail the transaction
+func (st *storeImplementation) addToCounter(ctx context.Context, recordType string, tenantID string, delta int64) error {
+	sqlStr := rebindPlaceholders(st.driverName(), counterUpsertSQL(st.driverName(), st.countersTable))
+	_, err := st.exec(st.newQuery(ctx), "Counters", sqlStr, recordType, tenantID, delta)
+	return st.wrapError(err, "Counters", "", recordType, func() string {
+		return sqlStr
+	})
+}
+
+// counterUpsertSQL returns the statement inserting the counter of a type
+// for a tenant, or adding to its count if it exists, with the type, tenant
+// and delta as placeholders
+func counterUpsertSQL(driver string, countersTable string) string {
+	columns := []string{COLUMN_RECORD_TYPE, COLUMN_TENANT_ID, countersColumnCount}
+	columnList := strings.Join(columns, ", ")
+
+	switch driver {
+	case "mysql":
+		return "INSERT INTO " + countersTable + " (" + columnList + ") VALUES (" + placeholders(len(columns)) + ")" +
+			" ON DUPLICATE KEY UPDATE " + countersColumnCount + " = " + countersColumnCount + " + VALUES(" + countersColumnCount + ")"
+	case "sqlserver":
+		source := make([]string, len(columns))
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			source[i] = "? AS " + column
+			values[i] = "source." + column
+		}
+		return "MERGE INTO " + countersTable + " WITH (HOLDLOCK) AS target USING (SELECT " + strings.Join(source, ", ") + ") AS source" +
+			" ON target." + COLUMN_RECORD_TYPE + " = source." + COLUMN_RECORD_TYPE +
+			" AND target." + COLUMN_TENANT_ID + " = source." + COLUMN_TENANT_ID +
+			" WHEN MATCHED THEN UPDATE SET target." + countersColumnCount + " = target." + countersColumnCount + " + source." + countersColumnCount +
+			" WHEN NOT MATCHED THEN INSERT (" + columnList + ") VALUES (" + strings.Join(values, ", ") + ");"
+	default:
+		return "INSERT INTO " + countersTable + " (" + columnList + ") VALUES (" + placeholders(len(columns)) + ")" +
+			" ON CONFLICT (" + COLUMN_RECORD_TYPE + ", " + COLUMN_TENANT_ID + ") DO UPDATE SET " +
+			countersColumnCount + " = " + countersTable + "." + countersColumnCount + " + excluded." + countersColumnCount
+	}
+}
@@ -0,0 +1,189 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreCounters(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_counters",
+		AutomigrateEnabled: true,
+		CountersEnabled:    true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	countFast := func(recordType string, expected int64) {
+		t.Helper()
+		count, err := store.RecordCountFast(recordType)
+		if err != nil {
+			t.Fatalf("RecordCountFast failed: %v", err)
+		}
+		if count != expected {
+			t.Fatalf("Expected %d %s records, got %d", expected, recordType, count)
+		}
+	}
+
+	countFast("order", 0)
+
+	orders := []customstore.RecordInterface{}
+	for i := 0; i < 3; i++ {
+		order := customstore.NewRecord("order")
+		if err := store.RecordCreate(order); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		orders = append(orders, order)
+	}
+	if err := store.RecordCreate(customstore.NewRecord("invoice")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	countFast("order", 3)
+	countFast("invoice", 1)
+
+	if err := store.RecordSoftDeleteByID(orders[0].ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	countFast("order", 2)
+
+	if err := store.RecordRestoreByID(orders[0].ID()); err != nil {
+		t.Fatalf("RecordRestoreByID failed: %v", err)
+	}
+	countFast("order", 3)
+
+	if err := store.RecordDeleteByID(orders[1].ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+	countFast("order", 2)
+
+	// An update changing the type moves the record between the counters
+	orders[2].SetType("invoice")
+	if err := store.RecordUpdate(orders[2]); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+	countFast("order", 1)
+	countFast("invoice", 2)
+
+	// Writes outside the store are reconciled by a rebuild
+	if _, err := db.Exec("DELETE FROM data_counters WHERE record_type = 'invoice'"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	countFast("invoice", 2)
+	if err := store.RebuildCounters(t.Context()); err != nil {
+		t.Fatalf("RebuildCounters failed: %v", err)
+	}
+	countFast("invoice", 0)
+	countFast("order", 1)
+}
+
+func TestStoreCountersTenancy(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_counters_tenancy",
+		AutomigrateEnabled: true,
+		CountersEnabled:    true,
+		TenancyEnabled:     true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	acme, err := store.ForTenant("acme")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+	globex, err := store.ForTenant("globex")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := acme.RecordCreate(customstore.NewRecord("order")); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+	if err := globex.RecordCreate(customstore.NewRecord("order")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		store    customstore.StoreInterface
+		expected int64
+	}{{acme, 2}, {globex, 1}, {store, 3}} {
+		count, err := tc.store.RecordCountFast("order")
+		if err != nil {
+			t.Fatalf("RecordCountFast failed: %v", err)
+		}
+		if count != tc.expected {
+			t.Fatalf("Expected %d records, got %d", tc.expected, count)
+		}
+	}
+
+	if err := store.RebuildCounters(t.Context()); err != nil {
+		t.Fatalf("RebuildCounters failed: %v", err)
+	}
+	count, err := acme.RecordCountFast("order")
+	if err != nil || count != 2 {
+		t.Fatalf("Expected the rebuilt tenant count to be 2, got %d: %v", count, err)
+	}
+
+	// Written through the unscoped store, the counter of the tenant of the
+	// record is adjusted
+	extra := customstore.NewRecord("order")
+	if err := acme.RecordCreate(extra); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordDeleteByID(extra.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+	count, err = acme.RecordCountFast("order")
+	if err != nil || count != 2 {
+		t.Fatalf("Expected the tenant count to stay 2, got %d: %v", count, err)
+	}
+
+	if err := acme.RebuildCounters(t.Context()); err == nil {
+		t.Fatal("Expected an error rebuilding the counters from a tenant view")
+	}
+}
+
+func TestStoreCountersFailure(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_counters_failure",
+		AutomigrateEnabled: true,
+		CountersEnabled:    true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("order")
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if _, err := db.Exec("DROP TABLE data_counters_failure_counts"); err != nil {
+		t.Fatalf("Drop failed: %v", err)
+	}
+
+	// A failed count fails and rolls back the write
+	if err := store.RecordSoftDeleteByID(record.ID()); err == nil {
+		t.Fatal("Expected the soft delete to fail with the counters")
+	}
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("Expected the soft delete to be rolled back: %v", err)
+	}
+}
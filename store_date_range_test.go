@@ -0,0 +1,83 @@
+package customstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordListDateRange(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	clock := &fixedClock{now: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_date_range",
+		AutomigrateEnabled: true,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []customstore.RecordInterface{}
+	for day := 1; day <= 4; day++ {
+		clock.now = time.Date(2030, 1, day, 12, 0, 0, 0, time.UTC)
+		record := customstore.NewRecord("event")
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().
+		SetType("event").
+		SetCreatedAtGte("2030-01-02 00:00:00").
+		SetCreatedAtLte("2030-01-03 23:59:59"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 records created in the window, but got %d", len(list))
+	}
+	for _, record := range list {
+		if record.ID() != records[1].ID() && record.ID() != records[2].ID() {
+			t.Fatalf("Unexpected record %s created at %s", record.ID(), record.CreatedAt())
+		}
+	}
+
+	// Touch the first record later, so it is the only one updated after day 4
+	clock.now = time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	records[0].SetMeta("touched", "yes")
+	if err := store.RecordUpdate(records[0]); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().
+		SetType("event").
+		SetUpdatedAtLte("2030-01-04 23:59:59"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 records not updated since day 4, but got %d", count)
+	}
+
+	count, err = store.RecordCount(customstore.RecordQuery().
+		SetType("event").
+		SetUpdatedAtGte("2030-01-05 00:00:00").
+		SetUpdatedAtLte("2030-01-10 23:59:59"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 record updated in the window, but got %d", count)
+	}
+
+	if err := customstore.RecordQuery().SetCreatedAtGte("yesterday").Validate(); err == nil {
+		t.Fatalf("Expected error for a malformed datetime, but got nil")
+	}
+}
@@ -0,0 +1,115 @@
+package customstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	"github.com/dracory/neat"
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+	neatdatabase "github.com/dracory/neat/database"
+	neatquery "github.com/dracory/neat/database/query"
+)
+
+// errOffline is returned by the connections of an offlineConnector
+var errOffline = errors.New("customstore: SQL only store, not connected to a database")
+
+// offlineConnector is a database/sql connector which never connects, backing
+// the stores which only build SQL, see RecordQuery.ToSQL
+type offlineConnector struct{}
+
+func (offlineConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, errOffline
+}
+
+func (offlineConnector) Driver() driver.Driver {
+	return offlineDriver{}
+}
+
+type offlineDriver struct{}
+
+func (offlineDriver) Open(string) (driver.Conn, error) {
+	return nil, errOffline
+}
+
+// ToSQL returns the SQL and arguments RecordList executes for the query (or
+// RecordCount, for a count only query) on a store of the table using the
+// database driver ("sqlite", "mysql", "postgres", ...), without connecting
+// to a database.
+//
+// The soft delete and expiration filters compare against the current time
+// and the payload indexes of a store (see EnsurePayloadIndex) are not
+// known, the queries of a store using them differ accordingly.
+func (o *recordQueryImplementation) ToSQL(driver string, table string) (string, []any, error) {
+	if err := o.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	if table == "" {
+		return "", nil, errors.New("table name is required")
+	}
+
+	if o.IsFullTextSearchSet() && !fullTextSearchSupported(driver) {
+		return "", nil, ErrFullTextSearchDisabled
+	}
+
+	if _, ok := payloadRegexExpression(driver); !ok && queryHasPayloadSearchRegex(o) {
+		return "", nil, ErrRegexUnsupported
+	}
+
+	neatDB, err := neat.NewFromSQLDB(sql.OpenDB(offlineConnector{}), neatdatabase.WithDriver(driver))
+	if err != nil {
+		return "", nil, err
+	}
+
+	st := &storeImplementation{
+		tables:         &tableState{name: table},
+		db:             neatDB,
+		clock:          systemClock{},
+		fullTextSearch: true,
+		payloadIndexes: &payloadIndexRegistry{keys: map[string]map[string]bool{}},
+	}
+
+	q := st.buildQuery(context.Background(), o)
+	if o.IsCountOnly() {
+		sqlStr, args := countSQL(q.Table(table))
+		return sqlStr, args, nil
+	}
+
+	sqlStr, args := selectSQL(st.selectQuery(q, o))
+	return sqlStr, args, nil
+}
+
+// dryRun logs the statement for the operation instead of executing it when
+// DebugSQL is enabled, returning whether it must not be executed
+func (st *storeImplementation) dryRun(op string, statement func() (string, []any)) bool {
+	if !st.debugSQL {
+		return false
+	}
+
+	sqlStr, args := statement()
+	st.logger.Info("Debug SQL", "op", op, "sql", sqlStr, "args", args)
+	return true
+}
+
+// selectSQL returns the SQL and arguments of the select statement of q
+func selectSQL(q contractsorm.Query) (string, []any) {
+	if neatQuery, ok := q.(*neatquery.Query); ok {
+		return neatquery.NewBuilder(neatQuery).BuildSelect()
+	}
+	return q.ToRawSql().Get(nil), nil
+}
+
+// countSQL returns the SQL and arguments of the count statement of q.
+// Leaves q counting, do not execute it afterwards.
+func countSQL(q contractsorm.Query) (string, []any) {
+	neatQuery, ok := q.(*neatquery.Query)
+	if !ok {
+		return q.ToRawSql().Count(), nil
+	}
+
+	// Sets the COUNT aggregate built by BuildSelect
+	neatQuery.ToSql().Count()
+	return neatquery.NewBuilder(neatQuery).BuildSelect()
+}
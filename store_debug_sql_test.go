@@ -0,0 +1,157 @@
+package customstore_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordQueryToSQL(t *testing.T) {
+	query := customstore.NewRecordQuery().
+		SetType("person").
+		AddPayloadKeyLike("name", "John%").
+		SetLimit(10)
+
+	expected := map[string]string{
+		"sqlite":   `CAST(json_extract(NULLIF(payload, ''), ?) AS TEXT) LIKE ?`,
+		"mysql":    `JSON_UNQUOTE(JSON_EXTRACT(NULLIF(payload, ''), ?)) LIKE ?`,
+		"postgres": `(NULLIF(payload, '')::jsonb ->> $4) LIKE $5`,
+	}
+
+	for driver, filter := range expected {
+		sqlStr, args, err := query.ToSQL(driver, "records")
+		if err != nil {
+			t.Fatalf("ToSQL for %s failed: %v", driver, err)
+		}
+		if !strings.HasPrefix(sqlStr, "SELECT ") || !strings.Contains(sqlStr, "records") {
+			t.Fatalf("Expected a select from the table for %s, but got %s", driver, sqlStr)
+		}
+		if !strings.Contains(sqlStr, filter) || !strings.HasSuffix(sqlStr, "LIMIT 10") {
+			t.Fatalf("Expected the payload filter %s for %s, but got %s", filter, driver, sqlStr)
+		}
+		if len(args) != 5 || args[2] != "person" || args[4] != "John%" {
+			t.Fatalf("Unexpected arguments for %s: %v", driver, args)
+		}
+	}
+
+	sqlStr, _, err := customstore.NewRecordQuery().SetType("person").SetCountOnly(true).ToSQL("sqlite", "records")
+	if err != nil {
+		t.Fatalf("ToSQL failed: %v", err)
+	}
+	if !strings.HasPrefix(sqlStr, "SELECT COUNT(*)") {
+		t.Fatalf("Expected a count for a count only query, but got %s", sqlStr)
+	}
+
+	if _, _, err := customstore.NewRecordQuery().SetFullTextSearch(" ").ToSQL("sqlite", "records"); err == nil {
+		t.Fatalf("Expected error for an invalid query, but got nil")
+	}
+
+	if _, _, err := customstore.NewRecordQuery().SetFullTextSearch("north").ToSQL("mysql", "records"); !errors.Is(err, customstore.ErrFullTextSearchDisabled) {
+		t.Fatalf("Expected ErrFullTextSearchDisabled for MySQL, but got %v", err)
+	}
+}
+
+func TestRecordQueryToSQLPostgresContainment(t *testing.T) {
+	query := customstore.NewRecordQuery().
+		SetType("person").
+		AddPayloadKeyEquals("age", "42").
+		AddPayloadKeyEquals("name", "John")
+
+	sqlStr, args, err := query.ToSQL("postgres", "records")
+	if err != nil {
+		t.Fatalf("ToSQL failed: %v", err)
+	}
+
+	// Numbers match as a string or a number, other values as a string
+	filters := []string{
+		`(NULLIF(payload, '')::jsonb @> $4::jsonb OR NULLIF(payload, '')::jsonb @> $5::jsonb) = TRUE`,
+		`(NULLIF(payload, '')::jsonb @> $6::jsonb) = TRUE`,
+	}
+	for _, filter := range filters {
+		if !strings.Contains(sqlStr, filter) {
+			t.Fatalf("Expected the payload filter %s, but got %s", filter, sqlStr)
+		}
+	}
+	if strings.Contains(sqlStr, "->>") {
+		t.Fatalf("Expected no text extraction, but got %s", sqlStr)
+	}
+	if len(args) != 6 || args[3] != `{"age":"42"}` || args[4] != `{"age":42}` || args[5] != `{"name":"John"}` {
+		t.Fatalf("Unexpected arguments: %v", args)
+	}
+
+	// Other drivers keep comparing the text of the key
+	sqlStr, _, err = query.ToSQL("sqlite", "records")
+	if err != nil {
+		t.Fatalf("ToSQL failed: %v", err)
+	}
+	if !strings.Contains(sqlStr, `CAST(json_extract(NULLIF(payload, ''), ?) AS TEXT) = ?`) {
+		t.Fatalf("Expected the text comparison for SQLite, but got %s", sqlStr)
+	}
+}
+
+func TestStoreDebugSQL(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_debug_sql",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+	if err := store.RecordCreate(customstore.NewRecord("person")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	var logs bytes.Buffer
+	debugStore, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:        db,
+		TableName: "data_debug_sql",
+		DebugSQL:  true,
+		Logger:    slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	list, err := debugStore.RecordList(customstore.NewRecordQuery().SetType("person").AddPayloadKeyLike("name", "Jo%"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("Expected no records from a debug SQL store, but got %d", len(list))
+	}
+
+	count, err := debugStore.RecordCount(customstore.NewRecordQuery().SetType("person"))
+	if err != nil || count != 0 {
+		t.Fatalf("Expected no count from a debug SQL store, but got %d, %v", count, err)
+	}
+
+	output := logs.String()
+	if !strings.Contains(output, `msg="Debug SQL" op=RecordList`) || !strings.Contains(output, "LIKE ?") || !strings.Contains(output, "Jo%") {
+		t.Fatalf("Expected the RecordList SQL and arguments to be logged, but got %s", output)
+	}
+	if !strings.Contains(output, `op=RecordCount sql="SELECT COUNT(*)`) {
+		t.Fatalf("Expected the RecordCount SQL to be logged, but got %s", output)
+	}
+
+	if err := debugStore.RecordCreate(customstore.NewRecord("person")); !errors.Is(err, customstore.ErrReadOnly) {
+		t.Fatalf("Expected ErrReadOnly from a debug SQL store, but got %v", err)
+	}
+
+	_, err = customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_debug_sql",
+		DebugSQL:           true,
+		AutomigrateEnabled: true,
+	})
+	if err == nil {
+		t.Fatalf("Expected error for automigrate with debug SQL, but got nil")
+	}
+}
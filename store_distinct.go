@@ -0,0 +1,94 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// RecordDistinctPayloadKey returns the distinct values of a top level
+// payload key over the records matching the query, sorted, i.e. for the
+// options of a filter dropdown. Values are returned in their text form
+// (numbers included). Records missing the key, or with a null value, are
+// ignored. The limit, offset and order of the query are ignored.
+func (st *storeImplementation) RecordDistinctPayloadKey(query RecordQueryInterface, key string) ([]string, error) {
+	if key == "" {
+		return nil, errors.New("key is required")
+	}
+
+	return st.distinctValues(query, COLUMN_PAYLOAD, key, "RecordDistinctPayloadKey")
+}
+
+// RecordDistinctMeta returns the distinct values of a meta over the records
+// matching the query, sorted. Records without the meta are ignored. The
+// limit, offset and order of the query are ignored.
+func (st *storeImplementation) RecordDistinctMeta(query RecordQueryInterface, name string) ([]string, error) {
+	if name == "" {
+		return nil, errors.New("meta name is required")
+	}
+
+	return st.distinctValues(query, COLUMN_METAS, name, "RecordDistinctMeta")
+}
+
+// distinctValues returns the sorted distinct non null values of the key of
+// the JSON column over the records matching the query
+func (st *storeImplementation) distinctValues(query RecordQueryInterface, column string, key string, op string) ([]string, error) {
+	if st.sharded() {
+		view, err := st.forQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		return view.distinctValues(query, column, key, op)
+	}
+
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	if err := st.checkQueryGuards(query); err != nil {
+		return nil, err
+	}
+
+	driver := st.driverName()
+	value := jsonExtractText(driver, column)
+	arg := jsonPathArg(driver, key)
+
+	// Every matching record contributes its value
+	q := st.buildFilterQuery(context.Background(), query).
+		Table(st.tableName()).
+		Select(value+" AS distinct_value", arg).
+		Distinct()
+
+	values := []string{}
+	if st.dryRun(op, func() (string, []any) { return selectSQL(q) }) {
+		return values, nil
+	}
+
+	var rows []map[string]any
+	start := time.Now()
+	err := st.retry(context.Background(), op, func() error {
+		return q.Get(&rows)
+	})
+	st.logQuery(op, start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return nil, st.wrapError(err, op, queryRecordID(query), queryRecordType(query), func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	for _, row := range rows {
+		// The records missing the key select a single NULL
+		if row["distinct_value"] == nil {
+			continue
+		}
+		values = append(values, cast.ToString(row["distinct_value"]))
+	}
+	sort.Strings(values)
+
+	return values, nil
+}
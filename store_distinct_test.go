@@ -0,0 +1,65 @@
+package customstore_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRecordDistinctValues(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_distinct",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []customstore.RecordInterface{
+		customstore.NewRecord("product", customstore.WithPayload(`{"color":"red","size":2}`), customstore.WithMetas(map[string]string{"brand": "acme"})),
+		customstore.NewRecord("product", customstore.WithPayload(`{"color":"blue","size":2}`), customstore.WithMetas(map[string]string{"brand": "globex"})),
+		customstore.NewRecord("product", customstore.WithPayload(`{"color":"red","size":10}`), customstore.WithMetas(map[string]string{"brand": "acme"})),
+		customstore.NewRecord("product", customstore.WithPayload(`{"size":3}`)),
+		customstore.NewRecord("order", customstore.WithPayload(`{"color":"green"}`), customstore.WithMetas(map[string]string{"brand": "initech"})),
+	}
+	for _, record := range records {
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	query := customstore.NewRecordQuery().SetType("product").SetLimit(1)
+
+	colors, err := store.RecordDistinctPayloadKey(query, "color")
+	if err != nil {
+		t.Fatalf("RecordDistinctPayloadKey failed: %v", err)
+	}
+	if !slices.Equal(colors, []string{"blue", "red"}) {
+		t.Fatalf("Unexpected colors: %v", colors)
+	}
+
+	sizes, err := store.RecordDistinctPayloadKey(query, "size")
+	if err != nil {
+		t.Fatalf("RecordDistinctPayloadKey failed: %v", err)
+	}
+	if !slices.Equal(sizes, []string{"10", "2", "3"}) {
+		t.Fatalf("Unexpected sizes: %v", sizes)
+	}
+
+	brands, err := store.RecordDistinctMeta(query, "brand")
+	if err != nil {
+		t.Fatalf("RecordDistinctMeta failed: %v", err)
+	}
+	if !slices.Equal(brands, []string{"acme", "globex"}) {
+		t.Fatalf("Unexpected brands: %v", brands)
+	}
+
+	if _, err := store.RecordDistinctPayloadKey(query, ""); err == nil {
+		t.Fatal("Expected an error for an empty key")
+	}
+}
@@ -0,0 +1,87 @@
+package customstore
+
+import (
+	"context"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// RecordPurgeExpired hard deletes the records which expired (see
+// WithExpiresAt and WithTTL), returning the number of records deleted.
+// Protected records are kept.
+func (st *storeImplementation) RecordPurgeExpired() (int64, error) {
+	return st.RecordPurgeExpiredContext(context.Background())
+}
+
+// RecordPurgeExpiredContext is RecordPurgeExpired using the given context
+func (st *storeImplementation) RecordPurgeExpiredContext(ctx context.Context) (int64, error) {
+	if st.sharded() {
+		total := int64(0)
+		for _, view := range st.tableViews() {
+			affected, err := view.RecordPurgeExpiredContext(ctx)
+			if err != nil {
+				return total, err
+			}
+			total += affected
+		}
+		return total, nil
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	now := st.nowTimestamp()
+
+	unlock := st.lockWrite()
+	defer unlock()
+
+	purge := func(tableName string) (int64, error) {
+		return st.purgeRows(ctx, "RecordPurgeExpired", tableName, func(q contractsorm.Query) contractsorm.Query {
+			return st.whereNotProtected(st.whereTenant(q.Where(COLUMN_EXPIRES_AT+" <= ?", now)))
+		})
+	}
+
+	affected, err := purge(st.tableName())
+	if err != nil {
+		return 0, err
+	}
+
+	// Double write of a running MigrateToTable
+	if target := st.migrationTarget(); target != "" {
+		if _, err := purge(target); err != nil {
+			return 0, err
+		}
+	}
+
+	return affected, nil
+}
+
+// ttlRecord is implemented by the records keeping the time to live set by
+// WithTTL until created, so it is counted from the time of the store
+type ttlRecord interface {
+	setTTL(ttl time.Duration)
+	pendingTTL() time.Duration
+}
+
+// applyTTL sets the expires at of a record created with WithTTL to the
+// time to live from now, in the time of the store
+func (st *storeImplementation) applyTTL(record RecordInterface) {
+	pending, ok := record.(ttlRecord)
+	if !ok || pending.pendingTTL() <= 0 {
+		return
+	}
+	record.SetExpiresAt(st.now().Add(pending.pendingTTL()).UTC().Format(time.DateTime))
+}
+
+// expiresAtTimestamp returns the value of the expires at of the record
+// written to the table, MAX_DATETIME for a record without one (i.e. built
+// with NewRecordFromExistingData from data without it), which never expires
+// as reported by IsExpired
+func (st *storeImplementation) expiresAtTimestamp(record RecordInterface) any {
+	if record.ExpiresAt() == "" {
+		return st.timestamp(maxTime)
+	}
+	return st.timestamp(record.ExpiresAtCarbon().StdTime())
+}
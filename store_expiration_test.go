@@ -0,0 +1,211 @@
+package customstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordExpiration(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	clock := &fixedClock{now: time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_expiration",
+		AutomigrateEnabled: true,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	session := customstore.NewRecord("session", customstore.WithExpiresAt(clock.now.Add(time.Hour)))
+	permanent := customstore.NewRecord("session")
+	for _, record := range []customstore.RecordInterface{session, permanent} {
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	if permanent.ExpiresAt() != customstore.MAX_DATETIME {
+		t.Fatalf("Expected a record without expiry to expire at %q, but got %q", customstore.MAX_DATETIME, permanent.ExpiresAt())
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().SetType("session"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 sessions before the expiry, but got %d", count)
+	}
+
+	clock.now = clock.now.Add(2 * time.Hour)
+
+	list, err := store.RecordList(customstore.RecordQuery().SetType("session"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 || list[0].ID() != permanent.ID() {
+		t.Fatalf("Expected only the permanent session after the expiry, but got %d records", len(list))
+	}
+
+	found, err := store.RecordFindByID(session.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatalf("Expected the expired session not to be found, but got %s", found.ID())
+	}
+
+	list, err = store.RecordList(customstore.RecordQuery().SetType("session").SetExpiredIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordList with expired failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 sessions with the expired included, but got %d", len(list))
+	}
+
+	purged, err := store.RecordPurgeExpired()
+	if err != nil {
+		t.Fatalf("RecordPurgeExpired failed: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("Expected 1 expired session purged, but got %d", purged)
+	}
+
+	count, err = store.RecordCount(customstore.RecordQuery().SetType("session").SetExpiredIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 session left after the purge, but got %d", count)
+	}
+}
+
+func TestRecordWithTTL(t *testing.T) {
+	record, err := customstore.NewRecordE("cache", customstore.WithTTL(time.Minute))
+	if err != nil {
+		t.Fatalf("NewRecordE failed: %v", err)
+	}
+	if record.IsExpired() {
+		t.Fatalf("Expected a record with a TTL in the future not to be expired")
+	}
+
+	expired := customstore.NewRecord("cache", customstore.WithExpiresAt(time.Now().Add(-time.Minute)))
+	if !expired.IsExpired() {
+		t.Fatalf("Expected a record with a past expiry to be expired")
+	}
+
+	if _, err := customstore.NewRecordE("cache", customstore.WithTTL(0)); err == nil {
+		t.Fatalf("Expected error for a zero TTL, but got nil")
+	}
+}
+
+func TestMigrateUpAddsExpiresAtColumn(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	_, err := db.Exec(`CREATE TABLE data_expiration_legacy (
+		id VARCHAR(40) PRIMARY KEY,
+		record_type VARCHAR(100),
+		payload TEXT,
+		metas TEXT,
+		memo TEXT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		soft_deleted_at DATETIME,
+		version BIGINT DEFAULT 0
+	)`)
+	if err != nil {
+		t.Fatalf("Legacy table could not be created: %v", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO data_expiration_legacy VALUES
+		('legacy1', 'person', '', '{}', '', '2020-01-01 00:00:00', '2020-01-01 00:00:00', '9999-12-31 23:59:59', 1)`)
+	if err != nil {
+		t.Fatalf("Legacy record could not be inserted: %v", err)
+	}
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_expiration_legacy",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	found, err := store.RecordFindByID("legacy1")
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found == nil {
+		t.Fatalf("Expected the legacy record to be found after the upgrade")
+	}
+	if found.ExpiresAt() != customstore.MAX_DATETIME {
+		t.Fatalf("Expected the legacy record to never expire, but got %q", found.ExpiresAt())
+	}
+
+	if err := store.RecordCreate(customstore.NewRecord("person", customstore.WithTTL(time.Hour))); err != nil {
+		t.Fatalf("RecordCreate in the upgraded table failed: %v", err)
+	}
+}
+
+func TestRecordExpirationDefaults(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	clock := &fixedClock{now: time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_expiration_defaults",
+		AutomigrateEnabled: true,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// A record built without an expires at never expires
+	record := customstore.NewRecordFromExistingData(map[string]string{
+		customstore.COLUMN_ID:          "existing1",
+		customstore.COLUMN_RECORD_TYPE: "person",
+	})
+	if record.ExpiresAt() != customstore.MAX_DATETIME || record.IsExpired() {
+		t.Fatalf("Expected the record to never expire, but got %q", record.ExpiresAt())
+	}
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordUpdate(record); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+	count, err := store.RecordCount(customstore.RecordQuery().SetID("existing1"))
+	if err != nil || count != 1 {
+		t.Fatalf("Expected the record to be found after the create and update, but got %d, %v", count, err)
+	}
+
+	// The TTL is counted from the time of the store
+	session := customstore.NewRecord("session", customstore.WithTTL(time.Hour))
+	if err := store.RecordCreate(session); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if session.ExpiresAt() != "2030-01-01 13:00:00" {
+		t.Fatalf("Expected the TTL from the store time, but got %q", session.ExpiresAt())
+	}
+	found, err := store.RecordFindByID(session.ID())
+	if err != nil || found == nil || found.ExpiresAt() != "2030-01-01 13:00:00" {
+		t.Fatalf("Expected the session to be stored with the expiry, but got %v, %v", found, err)
+	}
+
+	clock.now = clock.now.Add(2 * time.Hour)
+	found, err = store.RecordFindByID(session.ID())
+	if err != nil || found != nil {
+		t.Fatalf("Expected the session to be expired in the store time, but got %v, %v", found, err)
+	}
+}
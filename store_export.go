@@ -0,0 +1,348 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// exportBatchSize is the number of records read per batch by Export
+const exportBatchSize = 500
+
+// importBatchSize is the number of records written per transaction by Import
+const importBatchSize = 100
+
+// exportedRecord is a line of the newline delimited JSON written by Export
+// and read by Import, holding every column of a record
+type exportedRecord struct {
+	ID            string            `json:"id"`
+	Type          string            `json:"record_type"`
+	Payload       string            `json:"payload"`
+	Metas         map[string]string `json:"metas"`
+	Memo          string            `json:"memo"`
+	CreatedAt     string            `json:"created_at"`
+	UpdatedAt     string            `json:"updated_at"`
+	SoftDeletedAt string            `json:"soft_deleted_at"`
+	ExpiresAt     string            `json:"expires_at"`
+	Version       int64             `json:"version"`
+}
+
+// importColumns lists the columns written by Import, the ID first
+var importColumns = []string{
+	COLUMN_ID,
+	COLUMN_RECORD_TYPE,
+	COLUMN_PAYLOAD,
+	COLUMN_METAS,
+	COLUMN_MEMO,
+	COLUMN_CREATED_AT,
+	COLUMN_UPDATED_AT,
+	COLUMN_SOFT_DELETED_AT,
+	COLUMN_VERSION,
+	COLUMN_EXPIRES_AT,
+}
+
+// Export writes the records matching the query (all active records if nil)
+// to w as newline delimited JSON, one object with every column per record,
+// returning the number of records written. Include the soft deleted and
+// expired records in the query for a complete backup.
+//
+// Queries without a limit, offset, order or page token are read in batches
+// ordered by ID, so large tables are streamed. Other queries are exported
+// as listed.
+func (st *storeImplementation) Export(w io.Writer, query RecordQueryInterface) (int, error) {
+	return st.ExportContext(context.Background(), w, query)
+}
+
+// ExportContext is Export using the given context
+func (st *storeImplementation) ExportContext(ctx context.Context, w io.Writer, query RecordQueryInterface) (int, error) {
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if w == nil {
+		return 0, errors.New("writer is nil")
+	}
+
+	if query == nil {
+		query = NewRecordQuery()
+	}
+
+	encoder := json.NewEncoder(w)
+	exported := 0
+
+	write := func(list []RecordInterface) error {
+		for _, record := range list {
+			line, err := newExportedRecord(record)
+			if err != nil {
+				return err
+			}
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+			exported++
+		}
+		return nil
+	}
+
+	batched, ok := cloneRecordQuery(query)
+	if !ok || query.IsLimitSet() || query.IsOffsetSet() || query.IsOrderBySet() || len(query.GetOrderByList()) > 0 || query.IsPageTokenSet() {
+		list, err := st.recordList(ctx, query)
+		if err != nil {
+			return 0, err
+		}
+		return exported, write(list)
+	}
+
+	batched.SetOrderBy(COLUMN_ID).SetLimit(exportBatchSize)
+
+	for {
+		list, err := st.recordList(ctx, batched)
+		if err != nil {
+			return exported, err
+		}
+
+		if len(list) == 0 {
+			return exported, nil
+		}
+
+		if err := write(list); err != nil {
+			return exported, err
+		}
+
+		token, err := NewPageToken(COLUMN_ID, list[len(list)-1])
+		if err != nil {
+			return exported, err
+		}
+		batched.SetPageToken(token)
+	}
+}
+
+// Import reads newline delimited JSON written by Export from r, creating
+// the records or replacing the stored records with the same IDs, returning
+// the number of records imported. Every column is kept as exported, missing
+// ones take the defaults of NewRecord.
+//
+// Records are written in batches, each in a transaction. On error the
+// batches before the failing one stay imported. Hooks do not run and the
+// writes are not streamed by Changes.
+func (st *storeImplementation) Import(r io.Reader) (int, error) {
+	return st.ImportContext(context.Background(), r)
+}
+
+// ImportContext is Import using the given context
+func (st *storeImplementation) ImportContext(ctx context.Context, r io.Reader) (int, error) {
+	if err := st.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if r == nil {
+		return 0, errors.New("reader is nil")
+	}
+
+	decoder := json.NewDecoder(r)
+	imported := 0
+	batch := []RecordInterface{}
+
+	for {
+		line := exportedRecord{}
+		err := decoder.Decode(&line)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imported, errors.New("import record " + strconv.Itoa(imported+len(batch)+1) + ": " + err.Error())
+		}
+
+		record, err := line.record()
+		if err != nil {
+			return imported, errors.New("import record " + strconv.Itoa(imported+len(batch)+1) + ": " + err.Error())
+		}
+
+		batch = append(batch, record)
+		if len(batch) < importBatchSize {
+			continue
+		}
+
+		if err := st.importBatch(ctx, batch); err != nil {
+			return imported, err
+		}
+		imported += len(batch)
+		batch = batch[:0]
+	}
+
+	if len(batch) > 0 {
+		if err := st.importBatch(ctx, batch); err != nil {
+			return imported, err
+		}
+		imported += len(batch)
+	}
+
+	return imported, nil
+}
+
+// importBatch writes the records in one transaction
+func (st *storeImplementation) importBatch(ctx context.Context, records []RecordInterface) error {
+	ids := make([]string, len(records))
+	for i, record := range records {
+		ids[i] = record.ID()
+	}
+
+	unlock := st.lockWrite()
+	defer unlock()
+
+	err := st.transaction(func(tx contractsorm.Query) error {
+		return st.writeImported(ctx, tx, records, "Import")
+	})
+	if err != nil {
+		return err
+	}
+
+	return st.copyToMigrationTarget(ctx, ids)
+}
+
+// writeImported creates or replaces the records with every column as given,
+// within the transaction, stamping the tenant the store is scoped to
+func (st *storeImplementation) writeImported(ctx context.Context, tx contractsorm.Query, records []RecordInterface, op string) error {
+	if st.sharded() {
+		views, groups := st.recordsByTable(records)
+		for i, view := range views {
+			if len(groups[i]) == 0 {
+				continue
+			}
+			if err := view.writeImported(ctx, tx, groups[i], op); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	columns := importColumns
+	if st.tenantID != "" {
+		columns = append(slices.Clone(importColumns), COLUMN_TENANT_ID)
+	}
+
+	sqlStr := rebindPlaceholders(st.driverName(), insertOrUpdateSQL(st.driverName(), st.tableName(), columns, importColumns[1:], false))
+
+	ids := make([]string, len(records))
+	for i, record := range records {
+		ids[i] = record.ID()
+	}
+	if err := st.checkTenantIDs(ctx, cloneQuery(ctx, tx), ids, op); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		args, err := st.importArgs(record)
+		if err != nil {
+			return err
+		}
+		if st.tenantID != "" {
+			args = append(args, st.tenantID)
+		}
+
+		if _, err := st.exec(cloneQuery(ctx, tx), op, sqlStr, args...); err != nil {
+			return st.wrapError(err, op, record.ID(), record.Type(), func() string {
+				return sqlStr
+			})
+		}
+	}
+
+	return nil
+}
+
+// importArgs returns the values of the importColumns of the record
+func (st *storeImplementation) importArgs(record RecordInterface) ([]any, error) {
+	metas, err := record.Metas()
+	if err != nil {
+		return nil, err
+	}
+	metasJSON, err := json.Marshal(metas)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{
+		record.ID(),
+		record.Type(),
+		record.Payload(),
+		string(metasJSON),
+		record.Memo(),
+		st.timestamp(record.CreatedAtCarbon().StdTime()),
+		st.timestamp(record.UpdatedAtCarbon().StdTime()),
+		st.timestamp(record.SoftDeletedAtCarbon().StdTime()),
+		record.Version(),
+		st.expiresAtTimestamp(record),
+	}, nil
+}
+
+// newExportedRecord returns the exported line of the record
+func newExportedRecord(record RecordInterface) (exportedRecord, error) {
+	metas, err := record.Metas()
+	if err != nil {
+		return exportedRecord{}, err
+	}
+
+	return exportedRecord{
+		ID:            record.ID(),
+		Type:          record.Type(),
+		Payload:       record.Payload(),
+		Metas:         metas,
+		Memo:          record.Memo(),
+		CreatedAt:     record.CreatedAt(),
+		UpdatedAt:     record.UpdatedAt(),
+		SoftDeletedAt: record.SoftDeletedAt(),
+		ExpiresAt:     record.ExpiresAt(),
+		Version:       record.Version(),
+	}, nil
+}
+
+// record returns the imported record of the line
+func (line exportedRecord) record() (RecordInterface, error) {
+	if line.ID == "" {
+		return nil, errors.New("record ID is required")
+	}
+
+	if line.Type == "" {
+		return nil, errors.New("record type is required")
+	}
+
+	record := NewRecord(line.Type)
+	record.SetID(line.ID)
+	record.SetPayload(line.Payload)
+	record.SetMemo(line.Memo)
+
+	if line.Metas != nil {
+		if err := record.SetMetas(line.Metas); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, column := range []struct {
+		value string
+		set   func(string)
+	}{
+		{line.CreatedAt, record.SetCreatedAt},
+		{line.UpdatedAt, record.SetUpdatedAt},
+		{line.SoftDeletedAt, record.SetSoftDeletedAt},
+		{line.ExpiresAt, record.SetExpiresAt},
+	} {
+		if column.value != "" {
+			column.set(column.value)
+		}
+	}
+
+	if line.Version > 0 {
+		record.SetVersion(line.Version)
+	}
+
+	return record, nil
+}
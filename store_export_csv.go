@@ -0,0 +1,138 @@
+package customstore
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ExportCSV writes the records matching the query (all active records if
+// nil) to w as CSV, returning the number of records written. The first row
+// is the header holding the columns, i.e. for spreadsheet exports:
+//
+//	store.ExportCSV(w, query, []string{"id", "created_at", "payload.email"})
+//
+// Columns are table column names or payload keys prefixed with
+// PAYLOAD_KEY_PREFIX, as for RecordRows. Payload strings are written as is,
+// other payload values as JSON, missing payload keys as empty cells.
+//
+// Queries without a limit, offset, order or page token are read in batches
+// ordered by ID, as for Export.
+func (st *storeImplementation) ExportCSV(w io.Writer, query RecordQueryInterface, columns []string) (int, error) {
+	return st.ExportCSVContext(context.Background(), w, query, columns)
+}
+
+// ExportCSVContext is ExportCSV using the given context
+func (st *storeImplementation) ExportCSVContext(ctx context.Context, w io.Writer, query RecordQueryInterface, columns []string) (int, error) {
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if w == nil {
+		return 0, errors.New("writer is nil")
+	}
+
+	if len(columns) == 0 {
+		return 0, errors.New("columns are required")
+	}
+
+	if query == nil {
+		query = NewRecordQuery()
+	}
+
+	batched, ok := cloneRecordQuery(query)
+	if !ok {
+		return 0, errors.New("query must be created with NewRecordQuery")
+	}
+
+	// The ID is selected to page through the records
+	selected := columns
+	hasID := false
+	for _, column := range columns {
+		hasID = hasID || column == COLUMN_ID
+	}
+	if !hasID {
+		selected = append([]string{COLUMN_ID}, columns...)
+	}
+	batched.SetColumns(selected)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	write := func(rows []RecordRow) error {
+		for _, row := range rows {
+			cells := make([]string, len(columns))
+			for i, column := range columns {
+				cell, err := csvCell(row, column)
+				if err != nil {
+					return err
+				}
+				cells[i] = cell
+			}
+			if err := writer.Write(cells); err != nil {
+				return err
+			}
+			exported++
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	if query.IsLimitSet() || query.IsOffsetSet() || query.IsOrderBySet() || len(query.GetOrderByList()) > 0 || query.IsPageTokenSet() {
+		rows, err := st.recordRows(ctx, batched)
+		if err != nil {
+			return 0, err
+		}
+		return exported, write(rows)
+	}
+
+	batched.SetOrderBy(COLUMN_ID).SetLimit(exportBatchSize)
+
+	for {
+		rows, err := st.recordRows(ctx, batched)
+		if err != nil {
+			return exported, err
+		}
+
+		if len(rows) == 0 {
+			writer.Flush()
+			return exported, writer.Error()
+		}
+
+		if err := write(rows); err != nil {
+			return exported, err
+		}
+
+		lastID := rows[len(rows)-1].Column(COLUMN_ID)
+		batched.SetPageToken(PageToken{OrderBy: COLUMN_ID, Value: lastID, ID: lastID})
+	}
+}
+
+// csvCell returns the CSV cell of the column of the row
+func csvCell(row RecordRow, column string) (string, error) {
+	key, isPayloadKey := strings.CutPrefix(column, PAYLOAD_KEY_PREFIX)
+	if !isPayloadKey {
+		return row.Column(column), nil
+	}
+
+	value, exists := row.Payload[key]
+	if !exists {
+		return "", nil
+	}
+
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
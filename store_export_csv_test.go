@@ -0,0 +1,97 @@
+package customstore_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreExportCSV(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_export_csv",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for i := 0; i < 600; i++ {
+		if err := store.RecordCreate(customstore.NewRecord("person", customstore.WithPayload(`{"email":"a@example.com","age":42}`))); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+	ada := customstore.NewRecord("person",
+		customstore.WithPayload(`{"email":"ada@example.com","tags":["math"]}`),
+		customstore.WithMemo("first, programmer"))
+	if err := store.RecordCreate(ada); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordCreate(customstore.NewRecord("invoice")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	columns := []string{"memo", "payload.email", "payload.age", "payload.tags"}
+	exported, err := store.ExportCSV(&buffer, customstore.NewRecordQuery().SetType("person"), columns)
+	if err != nil {
+		t.Fatalf("ExportCSV failed: %v", err)
+	}
+	if exported != 601 {
+		t.Fatalf("Expected 601 exported records, but got %d", exported)
+	}
+
+	rows, err := csv.NewReader(&buffer).ReadAll()
+	if err != nil {
+		t.Fatalf("Reading the CSV failed: %v", err)
+	}
+	if len(rows) != 602 {
+		t.Fatalf("Expected a header and 601 rows, but got %d", len(rows))
+	}
+
+	header := rows[0]
+	for i, column := range columns {
+		if header[i] != column {
+			t.Fatalf("Expected header %v, but got %v", columns, header)
+		}
+	}
+
+	found := false
+	for _, row := range rows[1:] {
+		if len(row) != len(columns) {
+			t.Fatalf("Expected %d cells, but got %v", len(columns), row)
+		}
+		if row[1] == "ada@example.com" {
+			found = true
+			if row[0] != "first, programmer" || row[2] != "" || row[3] != `["math"]` {
+				t.Fatalf("Unexpected row %v", row)
+			}
+		} else if row[2] != "42" || row[3] != "" {
+			t.Fatalf("Unexpected row %v", row)
+		}
+	}
+	if !found {
+		t.Fatalf("Expected the row of %s", ada.ID())
+	}
+
+	buffer.Reset()
+	exported, err = store.ExportCSV(&buffer, customstore.NewRecordQuery().SetID(ada.ID()), []string{"id", "record_type"})
+	if err != nil || exported != 1 {
+		t.Fatalf("ExportCSV failed: %d, %v", exported, err)
+	}
+	if buffer.String() != "id,record_type\n"+ada.ID()+",person\n" {
+		t.Fatalf("Unexpected CSV %q", buffer.String())
+	}
+
+	if _, err := store.ExportCSV(&buffer, nil, nil); err == nil {
+		t.Fatalf("Expected error for missing columns, but got nil")
+	}
+	if _, err := store.ExportCSV(&buffer, nil, []string{"password"}); err == nil {
+		t.Fatalf("Expected error for an unsupported column, but got nil")
+	}
+}
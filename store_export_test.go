@@ -0,0 +1,150 @@
+package customstore_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreExportImport(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	source, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_export_source",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	target, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_export_target",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	ids := map[string]bool{}
+	for i := 0; i < 1200; i++ {
+		record := customstore.NewRecord("person",
+			customstore.WithPayload(`{"n":1}`),
+			customstore.WithMetas(map[string]string{"source": "seed"}),
+			customstore.WithMemo("memo"))
+		if err := source.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		ids[record.ID()] = true
+	}
+
+	softDeleted := customstore.NewRecord("person")
+	if err := source.RecordCreate(softDeleted); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := source.RecordSoftDeleteByID(softDeleted.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	if err := source.RecordCreate(customstore.NewRecord("invoice")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	var buffer bytes.Buffer
+	exported, err := source.Export(&buffer, customstore.NewRecordQuery().SetType("person").SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if exported != 1201 {
+		t.Fatalf("Expected 1201 exported records, but got %d", exported)
+	}
+	if lines := strings.Count(buffer.String(), "\n"); lines != 1201 {
+		t.Fatalf("Expected one line per record, but got %d", lines)
+	}
+
+	imported, err := target.Import(bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if imported != 1201 {
+		t.Fatalf("Expected 1201 imported records, but got %d", imported)
+	}
+
+	count, err := target.RecordCount(customstore.NewRecordQuery().SetType("person"))
+	if err != nil || count != 1200 {
+		t.Fatalf("Expected 1200 active imported records, but got %d, %v", count, err)
+	}
+
+	originals, err := source.RecordList(customstore.NewRecordQuery().SetID(softDeleted.ID()).SetSoftDeletedIncluded(true))
+	if err != nil || len(originals) != 1 {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	original := originals[0]
+	list, err := target.RecordList(customstore.NewRecordQuery().SetID(softDeleted.ID()).SetSoftDeletedIncluded(true))
+	if err != nil || len(list) != 1 {
+		t.Fatalf("Expected the soft deleted record to be imported, but got %d, %v", len(list), err)
+	}
+	copied := list[0]
+	if copied.SoftDeletedAt() != original.SoftDeletedAt() || copied.CreatedAt() != original.CreatedAt() || copied.Version() != original.Version() {
+		t.Fatalf("Expected every column to be kept, but got %s, %s, %d", copied.SoftDeletedAt(), copied.CreatedAt(), copied.Version())
+	}
+
+	// Importing again replaces the records instead of duplicating them
+	if _, err := target.Import(bytes.NewReader(buffer.Bytes())); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	count, err = target.RecordCount(customstore.NewRecordQuery().SetSoftDeletedIncluded(true))
+	if err != nil || count != 1201 {
+		t.Fatalf("Expected 1201 records after importing twice, but got %d, %v", count, err)
+	}
+
+	some, err := target.RecordList(customstore.NewRecordQuery().SetType("person").SetLimit(1))
+	if err != nil || len(some) != 1 {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if !ids[some[0].ID()] || some[0].Payload() != `{"n":1}` || some[0].Meta("source") != "seed" || some[0].Memo() != "memo" {
+		t.Fatalf("Expected the imported record to match, but got %s %s %s", some[0].ID(), some[0].Payload(), some[0].Memo())
+	}
+}
+
+func TestStoreImportInvalid(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_import_invalid",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	input := `{"id":"a1","record_type":"person","payload":"{}"}` + "\n" + `{"id":"","record_type":"person"}` + "\n"
+	imported, err := store.Import(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "import record 2") {
+		t.Fatalf("Expected error for the record without ID, but got %v", err)
+	}
+	if imported != 0 {
+		t.Fatalf("Expected nothing imported before the failing batch, but got %d", imported)
+	}
+
+	seeded, err := store.Import(strings.NewReader(`{"id":"a1","record_type":"person","payload":"{\"name\":\"Ada\"}"}`))
+	if err != nil || seeded != 1 {
+		t.Fatalf("Import failed: %d, %v", seeded, err)
+	}
+	record, err := store.RecordFindByID("a1")
+	if err != nil || record == nil {
+		t.Fatalf("Expected the seeded record with defaults, but got %v", err)
+	}
+	if record.IsSoftDeleted() || record.Version() != 1 {
+		t.Fatalf("Expected the defaults of a new record, but got soft deleted %v, version %d", record.IsSoftDeleted(), record.Version())
+	}
+
+	if _, err := store.ReadOnlyView().Import(strings.NewReader("")); err != customstore.ErrReadOnly {
+		t.Fatalf("Expected ErrReadOnly, but got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package customstore
+
+import "context"
+
+// RecordFindFirst returns the first record matching the query, in the order
+// of the query, or nil if none matches
+func (st *storeImplementation) RecordFindFirst(query RecordQueryInterface) (RecordInterface, error) {
+	return st.RecordFindFirstContext(context.Background(), query)
+}
+
+// RecordFindFirstContext returns the first record matching the query using
+// the given context, or nil if none matches. The query is limited to one
+// record without being modified.
+func (st *storeImplementation) RecordFindFirstContext(ctx context.Context, query RecordQueryInterface) (RecordInterface, error) {
+	if query == nil {
+		query = NewRecordQuery()
+	}
+
+	list, err := st.listRecords(ctx, firstQuery{query}, "RecordFindFirst")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(list) > 0 {
+		return list[0], nil
+	}
+
+	return nil, nil
+}
+
+// firstQuery wraps a record query limiting it to the first record
+type firstQuery struct {
+	RecordQueryInterface
+}
+
+func (q firstQuery) IsLimitSet() bool {
+	return true
+}
+
+func (q firstQuery) GetLimit() int {
+	return 1
+}
@@ -0,0 +1,60 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRecordFindFirst(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_find_first",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record, err := store.RecordFindFirst(customstore.RecordQuery().SetType("person"))
+	if err != nil {
+		t.Fatalf("RecordFindFirst failed: %v", err)
+	}
+	if record != nil {
+		t.Fatalf("Expected nil when no record matches, got %s", record.ID())
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := store.RecordCreate(customstore.NewRecord("person", customstore.WithID(id))); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	query := customstore.RecordQuery().
+		SetType("person").
+		AddOrderBy(customstore.COLUMN_ID, "asc").
+		SetLimit(10)
+	record, err = store.RecordFindFirstContext(t.Context(), query)
+	if err != nil {
+		t.Fatalf("RecordFindFirstContext failed: %v", err)
+	}
+	if record == nil || record.ID() != "a" {
+		t.Fatalf("Expected the first record in order, got %v", record)
+	}
+
+	// The query is not modified
+	if query.GetLimit() != 10 {
+		t.Fatalf("Expected the query limit to be unchanged, got %d", query.GetLimit())
+	}
+
+	record, err = store.RecordFindFirst(query.SetOffset(2))
+	if err != nil {
+		t.Fatalf("RecordFindFirst failed: %v", err)
+	}
+	if record == nil || record.ID() != "c" {
+		t.Fatalf("Expected the first record after the offset, got %v", record)
+	}
+}
@@ -0,0 +1,99 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// RecordFindOrCreate returns the first record matching the query, or creates
+// the record returned by create when none matches.
+//
+// The lookup and the insert run in a single transaction, with the matching
+// rows locked for update where the driver supports it. If a concurrent
+// caller inserts the same record ID first, the insert fails on the primary
+// key and the record created by the other caller is returned instead.
+func (st *storeImplementation) RecordFindOrCreate(query RecordQueryInterface, create func() RecordInterface) (record RecordInterface, created bool, err error) {
+	if st.sharded() {
+		view, err := st.forQuery(query)
+		if err != nil {
+			return nil, false, err
+		}
+		return view.RecordFindOrCreate(query, create)
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return nil, false, err
+	}
+
+	if st.db == nil {
+		return nil, false, errors.New("database is not initialized")
+	}
+
+	if query == nil {
+		return nil, false, errors.New("query is nil")
+	}
+
+	if create == nil {
+		return nil, false, errors.New("create function is nil")
+	}
+
+	if err := query.Validate(); err != nil {
+		return nil, false, err
+	}
+
+	if err := st.checkQueryGuards(query); err != nil {
+		return nil, false, err
+	}
+
+	ctx := context.Background()
+
+	changes := st.txChanges
+	if changes == nil {
+		changes = &changeBuffer{}
+	}
+
+	err = st.transaction(func(tx contractsorm.Query) error {
+		list, err := st.selectRecords(ctx, st.applyQuery(cloneQuery(ctx, tx), query).LockForUpdate().Limit(1), query, "RecordFindOrCreate")
+		if err != nil {
+			return err
+		}
+
+		if len(list) > 0 {
+			record = list[0]
+			return nil
+		}
+
+		newRecord := create()
+		if newRecord == nil {
+			return errors.New("create function returned nil record")
+		}
+
+		bound := *st
+		bound.tx = tx
+		bound.txChanges = changes
+		if err := bound.insertRecord(ctx, newRecord, "RecordFindOrCreate"); err != nil {
+			return err
+		}
+
+		record = newRecord
+		created = true
+		return nil
+	})
+
+	if err == nil {
+		if st.txChanges == nil && len(changes.events) > 0 {
+			st.changes.deliver(changes.events)
+		}
+		return record, created, nil
+	}
+
+	// The insert may have lost a race against a concurrent create
+	list, findErr := st.selectRecords(ctx, st.buildQuery(ctx, query).Limit(1), query, "RecordFindOrCreate")
+	if findErr == nil && len(list) > 0 {
+		return list[0], false, nil
+	}
+
+	return nil, false, st.wrapError(err, "RecordFindOrCreate", queryRecordID(query), queryRecordType(query), nil)
+}
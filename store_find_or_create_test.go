@@ -0,0 +1,88 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordFindOrCreate(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_find_or_create",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	query := customstore.RecordQuery().SetType("setting").AddMetaEquals("key", "theme")
+	create := func() customstore.RecordInterface {
+		return customstore.NewRecord("setting",
+			customstore.WithMetas(map[string]string{"key": "theme"}),
+			customstore.WithPayload(`{"value":"dark"}`))
+	}
+
+	record, created, err := store.RecordFindOrCreate(query, create)
+	if err != nil {
+		t.Fatalf("RecordFindOrCreate failed: %v", err)
+	}
+	if !created {
+		t.Fatalf("Expected record to be created on first call")
+	}
+	if record == nil {
+		t.Fatalf("Expected record, but got nil")
+	}
+
+	found, created, err := store.RecordFindOrCreate(query, create)
+	if err != nil {
+		t.Fatalf("RecordFindOrCreate second call failed: %v", err)
+	}
+	if created {
+		t.Fatalf("Expected existing record to be found on second call")
+	}
+	if found.ID() != record.ID() {
+		t.Fatalf("Expected record ID %s, but got %s", record.ID(), found.ID())
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().SetType("setting"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 record, but got %d", count)
+	}
+}
+
+func TestRecordFindOrCreateErrors(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_find_or_create_errors",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if _, _, err := store.RecordFindOrCreate(nil, func() customstore.RecordInterface { return nil }); err == nil {
+		t.Fatalf("Expected error for nil query, but got nil")
+	}
+
+	if _, _, err := store.RecordFindOrCreate(customstore.RecordQuery().SetType("setting"), nil); err == nil {
+		t.Fatalf("Expected error for nil create function, but got nil")
+	}
+
+	_, created, err := store.RecordFindOrCreate(customstore.RecordQuery().SetType("setting"), func() customstore.RecordInterface { return nil })
+	if err == nil {
+		t.Fatalf("Expected error when create returns nil, but got nil")
+	}
+	if created {
+		t.Fatalf("Expected created to be false on error")
+	}
+}
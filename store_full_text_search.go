@@ -0,0 +1,150 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// fullTextColumn is the generated PostgreSQL column holding the tsvector of
+// the payload
+const fullTextColumn = "payload_tsv"
+
+// fullTextConfig is the PostgreSQL text search configuration. The simple
+// configuration lower cases words without language specific stemming, as
+// the SQLite unicode61 tokenizer does.
+const fullTextConfig = "'simple'"
+
+// fullTextSearchSupported returns whether full text search is available for
+// the driver
+func fullTextSearchSupported(driver string) bool {
+	return driver == "sqlite" || driver == "postgres"
+}
+
+// fullTextTableName returns the name of the FTS5 table indexing the
+// payloads of the store table (SQLite)
+func fullTextTableName(tableName string) string {
+	return tableName + "_fts"
+}
+
+// createFullTextIndex sets up the full text index of the table if full text
+// search is enabled. Safe to call on an already indexed table.
+//
+// SQLite uses an external content FTS5 table kept in sync by triggers,
+// PostgreSQL a generated tsvector column with a GIN index. Either way every
+// write path, including raw statements, keeps the index up to date.
+func (st *storeImplementation) createFullTextIndex(ctx context.Context, tableName string) error {
+	if !st.fullTextSearch {
+		return nil
+	}
+
+	switch st.driverName() {
+	case "sqlite":
+		ftsTable := fullTextTableName(tableName)
+		created := !st.db.Schema().HasTable(ftsTable)
+
+		statements := []string{
+			"CREATE VIRTUAL TABLE IF NOT EXISTS " + ftsTable + " USING fts5(" + COLUMN_PAYLOAD + ", content='" + tableName + "', content_rowid='rowid')",
+			"CREATE TRIGGER IF NOT EXISTS " + ftsTable + "_insert AFTER INSERT ON " + tableName + " BEGIN " +
+				"INSERT INTO " + ftsTable + "(rowid, " + COLUMN_PAYLOAD + ") VALUES (new.rowid, new." + COLUMN_PAYLOAD + "); END",
+			"CREATE TRIGGER IF NOT EXISTS " + ftsTable + "_delete AFTER DELETE ON " + tableName + " BEGIN " +
+				"INSERT INTO " + ftsTable + "(" + ftsTable + ", rowid, " + COLUMN_PAYLOAD + ") VALUES ('delete', old.rowid, old." + COLUMN_PAYLOAD + "); END",
+			"CREATE TRIGGER IF NOT EXISTS " + ftsTable + "_update AFTER UPDATE OF " + COLUMN_PAYLOAD + " ON " + tableName + " BEGIN " +
+				"INSERT INTO " + ftsTable + "(" + ftsTable + ", rowid, " + COLUMN_PAYLOAD + ") VALUES ('delete', old.rowid, old." + COLUMN_PAYLOAD + "); " +
+				"INSERT INTO " + ftsTable + "(rowid, " + COLUMN_PAYLOAD + ") VALUES (new.rowid, new." + COLUMN_PAYLOAD + "); END",
+		}
+		for _, statement := range statements {
+			if _, err := st.exec(st.newQuery(ctx), "MigrateUp", statement); err != nil {
+				return err
+			}
+		}
+
+		if created {
+			// Index the records stored before full text search was enabled
+			return st.rebuildFullTextIndex(ctx, tableName)
+		}
+		return nil
+	case "postgres":
+		statements := []string{
+			"ALTER TABLE " + tableName + " ADD COLUMN IF NOT EXISTS " + fullTextColumn + " tsvector" +
+				" GENERATED ALWAYS AS (to_tsvector(" + fullTextConfig + ", COALESCE(" + COLUMN_PAYLOAD + ", ''))) STORED",
+			"CREATE INDEX IF NOT EXISTS " + tableName + "_" + fullTextColumn + "_idx ON " + tableName + " USING GIN (" + fullTextColumn + ")",
+		}
+		for _, statement := range statements {
+			if _, err := st.exec(st.newQuery(ctx), "MigrateUp", statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errors.New("full text search is not supported by the " + st.driverName() + " driver")
+	}
+}
+
+// dropFullTextIndex drops the FTS5 table of the table (SQLite). The triggers
+// are dropped together with the table, the PostgreSQL column with it.
+func (st *storeImplementation) dropFullTextIndex(ctx context.Context, tableName string) error {
+	if st.driverName() != "sqlite" {
+		return nil
+	}
+
+	_, err := st.exec(st.newQuery(ctx), "MigrateDown", "DROP TABLE IF EXISTS "+fullTextTableName(tableName))
+	return err
+}
+
+// RebuildFullTextIndex rebuilds the full text index from the stored
+// payloads. Only needed on SQLite after a VACUUM, which may renumber the
+// rowids the index refers to.
+func (st *storeImplementation) RebuildFullTextIndex(ctx context.Context) error {
+	if st.sharded() {
+		for _, view := range st.tableViews() {
+			if err := view.RebuildFullTextIndex(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
+	if !st.fullTextSearch {
+		return ErrFullTextSearchDisabled
+	}
+
+	if st.driverName() != "sqlite" {
+		return nil
+	}
+
+	return st.wrapError(st.rebuildFullTextIndex(ctx, st.tableName()), "RebuildFullTextIndex", "", "", nil)
+}
+
+// rebuildFullTextIndex rebuilds the FTS5 table of the table (SQLite)
+func (st *storeImplementation) rebuildFullTextIndex(ctx context.Context, tableName string) error {
+	ftsTable := fullTextTableName(tableName)
+	_, err := st.exec(st.newQuery(ctx), "RebuildFullTextIndex", "INSERT INTO "+ftsTable+"("+ftsTable+") VALUES ('rebuild')")
+	return err
+}
+
+// fullTextCondition restricts the query to the records whose payload
+// contains every word of the search
+func (st *storeImplementation) fullTextCondition(search string) sqlCondition {
+	if st.driverName() == "postgres" {
+		return sqlCondition{sql: fullTextColumn + " @@ plainto_tsquery(" + fullTextConfig + ", ?)", args: []any{search}}
+	}
+
+	ftsTable := fullTextTableName(st.tableName())
+	return sqlCondition{sql: st.tableName() + ".rowid IN (SELECT rowid FROM " + ftsTable + " WHERE " + ftsTable + " MATCH ?)", args: []any{fts5Query(search)}}
+}
+
+// fts5Query converts the search to an FTS5 query matching every word, each
+// quoted as a string so the FTS5 operators and punctuation are taken
+// literally
+func fts5Query(search string) string {
+	words := strings.Fields(search)
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+	return strings.Join(words, " ")
+}
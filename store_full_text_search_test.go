@@ -0,0 +1,150 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestFullTextSearch(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                    db,
+		TableName:             "data_full_text_search",
+		AutomigrateEnabled:    true,
+		FullTextSearchEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	first := customstore.NewRecord("note", customstore.WithPayload(`{"title":"Quarterly report","body":"Revenue grew in the north region"}`))
+	second := customstore.NewRecord("note", customstore.WithPayload(`{"title":"Team offsite","body":"Travel to the north coast"}`))
+	third := customstore.NewRecord("note", customstore.WithPayload(`{"title":"Budget","body":"Revenue forecast"}`))
+	for _, record := range []customstore.RecordInterface{first, second, third} {
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	search := func(query string) map[string]bool {
+		t.Helper()
+		list, err := store.RecordList(customstore.RecordQuery().SetFullTextSearch(query))
+		if err != nil {
+			t.Fatalf("RecordList with full text search %q failed: %v", query, err)
+		}
+		ids := map[string]bool{}
+		for _, record := range list {
+			ids[record.ID()] = true
+		}
+		return ids
+	}
+
+	if ids := search("north"); len(ids) != 2 || !ids[first.ID()] || !ids[second.ID()] {
+		t.Fatalf("Expected the two north records, but got %v", ids)
+	}
+	if ids := search("REVENUE north"); len(ids) != 1 || !ids[first.ID()] {
+		t.Fatalf("Expected only the record with every word, but got %v", ids)
+	}
+	if ids := search(`"north" OR -coast*`); len(ids) != 0 {
+		t.Fatalf("Expected operators to be taken literally, but got %v", ids)
+	}
+
+	// Updates, raw statement writes and deletes keep the index in sync
+	third.SetPayload(`{"title":"Budget","body":"North office forecast"}`)
+	if err := store.RecordUpdate(third); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+	if ids := search("revenue"); len(ids) != 1 || !ids[first.ID()] {
+		t.Fatalf("Expected the updated record to no longer match, but got %v", ids)
+	}
+
+	if err := store.RecordIncrementPayloadKey(second.ID(), "attendees", 12); err != nil {
+		t.Fatalf("RecordIncrementPayloadKey failed: %v", err)
+	}
+	if ids := search("attendees"); len(ids) != 1 || !ids[second.ID()] {
+		t.Fatalf("Expected the incremented record to match its new key, but got %v", ids)
+	}
+
+	if err := store.RecordDeleteByID(first.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+	if ids := search("north"); len(ids) != 2 || !ids[second.ID()] || !ids[third.ID()] {
+		t.Fatalf("Expected the deleted record to no longer match, but got %v", ids)
+	}
+
+	count, err := store.Query().FullTextSearch("north").Count(context.Background())
+	if err != nil {
+		t.Fatalf("Count failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected count 2, but got %d", count)
+	}
+
+	if err := store.RebuildFullTextIndex(context.Background()); err != nil {
+		t.Fatalf("RebuildFullTextIndex failed: %v", err)
+	}
+	if ids := search("office"); len(ids) != 1 || !ids[third.ID()] {
+		t.Fatalf("Expected the rebuilt index to match, but got %v", ids)
+	}
+
+	if err := customstore.RecordQuery().SetFullTextSearch("  ").Validate(); err == nil {
+		t.Fatalf("Expected error for an empty full text search, but got nil")
+	}
+
+	if err := store.MigrateDown(context.Background()); err != nil {
+		t.Fatalf("MigrateDown failed: %v", err)
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name LIKE 'data_full_text_search%'").Scan(&tables); err != nil {
+		t.Fatalf("Query sqlite_master failed: %v", err)
+	}
+	if tables != 0 {
+		t.Fatalf("Expected MigrateDown to drop the full text index, but %d tables remain", tables)
+	}
+}
+
+func TestFullTextSearchExistingTable(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	plain, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_full_text_search_existing",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("note", customstore.WithPayload(`{"body":"stored before indexing"}`))
+	if err := plain.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if _, err := plain.RecordList(customstore.RecordQuery().SetFullTextSearch("stored")); !errors.Is(err, customstore.ErrFullTextSearchDisabled) {
+		t.Fatalf("Expected ErrFullTextSearchDisabled, but got %v", err)
+	}
+
+	indexed, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                    db,
+		TableName:             "data_full_text_search_existing",
+		AutomigrateEnabled:    true,
+		FullTextSearchEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store with full text search could not be created: %v", err)
+	}
+
+	list, err := indexed.RecordList(customstore.RecordQuery().SetFullTextSearch("indexing"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 || list[0].ID() != record.ID() {
+		t.Fatalf("Expected the existing record to be indexed, but got %d records", len(list))
+	}
+}
@@ -0,0 +1,117 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreMaxListLimit(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_guardrails_limit",
+		AutomigrateEnabled: true,
+		MaxListLimit:       3,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := store.RecordCreate(customstore.NewRecord("person")); err != nil {
+			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
+		}
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("Expected list without limit to be capped at 3, but got %d", len(list))
+	}
+
+	list, err = store.RecordList(customstore.RecordQuery().SetLimit(10))
+	if err != nil {
+		t.Fatalf("RecordList with limit failed: %v", err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("Expected list with higher limit to be capped at 3, but got %d", len(list))
+	}
+
+	list, err = store.RecordList(customstore.RecordQuery().SetLimit(2))
+	if err != nil {
+		t.Fatalf("RecordList with lower limit failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected lower limit to be kept, but got %d", len(list))
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 5 {
+		t.Fatalf("Expected count not to be capped, but got %d", count)
+	}
+}
+
+func TestStoreRequireTypeFilter(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_guardrails_type",
+		AutomigrateEnabled: true,
+		RequireTypeFilter:  true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person")
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if _, err := store.RecordList(customstore.RecordQuery()); !errors.Is(err, customstore.ErrTypeFilterRequired) {
+		t.Fatalf("Expected ErrTypeFilterRequired for list without type, but got %v", err)
+	}
+	if _, err := store.RecordCount(customstore.RecordQuery()); !errors.Is(err, customstore.ErrTypeFilterRequired) {
+		t.Fatalf("Expected ErrTypeFilterRequired for count without type, but got %v", err)
+	}
+	if _, err := store.Query().Limit(5).List(context.Background()); !errors.Is(err, customstore.ErrTypeFilterRequired) {
+		t.Fatalf("Expected ErrTypeFilterRequired for fluent query without type, but got %v", err)
+	}
+
+	// Empty values and lists filter nothing
+	for _, query := range []customstore.RecordQueryInterface{
+		customstore.RecordQuery().SetIDList([]string{}),
+		customstore.RecordQuery().SetTypeIn([]string{}),
+		customstore.RecordQuery().SetType(""),
+		customstore.RecordQuery().SetID(""),
+	} {
+		if _, err := store.RecordList(query); !errors.Is(err, customstore.ErrTypeFilterRequired) {
+			t.Fatalf("Expected ErrTypeFilterRequired for an empty filter, but got %v", err)
+		}
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetType("person"))
+	if err != nil {
+		t.Fatalf("RecordList with type failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 record, but got %d", len(list))
+	}
+
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("Expected RecordFindByID to be allowed, but got %v", err)
+	}
+}
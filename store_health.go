@@ -0,0 +1,115 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+)
+
+// healthError is an error of Healthy, matching ErrUnhealthy
+type healthError struct {
+	reason string
+}
+
+func (e *healthError) Error() string {
+	return ErrUnhealthy.Error() + ": " + e.reason
+}
+
+func (e *healthError) Is(target error) bool {
+	return target == ErrUnhealthy
+}
+
+// Ping verifies the connection to the database, and to the reader database
+// if any, i.e. for liveness probes
+func (st *storeImplementation) Ping(ctx context.Context) error {
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if err := st.GetDB().PingContext(ctx); err != nil {
+		return st.wrapError(err, "Ping", "", "", nil)
+	}
+
+	if st.readDB != nil {
+		readDB, err := st.readDB.DB()
+		if err == nil {
+			err = readDB.PingContext(ctx)
+		}
+		if err != nil {
+			return st.wrapError(err, "Ping", "", "", nil)
+		}
+	}
+
+	return nil
+}
+
+// Healthy verifies the connection to the database, that the tables of the
+// store exist with every column and that their schema is at the latest
+// version, i.e. for readiness probes. The tables of the enabled features
+// (audit, revisions, counters and links) must exist too. Returns the error of Ping
+// if the database is unreachable, otherwise an error matching ErrUnhealthy
+// naming the first problem found. Run MigrateUp to fix the schema.
+func (st *storeImplementation) Healthy(ctx context.Context) error {
+	if err := st.Ping(ctx); err != nil {
+		return err
+	}
+
+	for _, view := range st.tableViews() {
+		if err := view.checkTableHealth(ctx); err != nil {
+			return err
+		}
+	}
+
+	for _, table := range []string{st.auditTable, st.revisionsTable, st.countersTable, st.linksTable} {
+		if table != "" && !st.db.Schema().HasTable(table) {
+			return &healthError{reason: "table " + table + " does not exist"}
+		}
+	}
+
+	return nil
+}
+
+// checkTableHealth verifies that the table of the store exists with every
+// column and that its schema is at the latest version
+func (st *storeImplementation) checkTableHealth(ctx context.Context) error {
+	tableName := st.tableName()
+	if !st.db.Schema().HasTable(tableName) {
+		return &healthError{reason: "table " + tableName + " does not exist"}
+	}
+
+	columns := []string{
+		COLUMN_ID,
+		COLUMN_RECORD_TYPE,
+		COLUMN_PAYLOAD,
+		COLUMN_METAS,
+		COLUMN_MEMO,
+		COLUMN_CREATED_AT,
+		COLUMN_UPDATED_AT,
+		COLUMN_SOFT_DELETED_AT,
+		COLUMN_VERSION,
+		COLUMN_EXPIRES_AT,
+	}
+	if st.tenancy {
+		columns = append(columns, COLUMN_TENANT_ID)
+	}
+	for _, column := range columns {
+		if !st.db.Schema().HasColumn(tableName, column) {
+			return &healthError{reason: "table " + tableName + " has no " + column + " column"}
+		}
+	}
+
+	// The tables of the first release predate the schema table
+	if !st.db.Schema().HasTable(schemaTableName(tableName)) {
+		return &healthError{reason: "table " + tableName + " has no schema version"}
+	}
+
+	version, err := st.schemaVersion(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	if version < latestSchemaVersion() {
+		return &healthError{reason: "table " + tableName + " is at schema version " + strconv.Itoa(version) + " of " + strconv.Itoa(latestSchemaVersion())}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package customstore_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreHealthy(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_health",
+		AutomigrateEnabled: true,
+		AuditEnabled:       true,
+		MaxOpenConns:       4,
+		MaxIdleConns:       2,
+		ConnMaxLifetime:    time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if stats := db.Stats(); stats.MaxOpenConnections != 4 {
+		t.Fatalf("Expected 4 max open connections, got %d", stats.MaxOpenConnections)
+	}
+
+	if err := store.Ping(t.Context()); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if err := store.Healthy(t.Context()); err != nil {
+		t.Fatalf("Expected the store to be healthy, got %v", err)
+	}
+
+	// An outdated schema is not ready
+	if _, err := db.Exec("UPDATE data_health_schema SET value = '3'"); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if err := store.Healthy(t.Context()); !errors.Is(err, customstore.ErrUnhealthy) {
+		t.Fatalf("Expected ErrUnhealthy, got %v", err)
+	}
+	if err := store.MigrateUp(t.Context()); err != nil {
+		t.Fatalf("MigrateUp failed: %v", err)
+	}
+	if err := store.Healthy(t.Context()); err != nil {
+		t.Fatalf("Expected the store to be healthy, got %v", err)
+	}
+
+	// A missing audit table is not ready
+	if _, err := db.Exec("DROP TABLE data_health_audit"); err != nil {
+		t.Fatalf("Drop table failed: %v", err)
+	}
+	if err := store.Healthy(t.Context()); !errors.Is(err, customstore.ErrUnhealthy) {
+		t.Fatalf("Expected ErrUnhealthy, got %v", err)
+	}
+}
+
+func TestStoreHealthyMissingTable(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:        db,
+		TableName: "data_health_missing",
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if err := store.Ping(t.Context()); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+	if err := store.Healthy(t.Context()); !errors.Is(err, customstore.ErrUnhealthy) {
+		t.Fatalf("Expected ErrUnhealthy, got %v", err)
+	}
+
+	// A table of the first release lacks columns
+	_, err = db.Exec(`CREATE TABLE data_health_missing (
+		id VARCHAR(40) PRIMARY KEY,
+		record_type VARCHAR(100),
+		payload TEXT,
+		metas TEXT,
+		memo TEXT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		soft_deleted_at DATETIME
+	)`)
+	if err != nil {
+		t.Fatalf("Create table failed: %v", err)
+	}
+	if err := store.Healthy(t.Context()); !errors.Is(err, customstore.ErrUnhealthy) {
+		t.Fatalf("Expected ErrUnhealthy, got %v", err)
+	}
+
+	if err := store.MigrateUp(t.Context()); err != nil {
+		t.Fatalf("MigrateUp failed: %v", err)
+	}
+	if err := store.Healthy(t.Context()); err != nil {
+		t.Fatalf("Expected the store to be healthy, got %v", err)
+	}
+
+	db.Close()
+	if err := store.Ping(t.Context()); err == nil {
+		t.Fatal("Expected Ping to fail on a closed database")
+	}
+}
@@ -0,0 +1,154 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// HookEvent is a record lifecycle event hooks are registered for, see On
+type HookEvent string
+
+const (
+	// EventBeforeCreate runs before a record is inserted, an error aborts
+	// the create
+	EventBeforeCreate HookEvent = "before_create"
+
+	// EventAfterCreate runs after a record was inserted
+	EventAfterCreate HookEvent = "after_create"
+
+	// EventBeforeUpdate runs before a record is updated, an error aborts
+	// the update
+	EventBeforeUpdate HookEvent = "before_update"
+
+	// EventAfterUpdate runs after a record was updated
+	EventAfterUpdate HookEvent = "after_update"
+
+	// EventBeforeDelete runs before a record is hard deleted, an error
+	// aborts the delete
+	EventBeforeDelete HookEvent = "before_delete"
+
+	// EventAfterDelete runs after a record was hard deleted
+	EventAfterDelete HookEvent = "after_delete"
+
+	// EventBeforeSoftDelete runs before a record is soft deleted, an error
+	// aborts the soft delete
+	EventBeforeSoftDelete HookEvent = "before_soft_delete"
+
+	// EventAfterSoftDelete runs after a record was soft deleted
+	EventAfterSoftDelete HookEvent = "after_soft_delete"
+
+	// EventBeforeRestore runs before a soft deleted record is restored, an
+	// error aborts the restore
+	EventBeforeRestore HookEvent = "before_restore"
+
+	// EventAfterRestore runs after a soft deleted record was restored
+	EventAfterRestore HookEvent = "after_restore"
+)
+
+// hookEvents lists the events hooks can be registered for
+var hookEvents = map[HookEvent]bool{
+	EventBeforeCreate:     true,
+	EventAfterCreate:      true,
+	EventBeforeUpdate:     true,
+	EventAfterUpdate:      true,
+	EventBeforeDelete:     true,
+	EventAfterDelete:      true,
+	EventBeforeSoftDelete: true,
+	EventAfterSoftDelete:  true,
+	EventBeforeRestore:    true,
+	EventAfterRestore:     true,
+}
+
+// RecordEvent is passed to the hooks of a record lifecycle event
+type RecordEvent struct {
+	// Context is the context of the store operation
+	Context context.Context
+
+	// Event is the event the hook runs for
+	Event HookEvent
+
+	// RecordID is the ID of the record
+	RecordID string
+
+	// Record is the record, nil for the delete events of RecordDeleteByID
+	// and for the soft delete and restore events. Before hooks may modify
+	// it, i.e. to set defaults.
+	Record RecordInterface
+}
+
+// HookFunc is a lifecycle hook, see On
+type HookFunc func(event RecordEvent) error
+
+// hookRegistry holds the registered hooks, in registration order per event
+type hookRegistry struct {
+	mu    sync.RWMutex
+	hooks map[HookEvent][]HookFunc
+}
+
+// On registers fn to run on the record lifecycle event, after the hooks
+// registered before it. Hooks run for RecordCreate, RecordFindOrCreate,
+// RecordUpdate, RecordUpdateVersioned (and so RecordPatchPayloadByID),
+// RecordUpdateMany, RecordUpsert (the create or update events, as the
+// record is stored or not before the write), RecordDelete, RecordDeleteByID,
+// the soft deletes and the restores, from every view and transaction of the
+// store.
+//
+// No hook runs for the writes which do not load the record:
+// RecordIncrementPayloadKey and RecordTouch update the stored row in
+// place, and Import, backup restores, payload transforms and migrations and
+// the purges write in bulk.
+//
+// An error returned by a before hook aborts the operation and is returned
+// by it. An error returned by an after hook is returned by the operation,
+// which already took place. Within RunInTransaction, after hooks run
+// before the transaction is committed.
+func (st *storeImplementation) On(event HookEvent, fn HookFunc) error {
+	if !hookEvents[event] {
+		return errors.New("unknown hook event " + string(event))
+	}
+
+	if fn == nil {
+		return errors.New("hook function is required")
+	}
+
+	st.hooks.mu.Lock()
+	defer st.hooks.mu.Unlock()
+
+	st.hooks.hooks[event] = append(st.hooks.hooks[event], fn)
+	return nil
+}
+
+// hasHooks returns whether a hook is registered for any of the events
+func (st *storeImplementation) hasHooks(events ...HookEvent) bool {
+	st.hooks.mu.RLock()
+	defer st.hooks.mu.RUnlock()
+	for _, event := range events {
+		if len(st.hooks.hooks[event]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// runHooks runs the hooks of the event in registration order, stopping at
+// the first error
+func (st *storeImplementation) runHooks(ctx context.Context, event HookEvent, recordID string, record RecordInterface) error {
+	st.hooks.mu.RLock()
+	hooks := st.hooks.hooks[event]
+	st.hooks.mu.RUnlock()
+
+	for _, hook := range hooks {
+		err := hook(RecordEvent{
+			Context:  ctx,
+			Event:    event,
+			RecordID: recordID,
+			Record:   record,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package customstore_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreHooks(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_hooks",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	type ctxKey struct{}
+	events := []string{}
+	record := func(event customstore.RecordEvent) error {
+		if event.Context.Value(ctxKey{}) != "request" {
+			t.Fatalf("Expected the operation context in the %s hook", event.Event)
+		}
+		events = append(events, string(event.Event)+":"+event.RecordID)
+		return nil
+	}
+
+	for _, event := range []customstore.HookEvent{
+		customstore.EventBeforeCreate,
+		customstore.EventAfterCreate,
+		customstore.EventBeforeUpdate,
+		customstore.EventAfterUpdate,
+		customstore.EventBeforeDelete,
+		customstore.EventAfterDelete,
+	} {
+		if err := store.On(event, record); err != nil {
+			t.Fatalf("On %s failed: %v", event, err)
+		}
+	}
+
+	// Before hooks can enforce invariants and set defaults
+	errMissingStatus := errors.New("status is required")
+	err = store.On(customstore.EventBeforeCreate, func(event customstore.RecordEvent) error {
+		if event.Record.Type() == "invoice" && event.Record.Meta("status") == "" {
+			return errMissingStatus
+		}
+		return event.Record.SetMeta("created_by_hook", "yes")
+	})
+	if err != nil {
+		t.Fatalf("On failed: %v", err)
+	}
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+
+	rejected := customstore.NewRecord("invoice")
+	if err := store.RecordCreateContext(ctx, rejected); !errors.Is(err, errMissingStatus) {
+		t.Fatalf("Expected the before create hook error, but got %v", err)
+	}
+	if found, _ := store.RecordFindByID(rejected.ID()); found != nil {
+		t.Fatalf("Expected the rejected record not to be created")
+	}
+
+	invoice := customstore.NewRecord("invoice", customstore.WithMetas(map[string]string{"status": "open"}))
+	if err := store.RecordCreateContext(ctx, invoice); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	stored, err := store.RecordFindByID(invoice.ID())
+	if err != nil || stored == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if stored.Meta("created_by_hook") != "yes" {
+		t.Fatalf("Expected the meta set by the before create hook to be stored")
+	}
+
+	invoice.SetMemo("updated")
+	if err := store.RecordUpdateContext(ctx, invoice); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	if err := store.RecordDeleteByIDContext(ctx, invoice.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+
+	// Deleting a missing record runs no after delete hook
+	if err := store.RecordDeleteByIDContext(ctx, invoice.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID of a missing record failed: %v", err)
+	}
+
+	id := invoice.ID()
+	expected := []string{
+		"before_create:" + rejected.ID(),
+		"before_create:" + id,
+		"after_create:" + id,
+		"before_update:" + id,
+		"after_update:" + id,
+		"before_delete:" + id,
+		"after_delete:" + id,
+		"before_delete:" + id,
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected events %v, but got %v", expected, events)
+	}
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Fatalf("Expected event %d to be %s, but got %s", i, expected[i], events[i])
+		}
+	}
+
+	if err := store.On("after_anything", record); err == nil {
+		t.Fatalf("Expected error for an unknown event, but got nil")
+	}
+	if err := store.On(customstore.EventAfterCreate, nil); err == nil {
+		t.Fatalf("Expected error for a nil hook, but got nil")
+	}
+}
+
+func TestStoreHooksBeforeUpdateAborts(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_hooks_update",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	locked := customstore.NewRecord("invoice", customstore.WithMemo("original"))
+	open := customstore.NewRecord("invoice", customstore.WithMemo("original"))
+	for _, record := range []customstore.RecordInterface{locked, open} {
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	errLocked := errors.New("invoice is locked")
+	err = store.On(customstore.EventBeforeUpdate, func(event customstore.RecordEvent) error {
+		if event.RecordID == locked.ID() {
+			return errLocked
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("On failed: %v", err)
+	}
+
+	locked.SetMemo("changed")
+	if err := store.RecordUpdate(locked); !errors.Is(err, errLocked) {
+		t.Fatalf("Expected the before update hook error, but got %v", err)
+	}
+
+	open.SetMemo("changed")
+	result, err := store.RecordUpdateMany([]customstore.RecordInterface{locked, open})
+	if err != nil {
+		t.Fatalf("RecordUpdateMany failed: %v", err)
+	}
+	if result.Updated != 1 || len(result.Failures) != 1 || !errors.Is(result.Failures[0].Err, errLocked) {
+		t.Fatalf("Expected the locked record to be reported as a failure, but got %+v", result)
+	}
+
+	stored, err := store.RecordFindByID(locked.ID())
+	if err != nil || stored == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if stored.Memo() != "original" {
+		t.Fatalf("Expected the locked record to be unchanged, but got memo %q", stored.Memo())
+	}
+}
+
+func TestStoreHooksUpsertSoftDeleteRestore(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_hooks_write_paths",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	events := []string{}
+	for _, event := range []customstore.HookEvent{
+		customstore.EventBeforeCreate,
+		customstore.EventAfterCreate,
+		customstore.EventBeforeUpdate,
+		customstore.EventAfterUpdate,
+		customstore.EventBeforeSoftDelete,
+		customstore.EventAfterSoftDelete,
+		customstore.EventBeforeRestore,
+		customstore.EventAfterRestore,
+	} {
+		err := store.On(event, func(event customstore.RecordEvent) error {
+			events = append(events, string(event.Event))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("On %s failed: %v", event, err)
+		}
+	}
+
+	errLocked := errors.New("record is locked")
+	err = store.On(customstore.EventBeforeUpdate, func(event customstore.RecordEvent) error {
+		if event.Record.Meta("locked") == "yes" {
+			return errLocked
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("On failed: %v", err)
+	}
+
+	record := customstore.NewRecord("person", customstore.WithPayload(`{"name":"Ann"}`))
+	if err := store.RecordUpsert(record); err != nil {
+		t.Fatalf("RecordUpsert failed: %v", err)
+	}
+	if err := store.RecordUpsert(record); err != nil {
+		t.Fatalf("RecordUpsert failed: %v", err)
+	}
+
+	// The update validation cannot be bypassed by upserting
+	if err := record.SetMeta("locked", "yes"); err != nil {
+		t.Fatalf("SetMeta failed: %v", err)
+	}
+	if err := store.RecordUpsert(record); !errors.Is(err, errLocked) {
+		t.Fatalf("Expected the before update hook to abort the upsert, but got %v", err)
+	}
+
+	if err := store.RecordSoftDeleteByID(record.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	if err := store.RecordRestoreByID(record.ID()); err != nil {
+		t.Fatalf("RecordRestoreByID failed: %v", err)
+	}
+
+	// The writes not loading the record run no hook
+	if err := store.RecordIncrementPayloadKey(record.ID(), "visits", 1); err != nil {
+		t.Fatalf("RecordIncrementPayloadKey failed: %v", err)
+	}
+	if err := store.RecordTouch(record.ID()); err != nil {
+		t.Fatalf("RecordTouch failed: %v", err)
+	}
+	var exported bytes.Buffer
+	if _, err := store.Export(&exported, customstore.RecordQuery()); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if _, err := store.Import(&exported); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+
+	expected := []string{
+		"before_create", "after_create",
+		"before_update", "after_update",
+		"before_update",
+		"before_soft_delete", "after_soft_delete",
+		"before_restore", "after_restore",
+	}
+	if !slices.Equal(events, expected) {
+		t.Fatalf("Expected events %v, got %v", expected, events)
+	}
+}
@@ -0,0 +1,51 @@
+package customstore_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreQueryIDPrefix(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_id_prefix",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, id := range []string{"usr_1", "usr_2", "usrx1", "ord_1"} {
+		if err := store.RecordCreate(customstore.NewRecord("entity", customstore.WithID(id))); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetIDPrefix("usr_"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	ids := []string{}
+	for _, record := range list {
+		ids = append(ids, record.ID())
+	}
+	slices.Sort(ids)
+
+	// The underscore is matched literally, not as a LIKE wildcard
+	if !slices.Equal(ids, []string{"usr_1", "usr_2"}) {
+		t.Fatalf("Unexpected IDs: %v", ids)
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().SetIDPrefix("%"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected no records, got %d", count)
+	}
+}
@@ -0,0 +1,98 @@
+package customstore
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// idPrefixSeparator separates the prefix of the record type from the rest
+// of a prefixed ID, i.e. "usr_01H..."
+const idPrefixSeparator = "_"
+
+// idPrefixes holds the ID prefixes of the record types, see
+// NewStoreOptions.IDPrefixes. A nil registry prefixes nothing.
+type idPrefixes struct {
+	byType   map[string]string
+	byPrefix map[string]string
+}
+
+// newIDPrefixes validates the prefixes keyed by record type
+func newIDPrefixes(prefixes map[string]string) (*idPrefixes, error) {
+	if len(prefixes) == 0 {
+		return nil, nil
+	}
+
+	p := &idPrefixes{byType: map[string]string{}, byPrefix: map[string]string{}}
+	for recordType, prefix := range prefixes {
+		if recordType == "" {
+			return nil, errors.New("customstore store: ID prefixes have an empty record type")
+		}
+		if prefix == "" || strings.Contains(prefix, idPrefixSeparator) {
+			return nil, errors.New("customstore store: ID prefix of record type " + recordType + " must be set and cannot contain " + idPrefixSeparator)
+		}
+		if other, ok := p.byPrefix[prefix]; ok {
+			return nil, errors.New("customstore store: record types " + other + " and " + recordType + " have the same ID prefix " + prefix)
+		}
+		p.byType[recordType] = prefix
+		p.byPrefix[prefix] = recordType
+	}
+	return p, nil
+}
+
+// prefix returns the ID prefix of the record type, if any
+func (p *idPrefixes) prefix(recordType string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	prefix, ok := p.byType[recordType]
+	return prefix, ok
+}
+
+// typeOfID returns the record type whose prefix the ID carries, if any
+func (p *idPrefixes) typeOfID(id string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	prefix, _, found := strings.Cut(id, idPrefixSeparator)
+	if !found {
+		return "", false
+	}
+	recordType, ok := p.byPrefix[prefix]
+	return recordType, ok
+}
+
+// typesOfQueryIDs returns the sorted record types of the IDs the query
+// filters by (SetID, SetIDList), if every one of them carries a prefix.
+// Nil if the query does not filter by ID.
+func (p *idPrefixes) typesOfQueryIDs(query RecordQueryInterface) []string {
+	if p == nil || query == nil {
+		return nil
+	}
+
+	ids := []string{}
+	if query.IsIDSet() && query.GetID() != "" {
+		ids = append(ids, query.GetID())
+	}
+	if query.IsIDListSet() {
+		ids = append(ids, query.GetIDList()...)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	types := []string{}
+	for _, id := range ids {
+		recordType, ok := p.typeOfID(id)
+		if !ok {
+			return nil
+		}
+		if !seen[recordType] {
+			seen[recordType] = true
+			types = append(types, recordType)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
@@ -0,0 +1,84 @@
+package customstore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreIDPrefixes(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_id_prefixes",
+		AutomigrateEnabled: true,
+		IDPrefixes:         map[string]string{"user": "usr", "invoice": "inv"},
+		TypeTableMap:       map[string]string{"invoice": "data_id_prefixes_invoices"},
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	user := customstore.NewRecord("user")
+	if err := store.RecordCreate(user); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if !strings.HasPrefix(user.ID(), "usr_") || len(user.ID()) != len("usr_")+26 {
+		t.Fatalf("Expected a prefixed ULID, but got %q", user.ID())
+	}
+
+	invoice := customstore.NewRecord("invoice")
+	if err := store.RecordCreate(invoice); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if !strings.HasPrefix(invoice.ID(), "inv_") {
+		t.Fatalf("Expected a prefixed ID, but got %q", invoice.ID())
+	}
+
+	// Types without a prefix and set IDs are kept as is
+	note := customstore.NewRecord("note")
+	if err := store.RecordCreate(note); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if strings.Contains(note.ID(), "_") {
+		t.Fatalf("Expected an unprefixed ID, but got %q", note.ID())
+	}
+
+	// Prefixed IDs are found in the table of their type
+	found, err := store.RecordFindByID(invoice.ID())
+	if err != nil || found == nil || found.Type() != "invoice" {
+		t.Fatalf("Expected the invoice to be found, but got %v, %v", found, err)
+	}
+	list, err := store.RecordList(customstore.RecordQuery().SetIDList([]string{invoice.ID()}))
+	if err != nil || len(list) != 1 {
+		t.Fatalf("Expected the invoice to be listed, but got %d, %v", len(list), err)
+	}
+
+	// An ID with the prefix of a type only matches records of the type
+	mislabeled := customstore.NewRecord("note", customstore.WithID("usr_mislabeled"))
+	if err := store.RecordCreate(mislabeled); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	found, err = store.RecordFindByID("usr_mislabeled")
+	if err != nil || found != nil {
+		t.Fatalf("Expected no record of type user, but got %v, %v", found, err)
+	}
+
+	for name, prefixes := range map[string]map[string]string{
+		"empty prefix":     {"user": ""},
+		"separator":        {"user": "us_r"},
+		"duplicate prefix": {"user": "usr", "admin": "usr"},
+	} {
+		_, err := customstore.NewStore(customstore.NewStoreOptions{
+			DB:         db,
+			TableName:  "data_id_prefixes_invalid",
+			IDPrefixes: prefixes,
+		})
+		if err == nil {
+			t.Fatalf("Expected an error for the %s", name)
+		}
+	}
+}
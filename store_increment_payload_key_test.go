@@ -0,0 +1,134 @@
+package customstore_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordIncrementPayloadKey(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_increment_payload_key",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("counter", customstore.WithPayload(`{"views":5,"name":"home"}`))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if err := store.RecordIncrementPayloadKey(record.ID(), "views", 3); err != nil {
+		t.Fatalf("RecordIncrementPayloadKey failed: %v", err)
+	}
+	if err := store.RecordIncrementPayloadKey(record.ID(), "score", 1.5); err != nil {
+		t.Fatalf("RecordIncrementPayloadKey for a missing key failed: %v", err)
+	}
+	if err := store.RecordIncrementPayloadKey(record.ID(), "views", -1); err != nil {
+		t.Fatalf("RecordIncrementPayloadKey with a negative delta failed: %v", err)
+	}
+
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	views, err := found.PayloadMapKey("views")
+	if err != nil {
+		t.Fatalf("PayloadMapKey failed: %v", err)
+	}
+	if views != float64(7) {
+		t.Fatalf("Expected 7 views, but got %v in %s", views, found.Payload())
+	}
+	score, err := found.PayloadMapKey("score")
+	if err != nil {
+		t.Fatalf("PayloadMapKey failed: %v", err)
+	}
+	if score != 1.5 {
+		t.Fatalf("Expected score 1.5, but got %v in %s", score, found.Payload())
+	}
+	if found.PayloadString("name", "") != "home" {
+		t.Fatalf("Expected the other keys to be kept, but got %s", found.Payload())
+	}
+	if found.Version() != 4 {
+		t.Fatalf("Expected each increment to bump the version to 4, but got %d", found.Version())
+	}
+
+	empty := customstore.NewRecord("counter")
+	if err := store.RecordCreate(empty); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordIncrementPayloadKey(empty.ID(), "views", 1); err != nil {
+		t.Fatalf("RecordIncrementPayloadKey on an empty payload failed: %v", err)
+	}
+	found, err = store.RecordFindByID(empty.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found.Payload() != `{"views":1}` {
+		t.Fatalf("Expected the key to be created in the empty payload, but got %s", found.Payload())
+	}
+
+	if err := store.RecordIncrementPayloadKey("missing", "views", 1); err == nil {
+		t.Fatalf("Expected error for a missing record, but got nil")
+	}
+	if err := store.RecordIncrementPayloadKey(record.ID(), "", 1); err == nil {
+		t.Fatalf("Expected error for an empty key, but got nil")
+	}
+}
+
+func TestRecordIncrementPayloadKeyConcurrent(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	// Every connection opens a new in memory database
+	db.SetMaxOpenConns(1)
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_increment_payload_key_concurrent",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("counter", customstore.WithPayload(`{"hits":0}`))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	const increments = 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, increments)
+	for i := 0; i < increments; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- store.RecordIncrementPayloadKey(record.ID(), "hits", 1)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("RecordIncrementPayloadKey failed: %v", err)
+		}
+	}
+
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found.PayloadInt("hits", 0) != increments {
+		t.Fatalf("Expected %d hits, but got %s", increments, found.Payload())
+	}
+}
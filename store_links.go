@@ -0,0 +1,374 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+	contractsschema "github.com/dracory/neat/contracts/database/schema"
+	"github.com/spf13/cast"
+)
+
+// purgeChunkSize is the number of purged records deleted at once with
+// their links
+const purgeChunkSize = 500
+
+// Columns of the links table
+const (
+	linkColumnFromID = "from_id"
+	linkColumnToID   = "to_id"
+	linkColumnType   = "link_type"
+)
+
+// LinkDirection is the direction of the links followed by RecordListLinked
+type LinkDirection int
+
+const (
+	// LinkOutgoing follows the links from the record, to the records it
+	// links to
+	LinkOutgoing LinkDirection = iota
+
+	// LinkIncoming follows the links to the record, to the records linking
+	// to it
+	LinkIncoming
+)
+
+// linksTableName returns the name of the links table of a store table
+func linksTableName(tableName string) string {
+	return tableName + "_links"
+}
+
+// RecordLink links the record with fromID to the record with toID with the
+// link type, i.e. "tag" or "member_of". Both records must be stored (and of
+// the tenant the store is scoped to), or ErrNotFound is returned. Linking
+// records already linked does nothing. Links are directed, see
+// RecordListLinked. Requires LinksEnabled.
+func (st *storeImplementation) RecordLink(fromID string, toID string, linkType string) error {
+	return st.RecordLinkContext(context.Background(), fromID, toID, linkType)
+}
+
+// RecordLinkContext is RecordLink using the given context
+func (st *storeImplementation) RecordLinkContext(ctx context.Context, fromID string, toID string, linkType string) error {
+	if err := st.checkLink(fromID, toID, linkType); err != nil {
+		return err
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
+	// The records are checked in the transaction of the link, so a record
+	// deleted meanwhile is not linked
+	return st.inTransaction(func(st *storeImplementation) error {
+		for _, id := range []string{fromID, toID} {
+			view, err := st.forID(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			exists, err := view.hasID(ctx, id)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return ErrNotFound
+			}
+		}
+
+		sqlStr := rebindPlaceholders(st.driverName(), linkInsertSQL(st.driverName(), st.linksTable))
+		_, err := st.exec(st.newQuery(ctx), "RecordLink", sqlStr, fromID, toID, linkType, st.nowTimestamp())
+		return st.wrapError(err, "RecordLink", fromID, "", func() string {
+			return sqlStr
+		})
+	})
+}
+
+// linkInsertSQL returns the statement inserting a link, doing nothing if
+// the records are already linked, with the column values as placeholders
+func linkInsertSQL(driver string, linksTable string) string {
+	columns := []string{linkColumnFromID, linkColumnToID, linkColumnType, COLUMN_CREATED_AT}
+	columnList := strings.Join(columns, ", ")
+
+	switch driver {
+	case "mysql":
+		return "INSERT IGNORE INTO " + linksTable + " (" + columnList + ") VALUES (" + placeholders(len(columns)) + ")"
+	case "sqlserver":
+		source := make([]string, len(columns))
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			source[i] = "? AS " + column
+			values[i] = "source." + column
+		}
+		return "MERGE INTO " + linksTable + " WITH (HOLDLOCK) AS target USING (SELECT " + strings.Join(source, ", ") + ") AS source" +
+			" ON target." + linkColumnFromID + " = source." + linkColumnFromID +
+			" AND target." + linkColumnType + " = source." + linkColumnType +
+			" AND target." + linkColumnToID + " = source." + linkColumnToID +
+			" WHEN NOT MATCHED THEN INSERT (" + columnList + ") VALUES (" + strings.Join(values, ", ") + ");"
+	default:
+		return "INSERT INTO " + linksTable + " (" + columnList + ") VALUES (" + placeholders(len(columns)) + ")" +
+			" ON CONFLICT (" + linkColumnFromID + ", " + linkColumnType + ", " + linkColumnToID + ") DO NOTHING"
+	}
+}
+
+// RecordUnlink removes the link of the type from the record with fromID to
+// the record with toID. Unlinking records which are not linked does
+// nothing. On a store scoped to a tenant both records must be of the
+// tenant, or ErrNotFound is returned. Requires LinksEnabled.
+func (st *storeImplementation) RecordUnlink(fromID string, toID string, linkType string) error {
+	return st.RecordUnlinkContext(context.Background(), fromID, toID, linkType)
+}
+
+// RecordUnlinkContext is RecordUnlink using the given context
+func (st *storeImplementation) RecordUnlinkContext(ctx context.Context, fromID string, toID string, linkType string) error {
+	if err := st.checkLink(fromID, toID, linkType); err != nil {
+		return err
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
+	// The links table has no tenant, so the records are checked as for
+	// RecordLink, not to delete the links of other tenants
+	if st.tenantID != "" {
+		for _, id := range []string{fromID, toID} {
+			view, err := st.forID(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			exists, err := view.hasID(ctx, id)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return ErrNotFound
+			}
+		}
+	}
+
+	q := st.newQuery(ctx).
+		Table(st.linksTable).
+		Where(linkColumnFromID+" = ?", fromID).
+		Where(linkColumnToID+" = ?", toID).
+		Where(linkColumnType+" = ?", linkType)
+
+	start := time.Now()
+	err := st.retry(ctx, "RecordUnlink", func() error {
+		_, err := q.Delete()
+		return err
+	})
+	st.logQuery("RecordUnlink", start, func() (string, []any) {
+		return q.ToRawSql().Delete(), nil
+	})
+	return st.wrapError(err, "RecordUnlink", fromID, "", func() string {
+		return q.ToSql().Delete()
+	})
+}
+
+// RecordListLinked returns the records matching the query linked to the
+// record with the ID in the direction: the records it links to
+// (LinkOutgoing) or the records linking to it (LinkIncoming). Only the
+// links of the type are followed, or every link if the type is empty. The
+// query filters, orders and pages the linked records as for RecordList,
+// nil matching every record. Requires LinksEnabled.
+func (st *storeImplementation) RecordListLinked(id string, linkType string, direction LinkDirection, query RecordQueryInterface) ([]RecordInterface, error) {
+	return st.RecordListLinkedContext(context.Background(), id, linkType, direction, query)
+}
+
+// RecordListLinkedContext is RecordListLinked using the given context
+func (st *storeImplementation) RecordListLinkedContext(ctx context.Context, id string, linkType string, direction LinkDirection, query RecordQueryInterface) ([]RecordInterface, error) {
+	if st.linksTable == "" {
+		return nil, errors.New("links are not enabled")
+	}
+
+	if id == "" {
+		return nil, errors.New("record id is required")
+	}
+
+	if direction != LinkOutgoing && direction != LinkIncoming {
+		return nil, errors.New("invalid link direction")
+	}
+
+	// The linked IDs are selected by a subquery of the links table
+	selected, matched := linkColumnToID, linkColumnFromID
+	if direction == LinkIncoming {
+		selected, matched = linkColumnFromID, linkColumnToID
+	}
+	link := RawWhere{
+		SQL:  COLUMN_ID + " IN (SELECT " + selected + " FROM " + st.linksTable + " WHERE " + matched + " = ?",
+		Args: []any{id},
+	}
+	if linkType != "" {
+		link.SQL += " AND " + linkColumnType + " = ?"
+		link.Args = append(link.Args, linkType)
+	}
+	link.SQL += ")"
+
+	if query == nil {
+		query = NewRecordQuery()
+	}
+
+	return st.listRecords(ctx, linkedQuery{RecordQueryInterface: query, link: link}, "RecordListLinked")
+}
+
+// linkedQuery wraps a record query adding the condition matching the
+// linked records to its raw conditions
+type linkedQuery struct {
+	RecordQueryInterface
+	link RawWhere
+}
+
+func (q linkedQuery) GetRawWheres() []RawWhere {
+	return append(slices.Clip(q.RecordQueryInterface.GetRawWheres()), q.link)
+}
+
+// checkLink validates the arguments of RecordLink and RecordUnlink
+func (st *storeImplementation) checkLink(fromID string, toID string, linkType string) error {
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if st.linksTable == "" {
+		return errors.New("links are not enabled")
+	}
+
+	if fromID == "" || toID == "" {
+		return errors.New("record ids are required")
+	}
+
+	if linkType == "" {
+		return errors.New("link type is required")
+	}
+
+	return nil
+}
+
+// deleteLinks removes the links from and to the deleted records with the
+// IDs if links are enabled
+func (st *storeImplementation) deleteLinks(ctx context.Context, q contractsorm.Query, ids []any, op string) error {
+	if st.linksTable == "" || len(ids) == 0 {
+		return nil
+	}
+
+	q = q.Table(st.linksTable).
+		WhereIn(linkColumnFromID, ids).
+		OrWhereIn(linkColumnToID, ids)
+
+	start := time.Now()
+	err := st.retry(ctx, op, func() error {
+		_, err := q.Delete()
+		return err
+	})
+	st.logQuery(op, start, func() (string, []any) {
+		return q.ToRawSql().Delete(), nil
+	})
+	return st.wrapError(err, op, "", "", func() string {
+		return q.ToSql().Delete()
+	})
+}
+
+// purgeRows hard deletes the rows of the table matched by where, returning
+// the number of rows deleted. With links enabled or the unique values table
+// maintained, the matched rows are deleted in batches with their links and
+// unique values, in a single transaction.
+func (st *storeImplementation) purgeRows(ctx context.Context, op string, tableName string, where func(q contractsorm.Query) contractsorm.Query) (int64, error) {
+	deleteRows := func(st *storeImplementation, q contractsorm.Query) (int64, error) {
+		q = where(q.Table(tableName))
+
+		start := time.Now()
+		deleted, err := st.retryResult(ctx, op, func() (*contractsorm.Result, error) {
+			return q.Delete()
+		})
+		st.logQuery(op, start, func() (string, []any) {
+			return q.ToRawSql().Delete(), nil
+		})
+		if err != nil {
+			return 0, st.wrapError(err, op, "", "", func() string {
+				return q.ToSql().Delete()
+			})
+		}
+		return deleted.RowsAffected, nil
+	}
+
+	withUniques := st.usesUniqueTable() && st.db.Schema().HasTable(uniqueTableName(st.tableName()))
+	if (st.linksTable == "" && !withUniques) || tableName != st.tableName() {
+		return deleteRows(st, st.newQuery(ctx))
+	}
+
+	affected := int64(0)
+	err := st.inTransaction(func(st *storeImplementation) error {
+		q := where(st.newQuery(ctx).Table(tableName)).Select(COLUMN_ID)
+
+		var rows []map[string]any
+		start := time.Now()
+		err := q.Get(&rows)
+		st.logQuery(op, start, func() (string, []any) {
+			return q.ToRawSql().Get(&rows), nil
+		})
+		if err != nil {
+			return st.wrapError(err, op, "", "", func() string {
+				return q.ToSql().Get(&rows)
+			})
+		}
+
+		ids := make([]any, len(rows))
+		for i, row := range rows {
+			ids[i] = cast.ToString(row[COLUMN_ID])
+		}
+
+		for start := 0; start < len(ids); start += purgeChunkSize {
+			chunk := ids[start:min(start+purgeChunkSize, len(ids))]
+
+			if err := st.deleteLinks(ctx, st.newQuery(ctx), chunk, op); err != nil {
+				return err
+			}
+			if withUniques {
+				if err := st.deleteUniqueValues(ctx, st.newQuery(ctx), chunk, op); err != nil {
+					return err
+				}
+			}
+
+			deleted, err := deleteRows(st, st.newQuery(ctx).WhereIn(COLUMN_ID, chunk))
+			if err != nil {
+				return err
+			}
+			affected += deleted
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+// createLinksTable creates the links table if links are enabled. Safe to
+// call when it exists.
+func (st *storeImplementation) createLinksTable() error {
+	if st.linksTable == "" || st.db.Schema().HasTable(st.linksTable) {
+		return nil
+	}
+
+	return st.db.Schema().Create(st.linksTable, func(table contractsschema.Blueprint) {
+		table.String(linkColumnFromID, 40)
+		table.String(linkColumnToID, 40)
+		table.String(linkColumnType, 100)
+		table.DateTime(COLUMN_CREATED_AT)
+		table.Primary(linkColumnFromID, linkColumnType, linkColumnToID)
+		table.Index(linkColumnToID, linkColumnType).Name(tableIndexName(st.linksTable, []string{linkColumnToID, linkColumnType}))
+	})
+}
+
+// dropLinksTable drops the links table if links are enabled
+func (st *storeImplementation) dropLinksTable() error {
+	if st.linksTable == "" || !st.db.Schema().HasTable(st.linksTable) {
+		return nil
+	}
+
+	return st.db.Schema().Drop(st.linksTable)
+}
@@ -0,0 +1,236 @@
+package customstore_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRecordLinks(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_links",
+		AutomigrateEnabled: true,
+		LinksEnabled:       true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	post := customstore.NewRecord("post")
+	names := map[string]string{post.ID(): "post"}
+	tags := []customstore.RecordInterface{}
+	for _, name := range []string{"go", "sql", "web"} {
+		tag := customstore.NewRecord("tag", customstore.WithPayload(`{"name":"`+name+`"}`))
+		tags = append(tags, tag)
+		names[tag.ID()] = name
+	}
+	for _, record := range append([]customstore.RecordInterface{post}, tags...) {
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	linkedNames := func(id string, linkType string, direction customstore.LinkDirection, query customstore.RecordQueryInterface) []string {
+		t.Helper()
+		list, err := store.RecordListLinked(id, linkType, direction, query)
+		if err != nil {
+			t.Fatalf("RecordListLinked failed: %v", err)
+		}
+		found := []string{}
+		for _, record := range list {
+			found = append(found, names[record.ID()])
+		}
+		slices.Sort(found)
+		return found
+	}
+
+	for _, tag := range tags[:2] {
+		if err := store.RecordLink(post.ID(), tag.ID(), "tagged"); err != nil {
+			t.Fatalf("RecordLink failed: %v", err)
+		}
+	}
+	// Linking twice does nothing
+	if err := store.RecordLink(post.ID(), tags[0].ID(), "tagged"); err != nil {
+		t.Fatalf("RecordLink failed: %v", err)
+	}
+	if err := store.RecordLink(post.ID(), tags[2].ID(), "featured"); err != nil {
+		t.Fatalf("RecordLink failed: %v", err)
+	}
+
+	if found := linkedNames(post.ID(), "tagged", customstore.LinkOutgoing, nil); !slices.Equal(found, []string{"go", "sql"}) {
+		t.Fatalf("Unexpected linked records: %v", found)
+	}
+	if found := linkedNames(post.ID(), "", customstore.LinkOutgoing, nil); !slices.Equal(found, []string{"go", "sql", "web"}) {
+		t.Fatalf("Unexpected linked records: %v", found)
+	}
+	if found := linkedNames(tags[0].ID(), "tagged", customstore.LinkIncoming, nil); !slices.Equal(found, []string{"post"}) {
+		t.Fatalf("Unexpected linking records: %v", found)
+	}
+	if found := linkedNames(tags[0].ID(), "tagged", customstore.LinkOutgoing, nil); len(found) != 0 {
+		t.Fatalf("Expected links to be directed, got %v", found)
+	}
+
+	// The query filters the linked records
+	query := customstore.NewRecordQuery().AddPayloadKeyEquals("name", "sql")
+	if found := linkedNames(post.ID(), "tagged", customstore.LinkOutgoing, query); !slices.Equal(found, []string{"sql"}) {
+		t.Fatalf("Unexpected linked records: %v", found)
+	}
+	if len(query.GetRawWheres()) != 0 {
+		t.Fatal("Expected the query to be left unchanged")
+	}
+
+	if err := store.RecordUnlink(post.ID(), tags[1].ID(), "tagged"); err != nil {
+		t.Fatalf("RecordUnlink failed: %v", err)
+	}
+	if found := linkedNames(post.ID(), "tagged", customstore.LinkOutgoing, nil); !slices.Equal(found, []string{"go"}) {
+		t.Fatalf("Unexpected linked records: %v", found)
+	}
+
+	// Deleting a record removes its links
+	if err := store.RecordDeleteByID(tags[0].ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+	var links int
+	if err := db.QueryRow("SELECT COUNT(*) FROM data_links_links WHERE to_id = ?", tags[0].ID()).Scan(&links); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if links != 0 {
+		t.Fatalf("Expected the links of the deleted record to be removed, got %d", links)
+	}
+	if found := linkedNames(post.ID(), "", customstore.LinkOutgoing, nil); !slices.Equal(found, []string{"web"}) {
+		t.Fatalf("Expected the other links to be kept, got %v", found)
+	}
+
+	if err := store.RecordLink(post.ID(), tags[1].ID(), ""); err == nil {
+		t.Fatal("Expected an error for an empty link type")
+	}
+	if _, err := store.RecordListLinked(post.ID(), "", customstore.LinkDirection(5), nil); err == nil {
+		t.Fatal("Expected an error for an invalid direction")
+	}
+}
+
+func TestStoreRecordLinksDisabled(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_links_disabled",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if err := store.RecordLink("a", "b", "related"); err == nil {
+		t.Fatal("Expected an error when links are not enabled")
+	}
+	if _, err := store.RecordListLinked("a", "related", customstore.LinkOutgoing, nil); err == nil {
+		t.Fatal("Expected an error when links are not enabled")
+	}
+}
+
+func TestStoreRecordLinksIntegrity(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_links_integrity",
+		AutomigrateEnabled: true,
+		LinksEnabled:       true,
+		TenancyEnabled:     true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	acme, err := store.ForTenant("acme")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+	globex, err := store.ForTenant("globex")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+
+	post := customstore.NewRecord("post")
+	tag := customstore.NewRecord("tag")
+	other := customstore.NewRecord("tag")
+	for _, record := range []customstore.RecordInterface{post, tag} {
+		if err := acme.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+	if err := globex.RecordCreate(other); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	countLinks := func() int {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM data_links_integrity_links").Scan(&count); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		return count
+	}
+
+	// Both records must be stored, in the tenant of the store
+	if err := acme.RecordLink(post.ID(), "missing", "tag"); !errors.Is(err, customstore.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound linking a missing record, but got %v", err)
+	}
+	if err := acme.RecordLink(post.ID(), other.ID(), "tag"); !errors.Is(err, customstore.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound linking a record of another tenant, but got %v", err)
+	}
+	if countLinks() != 0 {
+		t.Fatal("Expected no link")
+	}
+
+	for range 2 {
+		if err := acme.RecordLink(post.ID(), tag.ID(), "tag"); err != nil {
+			t.Fatalf("RecordLink failed: %v", err)
+		}
+	}
+	if countLinks() != 1 {
+		t.Fatalf("Expected one link, got %d", countLinks())
+	}
+
+	// Another tenant cannot unlink the records
+	if err := globex.RecordUnlink(post.ID(), tag.ID(), "tag"); !errors.Is(err, customstore.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound unlinking the records of another tenant, but got %v", err)
+	}
+	if countLinks() != 1 {
+		t.Fatal("Expected the link of the other tenant to be kept")
+	}
+
+	// Purged records lose their links
+	if err := acme.RecordSoftDeleteByID(tag.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	if _, err := acme.RecordPurgeSoftDeleted(0); err != nil {
+		t.Fatalf("RecordPurgeSoftDeleted failed: %v", err)
+	}
+	if countLinks() != 0 {
+		t.Fatal("Expected the links of the purged record to be deleted")
+	}
+
+	expiring := customstore.NewRecord("tag", customstore.WithExpiresAt(time.Now().Add(-time.Hour)))
+	if err := acme.RecordCreate(expiring); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := acme.RecordLink(expiring.ID(), post.ID(), "tag"); err != nil {
+		t.Fatalf("RecordLink failed: %v", err)
+	}
+	if _, err := acme.RecordPurgeExpired(); err != nil {
+		t.Fatalf("RecordPurgeExpired failed: %v", err)
+	}
+	if countLinks() != 0 {
+		t.Fatal("Expected the links of the expired record to be deleted")
+	}
+}
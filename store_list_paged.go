@@ -0,0 +1,57 @@
+package customstore
+
+// PageResult is a page of records with the pagination details of a
+// paginated endpoint, see RecordListPaged
+type PageResult struct {
+	// Records are the records of the page
+	Records []RecordInterface
+
+	// TotalCount is the number of records matching the query over all pages
+	TotalCount int64
+
+	// Page is the number of the page, starting at 1
+	Page int
+
+	// PerPage is the limit of the query (capped by MaxListLimit), 0 if every
+	// record is listed
+	PerPage int
+
+	// HasMore reports whether records follow the page
+	HasMore bool
+}
+
+// RecordListPaged returns a page of the records matching the query, as set
+// by its limit and offset, with the total count and pagination details. The
+// records and the total are read by RecordListWithTotal, sharing the
+// filters of the query.
+func (st *storeImplementation) RecordListPaged(query RecordQueryInterface) (PageResult, error) {
+	records, total, err := st.RecordListWithTotal(query)
+	if err != nil {
+		return PageResult{}, err
+	}
+
+	perPage := 0
+	offset := 0
+	if query != nil && query.IsLimitSet() {
+		perPage = query.GetLimit()
+	}
+	if st.maxListLimit > 0 && (perPage <= 0 || perPage > st.maxListLimit) {
+		perPage = st.maxListLimit
+	}
+	if query != nil && query.IsOffsetSet() {
+		offset = query.GetOffset()
+	}
+
+	page := 1
+	if perPage > 0 {
+		page = offset/perPage + 1
+	}
+
+	return PageResult{
+		Records:    records,
+		TotalCount: total,
+		Page:       page,
+		PerPage:    perPage,
+		HasMore:    int64(offset+len(records)) < total,
+	}, nil
+}
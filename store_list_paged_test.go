@@ -0,0 +1,64 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRecordListPaged(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_list_paged",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := store.RecordCreate(customstore.NewRecord("person")); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+	if err := store.RecordCreate(customstore.NewRecord("order")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	page, err := store.RecordListPaged(customstore.RecordQuery().SetType("person").SetLimit(2).SetOffset(2))
+	if err != nil {
+		t.Fatalf("RecordListPaged failed: %v", err)
+	}
+	if len(page.Records) != 2 || page.TotalCount != 5 || page.Page != 2 || page.PerPage != 2 || !page.HasMore {
+		t.Fatalf("Unexpected page: %+v", page)
+	}
+
+	page, err = store.RecordListPaged(customstore.RecordQuery().SetType("person").SetLimit(2).SetOffset(4))
+	if err != nil {
+		t.Fatalf("RecordListPaged failed: %v", err)
+	}
+	if len(page.Records) != 1 || page.TotalCount != 5 || page.Page != 3 || page.HasMore {
+		t.Fatalf("Unexpected last page: %+v", page)
+	}
+
+	// Past the last page
+	page, err = store.RecordListPaged(customstore.RecordQuery().SetType("person").SetLimit(2).SetOffset(10))
+	if err != nil {
+		t.Fatalf("RecordListPaged failed: %v", err)
+	}
+	if len(page.Records) != 0 || page.TotalCount != 5 || page.HasMore {
+		t.Fatalf("Unexpected empty page: %+v", page)
+	}
+
+	// Without a limit every record is on the first page
+	page, err = store.RecordListPaged(customstore.RecordQuery().SetType("person"))
+	if err != nil {
+		t.Fatalf("RecordListPaged failed: %v", err)
+	}
+	if len(page.Records) != 5 || page.Page != 1 || page.PerPage != 0 || page.HasMore {
+		t.Fatalf("Unexpected unlimited page: %+v", page)
+	}
+}
@@ -0,0 +1,75 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RecordListWithTotal returns the records matching the query (honouring its
+// limit and offset) together with the total number of matching records,
+// as needed by paginated endpoints.
+//
+// The total is computed in the same round trip with a COUNT(*) OVER()
+// window function. Only when the page is empty (i.e. the offset is past the
+// last record) is a separate count issued.
+func (st *storeImplementation) RecordListWithTotal(query RecordQueryInterface) (records []RecordInterface, total int64, err error) {
+	if st.sharded() {
+		view, err := st.forQuery(query)
+		if err != nil {
+			return nil, 0, err
+		}
+		return view.RecordListWithTotal(query)
+	}
+
+	if st.db == nil {
+		return nil, 0, errors.New("database is not initialized")
+	}
+
+	if err := st.checkQueryGuards(query); err != nil {
+		return nil, 0, err
+	}
+
+	type recordRowWithTotal struct {
+		recordRow
+		Total int64 `db:"total_count"`
+	}
+
+	q := st.buildQuery(context.Background(), query).
+		Table(st.tableName()).
+		Select(recordColumnsFor(query) + st.orderKeySelect(query) + ", COUNT(*) OVER() AS total_count")
+
+	if st.dryRun("RecordListWithTotal", func() (string, []any) { return selectSQL(q) }) {
+		return []RecordInterface{}, 0, nil
+	}
+
+	var rows []recordRowWithTotal
+	start := time.Now()
+	err = st.retry(context.Background(), "RecordListWithTotal", func() error {
+		return q.Get(&rows)
+	})
+	st.logQuery("RecordListWithTotal", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return []RecordInterface{}, 0, st.wrapError(err, "RecordListWithTotal", queryRecordID(query), queryRecordType(query), func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	if len(rows) == 0 {
+		if query == nil || !query.IsOffsetSet() || query.GetOffset() == 0 {
+			return []RecordInterface{}, 0, nil
+		}
+
+		total, err := st.recordCount(context.Background(), query)
+		return []RecordInterface{}, total, err
+	}
+
+	list := make([]recordRow, 0, len(rows))
+	for _, row := range rows {
+		list = append(list, row.recordRow)
+	}
+
+	return markExcludedColumns(st.recordRowsToRecords(list), query), rows[0].Total, nil
+}
@@ -0,0 +1,63 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordListWithTotal(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_list_with_total",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		if err := store.RecordCreate(customstore.NewRecord("invoice", customstore.WithPayload(`{"n":1}`))); err != nil {
+			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
+		}
+	}
+	if err := store.RecordCreate(customstore.NewRecord("order")); err != nil {
+		t.Fatalf("RecordCreate order failed: %v", err)
+	}
+
+	list, total, err := store.RecordListWithTotal(customstore.RecordQuery().SetType("invoice").SetLimit(2).SetOffset(2))
+	if err != nil {
+		t.Fatalf("RecordListWithTotal failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 records in the page, but got %d", len(list))
+	}
+	if total != 5 {
+		t.Fatalf("Expected total 5, but got %d", total)
+	}
+	if list[0].Type() != "invoice" || list[0].Payload() != `{"n":1}` {
+		t.Fatalf("Expected hydrated invoice record, but got type %q payload %q", list[0].Type(), list[0].Payload())
+	}
+
+	list, total, err = store.RecordListWithTotal(customstore.RecordQuery().SetType("invoice").SetLimit(2).SetOffset(10))
+	if err != nil {
+		t.Fatalf("RecordListWithTotal past the end failed: %v", err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("Expected empty page past the end, but got %d records", len(list))
+	}
+	if total != 5 {
+		t.Fatalf("Expected total 5 past the end, but got %d", total)
+	}
+
+	_, total, err = store.RecordListWithTotal(customstore.RecordQuery().SetType("unknown"))
+	if err != nil {
+		t.Fatalf("RecordListWithTotal for unknown type failed: %v", err)
+	}
+	if total != 0 {
+		t.Fatalf("Expected total 0 for unknown type, but got %d", total)
+	}
+}
@@ -0,0 +1,60 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+)
+
+// RecordLoadPayload loads the payload and metas of a record listed with them
+// excluded (RecordQuery.SetExcludePayload / SetExcludeMetas). Only the
+// columns not yet loaded are fetched; fully loaded records are left as is.
+func (st *storeImplementation) RecordLoadPayload(record RecordInterface) error {
+	if st.sharded() && record != nil {
+		return st.forType(record.Type()).RecordLoadPayload(record)
+	}
+
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if record == nil {
+		return errors.New("record is nil")
+	}
+
+	if record.IsPayloadLoaded() && record.IsMetasLoaded() {
+		return nil
+	}
+
+	query := RecordQuery().
+		SetID(record.ID()).
+		SetSoftDeletedIncluded(true).
+		SetExpiredIncluded(true).
+		SetExcludePayload(record.IsPayloadLoaded()).
+		SetExcludeMetas(record.IsMetasLoaded()).
+		SetLimit(1)
+
+	list, err := st.selectRecords(context.Background(), st.buildQuery(context.Background(), query), query, "RecordLoadPayload")
+	if err != nil {
+		return err
+	}
+
+	if len(list) == 0 {
+		return ErrNotFound
+	}
+
+	if !record.IsPayloadLoaded() {
+		record.SetPayload(list[0].Payload())
+	}
+
+	if !record.IsMetasLoaded() {
+		metas, err := list[0].Metas()
+		if err != nil {
+			return err
+		}
+		if err := record.SetMetas(metas); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
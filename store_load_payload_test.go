@@ -0,0 +1,83 @@
+package customstore_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordListExcludePayload(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_exclude_payload",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person",
+		customstore.WithMemo("John"),
+		customstore.WithPayloadMap(map[string]any{"name": "John", "bio": "long text"}),
+		customstore.WithMetas(map[string]string{"status": "active"}))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().
+		SetType("person").
+		SetExcludePayload(true).
+		SetExcludeMetas(true))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 record, but got %d", len(list))
+	}
+
+	partial := list[0]
+	if partial.IsPayloadLoaded() || partial.IsMetasLoaded() {
+		t.Fatalf("Expected payload and metas not to be loaded")
+	}
+	if partial.Payload() != "" || partial.Meta("status") != "" {
+		t.Fatalf("Expected empty payload and metas, but got %q and %q", partial.Payload(), partial.Meta("status"))
+	}
+	if partial.Memo() != "John" || partial.CreatedAt() == "" {
+		t.Fatalf("Expected memo and timestamps to be loaded, but got %q and %q", partial.Memo(), partial.CreatedAt())
+	}
+
+	if err := partial.SetMeta("status", "inactive"); !errors.Is(err, customstore.ErrNotLoaded) {
+		t.Fatalf("Expected ErrNotLoaded setting a meta, but got %v", err)
+	}
+	if err := partial.SetPayloadMapKey("name", "Jane"); !errors.Is(err, customstore.ErrNotLoaded) {
+		t.Fatalf("Expected ErrNotLoaded setting a payload key, but got %v", err)
+	}
+
+	// Updating a partial record keeps the excluded columns
+	partial.SetMemo("Johnny")
+	if err := store.RecordUpdate(partial); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found.Memo() != "Johnny" || found.PayloadString("name", "") != "John" || found.Meta("status") != "active" {
+		t.Fatalf("Expected excluded columns to be kept, but got memo %q payload %q metas %q", found.Memo(), found.Payload(), found.Meta("status"))
+	}
+
+	if err := store.RecordLoadPayload(partial); err != nil {
+		t.Fatalf("RecordLoadPayload failed: %v", err)
+	}
+	if !partial.IsPayloadLoaded() || !partial.IsMetasLoaded() {
+		t.Fatalf("Expected payload and metas to be loaded")
+	}
+	if partial.PayloadString("bio", "") != "long text" || partial.Meta("status") != "active" {
+		t.Fatalf("Expected loaded payload and metas, but got %q and %q", partial.Payload(), partial.Meta("status"))
+	}
+}
@@ -0,0 +1,237 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Maintenance task names, as reported in MaintenanceResult.Task
+const (
+	MAINTENANCE_TASK_PURGE_SOFT_DELETED = "purge_soft_deleted"
+	MAINTENANCE_TASK_PURGE_EXPIRED      = "purge_expired"
+	MAINTENANCE_TASK_REBUILD_STATS      = "rebuild_stats"
+	MAINTENANCE_TASK_VERIFY_INTEGRITY   = "verify_integrity"
+)
+
+// MaintenanceConfig defines the scheduled store hygiene tasks
+type MaintenanceConfig struct {
+	// Interval between runs, defaults to 1 hour
+	Interval time.Duration
+
+	// Jitter adds a random delay of up to the given duration to each run,
+	// so that several instances do not run the tasks at the same time
+	Jitter time.Duration
+
+	// PurgeSoftDeleted hard deletes the records soft deleted more than
+	// PurgeSoftDeletedAfter ago
+	PurgeSoftDeleted      bool
+	PurgeSoftDeletedAfter time.Duration
+
+	// PurgeExpired hard deletes the expired records
+	PurgeExpired bool
+
+	// RebuildStats refreshes the database statistics of the table
+	RebuildStats bool
+
+	// VerifyIntegrity checks the payload and metas of the most recently
+	// updated IntegritySampleSize records (defaults to 100) are valid JSON
+	VerifyIntegrity     bool
+	IntegritySampleSize int
+
+	// OnResult is called with the result of every task run, i.e. to
+	// record metrics
+	OnResult func(result MaintenanceResult)
+}
+
+// MaintenanceResult is the outcome of a single maintenance task run
+type MaintenanceResult struct {
+	// Task is the task name, one of the MAINTENANCE_TASK_* constants
+	Task string
+
+	// StartedAt is the time the task started
+	StartedAt time.Time
+
+	// Duration is how long the task took
+	Duration time.Duration
+
+	// Affected is the number of records purged, or found invalid
+	Affected int64
+
+	// InvalidIDs lists the IDs of the records failing the integrity check
+	InvalidIDs []string
+
+	// Err is the error the task failed with, if any
+	Err error
+}
+
+// StartMaintenance runs the enabled maintenance tasks every interval (plus
+// jitter) in the background, until the context is cancelled
+func (st *storeImplementation) StartMaintenance(ctx context.Context, config MaintenanceConfig) error {
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if !config.PurgeSoftDeleted && !config.PurgeExpired && !config.RebuildStats && !config.VerifyIntegrity {
+		return errors.New("no maintenance task is enabled")
+	}
+
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+
+	go func() {
+		for {
+			delay := config.Interval
+			if config.Jitter > 0 {
+				delay += rand.N(config.Jitter)
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			st.RunMaintenance(ctx, config)
+		}
+	}()
+
+	return nil
+}
+
+// RunMaintenance runs the enabled maintenance tasks once, returning their results
+func (st *storeImplementation) RunMaintenance(ctx context.Context, config MaintenanceConfig) []MaintenanceResult {
+	tasks := []struct {
+		name    string
+		enabled bool
+		run     func(ctx context.Context, result *MaintenanceResult) error
+	}{
+		{MAINTENANCE_TASK_PURGE_SOFT_DELETED, config.PurgeSoftDeleted, func(ctx context.Context, result *MaintenanceResult) error {
+			return st.purgeSoftDeleted(ctx, config.PurgeSoftDeletedAfter, result)
+		}},
+		{MAINTENANCE_TASK_PURGE_EXPIRED, config.PurgeExpired, st.purgeExpired},
+		{MAINTENANCE_TASK_REBUILD_STATS, config.RebuildStats, st.rebuildStats},
+		{MAINTENANCE_TASK_VERIFY_INTEGRITY, config.VerifyIntegrity, func(ctx context.Context, result *MaintenanceResult) error {
+			return st.verifyIntegrity(ctx, config.IntegritySampleSize, result)
+		}},
+	}
+
+	results := []MaintenanceResult{}
+	for _, task := range tasks {
+		if !task.enabled || ctx.Err() != nil {
+			continue
+		}
+
+		result := MaintenanceResult{Task: task.name, StartedAt: time.Now()}
+		result.Err = task.run(ctx, &result)
+		result.Duration = time.Since(result.StartedAt)
+
+		if result.Err != nil {
+			st.logger.Error("Maintenance task failed", "table", st.tableName(), "task", task.name, "error", result.Err)
+		} else if st.debugEnabled {
+			st.logger.Debug("Maintenance task completed", "table", st.tableName(), "task", task.name, "affected", result.Affected, "duration", result.Duration)
+		}
+
+		if config.OnResult != nil {
+			config.OnResult(result)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// purgeSoftDeleted hard deletes the records soft deleted before the cutoff
+func (st *storeImplementation) purgeSoftDeleted(ctx context.Context, after time.Duration, result *MaintenanceResult) error {
+	affected, err := st.RecordPurgeSoftDeletedContext(ctx, after)
+	if err != nil {
+		return err
+	}
+
+	result.Affected = affected
+	return nil
+}
+
+// purgeExpired hard deletes the expired records
+func (st *storeImplementation) purgeExpired(ctx context.Context, result *MaintenanceResult) error {
+	affected, err := st.RecordPurgeExpiredContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	result.Affected = affected
+	return nil
+}
+
+// rebuildStats refreshes the query planner statistics of the table
+func (st *storeImplementation) rebuildStats(ctx context.Context, result *MaintenanceResult) error {
+	if st.sharded() {
+		for _, view := range st.tableViews() {
+			if err := view.rebuildStats(ctx, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var sqlStr string
+	switch st.driverName() {
+	case "mysql":
+		sqlStr = "ANALYZE TABLE " + st.tableName()
+	case "sqlserver":
+		sqlStr = "UPDATE STATISTICS " + st.tableName()
+	default:
+		sqlStr = "ANALYZE " + st.tableName()
+	}
+
+	_, err := st.exec(st.newQuery(ctx), "RebuildStats", sqlStr)
+	return st.wrapError(err, "RebuildStats", "", "", func() string {
+		return sqlStr
+	})
+}
+
+// verifyIntegrity checks a sample of records hold valid JSON payloads and metas
+func (st *storeImplementation) verifyIntegrity(ctx context.Context, sampleSize int, result *MaintenanceResult) error {
+	if st.sharded() {
+		for _, view := range st.tableViews() {
+			if err := view.verifyIntegrity(ctx, sampleSize, result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if sampleSize <= 0 {
+		sampleSize = 100
+	}
+
+	list, err := st.selectRecords(ctx, st.buildQuery(ctx, NewRecordQuery().
+		SetOrderBy(COLUMN_UPDATED_AT).
+		SetLimit(sampleSize).
+		SetSoftDeletedIncluded(true).
+		SetExpiredIncluded(true)), nil, "VerifyIntegrity")
+	if err != nil {
+		return err
+	}
+
+	for _, record := range list {
+		payloadValid := record.Payload() == "" || json.Valid([]byte(record.Payload()))
+		_, metasErr := record.Metas()
+		if !payloadValid || metasErr != nil {
+			result.InvalidIDs = append(result.InvalidIDs, record.ID())
+		}
+	}
+
+	result.Affected = int64(len(result.InvalidIDs))
+	if result.Affected > 0 {
+		st.logger.Warn("Maintenance found invalid records", "table", st.tableName(), "ids", result.InvalidIDs)
+	}
+
+	return nil
+}
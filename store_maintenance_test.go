@@ -0,0 +1,125 @@
+package customstore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRunMaintenance(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_maintenance",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	active := customstore.NewRecord("person", customstore.WithPayload(`{"name":"John"}`))
+	oldDeleted := customstore.NewRecord("person", customstore.WithSoftDeletedAt(time.Now().Add(-48*time.Hour)))
+	recentDeleted := customstore.NewRecord("person", customstore.WithSoftDeleted(true))
+	invalid := customstore.NewRecord("person", customstore.WithPayload(`{invalid`))
+	expired := customstore.NewRecord("person", customstore.WithExpiresAt(time.Now().Add(-time.Hour)))
+	for _, rec := range []customstore.RecordInterface{active, oldDeleted, recentDeleted, invalid, expired} {
+		if err := store.RecordCreate(rec); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	var reported []string
+	results := store.RunMaintenance(context.Background(), customstore.MaintenanceConfig{
+		PurgeSoftDeleted:      true,
+		PurgeSoftDeletedAfter: 24 * time.Hour,
+		PurgeExpired:          true,
+		RebuildStats:          true,
+		VerifyIntegrity:       true,
+		OnResult: func(result customstore.MaintenanceResult) {
+			reported = append(reported, result.Task)
+		},
+	})
+
+	if len(results) != 4 || len(reported) != 4 {
+		t.Fatalf("Expected 4 task results, but got %d (reported %d)", len(results), len(reported))
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			t.Fatalf("Task %s failed: %v", result.Task, result.Err)
+		}
+
+		switch result.Task {
+		case customstore.MAINTENANCE_TASK_PURGE_SOFT_DELETED:
+			if result.Affected != 1 {
+				t.Fatalf("Expected 1 record purged, but got %d", result.Affected)
+			}
+		case customstore.MAINTENANCE_TASK_PURGE_EXPIRED:
+			if result.Affected != 1 {
+				t.Fatalf("Expected 1 expired record purged, but got %d", result.Affected)
+			}
+		case customstore.MAINTENANCE_TASK_VERIFY_INTEGRITY:
+			if len(result.InvalidIDs) != 1 || result.InvalidIDs[0] != invalid.ID() {
+				t.Fatalf("Expected invalid record %s to be reported, but got %v", invalid.ID(), result.InvalidIDs)
+			}
+		}
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().SetSoftDeletedIncluded(true).SetExpiredIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 records left after purge, but got %d", count)
+	}
+}
+
+func TestStartMaintenance(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_maintenance_start",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if err := store.StartMaintenance(context.Background(), customstore.MaintenanceConfig{}); err == nil {
+		t.Fatalf("Expected error when no task is enabled, but got nil")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan customstore.MaintenanceResult, 1)
+	err = store.StartMaintenance(ctx, customstore.MaintenanceConfig{
+		Interval:     10 * time.Millisecond,
+		Jitter:       5 * time.Millisecond,
+		RebuildStats: true,
+		OnResult: func(result customstore.MaintenanceResult) {
+			select {
+			case done <- result:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("StartMaintenance failed: %v", err)
+	}
+
+	select {
+	case result := <-done:
+		if result.Task != customstore.MAINTENANCE_TASK_REBUILD_STATS || result.Err != nil {
+			t.Fatalf("Unexpected maintenance result %+v", result)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Maintenance did not run in time")
+	}
+}
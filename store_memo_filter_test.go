@@ -0,0 +1,65 @@
+package customstore_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreQueryMemo(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_memo_filter",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	memos := map[string]string{}
+	for _, memo := range []string{"Refund approved", "Refund pending", "Checked", ""} {
+		record := customstore.NewRecord("note", customstore.WithMemo(memo))
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		memos[record.ID()] = memo
+	}
+
+	listMemos := func(query customstore.RecordQueryInterface) []string {
+		t.Helper()
+		list, err := store.RecordList(query)
+		if err != nil {
+			t.Fatalf("RecordList failed: %v", err)
+		}
+		found := []string{}
+		for _, record := range list {
+			found = append(found, memos[record.ID()])
+		}
+		slices.Sort(found)
+		return found
+	}
+
+	if found := listMemos(customstore.RecordQuery().SetMemoEquals("Checked")); !slices.Equal(found, []string{"Checked"}) {
+		t.Fatalf("Unexpected memos: %v", found)
+	}
+	if found := listMemos(customstore.RecordQuery().SetMemoEquals("")); !slices.Equal(found, []string{""}) {
+		t.Fatalf("Unexpected memos: %v", found)
+	}
+	if found := listMemos(customstore.RecordQuery().SetMemoLike("Refund%")); !slices.Equal(found, []string{"Refund approved", "Refund pending"}) {
+		t.Fatalf("Unexpected memos: %v", found)
+	}
+
+	// Combined with the other filters
+	query := customstore.RecordQuery().SetType("note").SetMemoLike("%pending")
+	if found := listMemos(query); !slices.Equal(found, []string{"Refund pending"}) {
+		t.Fatalf("Unexpected memos: %v", found)
+	}
+
+	if err := customstore.RecordQuery().SetMemoLike("").Validate(); err == nil {
+		t.Fatal("Expected an error for an empty memo like pattern")
+	}
+}
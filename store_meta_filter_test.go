@@ -0,0 +1,72 @@
+package customstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordListMetaFilters(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_meta_filter",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	metas := []map[string]string{
+		{"status": "open", "priority": "high"},
+		{"status": "pending", "priority": "low"},
+		{"status": "closed", "priority": "high"},
+		{"priority": "high"},
+	}
+	for i, m := range metas {
+		if err := store.RecordCreate(customstore.NewRecord("ticket", customstore.WithMetas(m))); err != nil {
+			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
+		}
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().AddMetaIn("status", []string{"open", "pending"}))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 open or pending tickets, but got %d", count)
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().
+		AddMetaIn("status", []string{"open", "closed"}).
+		AddMetaEquals("priority", "high"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 high priority open or closed tickets, but got %d", len(list))
+	}
+
+	count, err = store.Query().MetaIn("priority", []string{"low"}).Count(context.Background())
+	if err != nil {
+		t.Fatalf("Query Count failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 low priority ticket, but got %d", count)
+	}
+
+	count, err = store.RecordCount(customstore.RecordQuery().AddMetaIn("status", []string{}))
+	if err != nil {
+		t.Fatalf("RecordCount with empty list failed: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected an empty list to match nothing, but got %d", count)
+	}
+
+	if err := customstore.RecordQuery().AddMetaIn("status", nil).Validate(); err == nil {
+		t.Fatalf("Expected error for empty meta in values, but got nil")
+	}
+}
@@ -0,0 +1,85 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// MetaKeys returns the distinct meta keys in use by the active (not soft
+// deleted) records of the given type, sorted alphabetically.
+//
+// The keys are extracted from the metas column by the database JSON
+// functions, so no records are loaded. Reserved meta keys are excluded.
+func (st *storeImplementation) MetaKeys(recordType string) ([]string, error) {
+	if st.sharded() {
+		return st.forType(recordType).MetaKeys(recordType)
+	}
+
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	if recordType == "" {
+		return nil, errors.New("record type is required")
+	}
+
+	tenantWhere, tenantArgs := st.tenantSQL("r." + COLUMN_TENANT_ID)
+	sqlStr := rebindPlaceholders(st.driverName(), metaKeysSQL(st.driverName(), st.tableName(), tenantWhere))
+
+	args := append([]any{recordType, st.nowTimestamp(), st.nowTimestamp()}, tenantArgs...)
+
+	if st.dryRun("MetaKeys", rawStatement(sqlStr, args...)) {
+		return []string{}, nil
+	}
+
+	var rows []map[string]any
+	start := time.Now()
+	err := st.newQuery(context.Background()).Raw(sqlStr, args...).Get(&rows)
+	st.logQuery("MetaKeys", start, rawStatement(sqlStr, args...))
+	if err != nil {
+		return nil, st.wrapError(err, "MetaKeys", "", recordType, func() string {
+			return sqlStr
+		})
+	}
+
+	keys := make([]string, 0, len(rows))
+	for _, row := range rows {
+		key := cast.ToString(row["meta_key"])
+		if key == "" || strings.HasPrefix(key, RESERVED_META_PREFIX) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// metaKeysSQL returns the statement listing the distinct meta keys for the
+// driver, with the record type and twice the current time as placeholders,
+// followed by the extra conditions (prefixed with " AND ")
+func metaKeysSQL(driver string, tableName string, extraWhere string) string {
+	where := " WHERE r." + COLUMN_RECORD_TYPE + " = ? AND r." + COLUMN_SOFT_DELETED_AT + " > ? AND r." + COLUMN_EXPIRES_AT + " > ?" + extraWhere
+	metas := jsonColumn("r." + COLUMN_METAS)
+
+	switch driver {
+	case "mysql":
+		return "SELECT DISTINCT mk.meta_key AS meta_key FROM " + tableName + " AS r, " +
+			"JSON_TABLE(JSON_KEYS(" + metas + "), '$[*]' COLUMNS (meta_key VARCHAR(255) PATH '$')) AS mk" +
+			where + " ORDER BY meta_key"
+	case "postgres":
+		return "SELECT DISTINCT jsonb_object_keys(" + metas + "::jsonb) AS meta_key FROM " + tableName + " AS r" +
+			where + " ORDER BY meta_key"
+	case "sqlserver":
+		return "SELECT DISTINCT mk.[key] AS meta_key FROM " + tableName + " AS r " +
+			"CROSS APPLY OPENJSON(" + metas + ") AS mk" +
+			where + " ORDER BY meta_key"
+	default:
+		return "SELECT DISTINCT mk.key AS meta_key FROM " + tableName + " AS r, " +
+			"json_each(" + metas + ") AS mk" +
+			where + " ORDER BY meta_key"
+	}
+}
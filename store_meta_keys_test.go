@@ -0,0 +1,58 @@
+package customstore_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestMetaKeys(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_meta_keys",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []customstore.RecordInterface{
+		customstore.NewRecord("invoice", customstore.WithMetas(map[string]string{"status": "open", "customer": "acme"})),
+		customstore.NewRecord("invoice", customstore.WithMetas(map[string]string{"status": "paid"}), customstore.WithCreatedBy("user_1")),
+		customstore.NewRecord("invoice"),
+		customstore.NewRecord("invoice", customstore.WithMetas(map[string]string{"archived": "yes"}), customstore.WithSoftDeleted(true)),
+		customstore.NewRecord("order", customstore.WithMetas(map[string]string{"warehouse": "north"})),
+	}
+
+	for i, rec := range records {
+		if err := store.RecordCreate(rec); err != nil {
+			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
+		}
+	}
+
+	keys, err := store.MetaKeys("invoice")
+	if err != nil {
+		t.Fatalf("MetaKeys failed: %v", err)
+	}
+
+	expected := []string{"customer", "status"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Fatalf("Expected meta keys %v, but got %v", expected, keys)
+	}
+
+	keys, err = store.MetaKeys("unknown")
+	if err != nil {
+		t.Fatalf("MetaKeys for unknown type failed: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("Expected no meta keys for unknown type, but got %v", keys)
+	}
+
+	if _, err := store.MetaKeys(""); err == nil {
+		t.Fatalf("Expected error for empty record type, but got nil")
+	}
+}
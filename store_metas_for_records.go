@@ -0,0 +1,99 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// MetasForRecords returns the requested metas of many records in one query,
+// keyed by record ID, i.e. to render lists showing a few meta values per row
+// without querying each record.
+//
+// Metas missing from a record are omitted. All metas are returned when no
+// keys are given. Records not found (or soft deleted) are not in the result.
+func (st *storeImplementation) MetasForRecords(ids []string, keys []string) (map[string]map[string]string, error) {
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	result := map[string]map[string]string{}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	if st.sharded() {
+		for _, view := range st.tableViews() {
+			metas, err := view.MetasForRecords(ids, keys)
+			if err != nil {
+				return nil, err
+			}
+			maps.Copy(result, metas)
+		}
+		return result, nil
+	}
+
+	driver := st.driverName()
+
+	selects := []string{COLUMN_ID}
+	args := make([]any, 0, len(keys))
+	if len(keys) == 0 {
+		selects = append(selects, COLUMN_METAS)
+	}
+	for i, key := range keys {
+		selects = append(selects, jsonExtractText(driver, COLUMN_METAS)+" AS k"+strconv.Itoa(i))
+		args = append(args, jsonPathArg(driver, key))
+	}
+
+	q := st.buildQuery(context.Background(), NewRecordQuery().SetIDList(ids)).
+		Table(st.tableName()).
+		Select(strings.Join(selects, ", "), args...)
+
+	if st.dryRun("MetasForRecords", func() (string, []any) { return selectSQL(q) }) {
+		return result, nil
+	}
+
+	var rows []map[string]any
+	start := time.Now()
+	err := st.retry(context.Background(), "MetasForRecords", func() error {
+		return q.Get(&rows)
+	})
+	st.logQuery("MetasForRecords", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return nil, st.wrapError(err, "MetasForRecords", "", "", func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	for _, row := range rows {
+		metas := map[string]string{}
+
+		if len(keys) == 0 {
+			if raw := cast.ToString(row[COLUMN_METAS]); raw != "" {
+				if err := json.Unmarshal([]byte(raw), &metas); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		for i, key := range keys {
+			value := row["k"+strconv.Itoa(i)]
+			if value == nil {
+				continue
+			}
+			metas[key] = cast.ToString(value)
+		}
+
+		result[cast.ToString(row[COLUMN_ID])] = metas
+	}
+
+	return result, nil
+}
@@ -0,0 +1,63 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestMetasForRecords(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_metas_for_records",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	first := customstore.NewRecord("invoice", customstore.WithMetas(map[string]string{"status": "open", "owner": "john", "note": "x"}))
+	second := customstore.NewRecord("invoice", customstore.WithMetas(map[string]string{"status": "paid"}))
+	other := customstore.NewRecord("invoice", customstore.WithMetas(map[string]string{"status": "void"}))
+	for _, rec := range []customstore.RecordInterface{first, second, other} {
+		if err := store.RecordCreate(rec); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	metas, err := store.MetasForRecords([]string{first.ID(), second.ID(), "missing"}, []string{"status", "owner"})
+	if err != nil {
+		t.Fatalf("MetasForRecords failed: %v", err)
+	}
+	if len(metas) != 2 {
+		t.Fatalf("Expected metas for 2 records, but got %d", len(metas))
+	}
+	if metas[first.ID()]["status"] != "open" || metas[first.ID()]["owner"] != "john" {
+		t.Fatalf("Unexpected metas for first record %v", metas[first.ID()])
+	}
+	if _, exists := metas[first.ID()]["note"]; exists {
+		t.Fatalf("Expected only the requested metas, but got %v", metas[first.ID()])
+	}
+	if len(metas[second.ID()]) != 1 || metas[second.ID()]["status"] != "paid" {
+		t.Fatalf("Unexpected metas for second record %v", metas[second.ID()])
+	}
+
+	all, err := store.MetasForRecords([]string{first.ID()}, nil)
+	if err != nil {
+		t.Fatalf("MetasForRecords without keys failed: %v", err)
+	}
+	if len(all[first.ID()]) != 3 {
+		t.Fatalf("Expected all 3 metas without keys, but got %v", all[first.ID()])
+	}
+
+	empty, err := store.MetasForRecords(nil, []string{"status"})
+	if err != nil {
+		t.Fatalf("MetasForRecords without ids failed: %v", err)
+	}
+	if len(empty) != 0 {
+		t.Fatalf("Expected empty result without ids, but got %v", empty)
+	}
+}
@@ -0,0 +1,323 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// MigrateToTableOptions configures a MigrateToTable run
+type MigrateToTableOptions struct {
+	// BatchSize is the number of records copied per batch, defaults to 100
+	BatchSize int
+
+	// OnProgress is called after every copied batch
+	OnProgress func(progress MigrateToTableProgress)
+}
+
+// MigrateToTableProgress reports the progress of a MigrateToTable run
+type MigrateToTableProgress struct {
+	// Copied is the number of records copied to the new table
+	Copied int
+
+	// Switched is true once the store uses the new table
+	Switched bool
+}
+
+// tableState holds the table name, shared by the store, its read only views
+// and transaction bound copies so that a table switch applies to all
+type tableState struct {
+	mu   sync.RWMutex
+	name string
+
+	// target is the table of a running MigrateToTable, receiving the
+	// double writes
+	target string
+
+	// writeMu is held (read locked) by every write for the duration of the
+	// write and its double write, and locked by the table switch
+	writeMu sync.RWMutex
+}
+
+// tableName returns the name of the table the store uses
+func (st *storeImplementation) tableName() string {
+	st.tables.mu.RLock()
+	defer st.tables.mu.RUnlock()
+	return st.tables.name
+}
+
+// migrationTarget returns the table of a running MigrateToTable, if any
+func (st *storeImplementation) migrationTarget() string {
+	st.tables.mu.RLock()
+	defer st.tables.mu.RUnlock()
+	return st.tables.target
+}
+
+// lockWrite is held while writing to the table, so the table is not
+// switched between the write and its double write. Returns the unlock.
+func (st *storeImplementation) lockWrite() func() {
+	st.tables.writeMu.RLock()
+	return st.tables.writeMu.RUnlock
+}
+
+// MigrateToTable moves the records of the store to a new table without
+// downtime, i.e. to split an overgrown shared table.
+//
+// The new table is created, with the payload indexes and unique keys
+// ensured on the store, and the records are copied in batches. While
+// copying, every write to the store is also applied to the new table. Once
+// all records are copied the store (and its views) switch to the new table
+// atomically. The old table is kept, drop it once it is no longer used.
+//
+// Only writes through this store are double written: other processes
+// writing to the old table must be stopped, or use the same store.
+func (st *storeImplementation) MigrateToTable(ctx context.Context, newTable string, opts MigrateToTableOptions) (MigrateToTableProgress, error) {
+	progress := MigrateToTableProgress{}
+
+	if err := st.checkWritable(); err != nil {
+		return progress, err
+	}
+
+	if st.db == nil {
+		return progress, errors.New("database is not initialized")
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := st.checkUnscoped("MigrateToTable"); err != nil {
+		return progress, err
+	}
+
+	if st.sharded() {
+		return progress, errors.New("MigrateToTable is not supported with a type table map")
+	}
+
+	if newTable == "" {
+		return progress, errors.New("new table name is required")
+	}
+
+	if newTable == st.tableName() {
+		return progress, errors.New("new table must differ from the current table")
+	}
+
+	if st.tx != nil {
+		return progress, errors.New("table migration cannot run in a transaction")
+	}
+
+	if st.migrationTarget() != "" {
+		return progress, errors.New("a table migration is already running")
+	}
+
+	if st.db.Schema().HasTable(newTable) {
+		return progress, errors.New("table " + newTable + " already exists")
+	}
+
+	if err := st.createTable(newTable); err != nil {
+		return progress, st.wrapError(err, "MigrateToTable", "", "", nil)
+	}
+
+	if err := st.createSchemaTable(newTable); err != nil {
+		return progress, st.wrapError(err, "MigrateToTable", "", "", nil)
+	}
+
+	if err := st.saveSchemaVersion(ctx, newTable, latestSchemaVersion()); err != nil {
+		return progress, err
+	}
+
+	if err := st.createFullTextIndex(ctx, newTable); err != nil {
+		return progress, st.wrapError(err, "MigrateToTable", "", "", nil)
+	}
+
+	if err := st.createEnsuredIndexes(ctx, newTable); err != nil {
+		return progress, err
+	}
+
+	st.tables.mu.Lock()
+	if st.tables.target != "" {
+		st.tables.mu.Unlock()
+		return progress, errors.New("a table migration is already running")
+	}
+	st.tables.target = newTable
+	st.tables.mu.Unlock()
+
+	switched := false
+	defer func() {
+		if !switched {
+			st.tables.mu.Lock()
+			st.tables.target = ""
+			st.tables.mu.Unlock()
+		}
+	}()
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = payloadMigrationBatchSize
+	}
+
+	query := NewRecordQuery().
+		SetOrderBy(COLUMN_ID).
+		SetLimit(batchSize).
+		SetSoftDeletedIncluded(true).
+		SetExpiredIncluded(true)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+
+		q := st.buildQuery(ctx, query).
+			Table(st.tableName()).
+			Select(COLUMN_ID)
+
+		var rows []struct {
+			ID string `db:"id"`
+		}
+		start := time.Now()
+		err := q.Get(&rows)
+		st.logQuery("MigrateToTable", start, func() (string, []any) {
+			return q.ToRawSql().Get(&rows), nil
+		})
+		if err != nil {
+			return progress, st.wrapError(err, "MigrateToTable", "", "", func() string {
+				return q.ToSql().Get(&rows)
+			})
+		}
+
+		if len(rows) == 0 {
+			break
+		}
+
+		ids := make([]string, len(rows))
+		for i, row := range rows {
+			ids[i] = row.ID
+		}
+
+		if err := st.copyToMigrationTarget(ctx, ids); err != nil {
+			return progress, err
+		}
+
+		last := ids[len(ids)-1]
+		query.SetPageToken(PageToken{OrderBy: COLUMN_ID, Value: last, ID: last})
+
+		progress.Copied += len(ids)
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+	}
+
+	// Wait for the running writes (and their double writes) to complete
+	st.tables.writeMu.Lock()
+	if err := st.seedUniqueValues(ctx, newTable); err != nil {
+		st.tables.writeMu.Unlock()
+		return progress, err
+	}
+	st.tables.mu.Lock()
+	st.tables.name = newTable
+	st.tables.target = ""
+	st.tables.mu.Unlock()
+	st.tables.writeMu.Unlock()
+
+	switched = true
+	progress.Switched = true
+	if opts.OnProgress != nil {
+		opts.OnProgress(progress)
+	}
+
+	if st.debugEnabled {
+		st.logger.Info("MigrateToTable: switched table", "table", newTable, "copied", progress.Copied)
+	}
+
+	return progress, nil
+}
+
+// createEnsuredIndexes creates the payload indexes and unique keys ensured
+// on the store on the new table of a migration, before the records are
+// copied. The unique values table is created empty, see seedUniqueValues.
+func (st *storeImplementation) createEnsuredIndexes(ctx context.Context, newTable string) error {
+	for _, key := range st.payloadIndexes.indexedKeys() {
+		if err := st.createPayloadIndex(ctx, newTable, key); err != nil {
+			return err
+		}
+	}
+
+	uniques := st.uniqueKeys.all()
+	if len(uniques) == 0 {
+		return nil
+	}
+
+	if uniqueTableDriver(st.driverName()) {
+		return st.wrapError(st.createUniqueTable(newTable), "MigrateToTable", "", "", nil)
+	}
+
+	for recordType, keys := range uniques {
+		for _, unique := range keys {
+			if err := st.createUniqueIndex(ctx, newTable, recordType, unique, "MigrateToTable"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// seedUniqueValues fills the unique values table of the new table of a
+// migration from its copied records, once no write runs, if the driver
+// keeps the unique keys in the table
+func (st *storeImplementation) seedUniqueValues(ctx context.Context, newTable string) error {
+	if !uniqueTableDriver(st.driverName()) {
+		return nil
+	}
+
+	for recordType, keys := range st.uniqueKeys.all() {
+		for _, unique := range keys {
+			if err := st.rebuildUniqueValues(ctx, newTable, recordType, unique, "MigrateToTable"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// copyToMigrationTarget copies the current rows with the given IDs to the
+// migration target table, replacing previous copies. Rows no longer in the
+// table are removed from the target. Does nothing if no migration runs.
+func (st *storeImplementation) copyToMigrationTarget(ctx context.Context, ids []string) error {
+	target := st.migrationTarget()
+	if target == "" || len(ids) == 0 {
+		return nil
+	}
+
+	anyIDs := make([]any, len(ids))
+	for i, id := range ids {
+		anyIDs[i] = id
+	}
+
+	columns := st.tenantColumns(recordColumns)
+	sqlStr := rebindPlaceholders(st.driverName(), "INSERT INTO "+target+" ("+columns+") "+
+		"SELECT "+columns+" FROM "+st.tableName()+
+		" WHERE "+COLUMN_ID+" IN ("+placeholders(len(ids))+")")
+
+	err := st.transaction(func(tx contractsorm.Query) error {
+		q := cloneQuery(ctx, tx).Table(target).WhereIn(COLUMN_ID, anyIDs)
+		start := time.Now()
+		_, err := q.Delete()
+		st.logQuery("MigrateToTable", start, func() (string, []any) {
+			return q.ToRawSql().Delete(), nil
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = st.exec(cloneQuery(ctx, tx), "MigrateToTable", sqlStr, anyIDs...)
+		return err
+	})
+
+	return st.wrapError(err, "MigrateToTable", strings.Join(ids, ","), "", func() string {
+		return sqlStr
+	})
+}
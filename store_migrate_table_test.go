@@ -0,0 +1,172 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestMigrateToTable(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_migrate_table_old",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []customstore.RecordInterface{}
+	for i := 0; i < 5; i++ {
+		record := customstore.NewRecord("person", customstore.WithPayloadMap(map[string]any{"index": i}))
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
+		}
+		records = append(records, record)
+	}
+	if err := store.RecordSoftDelete(records[4]); err != nil {
+		t.Fatalf("RecordSoftDelete failed: %v", err)
+	}
+
+	view := store.ReadOnlyView()
+
+	// Writes during the copy are double written
+	created := customstore.NewRecord("person", customstore.WithMemo("created while copying"))
+	batches := 0
+	progress, err := store.MigrateToTable(context.Background(), "data_migrate_table_new", customstore.MigrateToTableOptions{
+		BatchSize: 2,
+		OnProgress: func(progress customstore.MigrateToTableProgress) {
+			if progress.Switched {
+				return
+			}
+			batches++
+			if batches != 1 {
+				return
+			}
+			if err := store.RecordCreate(created); err != nil {
+				t.Fatalf("RecordCreate during migration failed: %v", err)
+			}
+			records[0].SetMemo("updated while copying")
+			if err := store.RecordUpdate(records[0]); err != nil {
+				t.Fatalf("RecordUpdate during migration failed: %v", err)
+			}
+			if err := store.RecordDeleteByID(records[1].ID()); err != nil {
+				t.Fatalf("RecordDeleteByID during migration failed: %v", err)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("MigrateToTable failed: %v", err)
+	}
+	if !progress.Switched || progress.Copied == 0 {
+		t.Fatalf("Expected the migration to copy the records and switch, but got %+v", progress)
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 5 {
+		t.Fatalf("Expected 5 records in the new table, but got %d", count)
+	}
+
+	for _, s := range []customstore.StoreInterface{store, view} {
+		found, err := s.RecordFindByID(records[0].ID())
+		if err != nil || found == nil {
+			t.Fatalf("Expected to find the updated record, but got %v, %v", found, err)
+		}
+		if found.Memo() != "updated while copying" || found.PayloadInt("index", -1) != 0 {
+			t.Fatalf("Expected the double written update, but got memo %q payload %q", found.Memo(), found.Payload())
+		}
+
+		found, err = s.RecordFindByID(created.ID())
+		if err != nil || found == nil {
+			t.Fatalf("Expected to find the record created while copying, but got %v, %v", found, err)
+		}
+
+		found, err = s.RecordFindByID(records[1].ID())
+		if err != nil || found != nil {
+			t.Fatalf("Expected the record deleted while copying to be gone, but got %v, %v", found, err)
+		}
+	}
+
+	deleted, err := store.RecordList(customstore.RecordQuery().SetID(records[4].ID()).SetSoftDeletedIncluded(true))
+	if err != nil || len(deleted) != 1 || !deleted[0].IsSoftDeleted() {
+		t.Fatalf("Expected the soft deleted record to be copied, but got %v, %v", deleted, err)
+	}
+
+	// Writes after the switch go to the new table only
+	if err := store.RecordCreate(customstore.NewRecord("person")); err != nil {
+		t.Fatalf("RecordCreate after migration failed: %v", err)
+	}
+	old, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:        db,
+		TableName: "data_migrate_table_old",
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+	oldCount, err := old.RecordCount(customstore.RecordQuery().SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount on the old table failed: %v", err)
+	}
+	if oldCount != 5 {
+		t.Fatalf("Expected the old table to be kept as it was at the switch, but got %d records", oldCount)
+	}
+
+	if _, err := store.MigrateToTable(context.Background(), "data_migrate_table_new", customstore.MigrateToTableOptions{}); err == nil {
+		t.Fatalf("Expected error migrating to the current table, but got nil")
+	}
+	if _, err := store.MigrateToTable(context.Background(), "data_migrate_table_old", customstore.MigrateToTableOptions{}); err == nil {
+		t.Fatalf("Expected error migrating to an existing table, but got nil")
+	}
+}
+
+func TestMigrateToTableIndexes(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_migrate_table_indexes_old",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+	if err := store.EnsureUniquePayloadKey("user", "email"); err != nil {
+		t.Fatalf("EnsureUniquePayloadKey failed: %v", err)
+	}
+	if err := store.EnsurePayloadIndex("user", "status"); err != nil {
+		t.Fatalf("EnsurePayloadIndex failed: %v", err)
+	}
+
+	if err := store.RecordCreate(customstore.NewRecord("user", customstore.WithPayload(`{"email":"alice@test.com"}`))); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if _, err := store.MigrateToTable(context.Background(), "data_migrate_table_indexes_new", customstore.MigrateToTableOptions{}); err != nil {
+		t.Fatalf("MigrateToTable failed: %v", err)
+	}
+
+	err = store.RecordCreate(customstore.NewRecord("user", customstore.WithPayload(`{"email":"alice@test.com"}`)))
+	if !errors.Is(err, customstore.ErrDuplicate) {
+		t.Fatalf("Expected ErrDuplicate on the new table, but got %v", err)
+	}
+
+	count, err := store.RecordCount(customstore.NewRecordQuery().SetType("user"))
+	if err != nil || count != 1 {
+		t.Fatalf("Expected 1 user, got %d: %v", count, err)
+	}
+
+	var indexes int
+	err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name LIKE ?", "data_migrate_table_indexes_new", "%_payload_%").Scan(&indexes)
+	if err != nil || indexes != 1 {
+		t.Fatalf("Expected the payload index on the new table, got %d: %v", indexes, err)
+	}
+}
@@ -0,0 +1,65 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordListOnlySoftDeleted(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_only_soft_deleted",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	deleted := map[string]bool{}
+	for i, recordType := range []string{"invoice", "invoice", "invoice", "order"} {
+		record := customstore.NewRecord(recordType)
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		if i == 0 {
+			continue
+		}
+		if err := store.RecordSoftDelete(record); err != nil {
+			t.Fatalf("RecordSoftDelete failed: %v", err)
+		}
+		deleted[record.ID()] = true
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetType("invoice").SetOnlySoftDeleted(true))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 soft deleted invoices, but got %d", len(list))
+	}
+	for _, record := range list {
+		if !deleted[record.ID()] || record.Type() != "invoice" {
+			t.Fatalf("Unexpected %s record %s in the trash", record.Type(), record.ID())
+		}
+	}
+
+	list, err = store.RecordList(customstore.RecordQuery().SetType("invoice").SetOnlySoftDeleted(true).SetLimit(1).SetOffset(1))
+	if err != nil {
+		t.Fatalf("RecordList with limit failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 record on the second page, but got %d", len(list))
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().SetOnlySoftDeleted(true).SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected only soft deleted to take precedence with 3 records, but got %d", count)
+	}
+}
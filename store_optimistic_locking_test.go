@@ -0,0 +1,83 @@
+package customstore_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordUpdateVersioned(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_optimistic_locking",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("document", customstore.WithPayload("draft"))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	first, err := store.RecordFindByID(record.ID())
+	if err != nil || first == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	second, err := store.RecordFindByID(record.ID())
+	if err != nil || second == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	first.SetPayload("first edit")
+	if err := store.RecordUpdateVersioned(first); err != nil {
+		t.Fatalf("RecordUpdateVersioned failed: %v", err)
+	}
+	if first.Version() != 2 {
+		t.Fatalf("Expected version 2 after the update, but got %d", first.Version())
+	}
+
+	second.SetPayload("second edit")
+	err = store.RecordUpdateVersioned(second)
+	if !errors.Is(err, customstore.ErrStaleRecord) {
+		t.Fatalf("Expected ErrStaleRecord for the concurrent edit, but got %v", err)
+	}
+	if second.Version() != 1 {
+		t.Fatalf("Expected the stale record to keep version 1, but got %d", second.Version())
+	}
+
+	stored, err := store.RecordFindByID(record.ID())
+	if err != nil || stored == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if stored.Payload() != "first edit" {
+		t.Fatalf("Expected the first edit to be kept, but got %q", stored.Payload())
+	}
+	if stored.Version() != 2 {
+		t.Fatalf("Expected stored version 2, but got %d", stored.Version())
+	}
+
+	// A plain update also increments the version
+	stored.SetPayload("plain edit")
+	if err := store.RecordUpdate(stored); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	reloaded, err := store.RecordFindByID(record.ID())
+	if err != nil || reloaded == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if reloaded.Version() != 3 {
+		t.Fatalf("Expected stored version 3 after the plain update, but got %d", reloaded.Version())
+	}
+
+	err = store.RecordUpdateVersioned(first)
+	if !errors.Is(err, customstore.ErrStaleRecord) {
+		t.Fatalf("Expected ErrStaleRecord after the plain update, but got %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package customstore_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreQueryOrGroups(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_or_groups",
+		AutomigrateEnabled: true,
+		RequireTypeFilter:  true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := map[string]customstore.RecordInterface{
+		"a-x": customstore.NewRecord("a", customstore.WithPayload(`{"key":"x"}`), customstore.WithMetas(map[string]string{"status": "open"})),
+		"a-y": customstore.NewRecord("a", customstore.WithPayload(`{"key":"y"}`)),
+		"b-x": customstore.NewRecord("b", customstore.WithPayload(`{"key":"x"}`)),
+		"b-y": customstore.NewRecord("b", customstore.WithPayload(`{"key":"y"}`), customstore.WithMetas(map[string]string{"status": "closed"})),
+	}
+	names := map[string]string{}
+	for name, record := range records {
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		names[record.ID()] = name
+	}
+
+	listNames := func(query customstore.RecordQueryInterface) []string {
+		t.Helper()
+		list, err := store.RecordList(query)
+		if err != nil {
+			t.Fatalf("RecordList failed: %v", err)
+		}
+		found := []string{}
+		for _, record := range list {
+			found = append(found, names[record.ID()])
+		}
+		slices.Sort(found)
+		return found
+	}
+
+	// (type = a AND key = x) OR (type = b AND key = y)
+	query := customstore.NewRecordQuery().AddOrGroup(
+		customstore.NewRecordQuery().SetType("a").AddPayloadKeyEquals("key", "x"),
+		customstore.NewRecordQuery().SetType("b").AddPayloadKeyEquals("key", "y"),
+	)
+	if found := listNames(query); !slices.Equal(found, []string{"a-x", "b-y"}) {
+		t.Fatalf("Unexpected records: %v", found)
+	}
+
+	// Groups are ANDed with the other filters
+	query = customstore.NewRecordQuery().
+		AddPayloadKeyEquals("key", "x").
+		AddOrGroup(
+			customstore.NewRecordQuery().SetType("a"),
+			customstore.NewRecordQuery().SetType("b").AddMetaEquals("status", "closed"),
+		)
+	if found := listNames(query); !slices.Equal(found, []string{"a-x"}) {
+		t.Fatalf("Unexpected records: %v", found)
+	}
+
+	// Nested groups: type = b AND (key = x OR status = closed)
+	query = customstore.NewRecordQuery().AddOrGroup(
+		customstore.NewRecordQuery().SetType("b").AddOrGroup(
+			customstore.NewRecordQuery().AddPayloadKeyEquals("key", "x"),
+			customstore.NewRecordQuery().AddMetaEquals("status", "closed"),
+		),
+	)
+	if found := listNames(query); !slices.Equal(found, []string{"b-x", "b-y"}) {
+		t.Fatalf("Unexpected records: %v", found)
+	}
+
+	count, err := store.RecordCount(customstore.NewRecordQuery().AddOrGroup(
+		customstore.NewRecordQuery().SetType("a"),
+		customstore.NewRecordQuery().SetType("b").AddPayloadKeyEquals("key", "x"),
+	))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 records, got %d", count)
+	}
+
+	// A group with an untyped alternative does not satisfy the type filter
+	_, err = store.RecordList(customstore.NewRecordQuery().AddOrGroup(
+		customstore.NewRecordQuery().SetType("a"),
+		customstore.NewRecordQuery().AddPayloadKeyEquals("key", "x"),
+	))
+	if !errors.Is(err, customstore.ErrTypeFilterRequired) {
+		t.Fatalf("Expected ErrTypeFilterRequired, got %v", err)
+	}
+
+	if err := customstore.RecordQuery().AddOrGroup().Validate(); err == nil {
+		t.Fatal("Expected an error for an empty or group")
+	}
+	if err := customstore.RecordQuery().AddOrGroup(customstore.RecordQuery().SetTypeIn([]string{})).Validate(); err == nil {
+		t.Fatal("Expected an error for an invalid alternative")
+	}
+}
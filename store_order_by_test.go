@@ -0,0 +1,59 @@
+package customstore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordListAddOrderBy(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_order_by",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	records := []struct {
+		recordType string
+		memo       string
+	}{
+		{"order", "b"},
+		{"invoice", "a"},
+		{"order", "a"},
+		{"invoice", "c"},
+	}
+	for _, r := range records {
+		if err := store.RecordCreate(customstore.NewRecord(r.recordType, customstore.WithMemo(r.memo))); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().
+		AddOrderBy(customstore.COLUMN_RECORD_TYPE, customstore.ORDER_ASC).
+		AddOrderBy(customstore.COLUMN_MEMO, "DESC"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+
+	got := []string{}
+	for _, record := range list {
+		got = append(got, record.Type()+":"+record.Memo())
+	}
+	if strings.Join(got, ",") != "invoice:c,invoice:a,order:b,order:a" {
+		t.Fatalf("Expected type ascending then memo descending, but got %v", got)
+	}
+
+	if err := customstore.RecordQuery().AddOrderBy("payload", customstore.ORDER_ASC).Validate(); err == nil {
+		t.Fatalf("Expected error for an unsupported order by column, but got nil")
+	}
+	if err := customstore.RecordQuery().AddOrderBy(customstore.COLUMN_MEMO, "sideways").Validate(); err == nil {
+		t.Fatalf("Expected error for an invalid direction, but got nil")
+	}
+}
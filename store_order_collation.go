@@ -0,0 +1,78 @@
+package customstore
+
+import (
+	"errors"
+	"regexp"
+)
+
+// ORDER_KEY_ALIAS is the alias of the collated order by expression added to
+// the select list, as the query builder only orders by plain column names
+const ORDER_KEY_ALIAS = "order_key"
+
+// collationNamePattern matches collation names accepted by the supported
+// databases, i.e. "NOCASE", "utf8mb4_german2_ci", "de-DE-x-icu" or "de_DE.utf8"
+var collationNamePattern = regexp.MustCompile(`^[A-Za-z0-9_\-.@]+$`)
+
+// collationIdentifierPattern matches collation names which can be used
+// without quoting
+var collationIdentifierPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// validateOrderByCollation checks the collation name is safe to place in SQL
+func validateOrderByCollation(collation string) error {
+	if !collationNamePattern.MatchString(collation) {
+		return errors.New("invalid order by collation " + collation)
+	}
+	return nil
+}
+
+// checkOrderByCollation returns an error if the query orders by a collation
+// the database cannot use. Collation names which are not plain identifiers,
+// i.e. "de-DE-x-icu", are quoted on PostgreSQL only.
+func (st *storeImplementation) checkOrderByCollation(query RecordQueryInterface) error {
+	if !isOrderByCollated(query) {
+		return nil
+	}
+
+	collation := query.GetOrderByCollation()
+	if err := validateOrderByCollation(collation); err != nil {
+		return err
+	}
+
+	if st.driverName() != "postgres" && !collationIdentifierPattern.MatchString(collation) {
+		return errors.New("order by collation " + collation + " is not supported by " + st.driverName() + ", only PostgreSQL accepts collation names which are not identifiers")
+	}
+
+	return nil
+}
+
+// isOrderByCollated returns whether the query orders by a collated column
+func isOrderByCollated(query RecordQueryInterface) bool {
+	return query != nil &&
+		query.IsOrderByCollationSet() &&
+		query.IsOrderBySet() &&
+		query.GetOrderBy() != "" &&
+		!query.IsPageTokenSet()
+}
+
+// orderKeySelect returns the select list fragment (with a leading comma)
+// computing the collated order key of the query, or an empty string when the
+// query does not order by a collation. Collations rejected by
+// checkOrderByCollation are left out.
+func (st *storeImplementation) orderKeySelect(query RecordQueryInterface) string {
+	if !isOrderByCollated(query) {
+		return ""
+	}
+
+	collation := query.GetOrderByCollation()
+	if !recordRowColumns[query.GetOrderBy()] || validateOrderByCollation(collation) != nil {
+		return ""
+	}
+
+	if st.driverName() == "postgres" {
+		collation = `"` + collation + `"`
+	} else if !collationIdentifierPattern.MatchString(collation) {
+		return ""
+	}
+
+	return ", " + query.GetOrderBy() + " COLLATE " + collation + " AS " + ORDER_KEY_ALIAS
+}
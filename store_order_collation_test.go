@@ -0,0 +1,100 @@
+package customstore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordListOrderByCollation(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_order_collation",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, memo := range []string{"a", "B", "c"} {
+		if err := store.RecordCreate(customstore.NewRecord("person", customstore.WithMemo(memo))); err != nil {
+			t.Fatalf("RecordCreate %q failed: %v", memo, err)
+		}
+	}
+
+	memos := func(list []customstore.RecordInterface) string {
+		result := []string{}
+		for _, record := range list {
+			result = append(result, record.Memo())
+		}
+		return strings.Join(result, ",")
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetOrderBy(customstore.COLUMN_MEMO))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if got := memos(list); got != "c,a,B" {
+		t.Fatalf("Expected byte order c,a,B, but got %s", got)
+	}
+
+	query := customstore.RecordQuery().
+		SetOrderBy(customstore.COLUMN_MEMO).
+		SetOrderByCollation("NOCASE")
+
+	list, err = store.RecordList(query)
+	if err != nil {
+		t.Fatalf("RecordList with collation failed: %v", err)
+	}
+	if got := memos(list); got != "c,B,a" {
+		t.Fatalf("Expected collated order c,B,a, but got %s", got)
+	}
+
+	list, total, err := store.RecordListWithTotal(query)
+	if err != nil {
+		t.Fatalf("RecordListWithTotal with collation failed: %v", err)
+	}
+	if got := memos(list); got != "c,B,a" || total != 3 {
+		t.Fatalf("Expected collated order c,B,a with total 3, but got %s with total %d", got, total)
+	}
+
+	rows, err := store.RecordRows(customstore.RecordQuery().
+		SetColumns([]string{customstore.COLUMN_MEMO}).
+		SetOrderBy(customstore.COLUMN_MEMO).
+		SetOrderByCollation("NOCASE"))
+	if err != nil {
+		t.Fatalf("RecordRows with collation failed: %v", err)
+	}
+	if len(rows) != 3 || rows[1].Column(customstore.COLUMN_MEMO) != "B" {
+		t.Fatalf("Expected collated rows, but got %v", rows)
+	}
+
+	// Collation names which are not identifiers are only quoted on PostgreSQL
+	if _, err := store.RecordList(customstore.RecordQuery().
+		SetOrderBy(customstore.COLUMN_MEMO).
+		SetOrderByCollation("de-DE")); err == nil {
+		t.Fatal("Expected an error for a collation which is not an identifier")
+	}
+}
+
+func TestRecordQueryOrderByCollationValidate(t *testing.T) {
+	if err := customstore.RecordQuery().SetOrderBy("memo").SetOrderByCollation("NOCASE").Validate(); err != nil {
+		t.Fatalf("Expected valid collation, but got %v", err)
+	}
+
+	if err := customstore.RecordQuery().SetOrderBy("memo").SetOrderByCollation("de-DE-x-icu").Validate(); err != nil {
+		t.Fatalf("Expected valid collation, but got %v", err)
+	}
+
+	if err := customstore.RecordQuery().SetOrderBy("memo").SetOrderByCollation("NOCASE; DROP TABLE x").Validate(); err == nil {
+		t.Fatalf("Expected error for invalid collation, but got nil")
+	}
+
+	if err := customstore.RecordQuery().SetOrderByCollation("NOCASE").Validate(); err == nil {
+		t.Fatalf("Expected error for collation without order by, but got nil")
+	}
+}
@@ -0,0 +1,62 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+)
+
+// patchPayloadAttempts is the number of times RecordPatchPayloadByID reads
+// and writes the record when it is concurrently modified
+const patchPayloadAttempts = 5
+
+// RecordPatchPayloadByID applies a JSON merge patch (RFC 7386) to the payload
+// of the record with the ID
+func (st *storeImplementation) RecordPatchPayloadByID(id string, patch string) error {
+	return st.RecordPatchPayloadByIDContext(context.Background(), id, patch)
+}
+
+// RecordPatchPayloadByIDContext is RecordPatchPayloadByID using the given
+// context. The record is updated with optimistic locking, retrying when it
+// was modified concurrently, so no concurrent change is overwritten.
+func (st *storeImplementation) RecordPatchPayloadByIDContext(ctx context.Context, id string, patch string) error {
+	if st.sharded() {
+		view, err := st.forID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return view.RecordPatchPayloadByIDContext(ctx, id, patch)
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
+	if id == "" {
+		return errors.New("record id is empty")
+	}
+
+	if _, err := decodeJSONValue(patch); err != nil {
+		return err
+	}
+
+	for attempt := 1; ; attempt++ {
+		// Read the version to compare against from the primary
+		record, err := st.RecordFindByIDContext(WithPrimaryReads(ctx), id)
+		if err != nil {
+			return err
+		}
+
+		if record == nil {
+			return ErrNotFound
+		}
+
+		if err := record.PatchPayload(patch); err != nil {
+			return err
+		}
+
+		err = st.RecordUpdateVersionedContext(ctx, record)
+		if !errors.Is(err, ErrStaleRecord) || attempt == patchPayloadAttempts {
+			return err
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package customstore_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordPatchPayloadByID(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_patch_payload",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("invoice", customstore.WithPayload(`{"status":"open","total":10,"draft":true,"customer":{"name":"John","email":"john@test.com"}}`))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if err := store.RecordPatchPayloadByID(record.ID(), `{"status":"paid","draft":null,"customer":{"email":null}}`); err != nil {
+		t.Fatalf("RecordPatchPayloadByID failed: %v", err)
+	}
+
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	expected := `{"customer":{"name":"John"},"status":"paid","total":10}`
+	if found.Payload() != expected {
+		t.Fatalf("Expected patched payload %s, but got %s", expected, found.Payload())
+	}
+	if found.Version() != 2 {
+		t.Fatalf("Expected the patch to increment the version to 2, but got %d", found.Version())
+	}
+
+	if err := store.RecordPatchPayloadByID(record.ID(), `{"status":`); err == nil {
+		t.Fatalf("Expected error for an invalid patch, but got nil")
+	}
+	if err := store.RecordPatchPayloadByID("missing", `{"a":1}`); err == nil {
+		t.Fatalf("Expected error for a missing record, but got nil")
+	}
+}
+
+func TestRecordPatchPayloadByIDConcurrent(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	// Every connection opens a new in memory database
+	db.SetMaxOpenConns(1)
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_patch_payload_concurrent",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("settings", customstore.WithPayload(`{}`))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	keys := []string{"a", "b", "c", "d"}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(keys))
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			errs <- store.RecordPatchPayloadByID(record.ID(), `{"`+key+`":true}`)
+		}(key)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("RecordPatchPayloadByID failed: %v", err)
+		}
+	}
+
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	for _, key := range keys {
+		if !found.PayloadBool(key, false) {
+			t.Fatalf("Expected every concurrent patch to be kept, but %q is missing from %s", key, found.Payload())
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// payloadIndexRegistry holds the payload keys with an ensured index, keyed
+// by record type then by key
+type payloadIndexRegistry struct {
+	mu   sync.RWMutex
+	keys map[string]map[string]bool
+}
+
+// has returns whether an index was ensured for the key of the record type
+func (r *payloadIndexRegistry) has(recordType string, key string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys[recordType][key]
+}
+
+// indexedKeys returns the keys with an ensured index, of any record type
+func (r *payloadIndexRegistry) indexedKeys() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, typeKeys := range r.keys {
+		for key := range typeKeys {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// add registers the index of the key of the record type
+func (r *payloadIndexRegistry) add(recordType string, key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.keys[recordType] == nil {
+		r.keys[recordType] = map[string]bool{}
+	}
+	r.keys[recordType][key] = true
+}
+
+// EnsurePayloadIndex creates an expression index on the text of a top level
+// payload key (SQLite and PostgreSQL), unless it exists, and registers it so
+// the AddPayloadKeyEquals and AddPayloadKeyLike filters of queries with the
+// record type (SetType) use it.
+//
+// The registration is kept in memory, call EnsurePayloadIndex for every
+// indexed key when the store is created. On a read only store an existing
+// index is registered, a missing one returns ErrReadOnly.
+func (st *storeImplementation) EnsurePayloadIndex(recordType string, key string) error {
+	return st.EnsurePayloadIndexContext(context.Background(), recordType, key)
+}
+
+// EnsurePayloadIndexContext is EnsurePayloadIndex using the given context
+func (st *storeImplementation) EnsurePayloadIndexContext(ctx context.Context, recordType string, key string) error {
+	if st.sharded() {
+		return st.forType(recordType).EnsurePayloadIndexContext(ctx, recordType, key)
+	}
+
+	if recordType == "" {
+		return errors.New("record type is required")
+	}
+
+	if key == "" {
+		return errors.New("payload key is required")
+	}
+
+	if strings.Contains(key, "?") {
+		// The key is inlined in the statements, where a question mark
+		// would be taken as a placeholder
+		return errors.New("payload key of an index cannot contain a question mark")
+	}
+
+	driver := st.driverName()
+	if driver != "sqlite" && driver != "postgres" {
+		return errors.New("payload indexes are not supported by the " + driver + " driver")
+	}
+
+	indexName := payloadIndexName(st.tableName(), key)
+
+	if st.readOnly {
+		if !st.db.Schema().HasIndex(st.tableName(), indexName) {
+			return ErrReadOnly
+		}
+		st.payloadIndexes.add(recordType, key)
+		return nil
+	}
+
+	if err := st.createPayloadIndex(ctx, st.tableName(), key); err != nil {
+		return err
+	}
+
+	st.payloadIndexes.add(recordType, key)
+	return nil
+}
+
+// createPayloadIndex creates the index of the payload key on the table
+// unless it exists
+func (st *storeImplementation) createPayloadIndex(ctx context.Context, tableName string, key string) error {
+	driver := st.driverName()
+
+	// The record type leads the index, so one index per key serves the
+	// queries of every type
+	expression := payloadIndexExpression(driver, key)
+	if driver == "postgres" {
+		expression = "(" + expression + ")"
+	}
+	sqlStr := "CREATE INDEX IF NOT EXISTS " + payloadIndexName(tableName, key) + " ON " + tableName + " (" + COLUMN_RECORD_TYPE + ", " + expression + ")"
+
+	if _, err := st.exec(st.newQuery(ctx), "EnsurePayloadIndex", sqlStr); err != nil {
+		return st.wrapError(err, "EnsurePayloadIndex", "", "", func() string {
+			return sqlStr
+		})
+	}
+	return nil
+}
+
+// payloadIndexName returns the name of the index of the payload key. The
+// key is hashed as it may contain characters not allowed in identifiers.
+func payloadIndexName(tableName string, key string) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return tableName + "_payload_" + strconv.FormatUint(uint64(hash.Sum32()), 16) + "_idx"
+}
+
+// payloadIndexExpression returns the expression extracting the text of the
+// payload key, as jsonExtractText but with the path inlined. The database
+// only uses an expression index for a query with the identical expression.
+func payloadIndexExpression(driver string, key string) string {
+	return jsonIndexExpression(driver, COLUMN_PAYLOAD, key)
+}
+
+// jsonIndexExpression returns the expression extracting the text of the key
+// of the JSON column, with the path inlined as payloadIndexExpression
+func jsonIndexExpression(driver string, column string, key string) string {
+	path, _ := jsonPathArg(driver, key).(string)
+	literal := "'" + strings.ReplaceAll(path, "'", "''") + "'"
+	return strings.Replace(jsonExtractText(driver, column), "?", literal, 1)
+}
+
+// payloadKeyCondition compares the text of the payload key using the
+// operator, through the payload index when one is ensured for the type of
+// the query. Equality on PostgreSQL is a jsonb containment otherwise, see
+// jsonContainsCondition.
+func (st *storeImplementation) payloadKeyCondition(query RecordQueryInterface, key string, operator string, value string) sqlCondition {
+	driver := st.driverName()
+	if query.IsTypeSet() && st.payloadIndexes.has(query.GetType(), key) {
+		return sqlCondition{sql: payloadIndexExpression(driver, key) + " " + operator + " ?", args: []any{value}}
+	}
+	if driver == "postgres" && operator == "=" {
+		return jsonContainsCondition(COLUMN_PAYLOAD, key, value)
+	}
+	return sqlCondition{sql: jsonExtractText(driver, COLUMN_PAYLOAD) + " " + operator + " ?", args: []any{jsonPathArg(driver, key), value}}
+}
@@ -0,0 +1,113 @@
+package customstore_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestEnsurePayloadIndex(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_payload_index",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, key := range []string{"status", "it's.key"} {
+		if err := store.EnsurePayloadIndex("invoice", key); err != nil {
+			t.Fatalf("EnsurePayloadIndex %q failed: %v", key, err)
+		}
+	}
+	if err := store.EnsurePayloadIndex("invoice", "status"); err != nil {
+		t.Fatalf("Expected EnsurePayloadIndex to be idempotent, but got %v", err)
+	}
+
+	var indexName string
+	err = db.QueryRow("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'data_payload_index' AND sql LIKE ?", `%'$."status"'%`).Scan(&indexName)
+	if err != nil {
+		t.Fatalf("Expected the payload index to be created: %v", err)
+	}
+
+	payloads := []string{
+		`{"status":"open","it's.key":"a"}`,
+		`{"status":"open","it's.key":"b"}`,
+		`{"status":"paid","it's.key":"a"}`,
+	}
+	for _, payload := range payloads {
+		if err := store.RecordCreate(customstore.NewRecord("invoice", customstore.WithPayload(payload))); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+	if err := store.RecordCreate(customstore.NewRecord("order", customstore.WithPayload(`{"status":"open"}`))); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetType("invoice").AddPayloadKeyEquals("status", "open"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 open invoices, but got %d", len(list))
+	}
+
+	list, err = store.RecordList(customstore.RecordQuery().SetType("invoice").AddPayloadKeyLike("it's.key", "a%").AddPayloadKeyEquals("status", "paid"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 paid invoice with key a, but got %d", len(list))
+	}
+
+	list, err = store.RecordList(customstore.RecordQuery().SetType("order").AddPayloadKeyEquals("status", "open"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 open order, but got %d", len(list))
+	}
+
+	// The filter expression of an indexed key is served by the index
+	rows, err := db.Query(`EXPLAIN QUERY PLAN SELECT id FROM data_payload_index WHERE record_type = ? AND CAST(json_extract(NULLIF(payload, ''), '$."status"') AS TEXT) = ?`, "invoice", "open")
+	if err != nil {
+		t.Fatalf("EXPLAIN QUERY PLAN failed: %v", err)
+	}
+	defer rows.Close()
+	plan := ""
+	for rows.Next() {
+		var id, parent, notUsed int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notUsed, &detail); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		plan += detail + "\n"
+	}
+	if !strings.Contains(plan, indexName) {
+		t.Fatalf("Expected the query plan to use %s, but got %s", indexName, plan)
+	}
+
+	view := store.ReadOnlyView()
+	if err := view.EnsurePayloadIndex("order", "status"); err != nil {
+		t.Fatalf("Expected a read only view to register an existing index, but got %v", err)
+	}
+	if err := view.EnsurePayloadIndex("order", "total"); !errors.Is(err, customstore.ErrReadOnly) {
+		t.Fatalf("Expected ErrReadOnly for a missing index on a read only view, but got %v", err)
+	}
+
+	if err := store.EnsurePayloadIndex("", "status"); err == nil {
+		t.Fatalf("Expected error for an empty record type, but got nil")
+	}
+	if err := store.EnsurePayloadIndex("invoice", ""); err == nil {
+		t.Fatalf("Expected error for an empty key, but got nil")
+	}
+	if err := store.EnsurePayloadIndex("invoice", "what?"); err == nil {
+		t.Fatalf("Expected error for a key with a question mark, but got nil")
+	}
+}
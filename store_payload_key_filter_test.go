@@ -0,0 +1,78 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordListPayloadKeyFilters(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_payload_key_filter",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	payloads := []map[string]any{
+		{"name": "John Smith", "status": "active", "age": 30},
+		{"name": "Jane Smith", "status": "inactive", "age": 25},
+		{"name": "Bob Jones", "status": "active", "age": 30, "note": "status active"},
+		{"name": "Alice", "description": "status: active"},
+	}
+	for i, payload := range payloads {
+		if err := store.RecordCreate(customstore.NewRecord("person", customstore.WithPayloadMap(payload))); err != nil {
+			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
+		}
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().AddPayloadKeyEquals("status", "active"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 records with status active, but got %d", count)
+	}
+
+	count, err = store.RecordCount(customstore.RecordQuery().AddPayloadKeyEquals("age", "30"))
+	if err != nil {
+		t.Fatalf("RecordCount by number failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 records aged 30, but got %d", count)
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().
+		AddPayloadKeyLike("name", "%Smith").
+		AddPayloadKeyEquals("status", "active"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 || list[0].PayloadString("name", "") != "John Smith" {
+		t.Fatalf("Expected John Smith only, but got %d records", len(list))
+	}
+
+	// Keys with special characters are matched literally
+	special := map[string]any{`dir\name`: "backslash", `say "hi"`: "quote", "a.b": "dot"}
+	if err := store.RecordCreate(customstore.NewRecord("special", customstore.WithPayloadMap(special))); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	for key, value := range special {
+		count, err := store.RecordCount(customstore.RecordQuery().SetType("special").AddPayloadKeyEquals(key, value.(string)))
+		if err != nil {
+			t.Fatalf("RecordCount for key %q failed: %v", key, err)
+		}
+		if count != 1 {
+			t.Fatalf("Expected the record to match key %q, but got %d", key, count)
+		}
+	}
+
+	if err := customstore.RecordQuery().AddPayloadKeyEquals("", "x").Validate(); err == nil {
+		t.Fatalf("Expected error for an empty payload key, but got nil")
+	}
+}
@@ -0,0 +1,178 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"strconv"
+	"sync"
+)
+
+// payloadMigrationBatchSize is the number of records migrated per batch
+const payloadMigrationBatchSize = 100
+
+// PayloadMigrationFunc upgrades a payload from one schema version to the next
+type PayloadMigrationFunc func(payload map[string]any) (map[string]any, error)
+
+type payloadMigration struct {
+	toVersion int
+	migrate   PayloadMigrationFunc
+}
+
+// payloadMigrationRegistry holds the registered migrations, keyed by record
+// type then by the version they upgrade from
+type payloadMigrationRegistry struct {
+	mu         sync.RWMutex
+	migrations map[string]map[int]payloadMigration
+}
+
+// RegisterPayloadMigration registers the function upgrading the payloads of
+// the record type from fromVersion to toVersion.
+//
+// Records without a payload version are at version 1. New records of the
+// type are stamped with the latest registered version.
+func (st *storeImplementation) RegisterPayloadMigration(recordType string, fromVersion int, toVersion int, fn PayloadMigrationFunc) error {
+	if recordType == "" {
+		return errors.New("record type is required")
+	}
+
+	if fromVersion < 1 || toVersion <= fromVersion {
+		return errors.New("payload migration versions must satisfy 1 <= from < to")
+	}
+
+	if fn == nil {
+		return errors.New("payload migration function is required")
+	}
+
+	registry := st.payloadMigrations
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if registry.migrations[recordType] == nil {
+		registry.migrations[recordType] = map[int]payloadMigration{}
+	}
+
+	if _, exists := registry.migrations[recordType][fromVersion]; exists {
+		return errors.New("payload migration from version " + strconv.Itoa(fromVersion) + " is already registered for " + recordType)
+	}
+
+	registry.migrations[recordType][fromVersion] = payloadMigration{toVersion: toVersion, migrate: fn}
+	return nil
+}
+
+// MigratePayloads upgrades the stored payloads of the record type (soft
+// deleted ones included) to the latest registered version, in batches.
+// Returns the number of records migrated.
+func (st *storeImplementation) MigratePayloads(recordType string) (int, error) {
+	if st.sharded() {
+		return st.forType(recordType).MigratePayloads(recordType)
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	if st.db == nil {
+		return 0, errors.New("database is not initialized")
+	}
+
+	if recordType == "" {
+		return 0, errors.New("record type is required")
+	}
+
+	latest := st.latestPayloadVersion(recordType)
+	if latest == 0 {
+		return 0, errors.New("no payload migration is registered for " + recordType)
+	}
+
+	query := NewRecordQuery().
+		SetType(recordType).
+		SetOrderBy(COLUMN_ID).
+		SetLimit(payloadMigrationBatchSize).
+		SetSoftDeletedIncluded(true).
+		SetExpiredIncluded(true)
+
+	migrated := 0
+	for {
+		list, err := st.RecordListContext(WithPrimaryReads(context.Background()), query)
+		if err != nil {
+			return migrated, err
+		}
+
+		for _, record := range list {
+			if record.PayloadVersion() >= latest {
+				continue
+			}
+
+			if err := st.migratePayload(record, latest); err != nil {
+				return migrated, errors.New("migrating payload of record " + record.ID() + ": " + err.Error())
+			}
+
+			if err := st.RecordUpdate(record); err != nil {
+				return migrated, err
+			}
+
+			migrated++
+		}
+
+		if len(list) == 0 {
+			return migrated, nil
+		}
+
+		token, err := NewPageToken(COLUMN_ID, list[len(list)-1])
+		if err != nil {
+			return migrated, err
+		}
+		query.SetPageToken(token)
+	}
+}
+
+// migratePayload runs the migration chain on the record payload, up to the
+// latest version
+func (st *storeImplementation) migratePayload(record RecordInterface, latest int) error {
+	// Copied under the lock, registrations add to the map of the type
+	st.payloadMigrations.mu.RLock()
+	migrations := maps.Clone(st.payloadMigrations.migrations[record.Type()])
+	st.payloadMigrations.mu.RUnlock()
+
+	payload, err := record.PayloadMap()
+	if err != nil {
+		return err
+	}
+
+	version := record.PayloadVersion()
+	for version < latest {
+		migration, exists := migrations[version]
+		if !exists {
+			return errors.New("no payload migration from version " + strconv.Itoa(version))
+		}
+
+		payload, err = migration.migrate(payload)
+		if err != nil {
+			return err
+		}
+
+		version = migration.toVersion
+	}
+
+	if err := record.SetPayloadMap(payload); err != nil {
+		return err
+	}
+
+	return record.SetPayloadVersion(version)
+}
+
+// latestPayloadVersion returns the highest registered payload version of
+// the record type, 0 if no migrations are registered
+func (st *storeImplementation) latestPayloadVersion(recordType string) int {
+	st.payloadMigrations.mu.RLock()
+	defer st.payloadMigrations.mu.RUnlock()
+
+	latest := 0
+	for _, migration := range st.payloadMigrations.migrations[recordType] {
+		if migration.toVersion > latest {
+			latest = migration.toVersion
+		}
+	}
+	return latest
+}
@@ -0,0 +1,134 @@
+package customstore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestMigratePayloads(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_payload_migrations",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	oldRecords := []customstore.RecordInterface{}
+	for _, name := range []string{"John", "Jane"} {
+		rec := customstore.NewRecord("person", customstore.WithPayloadMap(map[string]any{"name": name}))
+		if err := store.RecordCreate(rec); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		oldRecords = append(oldRecords, rec)
+	}
+
+	if err := store.RegisterPayloadMigration("person", 1, 2, func(payload map[string]any) (map[string]any, error) {
+		payload["full_name"] = payload["name"]
+		delete(payload, "name")
+		return payload, nil
+	}); err != nil {
+		t.Fatalf("RegisterPayloadMigration 1 -> 2 failed: %v", err)
+	}
+	if err := store.RegisterPayloadMigration("person", 2, 3, func(payload map[string]any) (map[string]any, error) {
+		payload["full_name"] = strings.ToUpper(payload["full_name"].(string))
+		return payload, nil
+	}); err != nil {
+		t.Fatalf("RegisterPayloadMigration 2 -> 3 failed: %v", err)
+	}
+	if err := store.RegisterPayloadMigration("person", 1, 3, func(payload map[string]any) (map[string]any, error) {
+		return payload, nil
+	}); err == nil {
+		t.Fatalf("Expected error registering a duplicate migration, but got nil")
+	}
+
+	current := customstore.NewRecord("person", customstore.WithPayloadMap(map[string]any{"full_name": "Already Current"}))
+	if err := store.RecordCreate(current); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if current.PayloadVersion() != 3 {
+		t.Fatalf("Expected new record to be stamped with version 3, but got %d", current.PayloadVersion())
+	}
+
+	migrated, err := store.MigratePayloads("person")
+	if err != nil {
+		t.Fatalf("MigratePayloads failed: %v", err)
+	}
+	if migrated != 2 {
+		t.Fatalf("Expected 2 records migrated, but got %d", migrated)
+	}
+
+	found, err := store.RecordFindByID(oldRecords[0].ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found.PayloadVersion() != 3 {
+		t.Fatalf("Expected migrated record at version 3, but got %d", found.PayloadVersion())
+	}
+	if found.PayloadString("full_name", "") != "JOHN" || found.PayloadHasKey("name") {
+		t.Fatalf("Unexpected migrated payload %s", found.Payload())
+	}
+
+	found, err = store.RecordFindByID(current.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found.PayloadString("full_name", "") != "Already Current" {
+		t.Fatalf("Expected current record to be left untouched, but got %s", found.Payload())
+	}
+
+	migrated, err = store.MigratePayloads("person")
+	if err != nil {
+		t.Fatalf("Second MigratePayloads failed: %v", err)
+	}
+	if migrated != 0 {
+		t.Fatalf("Expected no records migrated on second run, but got %d", migrated)
+	}
+
+	if _, err := store.MigratePayloads("unknown"); err == nil {
+		t.Fatalf("Expected error migrating a type without migrations, but got nil")
+	}
+}
+
+func TestMigratePayloadsConcurrentRegistration(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_payload_migrations_concurrent",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if err := store.RecordCreate(customstore.NewRecord("person", customstore.WithPayload(`{"name":"John"}`))); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RegisterPayloadMigration("person", 1, 2, func(payload map[string]any) (map[string]any, error) {
+		return payload, nil
+	}); err != nil {
+		t.Fatalf("RegisterPayloadMigration failed: %v", err)
+	}
+
+	// Registering migrations of the type while migrating, run with -race
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for version := 10; version < 60; version++ {
+			_ = store.RegisterPayloadMigration("person", version, version+1, func(payload map[string]any) (map[string]any, error) {
+				return payload, nil
+			})
+		}
+	}()
+
+	_, _ = store.MigratePayloads("person")
+	<-done
+}
@@ -0,0 +1,68 @@
+package customstore
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// regexSupport caches whether the database supports the REGEXP operator,
+// which SQLite only provides when a regexp function is registered
+type regexSupport struct {
+	once      sync.Once
+	supported bool
+}
+
+// payloadRegexExpression returns the comparison of the payload with a
+// regular expression for the driver, false if the driver has none
+func payloadRegexExpression(driver string) (string, bool) {
+	switch driver {
+	case "postgres":
+		return COLUMN_PAYLOAD + " ~ ?", true
+	case "mysql", "sqlite":
+		return COLUMN_PAYLOAD + " REGEXP ?", true
+	default:
+		return "", false
+	}
+}
+
+// regexSupported returns whether the payload regex filters can run on the
+// database, probing SQLite once for a registered regexp function
+func (st *storeImplementation) regexSupported() bool {
+	driver := st.driverName()
+	if _, ok := payloadRegexExpression(driver); !ok {
+		return false
+	}
+	if driver != "sqlite" || st.regex == nil {
+		return true
+	}
+
+	st.regex.once.Do(func() {
+		sqlStr := "SELECT 'a' REGEXP 'a' AS matched"
+
+		var rows []map[string]any
+		start := time.Now()
+		err := st.newQuery(context.Background()).Raw(sqlStr).Get(&rows)
+		st.logQuery("RegexSupported", start, rawStatement(sqlStr))
+		st.regex.supported = err == nil
+	})
+	return st.regex.supported
+}
+
+// queryHasPayloadSearchRegex returns whether the query or any alternative of
+// its OR groups matches the payload by regular expression
+func queryHasPayloadSearchRegex(query RecordQueryInterface) bool {
+	if len(query.GetPayloadSearchRegex()) > 0 {
+		return true
+	}
+
+	for _, group := range query.GetOrGroups() {
+		for _, alternative := range group {
+			if queryHasPayloadSearchRegex(alternative) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
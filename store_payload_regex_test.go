@@ -0,0 +1,125 @@
+package customstore_test
+
+import (
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dracory/customstore"
+	"modernc.org/sqlite"
+)
+
+var registerRegexpOnce sync.Once
+
+// registerRegexp registers the regexp function SQLite calls for REGEXP with
+// the connections opened afterwards
+func registerRegexp(t *testing.T) {
+	t.Helper()
+
+	var err error
+	registerRegexpOnce.Do(func() {
+		err = sqlite.RegisterDeterministicScalarFunction("regexp", 2, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			pattern, _ := args[0].(string)
+			value, _ := args[1].(string)
+			return regexp.MatchString(pattern, value)
+		})
+	})
+	if err != nil {
+		t.Fatalf("Registering regexp failed: %v", err)
+	}
+}
+
+func TestRecordQueryPayloadSearchRegexSQL(t *testing.T) {
+	query := customstore.NewRecordQuery().AddPayloadSearchRegex(`"sku":"[A-Z]{3}-[0-9]+"`)
+
+	expected := map[string]string{
+		"sqlite":   "(payload REGEXP ?) = TRUE",
+		"mysql":    "(payload REGEXP ?) = TRUE",
+		"postgres": "(payload ~ $3) = TRUE",
+	}
+
+	for driver, filter := range expected {
+		sqlStr, _, err := query.ToSQL(driver, "records")
+		if err != nil {
+			t.Fatalf("ToSQL for %s failed: %v", driver, err)
+		}
+		if !strings.Contains(sqlStr, filter) {
+			t.Fatalf("Expected the filter %s for %s, but got %s", filter, driver, sqlStr)
+		}
+	}
+
+	if _, _, err := query.ToSQL("sqlserver", "records"); !errors.Is(err, customstore.ErrRegexUnsupported) {
+		t.Fatalf("Expected ErrRegexUnsupported for SQL Server, but got %v", err)
+	}
+
+	if err := customstore.NewRecordQuery().AddPayloadSearchRegex("").Validate(); err == nil {
+		t.Fatal("Expected an error for an empty regex")
+	}
+}
+
+func TestStorePayloadSearchRegexUnsupported(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	if _, err := db.Exec("SELECT 'a' REGEXP 'a'"); err == nil {
+		t.Skip("regexp is registered")
+	}
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_payload_regex_unsupported",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	_, err = store.RecordList(customstore.NewRecordQuery().AddPayloadSearchRegex("^a"))
+	if !errors.Is(err, customstore.ErrRegexUnsupported) {
+		t.Fatalf("Expected ErrRegexUnsupported, got %v", err)
+	}
+}
+
+func TestStorePayloadSearchRegex(t *testing.T) {
+	registerRegexp(t)
+
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_payload_regex",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, payload := range []string{`{"sku":"ABC-123"}`, `{"sku":"abc-123"}`, `{"sku":"ABC-X"}`} {
+		if err := store.RecordCreate(customstore.NewRecord("product", customstore.WithPayload(payload))); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	count, err := store.RecordCount(customstore.NewRecordQuery().AddPayloadSearchRegex(`"sku":"[A-Z]{3}-[0-9]+"`))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 record, got %d", count)
+	}
+
+	// Patterns are ANDed
+	count, err = store.RecordCount(customstore.NewRecordQuery().
+		AddPayloadSearchRegex(`(?i)"sku":"abc-`).
+		AddPayloadSearchRegex(`[0-9]+"`))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 records, got %d", count)
+	}
+}
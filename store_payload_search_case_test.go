@@ -0,0 +1,82 @@
+package customstore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordQueryPayloadSearchCaseInsensitiveSQL(t *testing.T) {
+	query := customstore.NewRecordQuery().
+		SetType("person").
+		AddPayloadSearch("John").
+		AddPayloadSearchNot("Smith").
+		SetPayloadSearchCaseInsensitive(true)
+
+	expected := map[string][]string{
+		"sqlite":   {"LOWER(payload) LIKE LOWER(?)", "LOWER(payload) NOT LIKE LOWER(?)"},
+		"mysql":    {"LOWER(payload) LIKE LOWER(?)", "LOWER(payload) NOT LIKE LOWER(?)"},
+		"postgres": {"payload ILIKE $", "payload NOT ILIKE $"},
+	}
+
+	for driver, filters := range expected {
+		sqlStr, _, err := query.ToSQL(driver, "records")
+		if err != nil {
+			t.Fatalf("ToSQL for %s failed: %v", driver, err)
+		}
+		for _, filter := range filters {
+			if !strings.Contains(sqlStr, filter) {
+				t.Fatalf("Expected the filter %s for %s, but got %s", filter, driver, sqlStr)
+			}
+		}
+	}
+
+	sqlStr, _, err := customstore.NewRecordQuery().AddPayloadSearch("John").ToSQL("postgres", "records")
+	if err != nil {
+		t.Fatalf("ToSQL failed: %v", err)
+	}
+	if strings.Contains(sqlStr, "ILIKE") {
+		t.Fatalf("Expected a case sensitive search by default, but got %s", sqlStr)
+	}
+}
+
+func TestStorePayloadSearchCaseInsensitive(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_payload_search_case",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, payload := range []string{`{"name":"john smith"}`, `{"name":"JOHN DOE"}`, `{"name":"Jane Doe"}`} {
+		if err := store.RecordCreate(customstore.NewRecord("person", customstore.WithPayload(payload))); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	count, err := store.RecordCount(customstore.NewRecordQuery().
+		AddPayloadSearch("John").
+		SetPayloadSearchCaseInsensitive(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 records, got %d", count)
+	}
+
+	count, err = store.RecordCount(customstore.NewRecordQuery().
+		AddPayloadSearchNot("doe").
+		SetPayloadSearchCaseInsensitive(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 record, got %d", count)
+	}
+}
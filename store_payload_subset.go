@@ -0,0 +1,104 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// RecordListPayloadSubset returns the requested payload keys of the records
+// matching the query, keyed by record ID.
+//
+// The keys are extracted by the database JSON functions, so only the
+// requested values are transferred and decoded. Keys missing from a payload
+// (or holding null) are omitted from its map.
+func (st *storeImplementation) RecordListPayloadSubset(query RecordQueryInterface, keys []string) (map[string]map[string]any, error) {
+	if st.sharded() {
+		view, err := st.forQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		return view.RecordListPayloadSubset(query, keys)
+	}
+
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	if err := st.checkQueryGuards(query); err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("keys are required")
+	}
+
+	driver := st.driverName()
+
+	selects := []string{COLUMN_ID}
+	args := make([]any, 0, len(keys))
+	for i, key := range keys {
+		selects = append(selects, jsonExtractJSON(driver, COLUMN_PAYLOAD)+" AS k"+strconv.Itoa(i))
+		args = append(args, jsonPathArg(driver, key))
+	}
+
+	q := st.buildQuery(context.Background(), query).
+		Table(st.tableName()).
+		Select(strings.Join(selects, ", ")+st.orderKeySelect(query), args...)
+
+	if st.dryRun("RecordListPayloadSubset", func() (string, []any) { return selectSQL(q) }) {
+		return map[string]map[string]any{}, nil
+	}
+
+	var rows []map[string]any
+	start := time.Now()
+	err := st.retry(context.Background(), "RecordListPayloadSubset", func() error {
+		return q.Get(&rows)
+	})
+	st.logQuery("RecordListPayloadSubset", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return nil, st.wrapError(err, "RecordListPayloadSubset", queryRecordID(query), queryRecordType(query), func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	result := make(map[string]map[string]any, len(rows))
+	for _, row := range rows {
+		subset := make(map[string]any, len(keys))
+		for i, key := range keys {
+			value, err := decodeJSONColumn(row["k"+strconv.Itoa(i)])
+			if err != nil {
+				return nil, err
+			}
+
+			if value != nil {
+				subset[key] = value
+			}
+		}
+		result[cast.ToString(row[COLUMN_ID])] = subset
+	}
+
+	return result, nil
+}
+
+// decodeJSONColumn decodes a value selected with jsonExtractJSON, returning
+// nil for SQL NULL and JSON null
+func decodeJSONColumn(raw any) (any, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	var value any
+	if err := json.Unmarshal([]byte(cast.ToString(raw)), &value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
@@ -0,0 +1,64 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordListPayloadSubset(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_payload_subset",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	full := customstore.NewRecord("person", customstore.WithPayload(`{"name":"John","address":{"city":"Paris"},"bio":"long text","nickname":null}`))
+	empty := customstore.NewRecord("person")
+	other := customstore.NewRecord("company", customstore.WithPayload(`{"name":"Acme"}`))
+
+	for _, rec := range []customstore.RecordInterface{full, empty, other} {
+		if err := store.RecordCreate(rec); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	subsets, err := store.RecordListPayloadSubset(customstore.RecordQuery().SetType("person"), []string{"name", "address", "nickname", "missing"})
+	if err != nil {
+		t.Fatalf("RecordListPayloadSubset failed: %v", err)
+	}
+
+	if len(subsets) != 2 {
+		t.Fatalf("Expected 2 records, but got %d", len(subsets))
+	}
+
+	subset := subsets[full.ID()]
+	if len(subset) != 2 {
+		t.Fatalf("Expected 2 keys, but got %d: %v", len(subset), subset)
+	}
+	if subset["name"] != "John" {
+		t.Fatalf("Expected name John, but got %v", subset["name"])
+	}
+	address, ok := subset["address"].(map[string]any)
+	if !ok || address["city"] != "Paris" {
+		t.Fatalf("Expected decoded address with city Paris, but got %v", subset["address"])
+	}
+
+	emptySubset, exists := subsets[empty.ID()]
+	if !exists {
+		t.Fatalf("Expected record with empty payload to be listed")
+	}
+	if len(emptySubset) != 0 {
+		t.Fatalf("Expected no keys for empty payload, but got %v", emptySubset)
+	}
+
+	if _, err := store.RecordListPayloadSubset(customstore.RecordQuery(), nil); err == nil {
+		t.Fatalf("Expected error when no keys are given, but got nil")
+	}
+}
@@ -0,0 +1,81 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestProtectedRecord(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_protected",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	protected := customstore.NewRecord("config", customstore.WithProtected())
+	if !protected.IsProtected() {
+		t.Fatalf("Expected record to be protected")
+	}
+	unprotected := customstore.NewRecord("config")
+	for _, record := range []customstore.RecordInterface{protected, unprotected} {
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	if err := store.RecordSoftDelete(protected); !errors.Is(err, customstore.ErrProtectedRecord) {
+		t.Fatalf("Expected ErrProtectedRecord on soft delete, but got %v", err)
+	}
+	if err := store.RecordDeleteByID(protected.ID()); !errors.Is(err, customstore.ErrProtectedRecord) {
+		t.Fatalf("Expected ErrProtectedRecord on delete, but got %v", err)
+	}
+
+	found, err := store.RecordFindByID(protected.ID())
+	if err != nil || found == nil {
+		t.Fatalf("Expected protected record to be kept, but got %v, %v", found, err)
+	}
+
+	if err := store.RecordSoftDelete(unprotected); err != nil {
+		t.Fatalf("RecordSoftDelete of unprotected record failed: %v", err)
+	}
+	if err := store.RecordDeleteByID("missing"); err != nil {
+		t.Fatalf("Expected deleting a missing record to succeed, but got %v", err)
+	}
+
+	// Forced soft deletes are not purged
+	if err := store.RecordSoftDelete(protected, customstore.WithForceDelete()); err != nil {
+		t.Fatalf("Forced RecordSoftDelete failed: %v", err)
+	}
+	results := store.RunMaintenance(context.Background(), customstore.MaintenanceConfig{
+		PurgeSoftDeleted:      true,
+		PurgeSoftDeletedAfter: -time.Hour,
+	})
+	if len(results) != 1 || results[0].Err != nil || results[0].Affected != 1 {
+		t.Fatalf("Expected the unprotected record only to be purged, but got %+v", results)
+	}
+
+	if err := store.RecordDelete(protected, customstore.WithForceDelete()); err != nil {
+		t.Fatalf("Forced RecordDelete failed: %v", err)
+	}
+	count, err := store.RecordCount(customstore.RecordQuery().SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected no records left, but got %d", count)
+	}
+
+	if err := protected.SetProtected(false); err != nil || protected.IsProtected() {
+		t.Fatalf("Expected record to be unprotected, but got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package customstore
+
+import (
+	"context"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// RecordPurgeSoftDeleted hard deletes the records soft deleted more than
+// olderThan ago, optionally only those of the given types, and returns the
+// number of records deleted. Protected records are kept.
+func (st *storeImplementation) RecordPurgeSoftDeleted(olderThan time.Duration, recordTypes ...string) (int64, error) {
+	return st.RecordPurgeSoftDeletedContext(context.Background(), olderThan, recordTypes...)
+}
+
+// RecordPurgeSoftDeletedContext is RecordPurgeSoftDeleted using the given context
+func (st *storeImplementation) RecordPurgeSoftDeletedContext(ctx context.Context, olderThan time.Duration, recordTypes ...string) (int64, error) {
+	if st.sharded() {
+		total := int64(0)
+		for _, view := range st.tableViews() {
+			affected, err := view.RecordPurgeSoftDeletedContext(ctx, olderThan, recordTypes...)
+			if err != nil {
+				return total, err
+			}
+			total += affected
+		}
+		return total, nil
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	cutoff := st.timestamp(st.now().Add(-olderThan))
+
+	unlock := st.lockWrite()
+	defer unlock()
+
+	purge := func(tableName string) (int64, error) {
+		return st.purgeRows(ctx, "RecordPurgeSoftDeleted", tableName, func(q contractsorm.Query) contractsorm.Query {
+			q = st.whereTenant(q.Where(COLUMN_SOFT_DELETED_AT+" <= ?", cutoff))
+
+			if len(recordTypes) > 0 {
+				anyList := make([]any, len(recordTypes))
+				for i, v := range recordTypes {
+					anyList[i] = v
+				}
+				q = q.WhereIn(COLUMN_RECORD_TYPE, anyList)
+			}
+
+			// Protected records are kept, even if they were soft deleted by force
+			return st.whereNotProtected(q)
+		})
+	}
+
+	affected, err := purge(st.tableName())
+	if err != nil {
+		return 0, err
+	}
+
+	// Double write of a running MigrateToTable
+	if target := st.migrationTarget(); target != "" {
+		if _, err := purge(target); err != nil {
+			return 0, err
+		}
+	}
+
+	return affected, nil
+}
@@ -0,0 +1,90 @@
+package customstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordPurgeSoftDeleted(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	clock := &fixedClock{now: time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_purge_soft_deleted",
+		AutomigrateEnabled: true,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	oldSession := customstore.NewRecord("session")
+	oldToken := customstore.NewRecord("token")
+	oldProtected := customstore.NewRecord("session", customstore.WithProtected())
+	recentSession := customstore.NewRecord("session")
+	activeSession := customstore.NewRecord("session")
+
+	for _, record := range []customstore.RecordInterface{oldSession, oldToken, oldProtected, recentSession, activeSession} {
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	for _, record := range []customstore.RecordInterface{oldSession, oldToken} {
+		if err := store.RecordSoftDelete(record); err != nil {
+			t.Fatalf("RecordSoftDelete failed: %v", err)
+		}
+	}
+	if err := store.RecordSoftDelete(oldProtected, customstore.WithForceDelete()); err != nil {
+		t.Fatalf("RecordSoftDelete with force failed: %v", err)
+	}
+
+	clock.now = clock.now.Add(40 * 24 * time.Hour)
+	if err := store.RecordSoftDelete(recentSession); err != nil {
+		t.Fatalf("RecordSoftDelete failed: %v", err)
+	}
+
+	purged, err := store.RecordPurgeSoftDeleted(30*24*time.Hour, "session")
+	if err != nil {
+		t.Fatalf("RecordPurgeSoftDeleted failed: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("Expected 1 old session purged, but got %d", purged)
+	}
+
+	remaining := map[string]bool{}
+	list, err := store.RecordList(customstore.RecordQuery().SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	for _, record := range list {
+		remaining[record.ID()] = true
+	}
+
+	if remaining[oldSession.ID()] {
+		t.Fatalf("Expected the old soft deleted session to be purged")
+	}
+	for name, record := range map[string]customstore.RecordInterface{
+		"old token":         oldToken,
+		"protected session": oldProtected,
+		"recent session":    recentSession,
+		"active session":    activeSession,
+	} {
+		if !remaining[record.ID()] {
+			t.Fatalf("Expected the %s to be kept", name)
+		}
+	}
+
+	purged, err = store.RecordPurgeSoftDeleted(30 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("RecordPurgeSoftDeleted without types failed: %v", err)
+	}
+	if purged != 1 {
+		t.Fatalf("Expected the old token purged, but got %d", purged)
+	}
+}
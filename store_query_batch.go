@@ -0,0 +1,55 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// QueryBatch executes several record queries over a single connection,
+// within one transaction so that all lists come from a consistent state,
+// i.e. for dashboards assembling multiple lists.
+//
+// The results are in the order of the queries. When one query fails no
+// results are returned.
+func (st *storeImplementation) QueryBatch(queries []RecordQueryInterface) ([][]RecordInterface, error) {
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	for i, query := range queries {
+		if query == nil {
+			continue
+		}
+		if err := query.Validate(); err != nil {
+			return nil, errors.New("query " + strconv.Itoa(i) + ": " + err.Error())
+		}
+	}
+
+	results := make([][]RecordInterface, len(queries))
+	if len(queries) == 0 {
+		return results, nil
+	}
+
+	err := st.transaction(func(tx contractsorm.Query) error {
+		bound := *st
+		bound.tx = tx
+
+		for i, query := range queries {
+			list, err := bound.recordList(context.Background(), query)
+			if err != nil {
+				return err
+			}
+			results[i] = list
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,58 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestQueryBatch(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_query_batch",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, recordType := range []string{"invoice", "invoice", "order", "person"} {
+		if err := store.RecordCreate(customstore.NewRecord(recordType)); err != nil {
+			t.Fatalf("RecordCreate %s failed: %v", recordType, err)
+		}
+	}
+
+	results, err := store.QueryBatch([]customstore.RecordQueryInterface{
+		customstore.RecordQuery().SetType("invoice"),
+		customstore.RecordQuery().SetType("order"),
+		customstore.RecordQuery().SetType("missing"),
+	})
+	if err != nil {
+		t.Fatalf("QueryBatch failed: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, but got %d", len(results))
+	}
+	if len(results[0]) != 2 || len(results[1]) != 1 || len(results[2]) != 0 {
+		t.Fatalf("Expected 2, 1 and 0 records, but got %d, %d and %d", len(results[0]), len(results[1]), len(results[2]))
+	}
+	if results[1][0].Type() != "order" {
+		t.Fatalf("Expected results in query order, but got type %q", results[1][0].Type())
+	}
+
+	if _, err := store.QueryBatch([]customstore.RecordQueryInterface{
+		customstore.RecordQuery().SetType("invoice"),
+		customstore.RecordQuery().SetOrderBy(customstore.COLUMN_MEMO).SetOrderByCollation("NOCASE; --"),
+	}); err == nil {
+		t.Fatalf("Expected error for an invalid query, but got nil")
+	}
+
+	if _, err := store.ReadOnlyView().QueryBatch([]customstore.RecordQueryInterface{
+		customstore.RecordQuery().SetType("invoice"),
+	}); err != nil {
+		t.Fatalf("Expected QueryBatch to work on a read only view, but got %v", err)
+	}
+}
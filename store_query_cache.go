@@ -0,0 +1,580 @@
+package customstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// queryCacheAllGeneration is the generation of every cached query, bumped
+// by the writes of which the record type is not known
+const queryCacheAllGeneration = "*all"
+
+// queryCacheUntypedGeneration is the generation of the cached queries not
+// filtered by a single record type, bumped by every write
+const queryCacheUntypedGeneration = "*"
+
+var _ StoreInterface = (*queryCachedStoreImplementation)(nil)
+
+// queryCachedStoreImplementation caches the results of RecordList and
+// RecordCount over a store, invalidated by the writes through it
+type queryCachedStoreImplementation struct {
+	StoreInterface
+	store     *storeImplementation
+	cache     CacheInterface
+	ttl       time.Duration
+	keyPrefix string
+}
+
+// fixedClock is a clock always returning the same time
+type fixedClock struct {
+	time time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.time
+}
+
+// NewQueryCachedStore returns the store with the results of RecordList and
+// RecordCount cached for the ttl, keyed by the SQL and arguments of the
+// query, i.e. for the list endpoints of rarely changing types.
+//
+// Cached results are invalidated by type: a create, update, upsert, delete,
+// soft delete or restore through the returned store invalidates the queries
+// filtered by the type of the record (SetType) and the queries not filtered
+// by a single type. An update invalidates both the stored and the new type
+// of the record. Writes by ID, of which the type is not known, and the
+// imports, restores, rollbacks, purges and transactions invalidate every
+// cached query. Invalidation bumps a generation kept in the cache, so no
+// keys need to be listed.
+//
+// As with NewCachedStore, writes bypassing the returned store are only seen
+// once the cached results expire, as are records expiring in the meantime.
+// Queries excluding the payload or metas are not cached. The store must be
+// created by NewStore (or be a view of one, i.e. ForTenant).
+func NewQueryCachedStore(store StoreInterface, cache CacheInterface, ttl time.Duration) (StoreInterface, error) {
+	st, ok := store.(*storeImplementation)
+	if !ok || st == nil {
+		return nil, errors.New("store must be created by NewStore")
+	}
+
+	if cache == nil {
+		return nil, errors.New("cache is nil")
+	}
+
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+
+	return &queryCachedStoreImplementation{
+		StoreInterface: store,
+		store:          st,
+		cache:          cache,
+		ttl:            ttl,
+		keyPrefix:      "customstore:" + st.tableName() + ":query:",
+	}, nil
+}
+
+// == KEYS ==
+
+// queryKey returns the cache key of the result of the query for the
+// operation, false if the query is not cached. The key hashes the SQL and
+// arguments of the query built with a fixed clock, so the current time
+// compared by the soft delete and expiry filters does not change it.
+func (c *queryCachedStoreImplementation) queryKey(ctx context.Context, op string, query RecordQueryInterface) (string, bool) {
+	if query != nil && (query.IsExcludePayload() || query.IsExcludeMetas()) {
+		return "", false
+	}
+
+	generations := []string{queryCacheAllGeneration, queryCacheUntypedGeneration}
+	if query != nil && query.IsTypeSet() {
+		generations[1] = query.GetType()
+	}
+
+	hash := sha256.New()
+	for _, name := range generations {
+		generation, err := c.generation(ctx, name)
+		if err != nil {
+			return "", false
+		}
+		hash.Write([]byte(generation + "\x00"))
+	}
+
+	normalized := *c.store
+	normalized.clock = fixedClock{}
+	q := normalized.buildQuery(ctx, query)
+	var sqlStr string
+	var args []any
+	if op == "RecordCount" {
+		sqlStr, args = countSQL(q.Table(normalized.tableName()))
+	} else {
+		sqlStr, args = selectSQL(normalized.selectQuery(q, query))
+	}
+
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return "", false
+	}
+	hash.Write([]byte(op + "\x00" + sqlStr + "\x00"))
+	hash.Write(argsJSON)
+
+	return c.keyPrefix + hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// generationKey returns the cache key of the generation
+func (c *queryCachedStoreImplementation) generationKey(name string) string {
+	return c.keyPrefix + "generation:" + name
+}
+
+// generation returns the current generation, empty if never bumped or
+// expired. A generation outlives the results cached under it, as it is
+// cached for the same ttl when bumped.
+func (c *queryCachedStoreImplementation) generation(ctx context.Context, name string) (string, error) {
+	data, found, err := c.cache.Get(ctx, c.generationKey(name))
+	if err != nil || !found {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// invalidate bumps the generations of the record type after a successful
+// write, every generation if the type is not known
+func (c *queryCachedStoreImplementation) invalidate(ctx context.Context, recordType string, err error) error {
+	if err != nil {
+		return err
+	}
+
+	names := []string{queryCacheAllGeneration}
+	if recordType != "" {
+		names = []string{recordType, queryCacheUntypedGeneration}
+	}
+
+	generation := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+	for _, name := range names {
+		if err := c.cache.Set(ctx, c.generationKey(name), generation, c.ttl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invalidateRecords bumps the generations of the types of the records
+func (c *queryCachedStoreImplementation) invalidateRecords(ctx context.Context, records []RecordInterface, err error) error {
+	if err != nil {
+		return err
+	}
+
+	seen := map[string]bool{}
+	for _, record := range records {
+		if record == nil || seen[record.Type()] {
+			continue
+		}
+		seen[record.Type()] = true
+		if err := c.invalidate(ctx, record.Type(), nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// invalidateBatches bumps the generations of the record type after a write
+// committed in batches, which may have written records before failing
+func (c *queryCachedStoreImplementation) invalidateBatches(ctx context.Context, recordType string, err error) error {
+	if invalidateErr := c.invalidate(ctx, recordType, nil); invalidateErr != nil && err == nil {
+		return invalidateErr
+	}
+	return err
+}
+
+// storedTypes returns the stored types of the records, i.e. the types to
+// invalidate besides the new types on an update changing the type of a
+// record, false if they could not be read
+func (c *queryCachedStoreImplementation) storedTypes(ctx context.Context, records []RecordInterface) ([]string, bool) {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, record := range records {
+		if record == nil || record.ID() == "" || seen[record.ID()] {
+			continue
+		}
+		seen[record.ID()] = true
+		ids = append(ids, record.ID())
+	}
+
+	if len(ids) == 0 {
+		return nil, true
+	}
+
+	if c.store.maxListLimit > 0 && len(ids) > c.store.maxListLimit {
+		return nil, false
+	}
+
+	list, err := c.StoreInterface.RecordListContext(ctx, RecordQuery().
+		SetIDList(ids).
+		SetSoftDeletedIncluded(true).
+		SetExpiredIncluded(true).
+		SetExcludePayload(true).
+		SetExcludeMetas(true))
+	if err != nil {
+		return nil, false
+	}
+
+	types := make([]string, 0, len(list))
+	for _, record := range list {
+		types = append(types, record.Type())
+	}
+	return types, true
+}
+
+// invalidateUpdate runs the update of the records, then bumps the
+// generations of both their stored and new types, every generation if a
+// stored type is not known
+func (c *queryCachedStoreImplementation) invalidateUpdate(ctx context.Context, records []RecordInterface, update func() error) error {
+	stored, found := c.storedTypes(ctx, records)
+
+	if err := update(); err != nil {
+		return err
+	}
+
+	if !found {
+		return c.invalidate(ctx, "", nil)
+	}
+
+	for _, recordType := range stored {
+		records = append(records, NewRecord(recordType))
+	}
+	return c.invalidateRecords(ctx, records, nil)
+}
+
+// == READ ==
+
+func (c *queryCachedStoreImplementation) RecordList(query RecordQueryInterface) ([]RecordInterface, error) {
+	return c.RecordListContext(context.Background(), query)
+}
+
+func (c *queryCachedStoreImplementation) RecordListContext(ctx context.Context, query RecordQueryInterface) ([]RecordInterface, error) {
+	key, cacheable := c.queryKey(ctx, "RecordList", query)
+	if cacheable {
+		if data, found, err := c.cache.Get(ctx, key); err == nil && found {
+			if list, err := decodeCachedRecords(data); err == nil {
+				return list, nil
+			}
+		}
+	}
+
+	list, err := c.StoreInterface.RecordListContext(ctx, query)
+	if err != nil || !cacheable {
+		return list, err
+	}
+
+	if data, err := encodeCachedRecords(list); err == nil {
+		_ = c.cache.Set(ctx, key, data, c.ttl)
+	}
+
+	return list, nil
+}
+
+func (c *queryCachedStoreImplementation) RecordCount(query RecordQueryInterface) (int64, error) {
+	return c.RecordCountContext(context.Background(), query)
+}
+
+func (c *queryCachedStoreImplementation) RecordCountContext(ctx context.Context, query RecordQueryInterface) (int64, error) {
+	key, cacheable := c.queryKey(ctx, "RecordCount", query)
+	if cacheable {
+		if data, found, err := c.cache.Get(ctx, key); err == nil && found {
+			if count, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+				return count, nil
+			}
+		}
+	}
+
+	count, err := c.StoreInterface.RecordCountContext(ctx, query)
+	if err != nil || !cacheable {
+		return count, err
+	}
+
+	_ = c.cache.Set(ctx, key, []byte(strconv.FormatInt(count, 10)), c.ttl)
+
+	return count, nil
+}
+
+// == WRITE ==
+
+func (c *queryCachedStoreImplementation) RecordCreate(record RecordInterface) error {
+	return c.RecordCreateContext(context.Background(), record)
+}
+
+func (c *queryCachedStoreImplementation) RecordCreateContext(ctx context.Context, record RecordInterface) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.invalidate(ctx, record.Type(), c.StoreInterface.RecordCreateContext(ctx, record))
+}
+
+func (c *queryCachedStoreImplementation) RecordClone(id string, opts ...RecordOption) (RecordInterface, error) {
+	return c.RecordCloneContext(context.Background(), id, opts...)
+}
+
+func (c *queryCachedStoreImplementation) RecordCloneContext(ctx context.Context, id string, opts ...RecordOption) (RecordInterface, error) {
+	clone, err := c.StoreInterface.RecordCloneContext(ctx, id, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return clone, c.invalidate(ctx, clone.Type(), nil)
+}
+
+func (c *queryCachedStoreImplementation) RecordUpdate(record RecordInterface) error {
+	return c.RecordUpdateContext(context.Background(), record)
+}
+
+func (c *queryCachedStoreImplementation) RecordUpdateContext(ctx context.Context, record RecordInterface) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.invalidateUpdate(ctx, []RecordInterface{record}, func() error {
+		return c.StoreInterface.RecordUpdateContext(ctx, record)
+	})
+}
+
+func (c *queryCachedStoreImplementation) RecordUpdateVersioned(record RecordInterface) error {
+	return c.RecordUpdateVersionedContext(context.Background(), record)
+}
+
+func (c *queryCachedStoreImplementation) RecordUpdateVersionedContext(ctx context.Context, record RecordInterface) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.invalidateUpdate(ctx, []RecordInterface{record}, func() error {
+		return c.StoreInterface.RecordUpdateVersionedContext(ctx, record)
+	})
+}
+
+func (c *queryCachedStoreImplementation) RecordUpdateMany(records []RecordInterface) (UpdateManyResult, error) {
+	return c.RecordUpdateManyContext(context.Background(), records)
+}
+
+func (c *queryCachedStoreImplementation) RecordUpdateManyContext(ctx context.Context, records []RecordInterface) (UpdateManyResult, error) {
+	var result UpdateManyResult
+	err := c.invalidateUpdate(ctx, records, func() error {
+		var err error
+		result, err = c.StoreInterface.RecordUpdateManyContext(ctx, records)
+		return err
+	})
+	return result, err
+}
+
+func (c *queryCachedStoreImplementation) RecordUpsert(record RecordInterface) error {
+	return c.RecordUpsertContext(context.Background(), record)
+}
+
+func (c *queryCachedStoreImplementation) RecordUpsertContext(ctx context.Context, record RecordInterface) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.invalidateUpdate(ctx, []RecordInterface{record}, func() error {
+		return c.StoreInterface.RecordUpsertContext(ctx, record)
+	})
+}
+
+func (c *queryCachedStoreImplementation) RecordPatchPayloadByID(id string, patch string) error {
+	return c.RecordPatchPayloadByIDContext(context.Background(), id, patch)
+}
+
+func (c *queryCachedStoreImplementation) RecordPatchPayloadByIDContext(ctx context.Context, id string, patch string) error {
+	return c.invalidate(ctx, "", c.StoreInterface.RecordPatchPayloadByIDContext(ctx, id, patch))
+}
+
+func (c *queryCachedStoreImplementation) RecordIncrementPayloadKey(id string, key string, delta float64) error {
+	return c.RecordIncrementPayloadKeyContext(context.Background(), id, key, delta)
+}
+
+func (c *queryCachedStoreImplementation) RecordIncrementPayloadKeyContext(ctx context.Context, id string, key string, delta float64) error {
+	return c.invalidate(ctx, "", c.StoreInterface.RecordIncrementPayloadKeyContext(ctx, id, key, delta))
+}
+
+func (c *queryCachedStoreImplementation) RecordDelete(record RecordInterface, opts ...DeleteOption) error {
+	return c.RecordDeleteContext(context.Background(), record, opts...)
+}
+
+func (c *queryCachedStoreImplementation) RecordDeleteContext(ctx context.Context, record RecordInterface, opts ...DeleteOption) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.invalidate(ctx, record.Type(), c.StoreInterface.RecordDeleteContext(ctx, record, opts...))
+}
+
+func (c *queryCachedStoreImplementation) RecordDeleteByID(id string, opts ...DeleteOption) error {
+	return c.RecordDeleteByIDContext(context.Background(), id, opts...)
+}
+
+func (c *queryCachedStoreImplementation) RecordDeleteByIDContext(ctx context.Context, id string, opts ...DeleteOption) error {
+	return c.invalidate(ctx, "", c.StoreInterface.RecordDeleteByIDContext(ctx, id, opts...))
+}
+
+func (c *queryCachedStoreImplementation) RecordSoftDelete(record RecordInterface, opts ...DeleteOption) error {
+	return c.RecordSoftDeleteContext(context.Background(), record, opts...)
+}
+
+func (c *queryCachedStoreImplementation) RecordSoftDeleteContext(ctx context.Context, record RecordInterface, opts ...DeleteOption) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.invalidate(ctx, record.Type(), c.StoreInterface.RecordSoftDeleteContext(ctx, record, opts...))
+}
+
+func (c *queryCachedStoreImplementation) RecordSoftDeleteByID(id string, opts ...DeleteOption) error {
+	return c.RecordSoftDeleteByIDContext(context.Background(), id, opts...)
+}
+
+func (c *queryCachedStoreImplementation) RecordSoftDeleteByIDContext(ctx context.Context, id string, opts ...DeleteOption) error {
+	return c.invalidate(ctx, "", c.StoreInterface.RecordSoftDeleteByIDContext(ctx, id, opts...))
+}
+
+func (c *queryCachedStoreImplementation) RecordRestore(record RecordInterface) error {
+	return c.RecordRestoreContext(context.Background(), record)
+}
+
+func (c *queryCachedStoreImplementation) RecordRestoreContext(ctx context.Context, record RecordInterface) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+	return c.invalidate(ctx, record.Type(), c.StoreInterface.RecordRestoreContext(ctx, record))
+}
+
+func (c *queryCachedStoreImplementation) RecordRestoreByID(id string) error {
+	return c.RecordRestoreByIDContext(context.Background(), id)
+}
+
+func (c *queryCachedStoreImplementation) RecordRestoreByIDContext(ctx context.Context, id string) error {
+	return c.invalidate(ctx, "", c.StoreInterface.RecordRestoreByIDContext(ctx, id))
+}
+
+func (c *queryCachedStoreImplementation) RecordTouch(id string) error {
+	return c.RecordTouchContext(context.Background(), id)
+}
+
+func (c *queryCachedStoreImplementation) RecordTouchContext(ctx context.Context, id string) error {
+	return c.invalidate(ctx, "", c.StoreInterface.RecordTouchContext(ctx, id))
+}
+
+func (c *queryCachedStoreImplementation) RecordFindOrCreate(query RecordQueryInterface, create func() RecordInterface) (RecordInterface, bool, error) {
+	record, created, err := c.StoreInterface.RecordFindOrCreate(query, create)
+	if err != nil || !created {
+		return record, created, err
+	}
+	return record, created, c.invalidate(context.Background(), record.Type(), nil)
+}
+
+func (c *queryCachedStoreImplementation) RecordRollback(id string, revision int64) error {
+	return c.RecordRollbackContext(context.Background(), id, revision)
+}
+
+func (c *queryCachedStoreImplementation) RecordRollbackContext(ctx context.Context, id string, revision int64) error {
+	return c.invalidate(ctx, "", c.StoreInterface.RecordRollbackContext(ctx, id, revision))
+}
+
+func (c *queryCachedStoreImplementation) Import(r io.Reader) (int, error) {
+	return c.ImportContext(context.Background(), r)
+}
+
+func (c *queryCachedStoreImplementation) ImportContext(ctx context.Context, r io.Reader) (int, error) {
+	imported, err := c.StoreInterface.ImportContext(ctx, r)
+	return imported, c.invalidateBatches(ctx, "", err)
+}
+
+func (c *queryCachedStoreImplementation) Restore(ctx context.Context, r io.Reader) (int, error) {
+	restored, err := c.StoreInterface.Restore(ctx, r)
+	return restored, c.invalidateBatches(ctx, "", err)
+}
+
+func (c *queryCachedStoreImplementation) TransformPayloads(recordType string, fn PayloadTransformFunc, opts TransformPayloadsOptions) (TransformPayloadsProgress, error) {
+	progress, err := c.StoreInterface.TransformPayloads(recordType, fn, opts)
+	return progress, c.invalidateBatches(context.Background(), recordType, err)
+}
+
+func (c *queryCachedStoreImplementation) MigratePayloads(recordType string) (int, error) {
+	migrated, err := c.StoreInterface.MigratePayloads(recordType)
+	return migrated, c.invalidateBatches(context.Background(), recordType, err)
+}
+
+func (c *queryCachedStoreImplementation) RecordPurgeSoftDeleted(olderThan time.Duration, recordTypes ...string) (int64, error) {
+	return c.RecordPurgeSoftDeletedContext(context.Background(), olderThan, recordTypes...)
+}
+
+func (c *queryCachedStoreImplementation) RecordPurgeSoftDeletedContext(ctx context.Context, olderThan time.Duration, recordTypes ...string) (int64, error) {
+	purged, err := c.StoreInterface.RecordPurgeSoftDeletedContext(ctx, olderThan, recordTypes...)
+	return purged, c.invalidateBatches(ctx, "", err)
+}
+
+func (c *queryCachedStoreImplementation) RecordPurgeExpired() (int64, error) {
+	return c.RecordPurgeExpiredContext(context.Background())
+}
+
+func (c *queryCachedStoreImplementation) RecordPurgeExpiredContext(ctx context.Context) (int64, error) {
+	purged, err := c.StoreInterface.RecordPurgeExpiredContext(ctx)
+	return purged, c.invalidateBatches(ctx, "", err)
+}
+
+func (c *queryCachedStoreImplementation) RunMaintenance(ctx context.Context, config MaintenanceConfig) []MaintenanceResult {
+	return c.StoreInterface.RunMaintenance(ctx, c.invalidateMaintenance(ctx, config))
+}
+
+func (c *queryCachedStoreImplementation) StartMaintenance(ctx context.Context, config MaintenanceConfig) error {
+	return c.StoreInterface.StartMaintenance(ctx, c.invalidateMaintenance(ctx, config))
+}
+
+// invalidateMaintenance returns the config with every generation bumped
+// after a purge task run
+func (c *queryCachedStoreImplementation) invalidateMaintenance(ctx context.Context, config MaintenanceConfig) MaintenanceConfig {
+	onResult := config.OnResult
+	config.OnResult = func(result MaintenanceResult) {
+		if result.Task == MAINTENANCE_TASK_PURGE_SOFT_DELETED || result.Task == MAINTENANCE_TASK_PURGE_EXPIRED {
+			_ = c.invalidateBatches(ctx, "", nil)
+		}
+		if onResult != nil {
+			onResult(result)
+		}
+	}
+	return config
+}
+
+func (c *queryCachedStoreImplementation) RunInTransaction(ctx context.Context, fn func(tx TransactionInterface) error) error {
+	return c.invalidate(ctx, "", c.StoreInterface.RunInTransaction(ctx, fn))
+}
+
+// == ENCODING ==
+
+// encodeCachedRecords encodes the records as cached, see encodeCachedRecord
+func encodeCachedRecords(records []RecordInterface) ([]byte, error) {
+	list := make([]json.RawMessage, 0, len(records))
+	for _, record := range records {
+		data, err := encodeCachedRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, data)
+	}
+	return json.Marshal(list)
+}
+
+// decodeCachedRecords decodes the records encoded by encodeCachedRecords
+func decodeCachedRecords(data []byte) ([]RecordInterface, error) {
+	list := []json.RawMessage{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	records := make([]RecordInterface, 0, len(list))
+	for _, item := range list {
+		record, err := decodeCachedRecord(item)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
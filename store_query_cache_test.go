@@ -0,0 +1,214 @@
+package customstore_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestQueryCachedStore(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_query_cached",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	cache := newMemoryCache()
+	cached, err := customstore.NewQueryCachedStore(store, cache, time.Minute)
+	if err != nil {
+		t.Fatalf("NewQueryCachedStore failed: %v", err)
+	}
+
+	country := customstore.NewRecord("country", customstore.WithPayload(`{"name":"France"}`))
+	if err := cached.RecordCreate(country); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := cached.RecordCreate(customstore.NewRecord("order")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	countries := customstore.RecordQuery().SetType("country")
+	list, err := cached.RecordList(countries)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+
+	// A write bypassing the cached store is not seen while cached
+	if err := store.RecordCreate(customstore.NewRecord("country")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	list, err = cached.RecordList(customstore.RecordQuery().SetType("country"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 || list[0].ID() != country.ID() || list[0].Payload() != `{"name":"France"}` {
+		t.Fatalf("Expected the cached list, got %d records", len(list))
+	}
+
+	count, err := cached.RecordCount(countries)
+	if err != nil || count != 2 {
+		t.Fatalf("Expected the first count to read the store, got %d: %v", count, err)
+	}
+
+	// A write of another type keeps the cached results of the type
+	if err := cached.RecordCreate(customstore.NewRecord("order")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	list, err = cached.RecordList(countries)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("Expected the cached list to be kept, got %d records: %v", len(list), err)
+	}
+
+	// A write of the type invalidates its cached results
+	if err := cached.RecordCreate(customstore.NewRecord("country")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	list, err = cached.RecordList(countries)
+	if err != nil || len(list) != 3 {
+		t.Fatalf("Expected the invalidated list to be read, got %d records: %v", len(list), err)
+	}
+	count, err = cached.RecordCount(countries)
+	if err != nil || count != 3 {
+		t.Fatalf("Expected the invalidated count to be read, got %d: %v", count, err)
+	}
+
+	// Untyped queries are invalidated by every write
+	all, err := cached.RecordCount(customstore.RecordQuery())
+	if err != nil || all != 5 {
+		t.Fatalf("RecordCount failed: %d %v", all, err)
+	}
+	if err := cached.RecordCreate(customstore.NewRecord("order")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	all, err = cached.RecordCount(customstore.RecordQuery())
+	if err != nil || all != 6 {
+		t.Fatalf("Expected the untyped count to be invalidated, got %d: %v", all, err)
+	}
+
+	// Writes by ID invalidate every cached query
+	if err := cached.RecordDeleteByID(country.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+	count, err = cached.RecordCount(countries)
+	if err != nil || count != 2 {
+		t.Fatalf("Expected the count to be invalidated by the delete, got %d: %v", count, err)
+	}
+
+	if _, err := customstore.NewQueryCachedStore(cached, cache, time.Minute); err == nil {
+		t.Fatal("Expected an error for a store not created by NewStore")
+	}
+}
+
+func TestQueryCachedStoreInvalidation(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_query_cached_invalidation",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	cached, err := customstore.NewQueryCachedStore(store, newMemoryCache(), time.Minute)
+	if err != nil {
+		t.Fatalf("NewQueryCachedStore failed: %v", err)
+	}
+
+	countOf := func(recordType string) int64 {
+		count, err := cached.RecordCount(customstore.RecordQuery().SetType(recordType))
+		if err != nil {
+			t.Fatalf("RecordCount failed: %v", err)
+		}
+		return count
+	}
+
+	draft := customstore.NewRecord("draft")
+	if err := cached.RecordCreate(draft); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if countOf("draft") != 1 || countOf("post") != 0 {
+		t.Fatal("Expected one draft and no post")
+	}
+
+	// An update changing the type invalidates both types
+	draft.SetType("post")
+	if err := cached.RecordUpdate(draft); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+	if countOf("draft") != 0 || countOf("post") != 1 {
+		t.Fatal("Expected the draft to be counted as a post")
+	}
+
+	// Find or create invalidates the type of the created record
+	_, created, err := cached.RecordFindOrCreate(customstore.RecordQuery().SetType("post").SetID("post-2"), func() customstore.RecordInterface {
+		record := customstore.NewRecord("post")
+		record.SetID("post-2")
+		return record
+	})
+	if err != nil || !created {
+		t.Fatalf("RecordFindOrCreate failed: %v", err)
+	}
+	if countOf("post") != 2 {
+		t.Fatal("Expected the created post to be counted")
+	}
+
+	// Transactions invalidate every cached query
+	err = cached.RunInTransaction(t.Context(), func(tx customstore.TransactionInterface) error {
+		return tx.RecordCreate(customstore.NewRecord("post"))
+	})
+	if err != nil {
+		t.Fatalf("RunInTransaction failed: %v", err)
+	}
+	if countOf("post") != 3 {
+		t.Fatal("Expected the post created in the transaction to be counted")
+	}
+
+	// Imports invalidate every cached query
+	page := customstore.NewRecord("page")
+	if err := store.RecordCreate(page); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := store.Export(&buf, customstore.RecordQuery().SetType("page")); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if err := store.RecordDeleteByID(page.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+	if countOf("page") != 0 {
+		t.Fatal("Expected no page")
+	}
+	if _, err := cached.Import(&buf); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if countOf("page") != 1 {
+		t.Fatal("Expected the imported page to be counted")
+	}
+
+	// Purges invalidate every cached query
+	if err := store.RecordSoftDeleteByID("post-2"); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	softDeleted, err := cached.RecordCount(customstore.RecordQuery().SetType("post").SetOnlySoftDeleted(true))
+	if err != nil || softDeleted != 1 {
+		t.Fatalf("Expected one soft deleted post, got %d: %v", softDeleted, err)
+	}
+	if _, err := cached.RecordPurgeSoftDeleted(0); err != nil {
+		t.Fatalf("RecordPurgeSoftDeleted failed: %v", err)
+	}
+	softDeleted, err = cached.RecordCount(customstore.RecordQuery().SetType("post").SetOnlySoftDeleted(true))
+	if err != nil || softDeleted != 0 {
+		t.Fatalf("Expected the soft deleted post to be purged, got %d: %v", softDeleted, err)
+	}
+}
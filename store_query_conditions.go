@@ -0,0 +1,300 @@
+package customstore
+
+import (
+	"sort"
+	"strings"
+)
+
+// sqlCondition is a condition of a WHERE clause with its arguments
+type sqlCondition struct {
+	sql  string
+	args []any
+}
+
+// queryConditions returns the filter conditions of the query, ANDed by the
+// statement: the IDs, types, dates, payload, memo and meta filters, the raw
+// conditions and the OR groups. The soft delete and expiry filters, limit,
+// order and page token are left to applyQuery.
+func (st *storeImplementation) queryConditions(query RecordQueryInterface) []sqlCondition {
+	conditions := []sqlCondition{}
+	where := func(sql string, args ...any) {
+		conditions = append(conditions, sqlCondition{sql: sql, args: args})
+	}
+
+	if query.IsIDSet() && query.GetID() != "" {
+		where(COLUMN_ID+" = ?", query.GetID())
+	}
+
+	if query.IsIDListSet() && len(query.GetIDList()) > 0 {
+		idList := query.GetIDList()
+		anyList := make([]any, len(idList))
+		for i, v := range idList {
+			anyList[i] = v
+		}
+		where(COLUMN_ID+" IN ("+placeholders(len(anyList))+")", anyList...)
+	}
+
+	// IDs carrying the prefix of a type identify records of the type
+	if types := st.idPrefixes.typesOfQueryIDs(query); len(types) > 0 {
+		anyList := make([]any, len(types))
+		for i, v := range types {
+			anyList[i] = v
+		}
+		where(COLUMN_RECORD_TYPE+" IN ("+placeholders(len(anyList))+")", anyList...)
+	}
+
+	if query.IsIDPrefixSet() && query.GetIDPrefix() != "" {
+		// A prefix match, which can use the primary key index
+		where(COLUMN_ID+" LIKE ? ESCAPE '"+likeEscapeChar+"'", likeEscape(query.GetIDPrefix())+"%")
+	}
+
+	if query.IsTypeSet() && query.GetType() != "" {
+		where(COLUMN_RECORD_TYPE+" = ?", query.GetType())
+	}
+
+	if query.IsTypeInSet() && len(query.GetTypeIn()) > 0 {
+		typeIn := query.GetTypeIn()
+		anyList := make([]any, len(typeIn))
+		for i, v := range typeIn {
+			anyList[i] = v
+		}
+		where(COLUMN_RECORD_TYPE+" IN ("+placeholders(len(anyList))+")", anyList...)
+	}
+
+	if query.IsTypePrefixSet() && query.GetTypePrefix() != "" {
+		// A prefix match, which unlike a contains match can use the index
+		where(COLUMN_RECORD_TYPE+" LIKE ? ESCAPE '"+likeEscapeChar+"'", likeEscape(query.GetTypePrefix())+"%")
+	}
+
+	if query.IsTypeNotSet() && query.GetTypeNot() != "" {
+		where(COLUMN_RECORD_TYPE+" <> ?", query.GetTypeNot())
+	}
+
+	if query.IsTypeNotInSet() && len(query.GetTypeNotIn()) > 0 {
+		typeNotIn := query.GetTypeNotIn()
+		anyList := make([]any, len(typeNotIn))
+		for i, v := range typeNotIn {
+			anyList[i] = v
+		}
+		where(COLUMN_RECORD_TYPE+" NOT IN ("+placeholders(len(anyList))+")", anyList...)
+	}
+
+	if query.IsCreatedAtGteSet() {
+		where(COLUMN_CREATED_AT+" >= ?", st.datetimeTimestamp(query.GetCreatedAtGte()))
+	}
+
+	if query.IsCreatedAtLteSet() {
+		where(COLUMN_CREATED_AT+" <= ?", st.datetimeTimestamp(query.GetCreatedAtLte()))
+	}
+
+	if query.IsUpdatedAtGteSet() {
+		where(COLUMN_UPDATED_AT+" >= ?", st.datetimeTimestamp(query.GetUpdatedAtGte()))
+	}
+
+	if query.IsUpdatedAtLteSet() {
+		where(COLUMN_UPDATED_AT+" <= ?", st.datetimeTimestamp(query.GetUpdatedAtLte()))
+	}
+
+	// Payload search (OR within positive searches, AND for negative)
+	driver := st.driverName()
+	caseInsensitive := query.IsPayloadSearchCaseInsensitive()
+	searchTerms := query.GetPayloadSearch()
+	if len(searchTerms) > 0 {
+		var searchQuery strings.Builder
+		searchArgs := make([]any, 0, len(searchTerms))
+		for i, needle := range searchTerms {
+			if i > 0 {
+				searchQuery.WriteString(" OR ")
+			}
+			searchQuery.WriteString(payloadSearchExpression(driver, caseInsensitive, false))
+			searchArgs = append(searchArgs, "%"+needle+"%")
+		}
+		where(payloadSearchCondition(driver, caseInsensitive, "("+searchQuery.String()+")"), searchArgs...)
+	}
+	for _, needle := range query.GetPayloadSearchNot() {
+		where(payloadSearchCondition(driver, caseInsensitive, payloadSearchExpression(driver, caseInsensitive, true)), "%"+needle+"%")
+	}
+
+	if expression, ok := payloadRegexExpression(driver); ok {
+		for _, pattern := range query.GetPayloadSearchRegex() {
+			where(comparedToTrue(expression), pattern)
+		}
+	}
+
+	if query.IsFullTextSearchSet() && st.fullTextSearch {
+		conditions = append(conditions, st.fullTextCondition(query.GetFullTextSearch()))
+	}
+
+	// Payload key filters (AND between keys)
+	payloadKeyEquals := query.GetPayloadKeyEquals()
+	for _, key := range sortedKeys(payloadKeyEquals) {
+		conditions = append(conditions, st.payloadKeyCondition(query, key, "=", payloadKeyEquals[key]))
+	}
+	payloadKeyLike := query.GetPayloadKeyLike()
+	for _, key := range sortedKeys(payloadKeyLike) {
+		conditions = append(conditions, st.payloadKeyCondition(query, key, "LIKE", payloadKeyLike[key]))
+	}
+
+	if query.IsMemoEqualsSet() {
+		// The memo of records created without one may be NULL
+		where("COALESCE("+COLUMN_MEMO+", '') = ?", query.GetMemoEquals())
+	}
+	if query.IsMemoLikeSet() {
+		where(COLUMN_MEMO+" LIKE ?", query.GetMemoLike())
+	}
+
+	// Meta filters (AND between metas)
+	metaEquals := query.GetMetaEquals()
+	for _, name := range sortedKeys(metaEquals) {
+		where(jsonExtractText(driver, COLUMN_METAS)+" = ?", jsonPathArg(driver, name), metaEquals[name])
+	}
+	metaIn := query.GetMetaIn()
+	metaInNames := make([]string, 0, len(metaIn))
+	for name := range metaIn {
+		metaInNames = append(metaInNames, name)
+	}
+	sort.Strings(metaInNames)
+	for _, name := range metaInNames {
+		values := metaIn[name]
+		if len(values) == 0 {
+			// Nothing is in an empty list
+			where("1 = 0")
+			continue
+		}
+		args := []any{jsonPathArg(driver, name)}
+		for _, value := range values {
+			args = append(args, value)
+		}
+		where(jsonExtractText(driver, COLUMN_METAS)+" IN ("+placeholders(len(values))+")", args...)
+	}
+
+	for _, raw := range query.GetRawWheres() {
+		conditions = append(conditions, rawWhereCondition(raw))
+	}
+
+	for _, group := range query.GetOrGroups() {
+		conditions = append(conditions, st.orGroupCondition(group))
+	}
+
+	return conditions
+}
+
+// payloadSearchExpression returns the LIKE comparison of the payload with a
+// search pattern, ignoring case if requested
+func payloadSearchExpression(driver string, caseInsensitive bool, not bool) string {
+	operator := "LIKE"
+	if not {
+		operator = "NOT LIKE"
+	}
+
+	if !caseInsensitive {
+		return COLUMN_PAYLOAD + " " + operator + " ?"
+	}
+	if driver == "postgres" {
+		return COLUMN_PAYLOAD + " " + strings.Replace(operator, "LIKE", "ILIKE", 1) + " ?"
+	}
+	return "LOWER(" + COLUMN_PAYLOAD + ") " + operator + " LOWER(?)"
+}
+
+// payloadSearchCondition returns the condition of payload search
+// expressions, see comparedToTrue for ILIKE
+func payloadSearchCondition(driver string, caseInsensitive bool, expression string) string {
+	if caseInsensitive && driver == "postgres" {
+		return comparedToTrue(expression)
+	}
+	return expression
+}
+
+// comparedToTrue returns the boolean expression compared to TRUE. The query
+// builder does not recognise operators such as ILIKE, ~ or REGEXP and would
+// append " = ?" to a condition with a single argument.
+func comparedToTrue(expression string) string {
+	return "(" + expression + ") = TRUE"
+}
+
+// rawWhereCondition returns the condition of a raw where. The query builder
+// appends " = ?" to a condition with a single argument and no operator it
+// recognises, which an always true comparison prevents.
+func rawWhereCondition(raw RawWhere) sqlCondition {
+	sql := "(" + raw.SQL + ")"
+	if len(raw.Args) == 1 {
+		sql += " AND 1 = 1"
+	}
+	return sqlCondition{sql: sql, args: raw.Args}
+}
+
+// orGroupCondition returns the condition matching the records matching any
+// of the alternatives, each matching the records matching all its filters
+func (st *storeImplementation) orGroupCondition(alternatives []RecordQueryInterface) sqlCondition {
+	if len(alternatives) == 0 {
+		// Nothing matches none of no alternatives
+		return sqlCondition{sql: "1 = 0"}
+	}
+
+	parts := make([]string, 0, len(alternatives))
+	args := []any{}
+	for _, alternative := range alternatives {
+		conditions := st.queryConditions(alternative)
+		if len(conditions) == 0 {
+			// An alternative without filters matches every record
+			return sqlCondition{sql: "1 = 1"}
+		}
+
+		sqls := make([]string, len(conditions))
+		for i, condition := range conditions {
+			sqls[i] = "(" + condition.sql + ")"
+			args = append(args, condition.args...)
+		}
+		parts = append(parts, "("+strings.Join(sqls, " AND ")+")")
+	}
+
+	return sqlCondition{sql: "(" + strings.Join(parts, " OR ") + ")", args: args}
+}
+
+// queryHasTypeFilter returns whether the query filters by type or ID,
+// itself or through an OR group of which every alternative does. Excluding
+// types still matches every other type, so it is not a type filter, and
+// neither are the empty values and lists skipped by queryConditions
+func queryHasTypeFilter(query RecordQueryInterface) bool {
+	switch {
+	case query.IsTypeSet() && query.GetType() != "",
+		query.IsTypeInSet() && len(query.GetTypeIn()) > 0,
+		query.IsTypePrefixSet() && query.GetTypePrefix() != "",
+		query.IsIDSet() && query.GetID() != "",
+		query.IsIDListSet() && len(query.GetIDList()) > 0:
+		return true
+	}
+
+	for _, group := range query.GetOrGroups() {
+		if len(group) == 0 {
+			continue
+		}
+		filtered := true
+		for _, alternative := range group {
+			filtered = filtered && queryHasTypeFilter(alternative)
+		}
+		if filtered {
+			return true
+		}
+	}
+
+	return false
+}
+
+// queryHasFullTextSearch returns whether the query or any alternative of its
+// OR groups searches the full text index
+func queryHasFullTextSearch(query RecordQueryInterface) bool {
+	if query.IsFullTextSearchSet() {
+		return true
+	}
+
+	for _, group := range query.GetOrGroups() {
+		for _, alternative := range group {
+			if queryHasFullTextSearch(alternative) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
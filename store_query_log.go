@@ -0,0 +1,50 @@
+package customstore
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// logQuery logs a statement executed for the operation, started at start:
+// at debug level when debug is enabled, and as a warning when it ran for at
+// least the slow query threshold. statement returns the SQL and its
+// arguments (nil when inlined into the SQL), and is only called when the
+// statement is logged.
+func (st *storeImplementation) logQuery(op string, start time.Time, statement func() (string, []any)) {
+	duration := time.Since(start)
+	slow := st.slowQueryThreshold > 0 && duration >= st.slowQueryThreshold
+
+	if !slow && !(st.debugEnabled && st.logger.Enabled(context.Background(), slog.LevelDebug)) {
+		return
+	}
+
+	sqlStr, args := statement()
+
+	if slow {
+		st.logger.Warn("Slow query", "op", op, "duration", duration, "sql", sqlStr, "args", args)
+		return
+	}
+
+	st.logger.Debug("Query", "op", op, "duration", duration, "sql", sqlStr, "args", args)
+}
+
+// rawStatement returns a statement func for logQuery of SQL executed with
+// the given arguments
+func rawStatement(sqlStr string, args ...any) func() (string, []any) {
+	return func() (string, []any) {
+		return sqlStr, args
+	}
+}
+
+// exec executes a raw statement on q, logged as the operation
+func (st *storeImplementation) exec(q contractsorm.Query, op string, sqlStr string, args ...any) (*contractsorm.Result, error) {
+	start := time.Now()
+	result, err := st.retryResult(context.Background(), op, func() (*contractsorm.Result, error) {
+		return q.Exec(sqlStr, args...)
+	})
+	st.logQuery(op, start, rawStatement(sqlStr, args...))
+	return result, err
+}
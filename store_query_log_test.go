@@ -0,0 +1,81 @@
+package customstore_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreDebugQueryLog(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	var logs bytes.Buffer
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_query_log",
+		AutomigrateEnabled: true,
+		Logger:             slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if err := store.RecordCreate(customstore.NewRecord("person")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if strings.Contains(logs.String(), "msg=Query") {
+		t.Fatalf("Expected no statements logged without debug, but got %s", logs.String())
+	}
+
+	// The injected logger is kept when debug is enabled
+	store.EnableDebug(true)
+
+	if _, err := store.RecordList(customstore.NewRecordQuery().SetType("person")); err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+
+	output := logs.String()
+	if !strings.Contains(output, "msg=Query op=RecordList") {
+		t.Fatalf("Expected the RecordList statement to be logged, but got %s", output)
+	}
+	if !strings.Contains(output, "SELECT") || !strings.Contains(output, "'person'") {
+		t.Fatalf("Expected the SQL with its arguments to be logged, but got %s", output)
+	}
+	if strings.Contains(output, "Slow query") {
+		t.Fatalf("Expected no slow query without a threshold, but got %s", output)
+	}
+}
+
+func TestStoreSlowQueryLog(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	var logs bytes.Buffer
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_slow_query_log",
+		AutomigrateEnabled: true,
+		Logger:             slog.New(slog.NewTextHandler(&logs, nil)),
+		SlowQueryThreshold: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if err := store.RecordIncrementPayloadKey("missing", "visits", 1); err == nil {
+		t.Fatalf("Expected error for a missing record, but got nil")
+	}
+
+	output := logs.String()
+	if !strings.Contains(output, `level=WARN msg="Slow query" op=RecordIncrementPayloadKey`) {
+		t.Fatalf("Expected the statement to be logged as slow, but got %s", output)
+	}
+	if !strings.Contains(output, "duration=") || !strings.Contains(output, "args=") || !strings.Contains(output, "missing") {
+		t.Fatalf("Expected the duration and arguments of the slow query, but got %s", output)
+	}
+}
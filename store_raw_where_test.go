@@ -0,0 +1,76 @@
+package customstore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreQueryRawWhere(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_raw_where",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, payload := range []string{`{"tags":["a","b"]}`, `{"tags":["a"]}`, `{"tags":[]}`} {
+		if err := store.RecordCreate(customstore.NewRecord("post", customstore.WithPayload(payload))); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+	deleted := customstore.NewRecord("post", customstore.WithPayload(`{"tags":["a","b","c"]}`))
+	if err := store.RecordCreate(deleted); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordSoftDeleteByID(deleted.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+
+	// A single argument, as the query builder would take for a column name
+	count, err := store.RecordCount(customstore.RecordQuery().
+		SetType("post").
+		AddRawWhere("json_array_length(payload, '$.tags') >= ?", 1))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 records, got %d", count)
+	}
+
+	// Several conditions are ANDed, the soft deleted record stays excluded
+	count, err = store.RecordCount(customstore.RecordQuery().
+		AddRawWhere("json_array_length(payload, '$.tags') > 1").
+		AddRawWhere("json_extract(payload, ?) = ?", "$.tags[0]", "a"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 record, got %d", count)
+	}
+
+	if err := customstore.RecordQuery().AddRawWhere(" ").Validate(); err == nil {
+		t.Fatal("Expected an error for an empty raw where")
+	}
+}
+
+func TestRecordQueryRawWhereSQL(t *testing.T) {
+	sqlStr, args, err := customstore.RecordQuery().
+		AddRawWhere("jsonb_array_length(payload::jsonb -> 'tags') > ?", 1).
+		ToSQL("postgres", "records")
+	if err != nil {
+		t.Fatalf("ToSQL failed: %v", err)
+	}
+	if !strings.Contains(sqlStr, "(jsonb_array_length(payload::jsonb -> 'tags') > $3)") {
+		t.Fatalf("Expected the raw where with a rebound placeholder, got %s", sqlStr)
+	}
+	if len(args) != 3 || args[2] != 1 {
+		t.Fatalf("Unexpected arguments: %v", args)
+	}
+}
@@ -0,0 +1,18 @@
+package customstore
+
+// ReadOnlyView returns a view of the store sharing its database and
+// configuration, where every mutating method returns ErrReadOnly
+func (st *storeImplementation) ReadOnlyView() StoreInterface {
+	view := *st
+	view.readOnly = true
+	view.automigrateEnabled = false
+	return &view
+}
+
+// checkWritable returns ErrReadOnly if the store is read only
+func (st *storeImplementation) checkWritable() error {
+	if st.readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreReadOnly(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_read_only",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person")
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if _, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_read_only",
+		AutomigrateEnabled: true,
+		ReadOnly:           true,
+	}); err == nil {
+		t.Fatalf("Expected error combining read only with automigrate, but got nil")
+	}
+
+	readOnly, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:        db,
+		TableName: "data_read_only",
+		ReadOnly:  true,
+	})
+	if err != nil {
+		t.Fatalf("Read only store could not be created: %v", err)
+	}
+
+	for name, store := range map[string]customstore.StoreInterface{"option": readOnly, "view": store.ReadOnlyView()} {
+		t.Run(name, func(t *testing.T) {
+			found, err := store.RecordFindByID(record.ID())
+			if err != nil || found == nil {
+				t.Fatalf("Expected reads to be allowed, but got %v", err)
+			}
+
+			if err := store.RecordCreate(customstore.NewRecord("person")); !errors.Is(err, customstore.ErrReadOnly) {
+				t.Fatalf("RecordCreate: expected ErrReadOnly, but got %v", err)
+			}
+			if err := store.RecordUpdate(found); !errors.Is(err, customstore.ErrReadOnly) {
+				t.Fatalf("RecordUpdate: expected ErrReadOnly, but got %v", err)
+			}
+			if err := store.RecordDeleteByID(record.ID()); !errors.Is(err, customstore.ErrReadOnly) {
+				t.Fatalf("RecordDeleteByID: expected ErrReadOnly, but got %v", err)
+			}
+			if err := store.RecordSoftDelete(found); !errors.Is(err, customstore.ErrReadOnly) {
+				t.Fatalf("RecordSoftDelete: expected ErrReadOnly, but got %v", err)
+			}
+			if err := store.MigrateDown(context.Background()); !errors.Is(err, customstore.ErrReadOnly) {
+				t.Fatalf("MigrateDown: expected ErrReadOnly, but got %v", err)
+			}
+			if _, _, err := store.RecordFindOrCreate(customstore.RecordQuery().SetID("missing"), func() customstore.RecordInterface {
+				return customstore.NewRecord("person")
+			}); !errors.Is(err, customstore.ErrReadOnly) {
+				t.Fatalf("RecordFindOrCreate: expected ErrReadOnly, but got %v", err)
+			}
+		})
+	}
+
+	if err := store.RecordUpdate(record); err != nil {
+		t.Fatalf("Expected the original store to stay writable, but got %v", err)
+	}
+}
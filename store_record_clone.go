@@ -0,0 +1,60 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+)
+
+// RecordClone creates a copy of the record with the ID under a new ID,
+// i.e. to duplicate an item. The type, payload, metas (reserved metas
+// included), memo and expiry are copied, while the timestamps are those of
+// the creation and the version starts at 1. The options are applied to the
+// copy before it is created, i.e. WithPayloadMap to change its payload.
+// The copy is created as by RecordCreate and returned. Links are not
+// copied.
+func (st *storeImplementation) RecordClone(id string, opts ...RecordOption) (RecordInterface, error) {
+	return st.RecordCloneContext(context.Background(), id, opts...)
+}
+
+// RecordCloneContext is RecordClone using the given context
+func (st *storeImplementation) RecordCloneContext(ctx context.Context, id string, opts ...RecordOption) (RecordInterface, error) {
+	if err := st.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		return nil, errors.New("record id is empty")
+	}
+
+	source, err := st.RecordFindByIDContext(WithPrimaryReads(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if source == nil {
+		return nil, ErrNotFound
+	}
+
+	metas, err := source.Metas()
+	if err != nil {
+		return nil, err
+	}
+
+	copySource := func(clone RecordInterface) error {
+		clone.SetPayload(source.Payload())
+		clone.SetMemo(source.Memo())
+		clone.SetExpiresAt(source.ExpiresAt())
+		return clone.SetMetas(metas)
+	}
+
+	clone, err := NewRecordE(source.Type(), append([]RecordOption{copySource}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := st.RecordCreateContext(ctx, clone); err != nil {
+		return nil, err
+	}
+
+	return clone, nil
+}
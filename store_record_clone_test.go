@@ -0,0 +1,85 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRecordClone(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_clone",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	source := customstore.NewRecord("item",
+		customstore.WithPayload(`{"name":"chair","price":10}`),
+		customstore.WithMetas(map[string]string{"status": "draft"}),
+		customstore.WithMemo("original"))
+	if err := store.RecordCreate(source); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	source.SetPayload(`{"name":"chair","price":12}`)
+	if err := store.RecordUpdate(source); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	clone, err := store.RecordClone(source.ID())
+	if err != nil {
+		t.Fatalf("RecordClone failed: %v", err)
+	}
+	if clone.ID() == source.ID() {
+		t.Fatal("Expected the clone to have a new ID")
+	}
+
+	found, err := store.RecordFindByID(clone.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found == nil {
+		t.Fatal("Expected the clone to be created")
+	}
+	if found.Type() != "item" || found.Payload() != `{"name":"chair","price":12}` || found.Meta("status") != "draft" || found.Memo() != "original" {
+		t.Fatalf("Expected the clone to copy the record, got %s %s %q %q", found.Type(), found.Payload(), found.Meta("status"), found.Memo())
+	}
+	if found.Version() != 1 {
+		t.Fatalf("Expected the clone to start at version 1, got %d", found.Version())
+	}
+
+	// The options override the copied fields
+	clone, err = store.RecordClone(source.ID(),
+		customstore.WithPayloadMap(map[string]any{"name": "chair (copy)"}),
+		customstore.WithMetas(map[string]string{"status": "published"}))
+	if err != nil {
+		t.Fatalf("RecordClone failed: %v", err)
+	}
+	found, err = store.RecordFindByID(clone.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found.Payload() != `{"name":"chair (copy)"}` || found.Meta("status") != "published" {
+		t.Fatalf("Expected the options to be applied, got %s %q", found.Payload(), found.Meta("status"))
+	}
+
+	count, err := store.RecordCount(customstore.NewRecordQuery().SetType("item"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 records, got %d", count)
+	}
+
+	if _, err := store.RecordClone("missing"); err == nil {
+		t.Fatal("Expected an error for a missing record")
+	}
+	if _, err := store.RecordClone(""); err == nil {
+		t.Fatal("Expected an error for an empty id")
+	}
+}
@@ -0,0 +1,60 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RecordExists returns whether any record matches the query
+func (st *storeImplementation) RecordExists(query RecordQueryInterface) (bool, error) {
+	return st.RecordExistsContext(context.Background(), query)
+}
+
+// RecordExistsContext returns whether any record matches the query. Unlike
+// RecordCount it selects a single row (SELECT 1 ... LIMIT 1), so the
+// database stops at the first match. The limit, offset and order of the
+// query are ignored.
+func (st *storeImplementation) RecordExistsContext(ctx context.Context, query RecordQueryInterface) (bool, error) {
+	if st.sharded() {
+		view, err := st.forQuery(query)
+		if err != nil {
+			return false, err
+		}
+		return view.RecordExistsContext(ctx, query)
+	}
+
+	if st.db == nil {
+		return false, errors.New("database is not initialized")
+	}
+
+	if err := st.checkQueryGuards(query); err != nil {
+		return false, err
+	}
+
+	// The single row limit replaces the paging of the query
+	q := st.buildFilterQuery(ctx, query).
+		Table(st.tableName()).
+		Select("1 AS found").
+		Limit(1)
+
+	if st.dryRun("RecordExists", func() (string, []any) { return selectSQL(q) }) {
+		return false, nil
+	}
+
+	var rows []map[string]any
+	start := time.Now()
+	err := st.retry(ctx, "RecordExists", func() error {
+		return q.Get(&rows)
+	})
+	st.logQuery("RecordExists", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return false, st.wrapError(err, "RecordExists", queryRecordID(query), queryRecordType(query), func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	return len(rows) > 0, nil
+}
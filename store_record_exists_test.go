@@ -0,0 +1,99 @@
+package customstore_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRecordExists(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_exists",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	exists, err := store.RecordExists(customstore.RecordQuery().SetType("person"))
+	if err != nil {
+		t.Fatalf("RecordExists failed: %v", err)
+	}
+	if exists {
+		t.Fatal("Expected no record to exist")
+	}
+
+	record := customstore.NewRecord("person", customstore.WithPayload(`{"name":"John"}`))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	exists, err = store.RecordExistsContext(t.Context(), customstore.RecordQuery().
+		SetType("person").
+		AddPayloadKeyEquals("name", "John").
+		SetOffset(5))
+	if err != nil {
+		t.Fatalf("RecordExistsContext failed: %v", err)
+	}
+	if !exists {
+		t.Fatal("Expected the record to exist, regardless of the offset")
+	}
+
+	// Soft deleted records do not exist unless included
+	if err := store.RecordSoftDeleteByID(record.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	exists, err = store.RecordExists(customstore.RecordQuery().SetType("person"))
+	if err != nil {
+		t.Fatalf("RecordExists failed: %v", err)
+	}
+	if exists {
+		t.Fatal("Expected the soft deleted record not to exist")
+	}
+	exists, err = store.RecordExists(customstore.RecordQuery().SetType("person").SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordExists failed: %v", err)
+	}
+	if !exists {
+		t.Fatal("Expected the soft deleted record to exist when included")
+	}
+}
+
+func TestStoreRecordExistsSQL(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	_, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_exists_sql",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	var logs bytes.Buffer
+	dryRun, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:        db,
+		TableName: "data_record_exists_sql",
+		DebugSQL:  true,
+		Logger:    slog.New(slog.NewTextHandler(&logs, nil)),
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if _, err := dryRun.RecordExists(customstore.RecordQuery().SetType("person")); err != nil {
+		t.Fatalf("RecordExists failed: %v", err)
+	}
+	if !strings.Contains(logs.String(), "SELECT 1 AS found") || !strings.Contains(logs.String(), "LIMIT 1") {
+		t.Fatalf("Expected a single row select, got %s", logs.String())
+	}
+}
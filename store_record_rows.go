@@ -0,0 +1,167 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// RecordRow is a lightweight projection of a record, holding only the
+// columns and payload keys selected with RecordQuery.SetColumns
+type RecordRow struct {
+	// Columns holds the selected table columns, keyed by column name
+	Columns map[string]any
+
+	// Payload holds the selected payload keys present in the payload
+	Payload map[string]any
+}
+
+// Column returns the value of the selected column as a string
+func (r RecordRow) Column(name string) string {
+	value, exists := r.Columns[name]
+	if !exists || value == nil {
+		return ""
+	}
+
+	if t, ok := value.(time.Time); ok {
+		return t.UTC().Format(time.DateTime)
+	}
+
+	return cast.ToString(value)
+}
+
+// recordRowColumns lists the table columns that can be selected into a RecordRow
+var recordRowColumns = map[string]bool{
+	COLUMN_ID:              true,
+	COLUMN_RECORD_TYPE:     true,
+	COLUMN_PAYLOAD:         true,
+	COLUMN_METAS:           true,
+	COLUMN_MEMO:            true,
+	COLUMN_CREATED_AT:      true,
+	COLUMN_UPDATED_AT:      true,
+	COLUMN_SOFT_DELETED_AT: true,
+	COLUMN_VERSION:         true,
+	COLUMN_EXPIRES_AT:      true,
+}
+
+// timestampColumns are the record columns holding timestamps, selected as
+// times in a RecordRow whatever the format of the store
+var timestampColumns = map[string]bool{
+	COLUMN_CREATED_AT:      true,
+	COLUMN_UPDATED_AT:      true,
+	COLUMN_SOFT_DELETED_AT: true,
+	COLUMN_EXPIRES_AT:      true,
+}
+
+// RecordRows returns the records matching the query as lightweight rows,
+// holding only the columns set with SetColumns.
+//
+// Columns are table column names, or payload keys prefixed with
+// PAYLOAD_KEY_PREFIX (i.e. "payload.name"). Payload keys are extracted by
+// the database, keys missing from a payload (or holding null) are omitted.
+func (st *storeImplementation) RecordRows(query RecordQueryInterface) ([]RecordRow, error) {
+	return st.recordRows(context.Background(), query)
+}
+
+// recordRows returns the rows of the records matching the query using the
+// given context
+func (st *storeImplementation) recordRows(ctx context.Context, query RecordQueryInterface) ([]RecordRow, error) {
+	if st.sharded() {
+		view, err := st.forQuery(query)
+		if err != nil {
+			return nil, err
+		}
+		return view.recordRows(ctx, query)
+	}
+
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	if err := st.checkQueryGuards(query); err != nil {
+		return nil, err
+	}
+
+	if query == nil || len(query.GetColumns()) == 0 {
+		return nil, errors.New("columns are required")
+	}
+
+	driver := st.driverName()
+
+	columns := []string{}
+	payloadKeys := []string{}
+	selects := []string{}
+	args := []any{}
+	for _, column := range query.GetColumns() {
+		if key, isPayloadKey := strings.CutPrefix(column, PAYLOAD_KEY_PREFIX); isPayloadKey {
+			if key == "" {
+				return nil, errors.New("payload key is required in column " + column)
+			}
+			selects = append(selects, jsonExtractJSON(driver, COLUMN_PAYLOAD)+" AS k"+strconv.Itoa(len(payloadKeys)))
+			args = append(args, jsonPathArg(driver, key))
+			payloadKeys = append(payloadKeys, key)
+			continue
+		}
+
+		if !recordRowColumns[column] {
+			return nil, errors.New("unsupported column " + column)
+		}
+		selects = append(selects, column)
+		columns = append(columns, column)
+	}
+
+	q := st.buildQuery(ctx, query).
+		Table(st.tableName()).
+		Select(strings.Join(selects, ", ")+st.orderKeySelect(query), args...)
+
+	if st.dryRun("RecordRows", func() (string, []any) { return selectSQL(q) }) {
+		return []RecordRow{}, nil
+	}
+
+	var rows []map[string]any
+	start := time.Now()
+	err := st.retry(ctx, "RecordRows", func() error {
+		return q.Get(&rows)
+	})
+	st.logQuery("RecordRows", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return nil, st.wrapError(err, "RecordRows", queryRecordID(query), queryRecordType(query), func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	result := make([]RecordRow, 0, len(rows))
+	for _, row := range rows {
+		recordRow := RecordRow{
+			Columns: make(map[string]any, len(columns)),
+			Payload: make(map[string]any, len(payloadKeys)),
+		}
+
+		for _, column := range columns {
+			recordRow.Columns[column] = row[column]
+			if timestampColumns[column] && row[column] != nil {
+				recordRow.Columns[column] = st.parseTimestamp(row[column])
+			}
+		}
+
+		for i, key := range payloadKeys {
+			value, err := decodeJSONColumn(row["k"+strconv.Itoa(i)])
+			if err != nil {
+				return nil, err
+			}
+			if value != nil {
+				recordRow.Payload[key] = value
+			}
+		}
+
+		result = append(result, recordRow)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordRows(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_rows",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person",
+		customstore.WithMemo("memo text"),
+		customstore.WithPayload(`{"name":"John","tags":["a","b"],"bio":"long text"}`))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	rows, err := store.RecordRows(customstore.RecordQuery().
+		SetType("person").
+		SetColumns([]string{customstore.COLUMN_ID, customstore.COLUMN_CREATED_AT, "payload.name", "payload.tags", "payload.missing"}))
+	if err != nil {
+		t.Fatalf("RecordRows failed: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Expected 1 row, but got %d", len(rows))
+	}
+
+	row := rows[0]
+	if row.Column(customstore.COLUMN_ID) != record.ID() {
+		t.Fatalf("Expected id %q, but got %q", record.ID(), row.Column(customstore.COLUMN_ID))
+	}
+	if row.Column(customstore.COLUMN_CREATED_AT) != record.CreatedAt() {
+		t.Fatalf("Expected created at %q, but got %q", record.CreatedAt(), row.Column(customstore.COLUMN_CREATED_AT))
+	}
+	if _, exists := row.Columns[customstore.COLUMN_MEMO]; exists {
+		t.Fatalf("Expected memo not to be selected")
+	}
+	if len(row.Payload) != 2 {
+		t.Fatalf("Expected 2 payload keys, but got %v", row.Payload)
+	}
+	if row.Payload["name"] != "John" {
+		t.Fatalf("Expected payload name John, but got %v", row.Payload["name"])
+	}
+	if tags, ok := row.Payload["tags"].([]any); !ok || len(tags) != 2 {
+		t.Fatalf("Expected decoded payload tags, but got %v", row.Payload["tags"])
+	}
+
+	if _, err := store.RecordRows(customstore.RecordQuery()); err == nil {
+		t.Fatalf("Expected error when no columns are set, but got nil")
+	}
+	if _, err := store.RecordRows(customstore.RecordQuery().SetColumns([]string{"id; DROP TABLE x"})); err == nil {
+		t.Fatalf("Expected error for unsupported column, but got nil")
+	}
+}
@@ -0,0 +1,152 @@
+package customstore
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RecordTypeDefinition declares a record type allowed by the store, see
+// RegisterRecordType
+type RecordTypeDefinition struct {
+	// Type is the record type
+	Type string
+
+	// RequiredPayloadKeys lists the top level keys the payload of a new
+	// record of the type must have
+	RequiredPayloadKeys []string
+
+	// AllowedMetas lists the meta names a new record of the type may have.
+	// Any meta is allowed if empty. Reserved metas (prefixed with
+	// RESERVED_META_PREFIX) are always allowed.
+	AllowedMetas []string
+}
+
+// RecordConstraintError is returned when creating a record of a type which
+// is not registered, or which does not satisfy the definition of its type.
+// It unwraps to ErrUnknownRecordType or ErrRecordConstraint.
+type RecordConstraintError struct {
+	// RecordType is the type of the record
+	RecordType string
+
+	// UnknownType is set if the type is not registered
+	UnknownType bool
+
+	// MissingPayloadKeys lists the required payload keys the record misses
+	MissingPayloadKeys []string
+
+	// DisallowedMetas lists the metas of the record which are not allowed
+	DisallowedMetas []string
+}
+
+// Error describes the violated constraints
+func (e *RecordConstraintError) Error() string {
+	if e.UnknownType {
+		return "customstore: unknown record type \"" + e.RecordType + "\""
+	}
+
+	problems := []string{}
+	if len(e.MissingPayloadKeys) > 0 {
+		problems = append(problems, "is missing payload keys "+strings.Join(e.MissingPayloadKeys, ", "))
+	}
+	if len(e.DisallowedMetas) > 0 {
+		problems = append(problems, "has metas which are not allowed "+strings.Join(e.DisallowedMetas, ", "))
+	}
+
+	return "customstore: record of type \"" + e.RecordType + "\" " + strings.Join(problems, " and ")
+}
+
+// Unwrap returns ErrUnknownRecordType or ErrRecordConstraint
+func (e *RecordConstraintError) Unwrap() error {
+	if e.UnknownType {
+		return ErrUnknownRecordType
+	}
+	return ErrRecordConstraint
+}
+
+// recordTypeRegistry holds the registered record types, keyed by type
+type recordTypeRegistry struct {
+	mu    sync.RWMutex
+	types map[string]RecordTypeDefinition
+}
+
+// RegisterRecordType declares a record type allowed by the store. Once a
+// type is registered, creates and upserts of records of unregistered types
+// fail with ErrUnknownRecordType, i.e. catching "order" typed as "orders",
+// and records missing required payload keys or with metas which are not
+// allowed fail with ErrRecordConstraint. Imports and restores are not
+// checked.
+func (st *storeImplementation) RegisterRecordType(definition RecordTypeDefinition) error {
+	if definition.Type == "" {
+		return errors.New("record type is required")
+	}
+
+	registry := st.recordTypes
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if _, exists := registry.types[definition.Type]; exists {
+		return errors.New("record type " + definition.Type + " is already registered")
+	}
+
+	definition.RequiredPayloadKeys = slices.Clone(definition.RequiredPayloadKeys)
+	definition.AllowedMetas = slices.Clone(definition.AllowedMetas)
+	registry.types[definition.Type] = definition
+	return nil
+}
+
+// checkRecordType returns a RecordConstraintError if record types are
+// registered and the record does not satisfy the definition of its type
+func (st *storeImplementation) checkRecordType(record RecordInterface) error {
+	registry := st.recordTypes
+	if registry == nil {
+		return nil
+	}
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	if len(registry.types) == 0 {
+		return nil
+	}
+
+	definition, ok := registry.types[record.Type()]
+	if !ok {
+		return &RecordConstraintError{RecordType: record.Type(), UnknownType: true}
+	}
+
+	constraintErr := &RecordConstraintError{RecordType: record.Type()}
+
+	if len(definition.RequiredPayloadKeys) > 0 {
+		payload, err := record.PayloadMap()
+		if err != nil {
+			return err
+		}
+		for _, key := range definition.RequiredPayloadKeys {
+			if _, ok := payload[key]; !ok {
+				constraintErr.MissingPayloadKeys = append(constraintErr.MissingPayloadKeys, key)
+			}
+		}
+	}
+
+	if len(definition.AllowedMetas) > 0 {
+		metas, err := record.Metas()
+		if err != nil {
+			return err
+		}
+		for name := range metas {
+			if !strings.HasPrefix(name, RESERVED_META_PREFIX) && !slices.Contains(definition.AllowedMetas, name) {
+				constraintErr.DisallowedMetas = append(constraintErr.DisallowedMetas, name)
+			}
+		}
+		sort.Strings(constraintErr.DisallowedMetas)
+	}
+
+	if len(constraintErr.MissingPayloadKeys) == 0 && len(constraintErr.DisallowedMetas) == 0 {
+		return nil
+	}
+
+	return constraintErr
+}
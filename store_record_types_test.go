@@ -0,0 +1,84 @@
+package customstore_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRecordTypes(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_types",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// Any type is allowed until one is registered
+	if err := store.RecordCreate(customstore.NewRecord("orders")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	err = store.RegisterRecordType(customstore.RecordTypeDefinition{
+		Type:                "order",
+		RequiredPayloadKeys: []string{"number", "total"},
+		AllowedMetas:        []string{"channel"},
+	})
+	if err != nil {
+		t.Fatalf("RegisterRecordType failed: %v", err)
+	}
+
+	if err := store.RegisterRecordType(customstore.RecordTypeDefinition{Type: "order"}); err == nil {
+		t.Fatal("Expected an error registering a type twice")
+	}
+
+	err = store.RecordCreate(customstore.NewRecord("orders", customstore.WithPayload(`{"number":1,"total":10}`)))
+	if !errors.Is(err, customstore.ErrUnknownRecordType) {
+		t.Fatalf("Expected ErrUnknownRecordType, got %v", err)
+	}
+
+	err = store.RecordCreate(customstore.NewRecord("order",
+		customstore.WithPayload(`{"number":1}`),
+		customstore.WithMetas(map[string]string{"channel": "web", "source": "import"})))
+	if !errors.Is(err, customstore.ErrRecordConstraint) {
+		t.Fatalf("Expected ErrRecordConstraint, got %v", err)
+	}
+
+	var constraintErr *customstore.RecordConstraintError
+	if !errors.As(err, &constraintErr) {
+		t.Fatalf("Expected a RecordConstraintError, got %T", err)
+	}
+	if !slices.Equal(constraintErr.MissingPayloadKeys, []string{"total"}) {
+		t.Fatalf("Expected total to be missing, got %v", constraintErr.MissingPayloadKeys)
+	}
+	if !slices.Equal(constraintErr.DisallowedMetas, []string{"source"}) {
+		t.Fatalf("Expected source to be disallowed, got %v", constraintErr.DisallowedMetas)
+	}
+
+	valid := customstore.NewRecord("order",
+		customstore.WithPayload(`{"number":1,"total":10}`),
+		customstore.WithMetas(map[string]string{"channel": "web", customstore.META_CREATED_BY: "user-1"}))
+	if err := store.RecordCreate(valid); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	err = store.RecordUpsert(customstore.NewRecord("orders", customstore.WithPayload(`{}`)))
+	if !errors.Is(err, customstore.ErrUnknownRecordType) {
+		t.Fatalf("Expected ErrUnknownRecordType from RecordUpsert, got %v", err)
+	}
+
+	count, err := store.RecordCount(customstore.NewRecordQuery().SetType("order"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected only the valid order to be created, got %d", count)
+	}
+}
@@ -0,0 +1,89 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// RecordsFromSQL runs a raw SELECT over the store table and maps the rows
+// to records, for the queries the record query cannot express. The
+// statement uses "?" placeholders, rebound for the driver.
+//
+// The statement runs as given: soft deleted, expired and (on a tenant view)
+// other tenants' records are only excluded if it filters them. The id
+// column is required; select every column (SELECT *) to update the records
+// returned. Records selected without the payload or metas behave as listed
+// with them excluded (see RecordQuery.SetExcludePayload).
+func (st *storeImplementation) RecordsFromSQL(ctx context.Context, sqlStr string, args ...any) ([]RecordInterface, error) {
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	statement := strings.ToUpper(strings.TrimSpace(sqlStr))
+	if !strings.HasPrefix(statement, "SELECT") && !strings.HasPrefix(statement, "WITH") {
+		return nil, errors.New("only SELECT statements are supported")
+	}
+
+	sqlStr = rebindPlaceholders(st.driverName(), sqlStr)
+	if st.dryRun("RecordsFromSQL", rawStatement(sqlStr, args...)) {
+		return []RecordInterface{}, nil
+	}
+
+	var rows []map[string]any
+	start := time.Now()
+	err := st.newQuery(ctx).Raw(sqlStr, args...).Get(&rows)
+	st.logQuery("RecordsFromSQL", start, rawStatement(sqlStr, args...))
+	if err != nil {
+		return nil, st.wrapError(err, "RecordsFromSQL", "", "", func() string {
+			return sqlStr
+		})
+	}
+
+	list := make([]recordRow, 0, len(rows))
+	for _, row := range rows {
+		if _, ok := row[COLUMN_ID]; !ok {
+			return nil, errors.New("the statement must select the " + COLUMN_ID + " column")
+		}
+		list = append(list, mapToRecordRow(row))
+	}
+
+	records := st.recordRowsToRecords(list)
+	for i, record := range records {
+		if r, ok := record.(*recordImplementation); ok {
+			_, payloadSelected := rows[i][COLUMN_PAYLOAD]
+			_, metasSelected := rows[i][COLUMN_METAS]
+			r.payloadExcluded = !payloadSelected
+			r.metasExcluded = !metasSelected
+		}
+	}
+
+	return records, nil
+}
+
+// mapToRecordRow converts a row selected by a raw statement, leaving the
+// columns not selected empty
+func mapToRecordRow(row map[string]any) recordRow {
+	toTime := func(value any) scannedTimestamp {
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		return scannedTimestamp{value: value}
+	}
+
+	return recordRow{
+		ID:            cast.ToString(row[COLUMN_ID]),
+		Type:          cast.ToString(row[COLUMN_RECORD_TYPE]),
+		Payload:       cast.ToString(row[COLUMN_PAYLOAD]),
+		Metas:         cast.ToString(row[COLUMN_METAS]),
+		Memo:          cast.ToString(row[COLUMN_MEMO]),
+		CreatedAt:     toTime(row[COLUMN_CREATED_AT]),
+		UpdatedAt:     toTime(row[COLUMN_UPDATED_AT]),
+		SoftDeletedAt: toTime(row[COLUMN_SOFT_DELETED_AT]),
+		Version:       cast.ToInt64(row[COLUMN_VERSION]),
+		ExpiresAt:     toTime(row[COLUMN_EXPIRES_AT]),
+	}
+}
@@ -0,0 +1,71 @@
+package customstore_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRecordsFromSQL(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_records_from_sql",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, payload := range []string{`{"tags":["a","b"]}`, `{"tags":["a"]}`, `{"tags":[]}`} {
+		if err := store.RecordCreate(customstore.NewRecord("post", customstore.WithPayload(payload), customstore.WithMemo("imported"))); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	records, err := store.RecordsFromSQL(t.Context(),
+		"SELECT * FROM data_records_from_sql WHERE json_array_length(payload, '$.tags') >= ? ORDER BY json_array_length(payload, '$.tags') DESC", 1)
+	if err != nil {
+		t.Fatalf("RecordsFromSQL failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].Payload() != `{"tags":["a","b"]}` || records[0].Type() != "post" || records[0].Memo() != "imported" {
+		t.Fatalf("Unexpected record: %s %s %s", records[0].Type(), records[0].Payload(), records[0].Memo())
+	}
+	if records[0].CreatedAt() == "" || records[0].IsSoftDeleted() || records[0].Version() != 1 {
+		t.Fatalf("Expected the timestamps and version to be mapped, got %s %v %d", records[0].CreatedAt(), records[0].IsSoftDeleted(), records[0].Version())
+	}
+
+	// The records are updatable
+	records[0].SetMemo("reviewed")
+	if err := store.RecordUpdate(records[0]); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	// Records selected without the payload cannot overwrite it
+	partial, err := store.RecordsFromSQL(t.Context(), "SELECT id, record_type, metas FROM data_records_from_sql WHERE memo = ?", "reviewed")
+	if err != nil {
+		t.Fatalf("RecordsFromSQL failed: %v", err)
+	}
+	if len(partial) != 1 {
+		t.Fatalf("Expected 1 record, got %d", len(partial))
+	}
+	if partial[0].IsPayloadLoaded() || !partial[0].IsMetasLoaded() {
+		t.Fatal("Expected only the payload to be marked as not loaded")
+	}
+	if err := partial[0].PatchPayload(`{"a":1}`); !errors.Is(err, customstore.ErrNotLoaded) {
+		t.Fatalf("Expected ErrNotLoaded, got %v", err)
+	}
+
+	if _, err := store.RecordsFromSQL(t.Context(), "SELECT record_type FROM data_records_from_sql"); err == nil {
+		t.Fatal("Expected an error for a statement without the id column")
+	}
+	if _, err := store.RecordsFromSQL(t.Context(), "DELETE FROM data_records_from_sql"); err == nil {
+		t.Fatal("Expected an error for a statement other than SELECT")
+	}
+}
@@ -0,0 +1,47 @@
+package customstore
+
+import (
+	"context"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// primaryReadsKey is the context key of WithPrimaryReads
+type primaryReadsKey struct{}
+
+// WithPrimaryReads returns a context reading from the primary database (DB)
+// instead of the replica (ReaderDB), i.e. to read a record just written
+// before the replica caught up:
+//
+//	record, err := store.RecordFindByIDContext(customstore.WithPrimaryReads(ctx), id)
+func WithPrimaryReads(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// primaryReads returns whether the context requests reads from the primary
+func primaryReads(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	forced, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return forced
+}
+
+// readQuery returns a new query of the database serving the record reads:
+// the replica if the store has one, unless bound to a transaction or the
+// context requests primary reads
+func (st *storeImplementation) readQuery(ctx context.Context) contractsorm.Query {
+	if st.readDB == nil || st.tx != nil || primaryReads(ctx) {
+		return st.newQuery(ctx)
+	}
+	return cloneQuery(ctx, st.readDB.Query())
+}
+
+// buildReadQuery builds the query of the record query against the database
+// serving the record reads, see readQuery
+func (st *storeImplementation) buildReadQuery(ctx context.Context, query RecordQueryInterface) contractsorm.Query {
+	return st.applyQuery(st.readQuery(ctx), query)
+}
@@ -0,0 +1,111 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreReaderDB(t *testing.T) {
+	primary := InitDB()
+	defer primary.Close()
+	replica := InitDB()
+	defer replica.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 primary,
+		ReaderDB:           replica,
+		TableName:          "data_replica",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// Stands in for replication, writing to the replica directly
+	replicaStore, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 replica,
+		TableName:          "data_replica",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Replica store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("note")
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	// The replica has not caught up yet
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatal("Expected the read to be served by the replica")
+	}
+	count, err := store.RecordCount(customstore.NewRecordQuery().SetType("note"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected 0 records on the replica, got %d", count)
+	}
+
+	// Reads of the primary see the write
+	ctx := customstore.WithPrimaryReads(t.Context())
+	found, err = store.RecordFindByIDContext(ctx, record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByIDContext failed: %v", err)
+	}
+	if found == nil {
+		t.Fatal("Expected the primary read to find the record")
+	}
+	list, err := store.RecordListContext(ctx, customstore.NewRecordQuery().SetType("note"))
+	if err != nil {
+		t.Fatalf("RecordListContext failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 record on the primary, got %d", len(list))
+	}
+
+	if err := replicaStore.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate on replica failed: %v", err)
+	}
+	list, err = store.RecordList(customstore.NewRecordQuery().SetType("note"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 || list[0].ID() != record.ID() {
+		t.Fatalf("Expected the replicated record, got %d records", len(list))
+	}
+
+	// Read-modify-write methods read the primary
+	unreplicated := customstore.NewRecord("draft")
+	if err := store.RecordCreate(unreplicated); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordPatchPayloadByID(unreplicated.ID(), `{"title":"x"}`); err != nil {
+		t.Fatalf("RecordPatchPayloadByID failed: %v", err)
+	}
+
+	// Transactions read and write the primary
+	err = store.RunInTransaction(t.Context(), func(tx customstore.TransactionInterface) error {
+		count, err := tx.RecordCount(customstore.NewRecordQuery().SetType("note"))
+		if err != nil {
+			return err
+		}
+		if count != 1 {
+			t.Fatalf("Expected 1 record in the transaction, got %d", count)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunInTransaction failed: %v", err)
+	}
+
+	if err := store.Ping(t.Context()); err != nil {
+		t.Fatalf("Ping failed: %v", err)
+	}
+}
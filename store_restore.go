@@ -0,0 +1,106 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// RecordRestore restores a soft deleted record, so it is listed again
+func (st *storeImplementation) RecordRestore(record RecordInterface) error {
+	return st.RecordRestoreContext(context.Background(), record)
+}
+
+// RecordRestoreContext is RecordRestore using the given context
+func (st *storeImplementation) RecordRestoreContext(ctx context.Context, record RecordInterface) error {
+	if record == nil {
+		return errors.New("record is nil")
+	}
+
+	if err := st.RecordRestoreByIDContext(ctx, record.ID()); err != nil {
+		return err
+	}
+
+	record.SetSoftDeletedAt(MAX_DATETIME)
+
+	return nil
+}
+
+// RecordRestoreByID restores a soft deleted record by ID
+func (st *storeImplementation) RecordRestoreByID(id string) error {
+	return st.RecordRestoreByIDContext(context.Background(), id)
+}
+
+// RecordRestoreByIDContext restores a soft deleted record by ID using the
+// given context, resetting the soft deleted at to MAX_DATETIME. Restoring a
+// record which is not soft deleted leaves it unchanged, restoring a record
+// which is not stored (or of another tenant) returns ErrNotFound.
+func (st *storeImplementation) RecordRestoreByIDContext(ctx context.Context, id string) error {
+	if st.sharded() {
+		view, err := st.forID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return view.RecordRestoreByIDContext(ctx, id)
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
+	if id == "" {
+		return errors.New("record id is empty")
+	}
+
+	if err := st.runHooks(ctx, EventBeforeRestore, id, nil); err != nil {
+		return err
+	}
+
+	err := st.trackChanges(ctx, ChangeUpdate, []string{id}, func(st *storeImplementation) error {
+		row := map[string]any{
+			COLUMN_SOFT_DELETED_AT: st.timestamp(maxTime),
+			COLUMN_UPDATED_AT:      st.nowTimestamp(),
+		}
+
+		unlock := st.lockWrite()
+		defer unlock()
+
+		q := st.whereTenant(st.newQuery(ctx).
+			Table(st.tableName()).
+			Where(COLUMN_ID+" = ?", id).
+			Where(COLUMN_SOFT_DELETED_AT+" <> ?", st.timestamp(maxTime)))
+
+		start := time.Now()
+		result, err := st.retryResult(ctx, "RecordRestoreByID", func() (*contractsorm.Result, error) {
+			return q.Update(row)
+		})
+		st.logQuery("RecordRestoreByID", start, func() (string, []any) {
+			return q.ToRawSql().Update(row), nil
+		})
+		if err != nil {
+			return st.duplicateOfStored(ctx, "RecordRestoreByID", st.wrapError(err, "RecordRestoreByID", id, "", func() string {
+				return q.ToSql().Update(row)
+			}), id)
+		}
+
+		if result.RowsAffected == 0 {
+			// Active already, unless it is not stored
+			exists, err := st.hasID(ctx, id)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return ErrNotFound
+			}
+		}
+
+		return st.copyToMigrationTarget(ctx, []string{id})
+	})
+	if err != nil {
+		return err
+	}
+
+	return st.runHooks(ctx, EventAfterRestore, id, nil)
+}
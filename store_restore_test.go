@@ -0,0 +1,82 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordRestore(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_restore",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	invoice := customstore.NewRecord("invoice")
+	order := customstore.NewRecord("order")
+	for _, record := range []customstore.RecordInterface{invoice, order} {
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		if err := store.RecordSoftDelete(record); err != nil {
+			t.Fatalf("RecordSoftDelete failed: %v", err)
+		}
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().SetType("invoice"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected no active invoices after soft delete, but got %d", count)
+	}
+
+	if err := store.RecordRestore(invoice); err != nil {
+		t.Fatalf("RecordRestore failed: %v", err)
+	}
+	if invoice.SoftDeletedAt() != customstore.MAX_DATETIME {
+		t.Fatalf("Expected restored record soft deleted at %q, but got %q", customstore.MAX_DATETIME, invoice.SoftDeletedAt())
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetType("invoice"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 || list[0].ID() != invoice.ID() {
+		t.Fatalf("Expected the restored invoice to be listed, but got %d records", len(list))
+	}
+
+	count, err = store.RecordCount(customstore.RecordQuery().SetType("order"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected the order to stay soft deleted, but got %d", count)
+	}
+
+	if err := store.RecordRestoreByID(order.ID()); err != nil {
+		t.Fatalf("RecordRestoreByID failed: %v", err)
+	}
+
+	found, err := store.RecordFindByID(order.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found == nil {
+		t.Fatalf("Expected the restored order to be found, but got nil")
+	}
+	if found.SoftDeletedAt() != customstore.MAX_DATETIME {
+		t.Fatalf("Expected soft deleted at %q, but got %q", customstore.MAX_DATETIME, found.SoftDeletedAt())
+	}
+
+	if err := store.RecordRestoreByID(""); err == nil {
+		t.Fatalf("Expected error when restoring with an empty ID, but got nil")
+	}
+}
@@ -0,0 +1,180 @@
+package customstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+	neatdatabase "github.com/dracory/neat/database"
+)
+
+// RetryPolicy retries the statements of the store failing with transient
+// errors, i.e. a connection reset or SQLite reporting the database is busy.
+// The zero policy does not retry.
+//
+// Classifying the failures needs the errors of the driver, so with a
+// policy retrying the store returns them as is instead of the generic
+// errors of the query builder, and they may include details of the SQL.
+//
+// Statements of a store bound to a transaction are not retried, as the
+// failure may have aborted the transaction, see RunInTransaction instead. A
+// write interrupted by a connection reset may have been applied before the
+// connection was lost, so with the default classifier such a write may be
+// applied twice (i.e. RecordIncrementPayloadKey).
+type RetryPolicy struct {
+	// MaxAttempts is the number of times a statement is run, the first
+	// attempt included. No retry if 1 or less.
+	MaxAttempts int
+
+	// Backoff returns the delay before the given retry (1 for the first),
+	// defaults to ExponentialBackoff(10*time.Millisecond, time.Second)
+	Backoff func(retry int) time.Duration
+
+	// Retryable returns whether the error is transient, defaults to
+	// IsTransientError
+	Retryable func(err error) bool
+}
+
+// retries returns whether the policy retries any statement
+func (p RetryPolicy) retries() bool {
+	return p.MaxAttempts > 1
+}
+
+// driverErrorOptions returns the options of the query builder returning
+// the errors of the driver, which classifying failures needs, if any of
+// the policies retries. The query builder only returns them in debug mode,
+// logging them, which the store does itself.
+func driverErrorOptions(policies ...RetryPolicy) []neatdatabase.Option {
+	if !slices.ContainsFunc(policies, RetryPolicy.retries) {
+		return nil
+	}
+	return []neatdatabase.Option{neatdatabase.WithDebug(), neatdatabase.WithLogger(discardLog{})}
+}
+
+// discardLog is a query builder log discarding every message
+type discardLog struct{}
+
+func (discardLog) Debugf(format string, args ...any)   {}
+func (discardLog) Infof(format string, args ...any)    {}
+func (discardLog) Warningf(format string, args ...any) {}
+func (discardLog) Warning(args ...any)                 {}
+func (discardLog) Errorf(format string, args ...any)   {}
+
+// ExponentialBackoff returns a RetryPolicy.Backoff doubling the delay from
+// base on every retry, up to max
+func ExponentialBackoff(base time.Duration, max time.Duration) func(retry int) time.Duration {
+	return func(retry int) time.Duration {
+		delay := base
+		for i := 1; i < retry && delay < max; i++ {
+			delay *= 2
+		}
+		return min(delay, max)
+	}
+}
+
+// transientErrorMessages are the messages of the transient errors of the
+// drivers which are not reported with a sentinel error
+var transientErrorMessages = []string{
+	"database is locked",       // SQLite BUSY
+	"database table is locked", // SQLite LOCKED
+	"sqlite_busy",
+	"connection reset by peer",
+	"broken pipe",
+	"bad connection",
+	"server has gone away",            // MySQL
+	"lost connection to mysql server", // MySQL
+	"too many connections",
+	"the database system is starting up", // PostgreSQL
+}
+
+// IsTransientError returns whether the error is a transient failure of the
+// database worth retrying: a lost or reset connection, or a busy or locked
+// SQLite database. Context cancellations and deadlines are not transient.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	// The store errors hide the message of the driver error they wrap
+	for ; err != nil; err = errors.Unwrap(err) {
+		message := strings.ToLower(err.Error())
+		for _, transient := range transientErrorMessages {
+			if strings.Contains(message, transient) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// retry runs the statement, running it again after the backoff while it
+// fails with a retryable error, up to the attempts of the retry policy
+func (st *storeImplementation) retry(ctx context.Context, op string, statement func() error) error {
+	if st.tx != nil {
+		return statement()
+	}
+
+	return st.retryPolicy.do(ctx, IsTransientError, func(attempt int, delay time.Duration, err error) {
+		if st.debugEnabled {
+			st.logger.Warn("Retrying transient failure", "op", op, "attempt", attempt, "delay", delay, "error", err)
+		}
+	}, statement)
+}
+
+// do runs fn, running it again after the backoff while it fails with an
+// error the policy (or defaultRetryable if the policy has no classifier)
+// retries, up to the attempts of the policy. onRetry is called before every
+// retry. Gives up with the last error once the context is done.
+func (p RetryPolicy) do(ctx context.Context, defaultRetryable func(err error) bool, onRetry func(attempt int, delay time.Duration, err error), fn func() error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = defaultRetryable
+	}
+	backoff := p.Backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff(10*time.Millisecond, time.Second)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !p.retries() || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		delay := backoff(attempt)
+		onRetry(attempt, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// retryResult is retry for the statements returning a result
+func (st *storeImplementation) retryResult(ctx context.Context, op string, statement func() (*contractsorm.Result, error)) (*contractsorm.Result, error) {
+	var result *contractsorm.Result
+	err := st.retry(ctx, op, func() (err error) {
+		result, err = statement()
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,114 @@
+package customstore_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRetryPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "retry.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	locker, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer locker.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_retry",
+		AutomigrateEnabled: true,
+		RetryPolicy: customstore.RetryPolicy{
+			MaxAttempts: 50,
+			Backoff:     func(retry int) time.Duration { return 10 * time.Millisecond },
+		},
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+	fewRetries, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:          db,
+		TableName:   "data_retry",
+		RetryPolicy: customstore.RetryPolicy{MaxAttempts: 2},
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// Another connection holds the write lock for a while
+	lock := func() *sql.Conn {
+		t.Helper()
+		conn, err := locker.Conn(t.Context())
+		if err != nil {
+			t.Fatalf("Conn failed: %v", err)
+		}
+		if _, err := conn.ExecContext(t.Context(), "BEGIN IMMEDIATE"); err != nil {
+			t.Fatalf("Begin failed: %v", err)
+		}
+		return conn
+	}
+
+	// The attempts run out while the lock is held
+	conn := lock()
+	err = fewRetries.RecordCreate(customstore.NewRecord("note"))
+	if err == nil || !customstore.IsTransientError(err) {
+		t.Fatalf("Expected a transient error, got %v", err)
+	}
+	if _, err := conn.ExecContext(t.Context(), "COMMIT"); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	conn.Close()
+
+	conn = lock()
+	released := make(chan struct{})
+	go func() {
+		defer close(released)
+		time.Sleep(100 * time.Millisecond)
+		conn.ExecContext(context.Background(), "COMMIT")
+		conn.Close()
+	}()
+	if err := store.RecordCreate(customstore.NewRecord("note")); err != nil {
+		t.Fatalf("Expected the create to be retried, got %v", err)
+	}
+	<-released
+
+	count, err := store.RecordCount(customstore.NewRecordQuery().SetType("note"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 record, got %d", count)
+	}
+}
+
+func TestIsTransientError(t *testing.T) {
+	if customstore.IsTransientError(nil) {
+		t.Fatal("Expected nil not to be transient")
+	}
+	if customstore.IsTransientError(context.Canceled) {
+		t.Fatal("Expected a cancellation not to be transient")
+	}
+	if !customstore.IsTransientError(errors.New("read tcp: connection reset by peer")) {
+		t.Fatal("Expected a connection reset to be transient")
+	}
+	if customstore.IsTransientError(errors.New("UNIQUE constraint failed: data.id")) {
+		t.Fatal("Expected a constraint failure not to be transient")
+	}
+
+	backoff := customstore.ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	for retry, expected := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 3: 40 * time.Millisecond, 4: 50 * time.Millisecond, 10: 50 * time.Millisecond} {
+		if delay := backoff(retry); delay != expected {
+			t.Fatalf("Expected a delay of %v for retry %d, got %v", expected, retry, delay)
+		}
+	}
+}
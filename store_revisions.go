@@ -0,0 +1,242 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	contractsschema "github.com/dracory/neat/contracts/database/schema"
+	neatuid "github.com/dracory/neat/support/uid"
+)
+
+// Columns of the revisions table
+const (
+	revisionColumnRecordID = "record_id"
+	revisionColumnRevision = "revision"
+)
+
+// RecordRevision is a snapshot of a record saved to the revisions table, see
+// RecordRevisions
+type RecordRevision struct {
+	// RecordID is the ID of the record
+	RecordID string
+
+	// Revision is the version of the record saved
+	Revision int64
+
+	// Payload is the payload of the revision
+	Payload string
+
+	// Metas are the metas of the revision
+	Metas map[string]string
+
+	// CreatedAt is when the revision was saved
+	CreatedAt string
+}
+
+// revisionRow is a row of the revisions table
+type revisionRow struct {
+	ID        string    `db:"id"`
+	RecordID  string    `db:"record_id"`
+	Revision  int64     `db:"revision"`
+	Payload   string    `db:"payload"`
+	Metas     string    `db:"metas"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// revisionsTableName returns the name of the revisions table of a store
+// table
+func revisionsTableName(tableName string) string {
+	return tableName + "_revisions"
+}
+
+// RecordRevisions returns the saved revisions of the record with the ID,
+// oldest first. Requires RevisionsEnabled. A tenant scoped view only
+// returns the revisions saved for its tenant.
+func (st *storeImplementation) RecordRevisions(id string) ([]RecordRevision, error) {
+	return st.RecordRevisionsContext(context.Background(), id)
+}
+
+// RecordRevisionsContext is RecordRevisions using the given context
+func (st *storeImplementation) RecordRevisionsContext(ctx context.Context, id string) ([]RecordRevision, error) {
+	return st.recordRevisions(ctx, id, 0)
+}
+
+// RecordRollback restores the payload and metas of the record with the ID
+// to the given revision. The record is updated as by RecordUpdate, saving a
+// new revision, so a rollback can be undone too. Requires RevisionsEnabled.
+func (st *storeImplementation) RecordRollback(id string, revision int64) error {
+	return st.RecordRollbackContext(context.Background(), id, revision)
+}
+
+// RecordRollbackContext is RecordRollback using the given context
+func (st *storeImplementation) RecordRollbackContext(ctx context.Context, id string, revision int64) error {
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
+	if revision < 1 {
+		return errors.New("revision must be positive")
+	}
+
+	revisions, err := st.recordRevisions(ctx, id, revision)
+	if err != nil {
+		return err
+	}
+
+	if len(revisions) == 0 {
+		return errors.New("revision " + strconv.FormatInt(revision, 10) + " of record " + id + " not found")
+	}
+
+	record, err := st.RecordFindByIDContext(WithPrimaryReads(ctx), id)
+	if err != nil {
+		return err
+	}
+
+	if record == nil {
+		return ErrNotFound
+	}
+
+	record.SetPayload(revisions[0].Payload)
+	if err := record.SetMetas(revisions[0].Metas); err != nil {
+		return err
+	}
+
+	return st.RecordUpdateContext(ctx, record)
+}
+
+// recordRevisions returns the revisions of the record, only the given one
+// if revision is positive
+func (st *storeImplementation) recordRevisions(ctx context.Context, id string, revision int64) ([]RecordRevision, error) {
+	if st.db == nil {
+		return nil, errors.New("database is not initialized")
+	}
+
+	if st.revisionsTable == "" {
+		return nil, errors.New("revisions are not enabled")
+	}
+
+	if id == "" {
+		return nil, errors.New("record id is required")
+	}
+
+	q := st.newQuery(ctx).
+		Table(st.revisionsTable).
+		Where(revisionColumnRecordID+" = ?", id)
+	q = st.whereTenant(q)
+	if revision > 0 {
+		q = q.Where(revisionColumnRevision+" = ?", revision)
+	}
+	q = q.OrderBy(revisionColumnRevision)
+
+	var rows []revisionRow
+	start := time.Now()
+	err := q.Get(&rows)
+	st.logQuery("RecordRevisions", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return nil, st.wrapError(err, "RecordRevisions", id, "", func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	revisions := make([]RecordRevision, 0, len(rows))
+	for _, row := range rows {
+		metas := map[string]string{}
+		if row.Metas != "" {
+			if err := json.Unmarshal([]byte(row.Metas), &metas); err != nil {
+				return nil, err
+			}
+		}
+
+		revisions = append(revisions, RecordRevision{
+			RecordID:  row.RecordID,
+			Revision:  row.Revision,
+			Payload:   row.Payload,
+			Metas:     metas,
+			CreatedAt: row.CreatedAt.Format(time.DateTime),
+		})
+	}
+
+	return revisions, nil
+}
+
+// createRevisionsTable creates the revisions table if revisions are
+// enabled. Safe to call when it exists.
+func (st *storeImplementation) createRevisionsTable() error {
+	if st.revisionsTable == "" || st.db.Schema().HasTable(st.revisionsTable) {
+		return nil
+	}
+
+	return st.db.Schema().Create(st.revisionsTable, func(table contractsschema.Blueprint) {
+		table.String(COLUMN_ID, 40)
+		table.Primary(COLUMN_ID)
+		table.String(revisionColumnRecordID, 40)
+		table.BigInteger(revisionColumnRevision)
+		table.String(COLUMN_TENANT_ID, 100).Default("")
+		table.Text(COLUMN_PAYLOAD)
+		table.Text(COLUMN_METAS)
+		table.DateTime(COLUMN_CREATED_AT)
+		table.Unique(revisionColumnRecordID, revisionColumnRevision)
+	})
+}
+
+// dropRevisionsTable drops the revisions table if revisions are enabled
+func (st *storeImplementation) dropRevisionsTable() error {
+	if st.revisionsTable == "" || !st.db.Schema().HasTable(st.revisionsTable) {
+		return nil
+	}
+
+	return st.db.Schema().Drop(st.revisionsTable)
+}
+
+// writeRevisions saves a revision of every record saved by the change
+// events, in the transaction the store is bound to if any. Soft deletes,
+// deletes and restores do not change the payload or version and save none.
+func (st *storeImplementation) writeRevisions(ctx context.Context, events []ChangeEvent) error {
+	now := st.now()
+
+	for _, event := range events {
+		if event.New == nil || (event.Old != nil && event.Old.Version() == event.New.Version()) {
+			continue
+		}
+
+		metas, err := event.New.Metas()
+		if err != nil {
+			return err
+		}
+		metasJSON, err := json.Marshal(metas)
+		if err != nil {
+			return err
+		}
+
+		row := map[string]any{
+			COLUMN_ID:              neatuid.GenerateShortID(),
+			revisionColumnRecordID: event.RecordID,
+			revisionColumnRevision: event.New.Version(),
+			COLUMN_PAYLOAD:         event.New.Payload(),
+			COLUMN_METAS:           string(metasJSON),
+			COLUMN_CREATED_AT:      now,
+		}
+		if st.tenancy {
+			row[COLUMN_TENANT_ID] = event.TenantID
+		}
+
+		q := st.newQuery(ctx).Table(st.revisionsTable)
+		start := time.Now()
+		err = q.Create(row)
+		st.logQuery("Revisions", start, func() (string, []any) {
+			return q.ToRawSql().Create(row), nil
+		})
+		if err != nil {
+			return st.wrapError(err, "Revisions", event.RecordID, event.New.Type(), func() string {
+				return q.ToSql().Create(row)
+			})
+		}
+	}
+
+	return nil
+}
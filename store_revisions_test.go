@@ -0,0 +1,188 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRevisionsRollback(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_revisions",
+		AutomigrateEnabled: true,
+		RevisionsEnabled:   true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("document",
+		customstore.WithPayload(`{"title":"Draft"}`),
+		customstore.WithMetas(map[string]string{"status": "draft"}))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	record.SetPayload(`{"title":"Bad edit"}`)
+	if err := record.SetMeta("status", "broken"); err != nil {
+		t.Fatalf("SetMeta failed: %v", err)
+	}
+	if err := store.RecordUpdate(record); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	// Soft deletes and restores save no revision
+	if err := store.RecordSoftDeleteByID(record.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	if err := store.RecordRestoreByID(record.ID()); err != nil {
+		t.Fatalf("RecordRestoreByID failed: %v", err)
+	}
+
+	revisions, err := store.RecordRevisions(record.ID())
+	if err != nil {
+		t.Fatalf("RecordRevisions failed: %v", err)
+	}
+	if len(revisions) != 2 {
+		t.Fatalf("Expected 2 revisions, got %d", len(revisions))
+	}
+	if revisions[0].Revision != 1 || revisions[0].Payload != `{"title":"Draft"}` || revisions[0].Metas["status"] != "draft" {
+		t.Fatalf("Unexpected first revision: %+v", revisions[0])
+	}
+	if revisions[1].Revision != 2 || revisions[1].Payload != `{"title":"Bad edit"}` {
+		t.Fatalf("Unexpected second revision: %+v", revisions[1])
+	}
+
+	if err := store.RecordRollback(record.ID(), 1); err != nil {
+		t.Fatalf("RecordRollback failed: %v", err)
+	}
+
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found.Payload() != `{"title":"Draft"}` || found.Meta("status") != "draft" {
+		t.Fatalf("Expected the first revision restored, got %s %v", found.Payload(), found.Meta("status"))
+	}
+	if found.Version() != 3 {
+		t.Fatalf("Expected the rollback to save version 3, got %d", found.Version())
+	}
+
+	revisions, err = store.RecordRevisions(record.ID())
+	if err != nil {
+		t.Fatalf("RecordRevisions failed: %v", err)
+	}
+	if len(revisions) != 3 || revisions[2].Payload != `{"title":"Draft"}` {
+		t.Fatalf("Expected the rollback to be saved as a revision, got %+v", revisions)
+	}
+
+	if err := store.RecordRollback(record.ID(), 10); err == nil {
+		t.Fatal("Expected an error rolling back to an unknown revision")
+	}
+}
+
+func TestStoreRevisionsDisabled(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_revisions_disabled",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if _, err := store.RecordRevisions("id"); err == nil {
+		t.Fatal("Expected an error when revisions are disabled")
+	}
+
+	if err := store.RecordRollback("id", 1); err == nil {
+		t.Fatal("Expected an error when revisions are disabled")
+	}
+}
+
+func TestStoreRevisionsFailure(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_revisions_failure",
+		AutomigrateEnabled: true,
+		RevisionsEnabled:   true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("page", customstore.WithPayload(`{"title":"Draft"}`))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	if _, err := db.Exec("DROP TABLE data_revisions_failure_revisions"); err != nil {
+		t.Fatalf("Drop failed: %v", err)
+	}
+
+	// A failed revision fails and rolls back the write
+	if err := store.RecordIncrementPayloadKey(record.ID(), "views", 1); err == nil {
+		t.Fatal("Expected the increment to fail with the revision")
+	}
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil || found == nil || found.Payload() != `{"title":"Draft"}` || found.Version() != 1 {
+		t.Fatalf("Expected the increment to be rolled back: %v", err)
+	}
+}
+
+func TestStoreRevisionsTenancy(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_revisions_tenancy",
+		AutomigrateEnabled: true,
+		RevisionsEnabled:   true,
+		TenancyEnabled:     true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	acme, err := store.ForTenant("acme")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+	globex, err := store.ForTenant("globex")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+
+	record := customstore.NewRecord("document", customstore.WithPayload(`{"title":"Draft"}`))
+	if err := acme.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	record.SetPayload(`{"title":"Final"}`)
+	if err := store.RecordUpdate(record); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	revisions, err := acme.RecordRevisions(record.ID())
+	if err != nil || len(revisions) != 2 {
+		t.Fatalf("Expected 2 revisions for the tenant, got %d: %v", len(revisions), err)
+	}
+
+	revisions, err = globex.RecordRevisions(record.ID())
+	if err != nil || len(revisions) != 0 {
+		t.Fatalf("Expected no revisions for another tenant, got %d: %v", len(revisions), err)
+	}
+	if err := globex.RecordRollback(record.ID(), 1); err == nil {
+		t.Fatal("Expected an error rolling back the record of another tenant")
+	}
+}
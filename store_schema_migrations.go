@@ -0,0 +1,187 @@
+package customstore
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	contractsschema "github.com/dracory/neat/contracts/database/schema"
+	"github.com/spf13/cast"
+)
+
+const schemaColumnKey = "key"
+const schemaColumnValue = "value"
+
+// schemaVersionKey is the key of the row holding the schema version of the
+// table in the schema table
+const schemaVersionKey = "schema_version"
+
+// schemaMigration is an ordered step upgrading the table to a schema
+// version. Steps must be idempotent, so a step interrupted before the
+// version was saved is safe to run again.
+type schemaMigration struct {
+	version int
+	name    string
+	up      func(st *storeImplementation, tableName string, report *MigrateReport) error
+}
+
+// schemaMigrations upgrade the tables created by older versions, version 1
+// being the table of the first release. A column added to createTable must
+// be added here too, with a default keeping the existing rows valid.
+var schemaMigrations = []schemaMigration{
+	// The existing rows start at the version of the records of NewRecord,
+	// so optimistic locking treats them as new records
+	{2, "add version column", func(st *storeImplementation, tableName string, report *MigrateReport) error {
+		return st.addColumn(tableName, COLUMN_VERSION, func(table contractsschema.Blueprint) {
+			table.BigInteger(COLUMN_VERSION).Default(1)
+		}, report)
+	}},
+	{3, "add expires_at column", func(st *storeImplementation, tableName string, report *MigrateReport) error {
+		return st.addColumn(tableName, COLUMN_EXPIRES_AT, func(table contractsschema.Blueprint) {
+			st.timestampColumn(table, COLUMN_EXPIRES_AT).Default(st.maxTimestampDefault())
+		}, report)
+	}},
+	{4, "add record_type, created_at and soft_deleted_at indexes", func(st *storeImplementation, tableName string, report *MigrateReport) error {
+		for _, columns := range [][]string{{COLUMN_RECORD_TYPE}, {COLUMN_CREATED_AT}, {COLUMN_SOFT_DELETED_AT}} {
+			if err := st.addIndex(tableName, columns, report); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+}
+
+// latestSchemaVersion returns the schema version of the tables created by
+// createTable
+func latestSchemaVersion() int {
+	return schemaMigrations[len(schemaMigrations)-1].version
+}
+
+// schemaTableName returns the name of the table holding the schema version
+// of the table
+func schemaTableName(tableName string) string {
+	return tableName + "_schema"
+}
+
+// createSchemaTable creates the schema table of the table if missing
+func (st *storeImplementation) createSchemaTable(tableName string) error {
+	if st.db.Schema().HasTable(schemaTableName(tableName)) {
+		return nil
+	}
+
+	return st.db.Schema().Create(schemaTableName(tableName), func(table contractsschema.Blueprint) {
+		table.String(schemaColumnKey, 100)
+		table.Primary(schemaColumnKey)
+		table.Text(schemaColumnValue)
+		table.DateTime(COLUMN_UPDATED_AT)
+	})
+}
+
+// dropSchemaTable drops the schema table of the table if it exists
+func (st *storeImplementation) dropSchemaTable(tableName string) error {
+	if !st.db.Schema().HasTable(schemaTableName(tableName)) {
+		return nil
+	}
+
+	return st.db.Schema().Drop(schemaTableName(tableName))
+}
+
+// schemaVersion returns the schema version saved for the table, 1 if none
+// was saved as the table predates the schema table
+func (st *storeImplementation) schemaVersion(ctx context.Context, tableName string) (int, error) {
+	q := st.newQuery(ctx).
+		Table(schemaTableName(tableName)).
+		Select(schemaColumnValue).
+		Where(schemaColumnKey+" = ?", schemaVersionKey)
+
+	var rows []map[string]any
+	start := time.Now()
+	err := q.Get(&rows)
+	st.logQuery("MigrateUp", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return 0, st.wrapError(err, "MigrateUp", "", "", func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	if len(rows) == 0 {
+		return 1, nil
+	}
+
+	return cast.ToInt(cast.ToString(rows[0][schemaColumnValue])), nil
+}
+
+// saveSchemaVersion saves the schema version of the table
+func (st *storeImplementation) saveSchemaVersion(ctx context.Context, tableName string, version int) error {
+	row := map[string]any{
+		schemaColumnValue: strconv.Itoa(version),
+		COLUMN_UPDATED_AT: st.nowDateTime(),
+	}
+
+	q := st.newQuery(ctx).
+		Table(schemaTableName(tableName)).
+		Where(schemaColumnKey+" = ?", schemaVersionKey)
+
+	start := time.Now()
+	result, err := q.Update(row)
+	st.logQuery("MigrateUp", start, func() (string, []any) {
+		return q.ToRawSql().Update(row), nil
+	})
+	if err != nil {
+		return st.wrapError(err, "MigrateUp", "", "", func() string {
+			return q.ToSql().Update(row)
+		})
+	}
+
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	row[schemaColumnKey] = schemaVersionKey
+	q = st.newQuery(ctx).Table(schemaTableName(tableName))
+	start = time.Now()
+	err = q.Create(row)
+	st.logQuery("MigrateUp", start, func() (string, []any) {
+		return q.ToRawSql().Create(row), nil
+	})
+	return st.wrapError(err, "MigrateUp", "", "", func() string {
+		return q.ToSql().Create(row)
+	})
+}
+
+// migrateSchema applies the schema migrations newer than the saved schema
+// version of the table in order, saving the version after every step, so
+// an interrupted upgrade resumes from the failed step
+func (st *storeImplementation) migrateSchema(ctx context.Context, tableName string, report *MigrateReport) error {
+	if err := st.createSchemaTable(tableName); err != nil {
+		return err
+	}
+
+	version, err := st.schemaVersion(ctx, tableName)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range schemaMigrations {
+		if migration.version <= version {
+			continue
+		}
+
+		if err := migration.up(st, tableName, report); err != nil {
+			return err
+		}
+
+		if err := st.saveSchemaVersion(ctx, tableName, migration.version); err != nil {
+			return err
+		}
+		report.AppliedMigrations = append(report.AppliedMigrations, strconv.Itoa(migration.version)+": "+migration.name)
+
+		if st.debugEnabled {
+			st.logger.Info("MigrateUp: schema migrated", "table", tableName, "version", migration.version, "migration", migration.name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package customstore_test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func schemaVersion(t *testing.T, db *sql.DB, table string) string {
+	t.Helper()
+
+	version := ""
+	err := db.QueryRow("SELECT value FROM "+table+"_schema WHERE key = ?", "schema_version").Scan(&version)
+	if err != nil {
+		t.Fatalf("Schema version query failed: %v", err)
+	}
+	return version
+}
+
+func TestStoreSchemaMigrations(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	// A table of the first release, which predates the schema table
+	_, err := db.Exec(`CREATE TABLE data_schema_migrations (
+		id VARCHAR(40) PRIMARY KEY,
+		record_type VARCHAR(100),
+		payload TEXT,
+		metas TEXT,
+		memo TEXT,
+		created_at DATETIME,
+		updated_at DATETIME,
+		soft_deleted_at DATETIME
+	)`)
+	if err != nil {
+		t.Fatalf("Create table failed: %v", err)
+	}
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:        db,
+		TableName: "data_schema_migrations",
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	report, err := store.MigrateUpReport(t.Context())
+	if err != nil {
+		t.Fatalf("MigrateUpReport failed: %v", err)
+	}
+	if len(report.AppliedMigrations) != 3 || !strings.HasPrefix(report.AppliedMigrations[0], "2: ") {
+		t.Fatalf("Expected the migrations 2 to 4 to be applied, got %v", report.AppliedMigrations)
+	}
+	if version := schemaVersion(t, db, "data_schema_migrations"); version != "4" {
+		t.Fatalf("Expected schema version 4, got %s", version)
+	}
+
+	// A step interrupted before its version was saved runs again safely
+	if _, err := db.Exec("UPDATE data_schema_migrations_schema SET value = '3'"); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	report, err = store.MigrateUpReport(t.Context())
+	if err != nil {
+		t.Fatalf("MigrateUpReport failed: %v", err)
+	}
+	if len(report.AppliedMigrations) != 1 || !strings.HasPrefix(report.AppliedMigrations[0], "4: ") {
+		t.Fatalf("Expected only migration 4 to be applied, got %v", report.AppliedMigrations)
+	}
+	if len(report.AddedColumns) != 0 || len(report.CreatedIndexes) != 0 {
+		t.Fatalf("Expected the repeated step to change nothing, got %+v", report)
+	}
+	if version := schemaVersion(t, db, "data_schema_migrations"); version != "4" {
+		t.Fatalf("Expected schema version 4, got %s", version)
+	}
+
+	report, err = store.MigrateUpReport(t.Context())
+	if err != nil {
+		t.Fatalf("MigrateUpReport failed: %v", err)
+	}
+	if report.Changed() {
+		t.Fatalf("Expected no changes, got %+v", report)
+	}
+}
+
+func TestStoreSchemaMigrationsNewTable(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_schema_migrations_new",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// A new table is created at the latest version
+	if version := schemaVersion(t, db, "data_schema_migrations_new"); version != "4" {
+		t.Fatalf("Expected schema version 4, got %s", version)
+	}
+
+	report, err := store.MigrateUpReport(t.Context())
+	if err != nil {
+		t.Fatalf("MigrateUpReport failed: %v", err)
+	}
+	if report.Changed() {
+		t.Fatalf("Expected no changes, got %+v", report)
+	}
+
+	if err := store.MigrateDown(t.Context()); err != nil {
+		t.Fatalf("MigrateDown failed: %v", err)
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'data_schema_migrations_new_schema'").Scan(&tables); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if tables != 0 {
+		t.Fatal("Expected the schema table to be dropped")
+	}
+}
@@ -0,0 +1,149 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+	"github.com/spf13/cast"
+)
+
+// ForTenant returns a view of the store scoped to the tenant, sharing its
+// database and configuration. Every query of the view is filtered by the
+// tenant_id column and every record it creates is stamped with the tenant,
+// so tenants are kept apart without smuggling the tenant into the payloads.
+//
+// Requires NewStoreOptions.TenancyEnabled. Records of other tenants are not
+// found, listed, updated or deleted by the view, and upserting or importing
+// a record with the ID of a record of another tenant fails. Migrating or
+// dropping the table is not supported on the view.
+func (st *storeImplementation) ForTenant(tenantID string) (StoreInterface, error) {
+	if !st.tenancy {
+		return nil, errors.New("customstore store: tenancy is not enabled")
+	}
+
+	if tenantID == "" {
+		return nil, errors.New("customstore store: tenant id is required")
+	}
+
+	view := *st
+	view.tenantID = tenantID
+	view.automigrateEnabled = false
+	return &view, nil
+}
+
+// checkUnscoped returns an error if the store is scoped to a tenant, for
+// the operations on the whole table
+func (st *storeImplementation) checkUnscoped(op string) error {
+	if st.tenantID != "" {
+		return errors.New(op + " is not supported on a tenant scoped store")
+	}
+	return nil
+}
+
+// whereTenant filters the query by the tenant the store is scoped to, if
+// any
+func (st *storeImplementation) whereTenant(q contractsorm.Query) contractsorm.Query {
+	if st.tenantID == "" {
+		return q
+	}
+	return q.Where(COLUMN_TENANT_ID+" = ?", st.tenantID)
+}
+
+// tenantSQL returns the condition filtering a raw statement by the tenant
+// the store is scoped to, prefixed with " AND ", with its argument. Empty
+// if the store is not scoped.
+func (st *storeImplementation) tenantSQL(column string) (string, []any) {
+	if st.tenantID == "" {
+		return "", nil
+	}
+	return " AND " + column + " = ?", []any{st.tenantID}
+}
+
+// checkTenantIDs returns an error if any of the IDs is the ID of a record
+// of another tenant than the one the store is scoped to, before writing
+// records by ID with an upsert
+func (st *storeImplementation) checkTenantIDs(ctx context.Context, q contractsorm.Query, ids []string, op string) error {
+	if st.tenantID == "" || len(ids) == 0 {
+		return nil
+	}
+
+	anyIDs := make([]any, len(ids))
+	for i, id := range ids {
+		anyIDs[i] = id
+	}
+
+	q = q.Table(st.tableName()).
+		Select(COLUMN_ID).
+		WhereIn(COLUMN_ID, anyIDs).
+		Where(COLUMN_TENANT_ID+" <> ?", st.tenantID)
+
+	var rows []map[string]any
+	start := time.Now()
+	err := st.retry(ctx, op, func() error {
+		return q.Get(&rows)
+	})
+	st.logQuery(op, start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return st.wrapError(err, op, "", "", func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	if len(rows) > 0 {
+		return errors.New("record " + cast.ToString(rows[0][COLUMN_ID]) + " belongs to another tenant")
+	}
+
+	return nil
+}
+
+// tenantColumns returns the columns copied with the records, the tenant
+// included if tenancy is enabled
+func (st *storeImplementation) tenantColumns(columns string) string {
+	if !st.tenancy {
+		return columns
+	}
+	return columns + ", " + COLUMN_TENANT_ID
+}
+
+// recordTenants returns the tenants of the records with the IDs, keyed by
+// ID, for the change events of an unscoped store. Nil without tenancy or on
+// a scoped store, whose records all belong to its tenant.
+func (st *storeImplementation) recordTenants(ctx context.Context, ids []string) (map[string]string, error) {
+	if !st.tenancy || st.tenantID != "" || len(ids) == 0 {
+		return nil, nil
+	}
+
+	anyIDs := make([]any, len(ids))
+	for i, id := range ids {
+		anyIDs[i] = id
+	}
+
+	q := st.newQuery(ctx).
+		Table(st.tableName()).
+		Select(COLUMN_ID+", "+COLUMN_TENANT_ID).
+		WhereIn(COLUMN_ID, anyIDs)
+
+	var rows []map[string]any
+	start := time.Now()
+	err := st.retry(ctx, "Changes", func() error {
+		return q.Get(&rows)
+	})
+	st.logQuery("Changes", start, func() (string, []any) {
+		return q.ToRawSql().Get(&rows), nil
+	})
+	if err != nil {
+		return nil, st.wrapError(err, "Changes", "", "", func() string {
+			return q.ToSql().Get(&rows)
+		})
+	}
+
+	tenants := make(map[string]string, len(rows))
+	for _, row := range rows {
+		tenants[cast.ToString(row[COLUMN_ID])] = cast.ToString(row[COLUMN_TENANT_ID])
+	}
+	return tenants, nil
+}
@@ -0,0 +1,140 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreForTenant(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_tenancy",
+		AutomigrateEnabled: true,
+		TenancyEnabled:     true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	acme, err := store.ForTenant("acme")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+	globex, err := store.ForTenant("globex")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+
+	acmeOrder := customstore.NewRecord("order", customstore.WithPayload(`{"total":10}`))
+	if err := acme.RecordCreate(acmeOrder); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	globexOrder := customstore.NewRecord("order", customstore.WithPayload(`{"total":20}`))
+	if err := globex.RecordCreate(globexOrder); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	list, err := acme.RecordList(customstore.NewRecordQuery().SetType("order"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 || list[0].ID() != acmeOrder.ID() {
+		t.Fatalf("Expected only the acme order, got %d records", len(list))
+	}
+
+	found, err := acme.RecordFindByID(globexOrder.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatal("Expected the record of another tenant not to be found")
+	}
+
+	// Writes do not reach the records of other tenants
+	if err := acme.RecordDeleteByID(globexOrder.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+	if err := acme.RecordSoftDeleteByID(globexOrder.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	if err := acme.RecordIncrementPayloadKey(globexOrder.ID(), "total", 1); err == nil {
+		t.Fatal("Expected incrementing the record of another tenant to fail")
+	}
+
+	hijack := customstore.NewRecord("order", customstore.WithPayload(`{"total":0}`))
+	hijack.SetID(globexOrder.ID())
+	if err := acme.RecordUpsert(hijack); err == nil {
+		t.Fatal("Expected upserting the record of another tenant to fail")
+	}
+
+	found, err = globex.RecordFindByID(globexOrder.ID())
+	if err != nil || found == nil {
+		t.Fatalf("Expected the globex order to be kept: %v", err)
+	}
+	if found.Payload() != `{"total":20}` {
+		t.Fatalf("Expected the globex order to be unchanged, got %s", found.Payload())
+	}
+
+	upserted := customstore.NewRecord("order")
+	if err := acme.RecordUpsert(upserted); err != nil {
+		t.Fatalf("RecordUpsert failed: %v", err)
+	}
+
+	count, err := acme.RecordCount(customstore.NewRecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 acme records, got %d", count)
+	}
+
+	// The unscoped store sees every tenant
+	count, err = store.RecordCount(customstore.NewRecordQuery())
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 3 {
+		t.Fatalf("Expected 3 records, got %d", count)
+	}
+
+	rows, err := db.Query("SELECT tenant_id FROM data_tenancy WHERE id = ?", upserted.ID())
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer rows.Close()
+	tenantID := ""
+	if rows.Next() {
+		if err := rows.Scan(&tenantID); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+	}
+	if tenantID != "acme" {
+		t.Fatalf("Expected the upserted record to be stamped with acme, got %q", tenantID)
+	}
+
+	if err := acme.MigrateDown(t.Context()); err == nil {
+		t.Fatal("Expected dropping the table from a tenant view to fail")
+	}
+}
+
+func TestStoreForTenantDisabled(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_tenancy_disabled",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if _, err := store.ForTenant("acme"); err == nil {
+		t.Fatal("Expected an error when tenancy is disabled")
+	}
+}
@@ -887,3 +887,182 @@ func TestRecordQueryPayloadSearch(t *testing.T) {
 		}
 	})
 }
+
+func TestRecordListSoftDeletedIncludedAppliesFilters(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_soft_deleted_filters",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	person1 := customstore.NewRecord("person")
+	person2 := customstore.NewRecord("person")
+	company := customstore.NewRecord("company")
+	for _, record := range []customstore.RecordInterface{person1, person2, company} {
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		if err := store.RecordSoftDelete(record); err != nil {
+			t.Fatalf("RecordSoftDelete failed: %v", err)
+		}
+	}
+
+	// Soft deleted record by ID
+	list, err := store.RecordList(customstore.RecordQuery().SetSoftDeletedIncluded(true).SetID(person2.ID()))
+	if err != nil {
+		t.Fatalf("RecordList by ID failed: %v", err)
+	}
+	if len(list) != 1 || list[0].ID() != person2.ID() {
+		t.Fatalf("Expected only record %s, but got %d records", person2.ID(), len(list))
+	}
+
+	// Type filter
+	count, err := store.RecordCount(customstore.RecordQuery().SetSoftDeletedIncluded(true).SetType("person"))
+	if err != nil {
+		t.Fatalf("RecordCount by type failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected 2 soft deleted persons, but got %d", count)
+	}
+
+	// Pagination
+	list, err = store.RecordList(customstore.RecordQuery().SetSoftDeletedIncluded(true).SetLimit(1).SetOffset(1))
+	if err != nil {
+		t.Fatalf("RecordList with pagination failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 record with limit 1, but got %d", len(list))
+	}
+
+	// Explicitly not included
+	count, err = store.RecordCount(customstore.RecordQuery().SetSoftDeletedIncluded(false))
+	if err != nil {
+		t.Fatalf("RecordCount without soft deleted failed: %v", err)
+	}
+	if count != 0 {
+		t.Fatalf("Expected 0 records when soft deleted are not included, but got %d", count)
+	}
+}
+
+func TestRecordCreateKeepsReplicatedFields(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_create_replicated",
+		AutomigrateEnabled: true,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	deletedAt := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	record := customstore.NewRecord("person",
+		customstore.WithSoftDeletedAt(deletedAt),
+		customstore.WithCreatedBy("replicator"),
+		customstore.WithVersion(5))
+
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetID(record.ID()).SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 record, but got %d", len(list))
+	}
+
+	retrieved := list[0]
+	if retrieved.SoftDeletedAt() != "2024-05-01 10:30:00" {
+		t.Errorf("Expected SoftDeletedAt %q, but got %q", "2024-05-01 10:30:00", retrieved.SoftDeletedAt())
+	}
+	if retrieved.CreatedBy() != "replicator" {
+		t.Errorf("Expected CreatedBy %q, but got %q", "replicator", retrieved.CreatedBy())
+	}
+	if retrieved.Version() != 5 {
+		t.Errorf("Expected Version 5, but got %d", retrieved.Version())
+	}
+}
+
+func TestRecordCreateSoftDeleted(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	// Later than the soft deleted at set by the option
+	clock := &fixedClock{now: time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_record_create_soft_deleted",
+		AutomigrateEnabled: true,
+		Clock:              clock,
+	})
+
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person", customstore.WithSoftDeleted(true))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatalf("Expected record created as soft deleted to be excluded by default")
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().SetID(record.ID()).SetSoftDeletedIncluded(true))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 soft deleted record, but got %d", count)
+	}
+}
+
+func TestRecordListReturnsTimestamps(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_list_timestamps",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person")
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetID(record.ID()))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected 1 record, but got %d", len(list))
+	}
+	if list[0].CreatedAt() != record.CreatedAt() {
+		t.Fatalf("Expected created at %q, but got %q", record.CreatedAt(), list[0].CreatedAt())
+	}
+	if list[0].UpdatedAt() != record.UpdatedAt() {
+		t.Fatalf("Expected updated at %q, but got %q", record.UpdatedAt(), list[0].UpdatedAt())
+	}
+}
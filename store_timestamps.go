@@ -0,0 +1,164 @@
+package customstore
+
+import (
+	"time"
+
+	contractsschema "github.com/dracory/neat/contracts/database/schema"
+	"github.com/spf13/cast"
+)
+
+// TimestampFormat is how the store writes the timestamps of the records
+// (created at, updated at, soft deleted at and expires at) to the table,
+// i.e. to work with an existing table. The zero format is the default,
+// UTC "2006-01-02 15:04:05" strings. The accessors of the records are not
+// affected and keep returning UTC times.
+//
+// The store compares the timestamps in SQL, so string layouts must sort
+// as the times they format: fixed width, the most significant unit first,
+// with a fixed offset.
+type TimestampFormat struct {
+	// Layout is the time layout of the stored strings, i.e. time.RFC3339,
+	// defaults to time.DateTime. Ignored if Native.
+	Layout string
+
+	// Location is the time zone the timestamps are stored in, defaults to
+	// UTC. Use a fixed offset zone with a layout including the offset.
+	Location *time.Location
+
+	// Native binds the timestamps as time.Time values, formatted by the
+	// driver, for native TIMESTAMP or DATETIME columns
+	Native bool
+}
+
+// TimestampRFC3339 stores the timestamps as RFC 3339 strings, with the
+// offset of the location ("Z" for UTC)
+var TimestampRFC3339 = TimestampFormat{Layout: time.RFC3339}
+
+// TimestampNative stores the timestamps as native time values
+var TimestampNative = TimestampFormat{Native: true}
+
+// layout returns the layout of the stored strings
+func (f TimestampFormat) layout() string {
+	if f.Layout == "" {
+		return time.DateTime
+	}
+	return f.Layout
+}
+
+// location returns the time zone of the stored timestamps
+func (f TimestampFormat) location() *time.Location {
+	if f.Location == nil {
+		return time.UTC
+	}
+	return f.Location
+}
+
+// custom returns whether the timestamps are stored as strings of another
+// layout than the columns created by default hold
+func (f TimestampFormat) custom() bool {
+	return !f.Native && f.layout() != time.DateTime
+}
+
+// maxTime is MAX_DATETIME, the soft deleted at and expires at of the
+// records which are not soft deleted or never expire
+var maxTime, _ = time.Parse(time.DateTime, MAX_DATETIME)
+
+// timestamp returns the value of the time written to the table. The far
+// future of MAX_DATETIME is kept as is, as converting it to the location
+// might overflow the year.
+func (st *storeImplementation) timestamp(t time.Time) any {
+	location := st.timestampFormat.location()
+	if !t.Before(maxTime) {
+		t = time.Date(9999, 12, 31, 23, 59, 59, 0, location)
+	} else {
+		t = t.In(location)
+	}
+
+	if st.timestampFormat.Native {
+		return t
+	}
+	return t.Format(st.timestampFormat.layout())
+}
+
+// nowTimestamp returns the value of the current store time written to the
+// table, i.e. to compare with the soft deleted at
+func (st *storeImplementation) nowTimestamp() any {
+	return st.timestamp(st.now())
+}
+
+// datetimeTimestamp returns the value written to the table of a date time
+// formatted as MAX_DATETIME in UTC, i.e. the dates of the query filters.
+// Values which do not parse are returned as is.
+func (st *storeImplementation) datetimeTimestamp(datetime string) any {
+	t, err := time.Parse(time.DateTime, datetime)
+	if err != nil {
+		return datetime
+	}
+	return st.timestamp(t)
+}
+
+// parseTimestamp returns the time of a timestamp read from the table. The
+// drivers parse some layouts themselves, setting the wall clock in UTC, in
+// which case the wall clock is taken in the location of the format.
+func (st *storeImplementation) parseTimestamp(value any) time.Time {
+	location := st.timestampFormat.location()
+
+	var t time.Time
+	switch v := value.(type) {
+	case nil:
+		return time.Time{}
+	case time.Time:
+		t = v
+		if v.Location() == time.UTC && location != time.UTC {
+			t = time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), v.Nanosecond(), location)
+		}
+	default:
+		text := cast.ToString(value)
+		parsed, err := time.ParseInLocation(st.timestampFormat.layout(), text, location)
+		if err != nil {
+			parsed, _ = cast.ToTimeInDefaultLocationE(text, location)
+		}
+		t = parsed
+	}
+
+	// MAX_DATETIME is stored as is in the location, see timestamp
+	wall := t.In(location)
+	if !time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), 0, time.UTC).Before(maxTime) {
+		return maxTime
+	}
+	return t.UTC()
+}
+
+// timestampColumn adds the timestamp column to the table, a string column
+// for a custom layout as the date time columns of some databases would
+// convert the strings
+func (st *storeImplementation) timestampColumn(table contractsschema.Blueprint, column string) contractsschema.ColumnDefinition {
+	if st.timestampFormat.custom() {
+		return table.String(column, 64)
+	}
+	return table.DateTime(column)
+}
+
+// maxTimestampDefault returns the default of the columns holding
+// MAX_DATETIME for the records which never expire
+func (st *storeImplementation) maxTimestampDefault() any {
+	if st.timestampFormat.custom() {
+		return st.timestamp(maxTime)
+	}
+	return MAX_DATETIME
+}
+
+// scannedTimestamp is a timestamp column of a selected row, kept as
+// returned by the driver until parsed by the store, which knows its format
+type scannedTimestamp struct {
+	value any
+}
+
+// Scan implements sql.Scanner
+func (s *scannedTimestamp) Scan(src any) error {
+	if b, ok := src.([]byte); ok {
+		src = string(b)
+	}
+	s.value = src
+	return nil
+}
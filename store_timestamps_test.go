@@ -0,0 +1,109 @@
+package customstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreTimestampFormat(t *testing.T) {
+	now := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	plusTwo := time.FixedZone("+02:00", 2*60*60)
+
+	formats := map[string]struct {
+		format  customstore.TimestampFormat
+		stored  string
+		maxDate string
+	}{
+		"default": {customstore.TimestampFormat{}, "2030-01-02 03:04:05", "9999-12-31 23:59:59"},
+		"rfc3339": {customstore.TimestampFormat{Layout: time.RFC3339, Location: plusTwo}, "2030-01-02T05:04:05+02:00", "9999-12-31T23:59:59+02:00"},
+		"layout":  {customstore.TimestampFormat{Layout: "20060102150405"}, "20300102030405", "99991231235959"},
+		"native":  {customstore.TimestampNative, "", ""},
+	}
+
+	for name, tc := range formats {
+		t.Run(name, func(t *testing.T) {
+			db := InitDB()
+			defer db.Close()
+
+			clock := &fixedClock{now: now}
+			store, err := customstore.NewStore(customstore.NewStoreOptions{
+				DB:                 db,
+				TableName:          "data_timestamps",
+				AutomigrateEnabled: true,
+				Clock:              clock,
+				TimestampFormat:    tc.format,
+			})
+			if err != nil {
+				t.Fatalf("Store could not be created: %v", err)
+			}
+
+			record := customstore.NewRecord("post")
+			if err := store.RecordCreate(record); err != nil {
+				t.Fatalf("RecordCreate failed: %v", err)
+			}
+			expiring := customstore.NewRecord("post", customstore.WithExpiresAt(now.Add(time.Hour)))
+			if err := store.RecordCreate(expiring); err != nil {
+				t.Fatalf("RecordCreate failed: %v", err)
+			}
+
+			if tc.stored != "" {
+				var createdAt, softDeletedAt string
+				err := db.QueryRow("SELECT CAST(created_at AS TEXT), CAST(soft_deleted_at AS TEXT) FROM data_timestamps WHERE id = ?", record.ID()).Scan(&createdAt, &softDeletedAt)
+				if err != nil {
+					t.Fatalf("Select failed: %v", err)
+				}
+				if createdAt != tc.stored || softDeletedAt != tc.maxDate {
+					t.Fatalf("Expected %q and %q to be stored, but got %q and %q", tc.stored, tc.maxDate, createdAt, softDeletedAt)
+				}
+			}
+
+			found, err := store.RecordFindByID(record.ID())
+			if err != nil || found == nil {
+				t.Fatalf("RecordFindByID failed: %v, %v", found, err)
+			}
+			if !found.CreatedAtCarbon().StdTime().Equal(now) || found.CreatedAt() != "2030-01-02 03:04:05" {
+				t.Fatalf("Expected the created at to read back as %v, but got %q", now, found.CreatedAt())
+			}
+			if found.SoftDeletedAt() != customstore.MAX_DATETIME || found.IsSoftDeleted() {
+				t.Fatalf("Expected the record not to be soft deleted, but got %q", found.SoftDeletedAt())
+			}
+
+			count, err := store.RecordCount(customstore.RecordQuery().SetType("post").SetCreatedAtGte("2030-01-02 03:00:00"))
+			if err != nil || count != 2 {
+				t.Fatalf("Expected 2 records created since 03:00, but got %d, %v", count, err)
+			}
+			count, err = store.RecordCount(customstore.RecordQuery().SetType("post").SetCreatedAtGte("2030-01-02 04:00:00"))
+			if err != nil || count != 0 {
+				t.Fatalf("Expected no record created since 04:00, but got %d, %v", count, err)
+			}
+
+			// Expiry and soft deletes compare against the stored timestamps
+			clock.now = now.Add(2 * time.Hour)
+			count, err = store.RecordCount(customstore.RecordQuery().SetType("post"))
+			if err != nil || count != 1 {
+				t.Fatalf("Expected the expired record to be filtered, but got %d, %v", count, err)
+			}
+
+			if err := store.RecordSoftDeleteByID(record.ID()); err != nil {
+				t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+			}
+			count, err = store.RecordCount(customstore.RecordQuery().SetType("post"))
+			if err != nil || count != 0 {
+				t.Fatalf("Expected the soft deleted record to be filtered, but got %d, %v", count, err)
+			}
+
+			if err := store.RecordRestoreByID(record.ID()); err != nil {
+				t.Fatalf("RecordRestore failed: %v", err)
+			}
+			found, err = store.RecordFindByID(record.ID())
+			if err != nil || found == nil {
+				t.Fatalf("Expected the restored record to be found, but got %v, %v", found, err)
+			}
+			if found.UpdatedAt() != "2030-01-02 05:04:05" {
+				t.Fatalf("Expected the updated at of the restore, but got %q", found.UpdatedAt())
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// RecordTouch sets the updated at of the record with the ID to the current
+// store time, i.e. for "last seen" or least recently used logic, in a
+// single UPDATE of that column only. The version is not incremented, and
+// no hooks, change events, audit entries or revisions are recorded. Soft
+// deleted and expired records are not touched, returning an error as a
+// missing record does.
+func (st *storeImplementation) RecordTouch(id string) error {
+	return st.RecordTouchContext(context.Background(), id)
+}
+
+// RecordTouchContext is RecordTouch using the given context
+func (st *storeImplementation) RecordTouchContext(ctx context.Context, id string) error {
+	if st.sharded() {
+		view, err := st.forID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return view.RecordTouchContext(ctx, id)
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if id == "" {
+		return errors.New("record id is empty")
+	}
+
+	now := st.nowTimestamp()
+	row := map[string]any{
+		COLUMN_UPDATED_AT: now,
+	}
+
+	unlock := st.lockWrite()
+	defer unlock()
+
+	q := st.whereTenant(st.newQuery(ctx).
+		Table(st.tableName()).
+		Where(COLUMN_ID+" = ?", id).
+		Where(COLUMN_SOFT_DELETED_AT+" > ?", now).
+		Where(COLUMN_EXPIRES_AT+" > ?", now))
+
+	start := time.Now()
+	result, err := st.retryResult(ctx, "RecordTouch", func() (*contractsorm.Result, error) {
+		return q.Update(row)
+	})
+	st.logQuery("RecordTouch", start, func() (string, []any) {
+		return q.ToRawSql().Update(row), nil
+	})
+	if err != nil {
+		return st.wrapError(err, "RecordTouch", id, "", func() string {
+			return q.ToSql().Update(row)
+		})
+	}
+
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return st.copyToMigrationTarget(ctx, []string{id})
+}
@@ -0,0 +1,67 @@
+package customstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreRecordTouch(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	clock := &fixedClock{now: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_touch",
+		AutomigrateEnabled: true,
+		Clock:              clock,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("session", customstore.WithPayload(`{"user":"alice"}`))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	clock.now = clock.now.Add(time.Hour)
+	if err := store.RecordTouch(record.ID()); err != nil {
+		t.Fatalf("RecordTouch failed: %v", err)
+	}
+
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v, %v", found, err)
+	}
+	if found.UpdatedAt() != "2030-01-02 04:04:05" {
+		t.Fatalf("Expected the updated at to be touched, but got %q", found.UpdatedAt())
+	}
+	if found.CreatedAt() != "2030-01-02 03:04:05" || found.Version() != record.Version() || found.Payload() != record.Payload() {
+		t.Fatalf("Expected the rest of the record to be unchanged, but got %q, %d, %q", found.CreatedAt(), found.Version(), found.Payload())
+	}
+
+	if err := store.RecordTouch("missing"); err == nil {
+		t.Fatal("Expected an error for a missing record")
+	}
+	if err := store.RecordSoftDeleteByID(record.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	if err := store.RecordTouch(record.ID()); err == nil {
+		t.Fatal("Expected an error for a soft deleted record")
+	}
+	if err := store.RecordTouch(""); err == nil {
+		t.Fatal("Expected an error for an empty id")
+	}
+
+	// Touching a record in memory
+	before := time.Now().UTC().Add(-time.Second)
+	record.SetUpdatedAt("2000-01-01 00:00:00")
+	record.Touch()
+	if record.UpdatedAtCarbon().StdTime().Before(before) {
+		t.Fatalf("Expected Touch to set the updated at to now, but got %q", record.UpdatedAt())
+	}
+}
@@ -0,0 +1,153 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+)
+
+// TransactionInterface is a store bound to a transaction, as passed to
+// RunInTransaction. All its methods run within the transaction.
+type TransactionInterface interface {
+	StoreInterface
+
+	// Savepoint marks a point in the transaction to roll back to
+	Savepoint(name string) error
+
+	// RollbackTo undoes the work done since the savepoint, keeping the
+	// transaction (and the work done before the savepoint) open
+	RollbackTo(name string) error
+}
+
+var _ TransactionInterface = (*transactionImplementation)(nil)
+
+type transactionImplementation struct {
+	*storeImplementation
+	ctx context.Context
+}
+
+// RunInTransaction runs fn with a store bound to a new transaction. The
+// transaction is committed if fn returns nil, and rolled back otherwise.
+//
+// Within the transaction, use Savepoint and RollbackTo to undo a single
+// failed step (i.e. one item of a batch) without abandoning the whole
+// transaction:
+//
+//	err := store.RunInTransaction(ctx, func(tx customstore.TransactionInterface) error {
+//		for _, record := range records {
+//			if err := tx.Savepoint("item"); err != nil {
+//				return err
+//			}
+//			if err := tx.RecordCreate(record); err != nil {
+//				if err := tx.RollbackTo("item"); err != nil {
+//					return err
+//				}
+//			}
+//		}
+//		return nil
+//	})
+//
+// When called on a store already bound to a transaction, fn runs within
+// that transaction.
+//
+// With NewStoreOptions.TransactionRetryPolicy, fn runs again in a new
+// transaction when the transaction fails with a deadlock or serialization
+// failure, so fn must not have effects outside the transaction (or must be
+// safe to repeat). The hooks of the failed attempts have run already, their
+// changes are not reported by Changes.
+func (st *storeImplementation) RunInTransaction(ctx context.Context, fn func(tx TransactionInterface) error) error {
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if fn == nil {
+		return errors.New("transaction function is nil")
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if st.tx != nil {
+		return st.runTransaction(ctx, fn)
+	}
+
+	// Only the outermost transaction runs again, with a fresh transaction
+	return st.txRetryPolicy.do(ctx, st.isTransactionConflict, func(attempt int, delay time.Duration, err error) {
+		if st.debugEnabled {
+			st.logger.Warn("Retrying transaction conflict", "attempt", attempt, "delay", delay, "error", err)
+		}
+	}, func() error {
+		return st.runTransaction(ctx, fn)
+	})
+}
+
+// runTransaction runs fn with a store bound to a new transaction, or to the
+// transaction the store is bound to
+func (st *storeImplementation) runTransaction(ctx context.Context, fn func(tx TransactionInterface) error) error {
+	return st.inTransaction(func(bound *storeImplementation) error {
+		return fn(&transactionImplementation{storeImplementation: bound, ctx: ctx})
+	})
+}
+
+// inTransaction runs fn with a copy of the store bound to a new
+// transaction, or to the transaction the store is bound to. Changes are
+// published once the outermost transaction is committed.
+func (st *storeImplementation) inTransaction(fn func(st *storeImplementation) error) error {
+	changes := st.txChanges
+	if changes == nil {
+		changes = &changeBuffer{}
+	}
+
+	err := st.transaction(func(tx contractsorm.Query) error {
+		bound := *st
+		bound.tx = tx
+		bound.txChanges = changes
+		return fn(&bound)
+	})
+
+	if err == nil && st.txChanges == nil && len(changes.events) > 0 {
+		st.changes.deliver(changes.events)
+	}
+
+	return err
+}
+
+// isTransactionConflict returns whether the error is a deadlock or a
+// serialization failure of the database of the store
+func (st *storeImplementation) isTransactionConflict(err error) bool {
+	return isTransactionConflict(st.driverName(), err)
+}
+
+// transaction runs fn in a new transaction, or in the transaction the
+// store is bound to
+func (st *storeImplementation) transaction(fn func(tx contractsorm.Query) error) error {
+	if st.tx != nil {
+		return fn(st.tx)
+	}
+	return st.db.Transaction(fn)
+}
+
+func (t *transactionImplementation) Savepoint(name string) error {
+	if err := cloneQuery(t.ctx, t.tx).SavePoint(name); err != nil {
+		return err
+	}
+	t.txChanges.savepoint(name)
+	return nil
+}
+
+// RollbackTo also drops the changes made since the savepoint, so they are
+// not reported by Changes once the transaction is committed
+func (t *transactionImplementation) RollbackTo(name string) error {
+	if err := cloneQuery(t.ctx, t.tx).RollbackTo(name); err != nil {
+		return err
+	}
+	t.txChanges.rollbackTo(name)
+	return nil
+}
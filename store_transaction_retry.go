@@ -0,0 +1,73 @@
+package customstore
+
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
+// conflictSQLStates are the SQLSTATE codes of the serialization failures
+// (40001) and deadlocks (40P01) reported by the drivers exposing them
+var conflictSQLStates = []string{"40001", "40P01"}
+
+// transactionConflictMessages are the messages of the errors of the drivers
+// reporting a transaction which must be run again, per driver
+var transactionConflictMessages = map[string][]string{
+	"postgres": {
+		"could not serialize access", // 40001
+		"deadlock detected",          // 40P01
+		"sqlstate 40001",
+		"sqlstate 40p01",
+	},
+	"mysql": {
+		"error 1213", // ER_LOCK_DEADLOCK
+		"error 1205", // ER_LOCK_WAIT_TIMEOUT
+		"deadlock found when trying to get lock",
+		"lock wait timeout exceeded",
+	},
+	"sqlite": {
+		"database is locked", // SQLITE_BUSY, i.e. upgrading a read to a write
+		"sqlite_busy",
+	},
+	"sqlserver": {
+		"was deadlocked", // 1205
+	},
+}
+
+// IsTransactionConflict returns whether the error is a deadlock or a
+// serialization failure of a transaction, which succeeds when run again:
+// SQLSTATE 40001 or 40P01 on PostgreSQL, errors 1213 and 1205 on MySQL and
+// a busy database on SQLite
+func IsTransactionConflict(err error) bool {
+	for driver := range transactionConflictMessages {
+		if isTransactionConflict(driver, err) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTransactionConflict returns whether the error of the driver is a
+// deadlock or a serialization failure
+func isTransactionConflict(driver string, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) && slices.Contains(conflictSQLStates, stateErr.SQLState()) {
+		return true
+	}
+
+	// The store errors hide the message of the driver error they wrap
+	for ; err != nil; err = errors.Unwrap(err) {
+		message := strings.ToLower(err.Error())
+		for _, conflict := range transactionConflictMessages[driver] {
+			if strings.Contains(message, conflict) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,111 @@
+package customstore_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreTransactionRetryPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transaction_retry.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	locker, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer locker.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_transaction_retry",
+		AutomigrateEnabled: true,
+		TransactionRetryPolicy: customstore.RetryPolicy{
+			MaxAttempts: 50,
+			Backoff:     func(retry int) time.Duration { return 10 * time.Millisecond },
+		},
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// Another connection holds the write lock for a while
+	conn, err := locker.Conn(t.Context())
+	if err != nil {
+		t.Fatalf("Conn failed: %v", err)
+	}
+	if _, err := conn.ExecContext(t.Context(), "BEGIN IMMEDIATE"); err != nil {
+		t.Fatalf("Begin failed: %v", err)
+	}
+	released := make(chan struct{})
+	go func() {
+		defer close(released)
+		time.Sleep(100 * time.Millisecond)
+		conn.ExecContext(context.Background(), "COMMIT")
+		conn.Close()
+	}()
+
+	attempts := 0
+	err = store.RunInTransaction(t.Context(), func(tx customstore.TransactionInterface) error {
+		attempts++
+		if err := tx.RecordCreate(customstore.NewRecord("note")); err != nil {
+			return err
+		}
+		return tx.RecordCreate(customstore.NewRecord("note"))
+	})
+	<-released
+	if err != nil {
+		t.Fatalf("Expected the transaction to be retried, got %v", err)
+	}
+	if attempts < 2 {
+		t.Fatalf("Expected several attempts, got %d", attempts)
+	}
+
+	count, err := store.RecordCount(customstore.NewRecordQuery().SetType("note"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("Expected the records of one attempt only, got %d", count)
+	}
+
+	// Other failures are not retried
+	attempts = 0
+	failure := errors.New("failed")
+	err = store.RunInTransaction(t.Context(), func(tx customstore.TransactionInterface) error {
+		attempts++
+		return failure
+	})
+	if !errors.Is(err, failure) || attempts != 1 {
+		t.Fatalf("Expected a single failed attempt, got %d attempts and %v", attempts, err)
+	}
+}
+
+func TestIsTransactionConflict(t *testing.T) {
+	conflicts := []error{
+		errors.New("pq: could not serialize access due to concurrent update"),
+		errors.New("ERROR: deadlock detected (SQLSTATE 40P01)"),
+		errors.New("Error 1213 (40001): Deadlock found when trying to get lock; try restarting transaction"),
+		errors.New("database is locked (5) (SQLITE_BUSY)"),
+	}
+	for _, err := range conflicts {
+		if !customstore.IsTransactionConflict(err) {
+			t.Fatalf("Expected %q to be a conflict", err)
+		}
+	}
+
+	if customstore.IsTransactionConflict(errors.New("UNIQUE constraint failed: data.id")) {
+		t.Fatal("Expected a constraint failure not to be a conflict")
+	}
+	if customstore.IsTransactionConflict(nil) {
+		t.Fatal("Expected nil not to be a conflict")
+	}
+}
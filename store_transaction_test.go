@@ -0,0 +1,105 @@
+package customstore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRunInTransactionSavepoint(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_transaction_savepoint",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	existing := customstore.NewRecord("person")
+	if err := store.RecordCreate(existing); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	first := customstore.NewRecord("person")
+	duplicate := customstore.NewRecord("person", customstore.WithID(existing.ID()))
+	last := customstore.NewRecord("person")
+
+	failed := 0
+	err = store.RunInTransaction(context.Background(), func(tx customstore.TransactionInterface) error {
+		for _, record := range []customstore.RecordInterface{first, duplicate, last} {
+			if err := tx.Savepoint("item"); err != nil {
+				return err
+			}
+			if err := tx.RecordCreate(record); err != nil {
+				failed++
+				if err := tx.RollbackTo("item"); err != nil {
+					return err
+				}
+			}
+		}
+
+		count, err := tx.RecordCount(customstore.RecordQuery())
+		if err != nil {
+			return err
+		}
+		if count != 3 {
+			t.Errorf("Expected 3 records within the transaction, but got %d", count)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunInTransaction failed: %v", err)
+	}
+
+	if failed != 1 {
+		t.Fatalf("Expected 1 failed item, but got %d", failed)
+	}
+
+	for _, record := range []customstore.RecordInterface{first, last} {
+		found, err := store.RecordFindByID(record.ID())
+		if err != nil || found == nil {
+			t.Fatalf("Expected record %s to be committed, but got %v", record.ID(), err)
+		}
+	}
+}
+
+func TestRunInTransactionRollback(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_transaction_rollback",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person")
+	errAbort := errors.New("abort")
+
+	err = store.RunInTransaction(context.Background(), func(tx customstore.TransactionInterface) error {
+		if err := tx.RecordCreate(record); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("Expected the function error to be returned, but got %v", err)
+	}
+
+	found, err := store.RecordFindByID(record.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if found != nil {
+		t.Fatalf("Expected record to be rolled back")
+	}
+}
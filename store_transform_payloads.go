@@ -0,0 +1,162 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+)
+
+// PayloadTransformFunc transforms a payload, returning the new payload and
+// whether it changed. Unchanged records are not written back.
+type PayloadTransformFunc func(payload map[string]any) (map[string]any, bool, error)
+
+// TransformPayloadsOptions configures a TransformPayloads run
+type TransformPayloadsOptions struct {
+	// BatchSize is the number of records read and written per batch,
+	// defaults to 100
+	BatchSize int
+
+	// ResumeToken continues an interrupted run after the last completed
+	// batch, as reported by TransformPayloadsProgress.ResumeToken
+	ResumeToken string
+
+	// SoftDeletedIncluded also transforms soft deleted records
+	SoftDeletedIncluded bool
+
+	// DryRun runs the transform without writing the changed records
+	DryRun bool
+
+	// OnProgress is called after every completed batch, i.e. to persist the
+	// resume token
+	OnProgress func(progress TransformPayloadsProgress)
+}
+
+// TransformPayloadsProgress reports the progress of a TransformPayloads run
+type TransformPayloadsProgress struct {
+	// Scanned is the number of records the transform was applied to
+	Scanned int
+
+	// Changed is the number of records written back
+	Changed int
+
+	// ResumeToken points after the last completed batch, empty when the run
+	// completed
+	ResumeToken string
+}
+
+// TransformPayloads applies fn to the payloads of all records of the type,
+// writing back the changed ones in batches, each batch in a transaction.
+//
+// Records are visited in descending ID order. A failed run can be resumed
+// from the last completed batch by passing the reported resume token in
+// TransformPayloadsOptions.ResumeToken.
+func (st *storeImplementation) TransformPayloads(recordType string, fn PayloadTransformFunc, opts TransformPayloadsOptions) (TransformPayloadsProgress, error) {
+	if st.sharded() {
+		return st.forType(recordType).TransformPayloads(recordType, fn, opts)
+	}
+
+	progress := TransformPayloadsProgress{ResumeToken: opts.ResumeToken}
+
+	if err := st.checkWritable(); err != nil {
+		return progress, err
+	}
+
+	if st.db == nil {
+		return progress, errors.New("database is not initialized")
+	}
+
+	if recordType == "" {
+		return progress, errors.New("record type is required")
+	}
+
+	if fn == nil {
+		return progress, errors.New("transform function is required")
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = payloadMigrationBatchSize
+	}
+
+	query := NewRecordQuery().
+		SetType(recordType).
+		SetOrderBy(COLUMN_ID).
+		SetLimit(batchSize).
+		SetSoftDeletedIncluded(opts.SoftDeletedIncluded).
+		SetExpiredIncluded(true)
+
+	if opts.ResumeToken != "" {
+		token, err := DecodePageToken(opts.ResumeToken)
+		if err != nil {
+			return progress, err
+		}
+		if token.OrderBy != COLUMN_ID {
+			return progress, errors.New("resume token is not a transform payloads token")
+		}
+		query.SetPageToken(token)
+	}
+
+	for {
+		list, err := st.RecordListContext(WithPrimaryReads(context.Background()), query)
+		if err != nil {
+			return progress, err
+		}
+
+		if len(list) == 0 {
+			progress.ResumeToken = ""
+			return progress, nil
+		}
+
+		changed := []RecordInterface{}
+		for _, record := range list {
+			payload, err := record.PayloadMap()
+			if err != nil {
+				return progress, errors.New("decoding payload of record " + record.ID() + ": " + err.Error())
+			}
+
+			payload, isChanged, err := fn(payload)
+			if err != nil {
+				return progress, errors.New("transforming payload of record " + record.ID() + ": " + err.Error())
+			}
+
+			if !isChanged {
+				continue
+			}
+
+			if err := record.SetPayloadMap(payload); err != nil {
+				return progress, err
+			}
+			changed = append(changed, record)
+		}
+
+		if len(changed) > 0 && !opts.DryRun {
+			err := st.RunInTransaction(context.Background(), func(tx TransactionInterface) error {
+				for _, record := range changed {
+					if err := tx.RecordUpdate(record); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return progress, err
+			}
+		}
+
+		token, err := NewPageToken(COLUMN_ID, list[len(list)-1])
+		if err != nil {
+			return progress, err
+		}
+		query.SetPageToken(token)
+
+		progress.Scanned += len(list)
+		progress.Changed += len(changed)
+		progress.ResumeToken, err = token.Encode()
+		if err != nil {
+			return progress, err
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package customstore_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestTransformPayloads(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_transform_payloads",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		status := "active"
+		if i%2 == 0 {
+			status = "ACTIVE"
+		}
+		record := customstore.NewRecord("person", customstore.WithPayloadMap(map[string]any{"status": status}))
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
+		}
+	}
+	if err := store.RecordCreate(customstore.NewRecord("order", customstore.WithPayloadMap(map[string]any{"status": "ACTIVE"}))); err != nil {
+		t.Fatalf("RecordCreate order failed: %v", err)
+	}
+
+	lowercase := func(payload map[string]any) (map[string]any, bool, error) {
+		if payload["status"] != "ACTIVE" {
+			return payload, false, nil
+		}
+		payload["status"] = "active"
+		return payload, true, nil
+	}
+
+	batches := 0
+	progress, err := store.TransformPayloads("person", lowercase, customstore.TransformPayloadsOptions{
+		BatchSize: 2,
+		OnProgress: func(progress customstore.TransformPayloadsProgress) {
+			batches++
+			if progress.ResumeToken == "" {
+				t.Fatalf("Expected a resume token in the batch progress")
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("TransformPayloads failed: %v", err)
+	}
+	if progress.Scanned != 5 || progress.Changed != 3 || progress.ResumeToken != "" {
+		t.Fatalf("Expected 5 scanned and 3 changed records, but got %+v", progress)
+	}
+	if batches != 3 {
+		t.Fatalf("Expected 3 batches, but got %d", batches)
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery())
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	for _, record := range list {
+		want := "active"
+		if record.Type() == "order" {
+			want = "ACTIVE"
+		}
+		if got := record.PayloadString("status", ""); got != want {
+			t.Fatalf("Expected %s status %q, but got %q", record.Type(), want, got)
+		}
+	}
+}
+
+func TestTransformPayloadsResume(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_transform_payloads_resume",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		record := customstore.NewRecord("person", customstore.WithPayloadMap(map[string]any{"count": i}))
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate record %d failed: %v", i+1, err)
+		}
+	}
+
+	errFailed := errors.New("failed")
+	calls := 0
+	increment := func(payload map[string]any) (map[string]any, bool, error) {
+		calls++
+		if calls == 3 {
+			return nil, false, errFailed
+		}
+		payload["transformed"] = true
+		return payload, true, nil
+	}
+
+	progress, err := store.TransformPayloads("person", increment, customstore.TransformPayloadsOptions{BatchSize: 2})
+	if err == nil {
+		t.Fatalf("Expected the failing transform to return an error")
+	}
+	if progress.Changed != 2 || progress.ResumeToken == "" {
+		t.Fatalf("Expected the first batch to be completed with a resume token, but got %+v", progress)
+	}
+
+	progress, err = store.TransformPayloads("person", increment, customstore.TransformPayloadsOptions{
+		BatchSize:   2,
+		ResumeToken: progress.ResumeToken,
+	})
+	if err != nil {
+		t.Fatalf("Resumed TransformPayloads failed: %v", err)
+	}
+	if progress.Scanned != 2 || progress.Changed != 2 {
+		t.Fatalf("Expected the resumed run to transform the remaining 2 records, but got %+v", progress)
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetType("person"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	for _, record := range list {
+		if !record.PayloadBool("transformed", false) {
+			t.Fatalf("Expected record %s to be transformed", record.ID())
+		}
+	}
+}
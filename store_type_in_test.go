@@ -0,0 +1,48 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordListTypeIn(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_type_in",
+		AutomigrateEnabled: true,
+		RequireTypeFilter:  true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, recordType := range []string{"invoice", "invoice", "order", "customer"} {
+		if err := store.RecordCreate(customstore.NewRecord(recordType)); err != nil {
+			t.Fatalf("RecordCreate %s failed: %v", recordType, err)
+		}
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetTypeIn([]string{"invoice", "order"}))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("Expected 3 invoice and order records, but got %d", len(list))
+	}
+	for _, record := range list {
+		if record.Type() != "invoice" && record.Type() != "order" {
+			t.Fatalf("Unexpected record type %q", record.Type())
+		}
+	}
+
+	if err := customstore.RecordQuery().SetTypeIn([]string{}).Validate(); err == nil {
+		t.Fatalf("Expected error for an empty type in list, but got nil")
+	}
+	if err := customstore.RecordQuery().SetTypeIn([]string{"invoice", ""}).Validate(); err == nil {
+		t.Fatalf("Expected error for an empty type in the list, but got nil")
+	}
+}
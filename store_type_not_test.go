@@ -0,0 +1,84 @@
+package customstore_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreQueryTypeNot(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_type_not",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, recordType := range []string{"order", "invoice", "audit", "lock"} {
+		if err := store.RecordCreate(customstore.NewRecord(recordType)); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+	}
+
+	listTypes := func(query customstore.RecordQueryInterface) []string {
+		t.Helper()
+		list, err := store.RecordList(query)
+		if err != nil {
+			t.Fatalf("RecordList failed: %v", err)
+		}
+		types := []string{}
+		for _, record := range list {
+			types = append(types, record.Type())
+		}
+		slices.Sort(types)
+		return types
+	}
+
+	if types := listTypes(customstore.RecordQuery().SetTypeNot("audit")); !slices.Equal(types, []string{"invoice", "lock", "order"}) {
+		t.Fatalf("Unexpected types: %v", types)
+	}
+	if types := listTypes(customstore.RecordQuery().SetTypeNotIn([]string{"audit", "lock"})); !slices.Equal(types, []string{"invoice", "order"}) {
+		t.Fatalf("Unexpected types: %v", types)
+	}
+
+	// Combined with a positive filter
+	query := customstore.RecordQuery().SetTypeIn([]string{"order", "audit"}).SetTypeNot("audit")
+	if types := listTypes(query); !slices.Equal(types, []string{"order"}) {
+		t.Fatalf("Unexpected types: %v", types)
+	}
+
+	if err := customstore.RecordQuery().SetTypeNotIn([]string{}).Validate(); err == nil {
+		t.Fatal("Expected an error for an empty type not in list")
+	}
+	if err := customstore.RecordQuery().SetTypeNotIn([]string{"audit", ""}).Validate(); err == nil {
+		t.Fatal("Expected an error for an empty type in the type not in list")
+	}
+}
+
+func TestStoreQueryTypeNotRequireTypeFilter(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_type_not_required",
+		AutomigrateEnabled: true,
+		RequireTypeFilter:  true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// Excluding types still scans every other type
+	_, err = store.RecordList(customstore.RecordQuery().SetTypeNot("audit"))
+	if !errors.Is(err, customstore.ErrTypeFilterRequired) {
+		t.Fatalf("Expected ErrTypeFilterRequired, got %v", err)
+	}
+}
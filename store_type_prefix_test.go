@@ -0,0 +1,58 @@
+package customstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordListTypePrefix(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_type_prefix",
+		AutomigrateEnabled: true,
+		RequireTypeFilter:  true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	for _, recordType := range []string{"shop.order", "shop.customer", "shopping", "shop_x.order", "blog.post"} {
+		if err := store.RecordCreate(customstore.NewRecord(recordType)); err != nil {
+			t.Fatalf("RecordCreate %s failed: %v", recordType, err)
+		}
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetTypePrefix("shop."))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 shop. records, but got %d", len(list))
+	}
+	for _, record := range list {
+		if record.Type() != "shop.order" && record.Type() != "shop.customer" {
+			t.Fatalf("Unexpected record type %q", record.Type())
+		}
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().SetTypePrefix("shop_"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected the underscore to match literally, but got %d records", count)
+	}
+
+	count, err = store.Query().TypePrefix("blog.").Count(context.Background())
+	if err != nil {
+		t.Fatalf("Query Count failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 blog. record, but got %d", count)
+	}
+}
@@ -0,0 +1,212 @@
+package customstore
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// newTypeTables returns the states of the tables of the record types
+// mapped by NewStoreOptions.TypeTableMap, keyed by record type, with the
+// states of the distinct tables sorted by name. Types mapped to the default
+// table are left out.
+func newTypeTables(defaultTable string, typeTableMap map[string]string) (map[string]*tableState, []*tableState, error) {
+	if len(typeTableMap) == 0 {
+		return nil, nil, nil
+	}
+
+	typeTables := map[string]*tableState{}
+	byName := map[string]*tableState{}
+	for recordType, tableName := range typeTableMap {
+		if recordType == "" {
+			return nil, nil, errors.New("customstore store: type table map has an empty record type")
+		}
+		if tableName == "" {
+			return nil, nil, errors.New("customstore store: type table map has no table for record type " + recordType)
+		}
+		if tableName == defaultTable {
+			continue
+		}
+
+		state, ok := byName[tableName]
+		if !ok {
+			state = &tableState{name: tableName}
+			byName[tableName] = state
+		}
+		typeTables[recordType] = state
+	}
+
+	shardTables := make([]*tableState, 0, len(byName))
+	for _, state := range byName {
+		shardTables = append(shardTables, state)
+	}
+	sort.Slice(shardTables, func(i, j int) bool {
+		return shardTables[i].name < shardTables[j].name
+	})
+
+	return typeTables, shardTables, nil
+}
+
+// sharded returns whether record types are routed to their own tables, see
+// NewStoreOptions.TypeTableMap
+func (st *storeImplementation) sharded() bool {
+	return len(st.typeTables) > 0
+}
+
+// onTable returns a view of the store using the table, which does not
+// route record types any further
+func (st *storeImplementation) onTable(tables *tableState) *storeImplementation {
+	view := *st
+	view.tables = tables
+	view.typeTables = nil
+	view.shardTables = nil
+	return &view
+}
+
+// tableViews returns a view of the store per table, the default table
+// first, or the store itself if record types are not routed
+func (st *storeImplementation) tableViews() []*storeImplementation {
+	if !st.sharded() {
+		return []*storeImplementation{st}
+	}
+
+	views := []*storeImplementation{st.onTable(st.tables)}
+	for _, tables := range st.shardTables {
+		views = append(views, st.onTable(tables))
+	}
+	return views
+}
+
+// forType returns a view of the store using the table of the record type
+func (st *storeImplementation) forType(recordType string) *storeImplementation {
+	if tables, ok := st.typeTables[recordType]; ok {
+		return st.onTable(tables)
+	}
+	return st.onTable(st.tables)
+}
+
+// forQuery returns a view of the store using the table of the types the
+// query filters by. Queries without a type filter use the default table,
+// queries of types stored in different tables fail.
+func (st *storeImplementation) forQuery(query RecordQueryInterface) (*storeImplementation, error) {
+	if query == nil {
+		return st.onTable(st.tables), nil
+	}
+
+	types := []string{}
+	if query.IsTypeSet() {
+		types = append(types, query.GetType())
+	}
+	if query.IsTypeInSet() {
+		types = append(types, query.GetTypeIn()...)
+	}
+	if len(types) == 0 {
+		types = st.idPrefixes.typesOfQueryIDs(query)
+	}
+	if len(types) == 0 {
+		return st.onTable(st.tables), nil
+	}
+
+	view := st.forType(types[0])
+	for _, recordType := range types[1:] {
+		if st.forType(recordType).tables != view.tables {
+			return nil, errors.New("query types " + types[0] + " and " + recordType + " are stored in different tables")
+		}
+	}
+	return view, nil
+}
+
+// forID returns a view of the store using the table holding the record
+// with the ID, the default table if no other table holds it. The table of
+// an ID carrying the prefix of a type is the table of the type.
+func (st *storeImplementation) forID(ctx context.Context, id string) (*storeImplementation, error) {
+	if recordType, ok := st.idPrefixes.typeOfID(id); ok {
+		return st.forType(recordType), nil
+	}
+
+	for _, tables := range st.shardTables {
+		view := st.onTable(tables)
+
+		exists, err := view.hasID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if exists {
+			return view, nil
+		}
+	}
+
+	return st.onTable(st.tables), nil
+}
+
+// hasID returns whether the table of the view holds a record with the ID,
+// soft deleted and expired records included
+func (st *storeImplementation) hasID(ctx context.Context, id string) (bool, error) {
+	q := st.whereTenant(st.newQuery(ctx).
+		Table(st.tableName()).
+		Where(COLUMN_ID+" = ?", id))
+
+	var count int64
+	start := time.Now()
+	err := q.Count(&count)
+	st.logQuery("RecordTable", start, func() (string, []any) {
+		return q.ToRawSql().Count(), nil
+	})
+	if err != nil {
+		return false, st.wrapError(err, "RecordTable", id, "", func() string {
+			return q.ToSql().Count()
+		})
+	}
+
+	return count > 0, nil
+}
+
+// forUpdate returns the view of the store using the table holding the
+// stored record, which must be the table of the (possibly changed) type of
+// the record: records are not moved between tables.
+func (st *storeImplementation) forUpdate(ctx context.Context, record RecordInterface) (*storeImplementation, error) {
+	target := st.forType(record.Type())
+
+	view, err := st.forID(ctx, record.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	if view.tables == target.tables {
+		return view, nil
+	}
+
+	// forID falls back to the default table, which may not hold the record
+	if view.tables == st.tables {
+		exists, err := view.hasID(ctx, record.ID())
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return target, nil
+		}
+	}
+
+	return nil, errors.New("record " + record.ID() + " cannot change to type " + record.Type() + ", which is stored in another table")
+}
+
+// recordsByTable groups the records by the view of the table of their type,
+// in table order
+func (st *storeImplementation) recordsByTable(records []RecordInterface) ([]*storeImplementation, [][]RecordInterface) {
+	views := st.tableViews()
+	groups := make([][]RecordInterface, len(views))
+
+	for _, record := range records {
+		tables := st.forType(record.Type()).tables
+		for i, view := range views {
+			if view.tables == tables {
+				groups[i] = append(groups[i], record)
+				break
+			}
+		}
+	}
+
+	return views, groups
+}
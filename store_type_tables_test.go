@@ -0,0 +1,155 @@
+package customstore_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestStoreTypeTableMap(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_type_tables",
+		AutomigrateEnabled: true,
+		TypeTableMap:       map[string]string{"event": "data_type_tables_events"},
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	order := customstore.NewRecord("order", customstore.WithPayload(`{"total":10}`))
+	if err := store.RecordCreate(order); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	event := customstore.NewRecord("event", customstore.WithPayload(`{"count":1}`))
+	if err := store.RecordCreate(event); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	countRows := func(table string) int {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			t.Fatalf("Query failed: %v", err)
+		}
+		return count
+	}
+	if countRows("data_type_tables") != 1 || countRows("data_type_tables_events") != 1 {
+		t.Fatal("Expected each record to be stored in the table of its type")
+	}
+
+	list, err := store.RecordList(customstore.NewRecordQuery().SetType("event"))
+	if err != nil {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+	if len(list) != 1 || list[0].ID() != event.ID() {
+		t.Fatalf("Expected the event to be listed, got %d records", len(list))
+	}
+
+	found, err := store.RecordFindByID(event.ID())
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+
+	if err := store.RecordIncrementPayloadKey(event.ID(), "count", 2); err != nil {
+		t.Fatalf("RecordIncrementPayloadKey failed: %v", err)
+	}
+
+	found.SetPayload(`{"count":5}`)
+	if err := store.RecordUpdate(found); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	found, err = store.RecordFindByID(event.ID())
+	if err != nil || found == nil || found.Payload() != `{"count":5}` {
+		t.Fatalf("Expected the event to be updated: %v", err)
+	}
+
+	if _, err := store.RecordList(customstore.NewRecordQuery().SetTypeIn([]string{"order", "event"})); err == nil {
+		t.Fatal("Expected an error querying types stored in different tables")
+	}
+
+	// A backup holds the records of every table
+	var buf bytes.Buffer
+	backedUp, err := store.Backup(t.Context(), &buf)
+	if err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if backedUp != 2 {
+		t.Fatalf("Expected 2 records backed up, got %d", backedUp)
+	}
+
+	if err := store.RecordDeleteByID(event.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+	if countRows("data_type_tables_events") != 0 {
+		t.Fatal("Expected the event to be deleted")
+	}
+
+	restored, err := store.Restore(t.Context(), &buf)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored != 2 || countRows("data_type_tables") != 1 || countRows("data_type_tables_events") != 1 {
+		t.Fatalf("Expected the records restored to the table of their type, got %d", restored)
+	}
+
+	if err := store.MigrateDown(t.Context()); err != nil {
+		t.Fatalf("MigrateDown failed: %v", err)
+	}
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name LIKE 'data_type_tables%'").Scan(&tables); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if tables != 0 {
+		t.Fatalf("Expected every table to be dropped, got %d", tables)
+	}
+}
+
+func TestStoreTypeTableMapUpdateRouting(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_type_tables_update",
+		AutomigrateEnabled: true,
+		TypeTableMap:       map[string]string{"event": "data_type_tables_update_events"},
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	order := customstore.NewRecord("order", customstore.WithPayload(`{"total":10}`))
+	if err := store.RecordCreate(order); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	// Changing the type to one stored in another table is rejected
+	order.SetType("event")
+	if err := store.RecordUpdate(order); err == nil {
+		t.Fatal("Expected an error changing the type across tables")
+	}
+	if order.Version() != 1 {
+		t.Fatalf("Expected the version to be kept, got %d", order.Version())
+	}
+
+	found, err := store.RecordFindByID(order.ID())
+	if err != nil || found == nil || found.Type() != "order" {
+		t.Fatalf("Expected the order to be kept: %v", err)
+	}
+
+	// Updating a record which is not stored returns ErrNotFound
+	missing := customstore.NewRecord("event")
+	if err := store.RecordUpdate(missing); !errors.Is(err, customstore.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, but got %v", err)
+	}
+	missing.SetType("order")
+	if err := store.RecordUpdate(missing); !errors.Is(err, customstore.ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, but got %v", err)
+	}
+}
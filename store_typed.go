@@ -0,0 +1,120 @@
+package customstore
+
+import "errors"
+
+// Typed is a store wrapper for the records of a single type, with the
+// payload holding a T (a struct) encoded as JSON, removing the boilerplate
+// of encoding and decoding the payload
+//
+// Example:
+//
+//	type Person struct {
+//		Name string `json:"name"`
+//	}
+//
+//	people := customstore.NewTyped[Person](store, "person")
+//	record, err := people.CreateTyped(Person{Name: "John"})
+//	person, err := people.FindTyped(record.ID())
+type Typed[T any] struct {
+	store      StoreInterface
+	recordType string
+}
+
+// NewTyped returns a typed wrapper for the records of the given type
+func NewTyped[T any](store StoreInterface, recordType string) *Typed[T] {
+	return &Typed[T]{
+		store:      store,
+		recordType: recordType,
+	}
+}
+
+// Store returns the wrapped store
+func (t *Typed[T]) Store() StoreInterface {
+	return t.store
+}
+
+// RecordType returns the type of the records
+func (t *Typed[T]) RecordType() string {
+	return t.recordType
+}
+
+// CreateTyped creates a record with the value as payload, applying the
+// record options, and returns the created record
+func (t *Typed[T]) CreateTyped(value T, opts ...RecordOption) (RecordInterface, error) {
+	if t.store == nil {
+		return nil, errors.New("store is nil")
+	}
+
+	// Copied, so the options of the caller are not appended to
+	options := make([]RecordOption, 0, len(opts)+1)
+	options = append(options, opts...)
+	record, err := NewRecordE(t.recordType, append(options, WithPayloadStruct(value))...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.store.RecordCreate(record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// FindTyped returns the payload of the record with the ID, or nil if no
+// record of the type is found
+func (t *Typed[T]) FindTyped(id string) (*T, error) {
+	if t.store == nil {
+		return nil, errors.New("store is nil")
+	}
+
+	record, err := t.store.RecordFindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if record == nil || record.Type() != t.recordType {
+		return nil, nil
+	}
+
+	return decodeTyped[T](record)
+}
+
+// ListTyped returns the payloads of the records matching the query,
+// restricted to the records of the type. A query built by NewRecordQuery is
+// copied, the type of other query implementations is set in place.
+func (t *Typed[T]) ListTyped(query RecordQueryInterface) ([]T, error) {
+	if t.store == nil {
+		return nil, errors.New("store is nil")
+	}
+
+	if query == nil {
+		query = RecordQuery()
+	} else if cloned, ok := cloneRecordQuery(query); ok {
+		query = cloned
+	}
+
+	records, err := t.store.RecordList(query.SetType(t.recordType))
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]T, 0, len(records))
+	for _, record := range records {
+		value, err := decodeTyped[T](record)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *value)
+	}
+
+	return list, nil
+}
+
+// decodeTyped decodes the payload of the record into a T
+func decodeTyped[T any](record RecordInterface) (*T, error) {
+	var value T
+	if err := record.PayloadStruct(&value); err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
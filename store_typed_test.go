@@ -0,0 +1,93 @@
+package customstore_test
+
+import (
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+type typedPerson struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestTyped(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_typed",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	people := customstore.NewTyped[typedPerson](store, "person")
+
+	record, err := people.CreateTyped(typedPerson{Name: "John", Age: 42}, customstore.WithMemo("imported"))
+	if err != nil {
+		t.Fatalf("CreateTyped failed: %v", err)
+	}
+	if record.Type() != "person" || record.Memo() != "imported" {
+		t.Fatalf("Expected a person record with the memo, but got type %q memo %q", record.Type(), record.Memo())
+	}
+	if record.PayloadString("name", "") != "John" {
+		t.Fatalf("Expected the payload to hold the struct, but got %q", record.Payload())
+	}
+
+	// The options of the caller are not appended to
+	opts := make([]customstore.RecordOption, 1, 2)
+	opts[0] = customstore.WithMemo("jane")
+	if _, err := people.CreateTyped(typedPerson{Name: "Jane", Age: 36}, opts...); err != nil {
+		t.Fatalf("CreateTyped failed: %v", err)
+	}
+	if extra := opts[:2][1]; extra != nil {
+		t.Fatal("Expected the backing array of the options to be unchanged")
+	}
+
+	if err := store.RecordCreate(customstore.NewRecord("order", customstore.WithPayload(`{"name":"Order"}`))); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	person, err := people.FindTyped(record.ID())
+	if err != nil {
+		t.Fatalf("FindTyped failed: %v", err)
+	}
+	if person == nil || person.Name != "John" || person.Age != 42 {
+		t.Fatalf("Expected John aged 42, but got %+v", person)
+	}
+
+	missing, err := people.FindTyped("missing")
+	if err != nil {
+		t.Fatalf("FindTyped for missing record failed: %v", err)
+	}
+	if missing != nil {
+		t.Fatalf("Expected nil for a missing record, but got %+v", missing)
+	}
+
+	query := customstore.RecordQuery().SetOrderBy(customstore.COLUMN_ID)
+	list, err := people.ListTyped(query)
+	if err != nil {
+		t.Fatalf("ListTyped failed: %v", err)
+	}
+	if query.IsTypeSet() {
+		t.Fatalf("Expected the query of the caller to be unchanged, but the type is %q", query.GetType())
+	}
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 people, but got %d", len(list))
+	}
+	for _, p := range list {
+		if p.Name != "John" && p.Name != "Jane" {
+			t.Fatalf("Unexpected person %+v", p)
+		}
+	}
+
+	if err := store.RecordCreate(customstore.NewRecord("person", customstore.WithPayload(`{invalid`))); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if _, err := people.ListTyped(nil); err == nil {
+		t.Fatalf("Expected error listing a record with an invalid payload, but got nil")
+	}
+}
@@ -0,0 +1,342 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// uniqueKey is a key of a JSON column (the payload or the metas) whose
+// values are unique per record type
+type uniqueKey struct {
+	column string
+	key    string
+}
+
+// uniqueKeyRegistry holds the unique keys with an ensured index, keyed by
+// record type
+type uniqueKeyRegistry struct {
+	mu   sync.RWMutex
+	keys map[string][]uniqueKey
+}
+
+// uniqueActiveSince is the start of the year of MAX_DATETIME: the records
+// with a later soft deleted at are neither soft deleted nor scheduled to
+// be, and hold their unique values. Compared with the start of the year,
+// as the stored MAX_DATETIME differs with the timestamp format.
+const uniqueActiveSince = "9999-01-01 00:00:00"
+
+// uniqueActiveCondition matches the records holding their unique values,
+// see uniqueActiveSince
+const uniqueActiveCondition = COLUMN_SOFT_DELETED_AT + " >= '" + uniqueActiveSince + "'"
+
+// all returns a copy of the unique keys, keyed by record type
+func (r *uniqueKeyRegistry) all() map[string][]uniqueKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	keys := make(map[string][]uniqueKey, len(r.keys))
+	for recordType, uniques := range r.keys {
+		keys[recordType] = append([]uniqueKey(nil), uniques...)
+	}
+	return keys
+}
+
+// any returns whether a unique key is registered for any record type
+func (r *uniqueKeyRegistry) any() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.keys) > 0
+}
+
+// get returns the unique keys of the record type
+func (r *uniqueKeyRegistry) get(recordType string) []uniqueKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.keys[recordType]
+}
+
+// add registers the unique key of the record type
+func (r *uniqueKeyRegistry) add(recordType string, unique uniqueKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.keys[recordType] {
+		if existing == unique {
+			return
+		}
+	}
+	r.keys[recordType] = append(r.keys[recordType], unique)
+}
+
+// duplicateError is the error of a write violating a unique key, matching
+// ErrDuplicate
+type duplicateError struct {
+	recordType string
+	unique     uniqueKey
+	err        error
+}
+
+func (e *duplicateError) Error() string {
+	what := "payload key "
+	if e.unique.column == COLUMN_METAS {
+		what = "meta "
+	}
+	return ErrDuplicate.Error() + ": " + what + e.unique.key + " of record type " + e.recordType
+}
+
+func (e *duplicateError) Is(target error) bool {
+	return target == ErrDuplicate
+}
+
+func (e *duplicateError) Unwrap() error {
+	return e.err
+}
+
+// EnsureUniquePayloadKey makes the text of a top level payload key unique
+// among the records of the type, i.e. the email of users. Creating,
+// updating, upserting or restoring a record with the value of another
+// record of the type then returns an error matching ErrDuplicate.
+//
+// Records missing the key do not collide. With TenancyEnabled the values
+// are unique per tenant. Soft deleted records (and records scheduled for a
+// soft delete) release their values, expired records keep them. Ensuring
+// the key fails if stored records already collide.
+//
+// SQLite and PostgreSQL enforce the key with a partial unique index created
+// unless it exists. MySQL and SQL Server keep the values in the unique
+// values table instead, written with every write made through the store
+// and rebuilt from the stored records here. Imports, backup restores,
+// payload transforms and migrations are not checked there.
+//
+// The registration is kept in memory, call EnsureUniquePayloadKey for every
+// unique key when the store is created. On a read only store an existing
+// index is registered, a missing one returns ErrReadOnly.
+func (st *storeImplementation) EnsureUniquePayloadKey(recordType string, key string) error {
+	return st.EnsureUniquePayloadKeyContext(context.Background(), recordType, key)
+}
+
+// EnsureUniquePayloadKeyContext is EnsureUniquePayloadKey using the given
+// context
+func (st *storeImplementation) EnsureUniquePayloadKeyContext(ctx context.Context, recordType string, key string) error {
+	if key == "" {
+		return errors.New("payload key is required")
+	}
+
+	return st.ensureUnique(ctx, recordType, uniqueKey{column: COLUMN_PAYLOAD, key: key}, "EnsureUniquePayloadKey")
+}
+
+// EnsureUniqueMeta makes the value of a meta unique among the records of
+// the type, as EnsureUniquePayloadKey
+func (st *storeImplementation) EnsureUniqueMeta(recordType string, name string) error {
+	return st.EnsureUniqueMetaContext(context.Background(), recordType, name)
+}
+
+// EnsureUniqueMetaContext is EnsureUniqueMeta using the given context
+func (st *storeImplementation) EnsureUniqueMetaContext(ctx context.Context, recordType string, name string) error {
+	if name == "" {
+		return errors.New("meta name is required")
+	}
+
+	return st.ensureUnique(ctx, recordType, uniqueKey{column: COLUMN_METAS, key: name}, "EnsureUniqueMeta")
+}
+
+// ensureUnique creates the unique index of the key of the record type,
+// unless it exists, and registers it
+func (st *storeImplementation) ensureUnique(ctx context.Context, recordType string, unique uniqueKey, op string) error {
+	if st.sharded() {
+		return st.forType(recordType).ensureUnique(ctx, recordType, unique, op)
+	}
+
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if recordType == "" {
+		return errors.New("record type is required")
+	}
+
+	if strings.Contains(unique.key, "?") || strings.Contains(recordType, "?") {
+		// The key and type are inlined in the statements, where a
+		// question mark would be taken as a placeholder
+		return errors.New("unique key and record type cannot contain a question mark")
+	}
+
+	driver := st.driverName()
+	if uniqueTableDriver(driver) {
+		return st.ensureUniqueTable(ctx, recordType, unique, op)
+	}
+	if driver != "sqlite" && driver != "postgres" {
+		return errors.New("unique keys are not supported by the " + driver + " driver")
+	}
+
+	indexName := uniqueIndexName(st.tableName(), recordType, unique, st.tenancy)
+
+	if st.readOnly {
+		if !st.db.Schema().HasIndex(st.tableName(), indexName) {
+			return ErrReadOnly
+		}
+		st.uniqueKeys.add(recordType, unique)
+		return nil
+	}
+
+	if err := st.createUniqueIndex(ctx, st.tableName(), recordType, unique, op); err != nil {
+		return err
+	}
+
+	st.uniqueKeys.add(recordType, unique)
+	return nil
+}
+
+// createUniqueIndex creates the unique index of the key of the record type
+// on the table unless it exists
+func (st *storeImplementation) createUniqueIndex(ctx context.Context, tableName string, recordType string, unique uniqueKey, op string) error {
+	driver := st.driverName()
+
+	// Partial, so the values of each type are unique on their own, among
+	// the records which are not soft deleted. Records missing the key
+	// extract NULL, which never collides. With tenancy the values are
+	// unique per tenant.
+	expression := jsonIndexExpression(driver, unique.column, unique.key)
+	if driver == "postgres" {
+		expression = "(" + expression + ")"
+	}
+	if st.tenancy {
+		expression = COLUMN_TENANT_ID + ", " + expression
+	}
+	sqlStr := "CREATE UNIQUE INDEX IF NOT EXISTS " + uniqueIndexName(tableName, recordType, unique, st.tenancy) + " ON " + tableName + " (" + expression + ")" +
+		" WHERE " + COLUMN_RECORD_TYPE + " = '" + strings.ReplaceAll(recordType, "'", "''") + "'" +
+		" AND " + uniqueActiveCondition
+
+	if _, err := st.exec(st.newQuery(ctx), op, sqlStr); err != nil {
+		return st.wrapError(err, op, "", recordType, func() string {
+			return sqlStr
+		})
+	}
+	return nil
+}
+
+// ensureUniqueTable creates the unique values table unless it exists,
+// rebuilds the values of the key of the record type and registers it. The
+// writes made while rebuilding are not checked, ensure the keys when the
+// store is created.
+func (st *storeImplementation) ensureUniqueTable(ctx context.Context, recordType string, unique uniqueKey, op string) error {
+	if st.readOnly {
+		if !st.db.Schema().HasTable(uniqueTableName(st.tableName())) {
+			return ErrReadOnly
+		}
+		st.uniqueKeys.add(recordType, unique)
+		return nil
+	}
+
+	if err := st.createUniqueTable(st.tableName()); err != nil {
+		return st.wrapError(err, op, "", recordType, nil)
+	}
+
+	unlock := st.lockWrite()
+	defer unlock()
+
+	if err := st.rebuildUniqueValues(ctx, st.tableName(), recordType, unique, op); err != nil {
+		return err
+	}
+
+	st.uniqueKeys.add(recordType, unique)
+	return nil
+}
+
+// uniqueIndexName returns the name of the unique index of the key of the
+// record type, per tenant or not. The type and key are hashed as they may
+// contain characters not allowed in identifiers.
+func uniqueIndexName(tableName string, recordType string, unique uniqueKey, perTenant bool) string {
+	hash := fnv.New32a()
+	hash.Write([]byte(recordType + "\x00" + unique.column + "\x00" + unique.key))
+	if perTenant {
+		hash.Write([]byte("\x00" + COLUMN_TENANT_ID))
+	}
+	return tableName + "_unique_" + strconv.FormatUint(uint64(hash.Sum32()), 16) + "_idx"
+}
+
+// duplicateOf returns the error of the failed write of the operation on the
+// record with the ID and type, as a duplicateError if another record of the
+// type has the value of one of its unique keys in the written payload or
+// metas (JSON, empty if not written). The drivers report the violation of
+// a unique index differently, and not at all in the generic errors of the
+// query builder, so the stored records are checked instead. Gives up with
+// the error if the check fails, i.e. in an aborted PostgreSQL transaction.
+func (st *storeImplementation) duplicateOf(ctx context.Context, op string, err error, id string, recordType string, payload string, metas string) error {
+	uniques := st.uniqueKeys.get(recordType)
+	if err == nil || len(uniques) == 0 {
+		return err
+	}
+
+	driver := st.driverName()
+	for _, unique := range uniques {
+		document := payload
+		if unique.column == COLUMN_METAS {
+			document = metas
+		}
+		if document == "" {
+			continue
+		}
+
+		// The written value is extracted from the document as the stored
+		// ones are, so both have the same text form
+		q := st.newQuery(ctx).
+			Table(st.tableName()).
+			Where(COLUMN_RECORD_TYPE+" = ?", recordType).
+			Where(COLUMN_ID+" <> ?", id).
+			Where(uniqueActiveCondition).
+			Where(jsonIndexExpression(driver, unique.column, unique.key)+" = "+jsonExtractText(driver, "?"), document, jsonPathArg(driver, unique.key))
+		if st.tenantID != "" {
+			q = q.Where(COLUMN_TENANT_ID+" = ?", st.tenantID)
+		} else if st.tenancy {
+			// The tenant of the written record, none if it was not stored
+			q = q.Where(COLUMN_TENANT_ID+" = COALESCE((SELECT "+COLUMN_TENANT_ID+" FROM "+st.tableName()+" WHERE "+COLUMN_ID+" = ?), '')", id)
+		}
+
+		var count int64
+		start := time.Now()
+		checkErr := st.retry(ctx, op, func() error {
+			return q.Count(&count)
+		})
+		st.logQuery(op, start, func() (string, []any) {
+			return q.ToRawSql().Count(), nil
+		})
+		if checkErr != nil {
+			return err
+		}
+		if count > 0 {
+			return &duplicateError{recordType: recordType, unique: unique, err: err}
+		}
+	}
+
+	return err
+}
+
+// duplicateOfStored is duplicateOf for a failed write of the stored record
+// with the ID which does not carry its payload and metas, i.e. a restore
+func (st *storeImplementation) duplicateOfStored(ctx context.Context, op string, err error, id string) error {
+	if err == nil || !st.uniqueKeys.any() {
+		return err
+	}
+
+	snapshots, snapshotErr := st.changeSnapshots(ctx, []string{id})
+	record := snapshots[id]
+	if snapshotErr != nil || record == nil {
+		return err
+	}
+
+	metas, metasErr := record.Metas()
+	if metasErr != nil {
+		return err
+	}
+	metasJSON, metasErr := json.Marshal(metas)
+	if metasErr != nil {
+		return err
+	}
+
+	return st.duplicateOf(ctx, op, err, id, record.Type(), record.Payload(), string(metasJSON))
+}
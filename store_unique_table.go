@@ -0,0 +1,295 @@
+package customstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+	contractsschema "github.com/dracory/neat/contracts/database/schema"
+	"github.com/spf13/cast"
+)
+
+// Columns of the unique values table
+const (
+	uniqueColumnHash     = "unique_hash"
+	uniqueColumnRecordID = "record_id"
+	uniqueColumnColumn   = "unique_column"
+	uniqueColumnKey      = "unique_key"
+)
+
+// uniqueTableName returns the name of the table holding the unique values
+// of a store table, for the drivers without partial unique indexes
+func uniqueTableName(tableName string) string {
+	return tableName + "_unique"
+}
+
+// uniqueTableDriver returns whether the unique keys of the driver are kept
+// in the unique values table (MySQL and SQL Server), which lack the partial
+// unique indexes on JSON expressions used otherwise
+func uniqueTableDriver(driver string) bool {
+	return driver == "mysql" || driver == "sqlserver"
+}
+
+// usesUniqueTable returns whether the writes maintain the unique values
+// table, i.e. a unique key is registered on a driver using it
+func (st *storeImplementation) usesUniqueTable() bool {
+	return uniqueTableDriver(st.driverName()) && st.uniqueKeys.any()
+}
+
+// uniqueHash returns the primary key of a unique value: the record type,
+// tenant, key and value hashed, as they may exceed the key length allowed
+// by the driver
+func uniqueHash(recordType string, tenantID string, unique uniqueKey, value string) string {
+	sum := sha256.Sum256([]byte(recordType + "\x00" + tenantID + "\x00" + unique.column + "\x00" + unique.key + "\x00" + value))
+	return hex.EncodeToString(sum[:])
+}
+
+// uniqueValue returns the text of the unique key in the payload or metas
+// (JSON), and false if the key is missing or null, which never collides.
+// Strings are taken as is, other values as JSON.
+func uniqueValue(unique uniqueKey, document string) (string, bool) {
+	if document == "" {
+		return "", false
+	}
+
+	data := map[string]any{}
+	if err := json.Unmarshal([]byte(document), &data); err != nil {
+		return "", false
+	}
+
+	value, exists := data[unique.key]
+	if !exists || value == nil {
+		return "", false
+	}
+	if text, ok := value.(string); ok {
+		return text, true
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	return string(encoded), true
+}
+
+// holdsUniqueValues returns whether the soft deleted at of a record (as
+// returned by its accessor) lets it hold its unique values, see
+// uniqueActiveCondition
+func holdsUniqueValues(softDeletedAt string) bool {
+	return softDeletedAt >= uniqueActiveSince
+}
+
+// createUniqueTable creates the unique values table of the table unless it
+// exists
+func (st *storeImplementation) createUniqueTable(table string) error {
+	tableName := uniqueTableName(table)
+	if st.db.Schema().HasTable(tableName) {
+		return nil
+	}
+
+	return st.db.Schema().Create(tableName, func(table contractsschema.Blueprint) {
+		table.String(uniqueColumnHash, 64)
+		table.Primary(uniqueColumnHash)
+		table.String(uniqueColumnRecordID, 40)
+		table.String(COLUMN_RECORD_TYPE, 100)
+		table.String(uniqueColumnColumn, 20)
+		table.String(uniqueColumnKey, 255)
+		table.String(COLUMN_TENANT_ID, 100).Default("")
+		table.Index(uniqueColumnRecordID)
+		table.Index(COLUMN_RECORD_TYPE, uniqueColumnKey)
+	})
+}
+
+// dropUniqueTable drops the unique values table if it exists
+func (st *storeImplementation) dropUniqueTable() error {
+	tableName := uniqueTableName(st.tableName())
+	if !st.db.Schema().HasTable(tableName) {
+		return nil
+	}
+
+	return st.db.Schema().Drop(tableName)
+}
+
+// rebuildUniqueValues replaces the values of the unique key of the record
+// type in the unique values table of the table with those of its records,
+// in a single transaction. Returns a duplicateError if the records collide.
+func (st *storeImplementation) rebuildUniqueValues(ctx context.Context, table string, recordType string, unique uniqueKey, op string) error {
+	return st.inTransaction(func(st *storeImplementation) error {
+		tableName := uniqueTableName(table)
+
+		q := st.newQuery(ctx).
+			Table(tableName).
+			Where(COLUMN_RECORD_TYPE+" = ?", recordType).
+			Where(uniqueColumnColumn+" = ?", unique.column).
+			Where(uniqueColumnKey+" = ?", unique.key)
+		start := time.Now()
+		_, err := q.Delete()
+		st.logQuery(op, start, func() (string, []any) {
+			return q.ToRawSql().Delete(), nil
+		})
+		if err != nil {
+			return st.wrapError(err, op, "", recordType, func() string {
+				return q.ToSql().Delete()
+			})
+		}
+
+		columns := COLUMN_ID + ", " + unique.column
+		if st.tenancy {
+			columns += ", " + COLUMN_TENANT_ID
+		}
+
+		lastID := ""
+		for {
+			q := st.newQuery(ctx).
+				Table(table).
+				Select(columns).
+				Where(COLUMN_RECORD_TYPE+" = ?", recordType).
+				Where(uniqueActiveCondition).
+				Where(COLUMN_ID+" > ?", lastID).
+				OrderBy(COLUMN_ID).
+				Limit(purgeChunkSize)
+
+			var rows []map[string]any
+			start := time.Now()
+			err := q.Get(&rows)
+			st.logQuery(op, start, func() (string, []any) {
+				return q.ToRawSql().Get(&rows), nil
+			})
+			if err != nil {
+				return st.wrapError(err, op, "", recordType, func() string {
+					return q.ToSql().Get(&rows)
+				})
+			}
+
+			for _, row := range rows {
+				lastID = cast.ToString(row[COLUMN_ID])
+				value, ok := uniqueValue(unique, cast.ToString(row[unique.column]))
+				if !ok {
+					continue
+				}
+				err := st.insertUniqueValue(ctx, op, table, recordType, unique, cast.ToString(row[COLUMN_TENANT_ID]), lastID, value)
+				if err != nil {
+					return err
+				}
+			}
+
+			if len(rows) < purgeChunkSize {
+				return nil
+			}
+		}
+	})
+}
+
+// writeUniqueValues replaces the unique values of the records changed by
+// the events, in the transaction the store is bound to. Records soft
+// deleted or deleted release their values. Returns a duplicateError if a
+// value is held by another record.
+func (st *storeImplementation) writeUniqueValues(ctx context.Context, events []ChangeEvent) error {
+	for _, event := range events {
+		hasKeys := func(record RecordInterface) bool {
+			return record != nil && len(st.uniqueKeys.get(record.Type())) > 0
+		}
+		if !hasKeys(event.Old) && !hasKeys(event.New) {
+			continue
+		}
+
+		if err := st.deleteUniqueValues(ctx, st.newQuery(ctx), []any{event.RecordID}, "Unique"); err != nil {
+			return err
+		}
+
+		if !hasKeys(event.New) || !holdsUniqueValues(event.New.SoftDeletedAt()) {
+			continue
+		}
+
+		recordType := event.New.Type()
+		for _, unique := range st.uniqueKeys.get(recordType) {
+			document := event.New.Payload()
+			if unique.column == COLUMN_METAS {
+				metas, err := event.New.Metas()
+				if err != nil {
+					return err
+				}
+				metasJSON, err := json.Marshal(metas)
+				if err != nil {
+					return err
+				}
+				document = string(metasJSON)
+			}
+
+			value, ok := uniqueValue(unique, document)
+			if !ok {
+				continue
+			}
+			if err := st.insertUniqueValue(ctx, "Unique", st.tableName(), recordType, unique, event.TenantID, event.RecordID, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// insertUniqueValue adds the value of the unique key of the record to the
+// unique values table of the table, returning a duplicateError if another record holds
+// it. The drivers report the violation of the primary key differently, so
+// the table is checked once the insert fails.
+func (st *storeImplementation) insertUniqueValue(ctx context.Context, op string, table string, recordType string, unique uniqueKey, tenantID string, recordID string, value string) error {
+	hash := uniqueHash(recordType, tenantID, unique, value)
+	row := map[string]any{
+		uniqueColumnHash:     hash,
+		uniqueColumnRecordID: recordID,
+		COLUMN_RECORD_TYPE:   recordType,
+		uniqueColumnColumn:   unique.column,
+		uniqueColumnKey:      unique.key,
+		COLUMN_TENANT_ID:     tenantID,
+	}
+
+	q := st.newQuery(ctx).Table(uniqueTableName(table))
+	start := time.Now()
+	err := q.Create(row)
+	st.logQuery(op, start, func() (string, []any) {
+		return q.ToRawSql().Create(row), nil
+	})
+	if err == nil {
+		return nil
+	}
+	err = st.wrapError(err, op, recordID, recordType, func() string {
+		return q.ToSql().Create(row)
+	})
+
+	var count int64
+	check := st.newQuery(ctx).
+		Table(uniqueTableName(table)).
+		Where(uniqueColumnHash+" = ?", hash).
+		Where(uniqueColumnRecordID+" <> ?", recordID)
+	if checkErr := check.Count(&count); checkErr != nil || count == 0 {
+		return err
+	}
+	return &duplicateError{recordType: recordType, unique: unique, err: err}
+}
+
+// deleteUniqueValues removes the unique values of the records with the IDs
+// if the writes maintain the unique values table
+func (st *storeImplementation) deleteUniqueValues(ctx context.Context, q contractsorm.Query, ids []any, op string) error {
+	if !st.usesUniqueTable() || len(ids) == 0 {
+		return nil
+	}
+
+	q = q.Table(uniqueTableName(st.tableName())).
+		WhereIn(uniqueColumnRecordID, ids)
+
+	start := time.Now()
+	err := st.retry(ctx, op, func() error {
+		_, err := q.Delete()
+		return err
+	})
+	st.logQuery(op, start, func() (string, []any) {
+		return q.ToRawSql().Delete(), nil
+	})
+	return st.wrapError(err, op, "", "", func() string {
+		return q.ToSql().Delete()
+	})
+}
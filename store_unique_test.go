@@ -0,0 +1,231 @@
+package customstore_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestEnsureUniquePayloadKey(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_unique_payload_key",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if err := store.EnsureUniquePayloadKey("user", "email"); err != nil {
+		t.Fatalf("EnsureUniquePayloadKey failed: %v", err)
+	}
+	if err := store.EnsureUniquePayloadKey("user", "email"); err != nil {
+		t.Fatalf("Expected EnsureUniquePayloadKey to be idempotent, but got %v", err)
+	}
+
+	alice := customstore.NewRecord("user", customstore.WithPayload(`{"email":"alice@test.com"}`))
+	if err := store.RecordCreate(alice); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	err = store.RecordCreate(customstore.NewRecord("user", customstore.WithPayload(`{"email":"alice@test.com"}`)))
+	if !errors.Is(err, customstore.ErrDuplicate) {
+		t.Fatalf("Expected ErrDuplicate, but got %v", err)
+	}
+
+	// Other types and records missing the key do not collide
+	if err := store.RecordCreate(customstore.NewRecord("admin", customstore.WithPayload(`{"email":"alice@test.com"}`))); err != nil {
+		t.Fatalf("Expected a record of another type to be created, but got %v", err)
+	}
+	for range 2 {
+		if err := store.RecordCreate(customstore.NewRecord("user", customstore.WithPayload(`{"name":"anonymous"}`))); err != nil {
+			t.Fatalf("Expected a record without the key to be created, but got %v", err)
+		}
+	}
+
+	bob := customstore.NewRecord("user", customstore.WithPayload(`{"email":"bob@test.com"}`))
+	if err := store.RecordCreate(bob); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	// Updating a record keeping its own value is allowed
+	if err := store.RecordUpdate(bob); err != nil {
+		t.Fatalf("RecordUpdate failed: %v", err)
+	}
+
+	bob.SetPayload(`{"email":"alice@test.com"}`)
+	if err := store.RecordUpdate(bob); !errors.Is(err, customstore.ErrDuplicate) {
+		t.Fatalf("Expected ErrDuplicate on update, but got %v", err)
+	}
+
+	upserted := customstore.NewRecord("user", customstore.WithPayload(`{"email":"alice@test.com"}`))
+	if err := store.RecordUpsert(upserted); !errors.Is(err, customstore.ErrDuplicate) {
+		t.Fatalf("Expected ErrDuplicate on upsert, but got %v", err)
+	}
+
+	// Failures other than a duplicate are not reported as one
+	err = store.RecordCreate(customstore.NewRecord("user", customstore.WithID(alice.ID()), customstore.WithPayload(`{"email":"carol@test.com"}`)))
+	if err == nil || errors.Is(err, customstore.ErrDuplicate) {
+		t.Fatalf("Expected an error other than ErrDuplicate, but got %v", err)
+	}
+
+	if err := store.EnsureUniquePayloadKey("", "email"); err == nil {
+		t.Fatal("Expected an error for an empty record type")
+	}
+	if err := store.EnsureUniquePayloadKey("user", ""); err == nil {
+		t.Fatal("Expected an error for an empty key")
+	}
+	if err := store.EnsureUniquePayloadKey("user", "what?"); err == nil {
+		t.Fatal("Expected an error for a key with a question mark")
+	}
+}
+
+func TestEnsureUniqueMeta(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_unique_meta",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if err := store.RecordCreate(customstore.NewRecord("page", customstore.WithMetas(map[string]string{"slug": "home"}))); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordCreate(customstore.NewRecord("page", customstore.WithMetas(map[string]string{"slug": "home"}))); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	// Creating the index fails while stored records collide
+	if err := store.EnsureUniqueMeta("page", "slug"); err == nil {
+		t.Fatal("Expected an error for colliding stored records")
+	}
+
+	if err := store.EnsureUniqueMeta("post", "slug"); err != nil {
+		t.Fatalf("EnsureUniqueMeta failed: %v", err)
+	}
+	if err := store.RecordCreate(customstore.NewRecord("post", customstore.WithMetas(map[string]string{"slug": "hello"}))); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	err = store.RecordCreate(customstore.NewRecord("post", customstore.WithMetas(map[string]string{"slug": "hello"})))
+	if !errors.Is(err, customstore.ErrDuplicate) {
+		t.Fatalf("Expected ErrDuplicate, but got %v", err)
+	}
+
+	post := customstore.NewRecord("post", customstore.WithMetas(map[string]string{"slug": "world"}))
+	if err := store.RecordCreate(post); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := post.SetMeta("slug", "hello"); err != nil {
+		t.Fatalf("SetMeta failed: %v", err)
+	}
+	if err := store.RecordUpdate(post); !errors.Is(err, customstore.ErrDuplicate) {
+		t.Fatalf("Expected ErrDuplicate on update, but got %v", err)
+	}
+}
+
+func TestEnsureUniquePayloadKeyTenancy(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_unique_tenancy",
+		AutomigrateEnabled: true,
+		TenancyEnabled:     true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+	if err := store.EnsureUniquePayloadKey("user", "email"); err != nil {
+		t.Fatalf("EnsureUniquePayloadKey failed: %v", err)
+	}
+
+	acme, err := store.ForTenant("acme")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+	globex, err := store.ForTenant("globex")
+	if err != nil {
+		t.Fatalf("ForTenant failed: %v", err)
+	}
+
+	if err := acme.RecordCreate(customstore.NewRecord("user", customstore.WithPayload(`{"email":"alice@test.com"}`))); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	// Each tenant has its own values
+	if err := globex.RecordCreate(customstore.NewRecord("user", customstore.WithPayload(`{"email":"alice@test.com"}`))); err != nil {
+		t.Fatalf("Expected the value of another tenant to be allowed, but got %v", err)
+	}
+
+	err = acme.RecordCreate(customstore.NewRecord("user", customstore.WithPayload(`{"email":"alice@test.com"}`)))
+	if !errors.Is(err, customstore.ErrDuplicate) {
+		t.Fatalf("Expected ErrDuplicate within the tenant, but got %v", err)
+	}
+
+	// Through the unscoped store, the record collides within its tenant
+	bob := customstore.NewRecord("user", customstore.WithPayload(`{"email":"bob@test.com"}`))
+	if err := acme.RecordCreate(bob); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	bob.SetPayload(`{"email":"alice@test.com"}`)
+	if err := store.RecordUpdate(bob); !errors.Is(err, customstore.ErrDuplicate) {
+		t.Fatalf("Expected ErrDuplicate on update, but got %v", err)
+	}
+}
+
+func TestEnsureUniquePayloadKeySoftDeleted(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_unique_soft_deleted",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+	if err := store.EnsureUniquePayloadKey("user", "email"); err != nil {
+		t.Fatalf("EnsureUniquePayloadKey failed: %v", err)
+	}
+
+	alice := customstore.NewRecord("user", customstore.WithPayload(`{"email":"alice@test.com"}`))
+	if err := store.RecordCreate(alice); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordSoftDeleteByID(alice.ID()); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+
+	// The soft deleted record released the value
+	again := customstore.NewRecord("user", customstore.WithPayload(`{"email":"alice@test.com"}`))
+	if err := store.RecordCreate(again); err != nil {
+		t.Fatalf("Expected the value of a soft deleted record to be reused, but got %v", err)
+	}
+
+	// Restoring it while another record holds the value collides
+	if err := store.RecordRestoreByID(alice.ID()); !errors.Is(err, customstore.ErrDuplicate) {
+		t.Fatalf("Expected ErrDuplicate on restore, but got %v", err)
+	}
+
+	if err := store.RecordDeleteByID(again.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+	if err := store.RecordRestoreByID(alice.ID()); err != nil {
+		t.Fatalf("RecordRestoreByID failed: %v", err)
+	}
+	err = store.RecordCreate(customstore.NewRecord("user", customstore.WithPayload(`{"email":"alice@test.com"}`)))
+	if !errors.Is(err, customstore.ErrDuplicate) {
+		t.Fatalf("Expected ErrDuplicate once restored, but got %v", err)
+	}
+}
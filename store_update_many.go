@@ -0,0 +1,329 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	contractsorm "github.com/dracory/neat/contracts/database/orm"
+	"github.com/spf13/cast"
+)
+
+// updateManyChunkSize is the number of records updated per statement
+const updateManyChunkSize = 100
+
+// UpdateManyResult reports the outcome of RecordUpdateMany
+type UpdateManyResult struct {
+	// Updated is the number of records updated
+	Updated int
+
+	// Failures lists the records which were skipped, in input order
+	Failures []UpdateManyFailure
+}
+
+// UpdateManyFailure is a record skipped by RecordUpdateMany
+type UpdateManyFailure struct {
+	// Index is the position of the record in the input
+	Index int
+
+	// RecordID is the ID of the record, empty if it has none
+	RecordID string
+
+	// Err is the reason the record was skipped
+	Err error
+}
+
+// updateManyRow is a validated record of a RecordUpdateMany call, with its
+// columns encoded
+type updateManyRow struct {
+	record  RecordInterface
+	index   int
+	payload *string
+	metas   *string
+}
+
+// RecordUpdateMany updates many records in one transaction, with one
+// statement per chunk of records, incrementing their versions like
+// RecordUpdate.
+//
+// Invalid records (nil, without ID, listed twice, rejected by a before
+// update hook or with metas that cannot be encoded) and records not found
+// are skipped and reported in the result, the other records are updated.
+// A database error rolls back the whole transaction and is returned.
+func (st *storeImplementation) RecordUpdateMany(records []RecordInterface) (UpdateManyResult, error) {
+	return st.RecordUpdateManyContext(context.Background(), records)
+}
+
+// RecordUpdateManyContext is RecordUpdateMany using the given context
+func (st *storeImplementation) RecordUpdateManyContext(ctx context.Context, records []RecordInterface) (UpdateManyResult, error) {
+	result := UpdateManyResult{}
+
+	if err := st.checkWritable(); err != nil {
+		return result, err
+	}
+
+	if st.db == nil {
+		return result, errors.New("database is not initialized")
+	}
+
+	if st.sharded() {
+		var view *storeImplementation
+		for _, record := range records {
+			if record == nil {
+				continue
+			}
+			recordView := st.forType(record.Type())
+			if view != nil && recordView.tables != view.tables {
+				return result, errors.New("records stored in different tables cannot be updated together")
+			}
+			view = recordView
+		}
+		if view == nil {
+			view = st.onTable(st.tables)
+		}
+		return view.RecordUpdateManyContext(ctx, records)
+	}
+
+	now := st.nowDateTime()
+
+	rows := []updateManyRow{}
+	seen := map[string]bool{}
+	for i, record := range records {
+		if record == nil {
+			result.Failures = append(result.Failures, UpdateManyFailure{Index: i, Err: errors.New("record is nil")})
+			continue
+		}
+
+		if record.ID() == "" {
+			result.Failures = append(result.Failures, UpdateManyFailure{Index: i, Err: errors.New("record id is required")})
+			continue
+		}
+
+		if seen[record.ID()] {
+			result.Failures = append(result.Failures, UpdateManyFailure{Index: i, RecordID: record.ID(), Err: errors.New("record is listed more than once")})
+			continue
+		}
+
+		if err := st.runHooks(ctx, EventBeforeUpdate, record.ID(), record); err != nil {
+			result.Failures = append(result.Failures, UpdateManyFailure{Index: i, RecordID: record.ID(), Err: err})
+			continue
+		}
+
+		row := updateManyRow{record: record, index: i}
+
+		// Columns excluded when listing are left as stored
+		if record.IsPayloadLoaded() {
+			payload := record.Payload()
+			row.payload = &payload
+		}
+
+		if record.IsMetasLoaded() {
+			metas, err := record.Metas()
+			if err == nil {
+				var metasJSON []byte
+				metasJSON, err = json.Marshal(metas)
+				metasStr := string(metasJSON)
+				row.metas = &metasStr
+			}
+			if err != nil {
+				result.Failures = append(result.Failures, UpdateManyFailure{Index: i, RecordID: record.ID(), Err: err})
+				continue
+			}
+		}
+
+		seen[record.ID()] = true
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	updated := []updateManyRow{}
+	missing := []updateManyRow{}
+
+	err := st.trackChanges(ctx, ChangeUpdate, updatedIDs(rows), func(st *storeImplementation) error {
+		unlock := st.lockWrite()
+		defer unlock()
+
+		err := st.transaction(func(tx contractsorm.Query) error {
+			for start := 0; start < len(rows); start += updateManyChunkSize {
+				chunk := rows[start:min(start+updateManyChunkSize, len(rows))]
+
+				found, err := st.existingIDs(cloneQuery(ctx, tx), chunk)
+				if err != nil {
+					return err
+				}
+
+				existing := []updateManyRow{}
+				for _, row := range chunk {
+					if found[row.record.ID()] {
+						existing = append(existing, row)
+					} else {
+						missing = append(missing, row)
+					}
+				}
+
+				if len(existing) == 0 {
+					continue
+				}
+
+				sqlStr, args := st.updateManySQL(existing, now)
+				if _, err := st.exec(cloneQuery(ctx, tx), "RecordUpdateMany", sqlStr, args...); err != nil {
+					return st.wrapError(err, "RecordUpdateMany", "", "", func() string {
+						return sqlStr
+					})
+				}
+
+				updated = append(updated, existing...)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return st.copyToMigrationTarget(ctx, updatedIDs(updated))
+	})
+
+	if err != nil {
+		return UpdateManyResult{}, st.wrapError(err, "RecordUpdateMany", "", "", nil)
+	}
+
+	for _, row := range updated {
+		row.record.SetUpdatedAt(now)
+		row.record.SetVersion(row.record.Version() + 1)
+	}
+
+	for _, row := range missing {
+		result.Failures = append(result.Failures, UpdateManyFailure{Index: row.index, RecordID: row.record.ID(), Err: ErrNotFound})
+	}
+	sort.SliceStable(result.Failures, func(i, j int) bool {
+		return result.Failures[i].Index < result.Failures[j].Index
+	})
+
+	result.Updated = len(updated)
+
+	for _, row := range updated {
+		if err := st.runHooks(ctx, EventAfterUpdate, row.record.ID(), row.record); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// updatedIDs returns the record IDs of the rows
+func updatedIDs(rows []updateManyRow) []string {
+	ids := make([]string, len(rows))
+	for i, row := range rows {
+		ids[i] = row.record.ID()
+	}
+	return ids
+}
+
+// existingIDs returns which IDs of the rows are stored, soft deleted or not,
+// of the tenant the store is scoped to if any
+func (st *storeImplementation) existingIDs(q contractsorm.Query, rows []updateManyRow) (map[string]bool, error) {
+	ids := make([]any, len(rows))
+	for i, row := range rows {
+		ids[i] = row.record.ID()
+	}
+
+	q = st.whereTenant(q.Table(st.tableName()).Select(COLUMN_ID).WhereIn(COLUMN_ID, ids))
+
+	var found []map[string]any
+	start := time.Now()
+	err := q.Get(&found)
+	st.logQuery("RecordUpdateMany", start, func() (string, []any) {
+		return q.ToRawSql().Get(&found), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	existing := map[string]bool{}
+	for _, row := range found {
+		existing[cast.ToString(row[COLUMN_ID])] = true
+	}
+	return existing, nil
+}
+
+// updateManySQL returns the statement updating the rows, selecting the
+// value of every column by ID with CASE expressions
+func (st *storeImplementation) updateManySQL(rows []updateManyRow, now string) (string, []any) {
+	driver := st.driverName()
+
+	sets := []string{}
+	args := []any{}
+	caseColumn := func(column string, sqlType string, value func(row updateManyRow) (any, bool)) {
+		var sb strings.Builder
+		for _, row := range rows {
+			v, ok := value(row)
+			if !ok {
+				continue
+			}
+			sb.WriteString(" WHEN ? THEN " + typedPlaceholder(driver, sqlType))
+			args = append(args, row.record.ID(), v)
+		}
+		if sb.Len() == 0 {
+			// Not loaded for any of the records
+			return
+		}
+		sets = append(sets, column+" = CASE "+COLUMN_ID+sb.String()+" ELSE "+column+" END")
+	}
+
+	caseColumn(COLUMN_RECORD_TYPE, "", func(row updateManyRow) (any, bool) {
+		return row.record.Type(), true
+	})
+	caseColumn(COLUMN_MEMO, "", func(row updateManyRow) (any, bool) {
+		return row.record.Memo(), true
+	})
+	// Timestamps stored in a custom layout are strings
+	timestampType := "TIMESTAMP"
+	if st.timestampFormat.custom() {
+		timestampType = ""
+	}
+	caseColumn(COLUMN_EXPIRES_AT, timestampType, func(row updateManyRow) (any, bool) {
+		return st.expiresAtTimestamp(row.record), true
+	})
+	caseColumn(COLUMN_VERSION, "BIGINT", func(row updateManyRow) (any, bool) {
+		return row.record.Version() + 1, true
+	})
+	caseColumn(COLUMN_PAYLOAD, "", func(row updateManyRow) (any, bool) {
+		if row.payload == nil {
+			return nil, false
+		}
+		return *row.payload, true
+	})
+	caseColumn(COLUMN_METAS, "", func(row updateManyRow) (any, bool) {
+		if row.metas == nil {
+			return nil, false
+		}
+		return *row.metas, true
+	})
+
+	sets = append(sets, COLUMN_UPDATED_AT+" = ?")
+	args = append(args, st.datetimeTimestamp(now))
+
+	for _, row := range rows {
+		args = append(args, row.record.ID())
+	}
+
+	sqlStr := "UPDATE " + st.tableName() + " SET " + strings.Join(sets, ", ") +
+		" WHERE " + COLUMN_ID + " IN (" + placeholders(len(rows)) + ")"
+
+	return rebindPlaceholders(driver, sqlStr), args
+}
+
+// typedPlaceholder returns a placeholder for a value of the SQL type. The
+// values of a PostgreSQL CASE expression are typed as text unless cast.
+func typedPlaceholder(driver string, sqlType string) string {
+	if driver != "postgres" || sqlType == "" {
+		return "?"
+	}
+	return "CAST(? AS " + sqlType + ")"
+}
@@ -0,0 +1,130 @@
+package customstore_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordUpdateMany(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_update_many",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	// More records than fit in one statement chunk
+	records := []customstore.RecordInterface{}
+	for i := 0; i < 150; i++ {
+		record := customstore.NewRecord("product", customstore.WithPayload(`{"price":`+strconv.Itoa(i)+`}`))
+		if err := store.RecordCreate(record); err != nil {
+			t.Fatalf("RecordCreate failed: %v", err)
+		}
+		records = append(records, record)
+	}
+
+	for i, record := range records {
+		record.SetPayload(`{"price":` + strconv.Itoa(i*2) + `}`)
+		record.SetMemo("synced")
+		if err := record.SetMeta("source", "sync"); err != nil {
+			t.Fatalf("SetMeta failed: %v", err)
+		}
+	}
+
+	missing := customstore.NewRecord("product", customstore.WithPayload(`{"price":1}`))
+	input := append([]customstore.RecordInterface{}, records...)
+	input = append(input, nil, missing, records[0])
+
+	result, err := store.RecordUpdateMany(input)
+	if err != nil {
+		t.Fatalf("RecordUpdateMany failed: %v", err)
+	}
+	if result.Updated != 150 {
+		t.Fatalf("Expected 150 updated records, but got %d", result.Updated)
+	}
+	if len(result.Failures) != 3 {
+		t.Fatalf("Expected 3 failures, but got %d: %v", len(result.Failures), result.Failures)
+	}
+	if result.Failures[0].Index != 150 || result.Failures[0].Err == nil {
+		t.Fatalf("Expected the nil record to fail first, but got %+v", result.Failures[0])
+	}
+	if result.Failures[1].Index != 151 || result.Failures[1].RecordID != missing.ID() || !errors.Is(result.Failures[1].Err, customstore.ErrNotFound) {
+		t.Fatalf("Expected the missing record to fail with record not found, but got %+v", result.Failures[1])
+	}
+	if result.Failures[2].Index != 152 || result.Failures[2].RecordID != records[0].ID() {
+		t.Fatalf("Expected the duplicate record to fail, but got %+v", result.Failures[2])
+	}
+
+	for _, i := range []int{0, 99, 100, 149} {
+		if records[i].Version() != 2 {
+			t.Fatalf("Expected record %d to be at version 2, but got %d", i, records[i].Version())
+		}
+
+		stored, err := store.RecordFindByID(records[i].ID())
+		if err != nil || stored == nil {
+			t.Fatalf("RecordFindByID failed: %v", err)
+		}
+		if stored.Payload() != `{"price":`+strconv.Itoa(i*2)+`}` {
+			t.Fatalf("Expected record %d to have the updated payload, but got %s", i, stored.Payload())
+		}
+		if stored.Memo() != "synced" || stored.Meta("source") != "sync" {
+			t.Fatalf("Expected record %d to have the updated memo and metas, but got %q and %q", i, stored.Memo(), stored.Meta("source"))
+		}
+		if stored.Version() != 2 {
+			t.Fatalf("Expected stored record %d to be at version 2, but got %d", i, stored.Version())
+		}
+	}
+
+	exists, err := store.RecordFindByID(missing.ID())
+	if err != nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if exists != nil {
+		t.Fatalf("Expected the missing record not to be created")
+	}
+}
+
+func TestRecordUpdateManyExcludedPayload(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_update_many_excluded",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("product", customstore.WithPayload(`{"price":10}`))
+	if err := store.RecordCreate(record); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	list, err := store.RecordList(customstore.RecordQuery().SetType("product").SetExcludePayload(true))
+	if err != nil || len(list) != 1 {
+		t.Fatalf("RecordList failed: %v", err)
+	}
+
+	list[0].SetMemo("listed")
+	if _, err := store.RecordUpdateMany(list); err != nil {
+		t.Fatalf("RecordUpdateMany failed: %v", err)
+	}
+
+	stored, err := store.RecordFindByID(record.ID())
+	if err != nil || stored == nil {
+		t.Fatalf("RecordFindByID failed: %v", err)
+	}
+	if stored.Payload() != `{"price":10}` || stored.Memo() != "listed" {
+		t.Fatalf("Expected the payload to be kept and the memo updated, but got %q and %q", stored.Payload(), stored.Memo())
+	}
+}
@@ -0,0 +1,207 @@
+package customstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// upsertUpdateColumns are the columns overwritten when the upserted record
+// already exists. The created at is kept, and the stored version is
+// incremented.
+var upsertUpdateColumns = []string{
+	COLUMN_RECORD_TYPE,
+	COLUMN_PAYLOAD,
+	COLUMN_METAS,
+	COLUMN_MEMO,
+	COLUMN_UPDATED_AT,
+	COLUMN_SOFT_DELETED_AT,
+	COLUMN_EXPIRES_AT,
+}
+
+// RecordUpsert creates the record, or updates it if a record with the same ID
+// exists, in a single statement. Use it to idempotently sync external data
+// without a find then save race. The upserted record is active: a soft
+// deleted record with the ID is restored.
+func (st *storeImplementation) RecordUpsert(record RecordInterface) error {
+	return st.RecordUpsertContext(context.Background(), record)
+}
+
+// RecordUpsertContext is RecordUpsert using the given context
+func (st *storeImplementation) RecordUpsertContext(ctx context.Context, record RecordInterface) error {
+	if st.sharded() && record != nil {
+		return st.forType(record.Type()).RecordUpsertContext(ctx, record)
+	}
+
+	if err := st.checkWritable(); err != nil {
+		return err
+	}
+
+	if st.db == nil {
+		return errors.New("database is not initialized")
+	}
+
+	if record == nil {
+		return errors.New("record is nil")
+	}
+
+	st.assignID(record)
+	st.applyTTL(record)
+
+	if record.ID() == "" {
+		return errors.New("record ID is required")
+	}
+
+	if !record.IsPayloadLoaded() || !record.IsMetasLoaded() {
+		return ErrNotLoaded
+	}
+
+	// The hooks of the branch the upsert takes, as the record is stored or
+	// not before the write
+	beforeEvent, afterEvent := EventBeforeCreate, EventAfterCreate
+	if st.hasHooks(EventBeforeCreate, EventAfterCreate, EventBeforeUpdate, EventAfterUpdate) {
+		exists, err := st.hasID(ctx, record.ID())
+		if err != nil {
+			return err
+		}
+		if exists {
+			beforeEvent, afterEvent = EventBeforeUpdate, EventAfterUpdate
+		}
+	}
+
+	if err := st.runHooks(ctx, beforeEvent, record.ID(), record); err != nil {
+		return err
+	}
+
+	if err := st.checkRecordType(record); err != nil {
+		return err
+	}
+
+	now := st.nowDateTime()
+	record.SetUpdatedAt(now)
+	record.SetSoftDeletedAt(MAX_DATETIME)
+
+	if latest := st.latestPayloadVersion(record.Type()); latest > 0 && record.Meta(META_PAYLOAD_VERSION) == "" {
+		if err := record.SetPayloadVersion(latest); err != nil {
+			return err
+		}
+	}
+
+	metas, err := record.Metas()
+	if err != nil {
+		return err
+	}
+	metasJSON, err := json.Marshal(metas)
+	if err != nil {
+		return err
+	}
+
+	columns := []string{
+		COLUMN_ID,
+		COLUMN_RECORD_TYPE,
+		COLUMN_PAYLOAD,
+		COLUMN_METAS,
+		COLUMN_MEMO,
+		COLUMN_CREATED_AT,
+		COLUMN_UPDATED_AT,
+		COLUMN_SOFT_DELETED_AT,
+		COLUMN_VERSION,
+		COLUMN_EXPIRES_AT,
+	}
+	args := []any{
+		record.ID(),
+		record.Type(),
+		record.Payload(),
+		string(metasJSON),
+		record.Memo(),
+		st.datetimeTimestamp(now),
+		st.datetimeTimestamp(now),
+		st.timestamp(maxTime),
+		record.Version(),
+		st.expiresAtTimestamp(record),
+	}
+
+	// Stamped when inserted, the tenant of a stored record is not updated
+	if st.tenantID != "" {
+		columns = append(columns, COLUMN_TENANT_ID)
+		args = append(args, st.tenantID)
+	}
+
+	sqlStr := rebindPlaceholders(st.driverName(), upsertSQL(st.driverName(), st.tableName(), columns))
+
+	err = st.trackChanges(ctx, ChangeUpdate, []string{record.ID()}, func(st *storeImplementation) error {
+		unlock := st.lockWrite()
+		defer unlock()
+
+		if err := st.checkTenantIDs(ctx, st.newQuery(ctx), []string{record.ID()}, "RecordUpsert"); err != nil {
+			return err
+		}
+
+		if _, err := st.exec(st.newQuery(ctx), "RecordUpsert", sqlStr, args...); err != nil {
+			return st.duplicateOf(ctx, "RecordUpsert", st.wrapError(err, "RecordUpsert", record.ID(), record.Type(), func() string {
+				return sqlStr
+			}), record.ID(), record.Type(), record.Payload(), string(metasJSON))
+		}
+
+		return st.copyToMigrationTarget(ctx, []string{record.ID()})
+	})
+	if err != nil {
+		return err
+	}
+
+	return st.runHooks(ctx, afterEvent, record.ID(), record)
+}
+
+// upsertSQL returns the insert or update by ID statement for the driver,
+// with the column values as placeholders
+func upsertSQL(driver string, tableName string, columns []string) string {
+	return insertOrUpdateSQL(driver, tableName, columns, upsertUpdateColumns, true)
+}
+
+// insertOrUpdateSQL returns the statement inserting the columns, or
+// updating the given columns (and incrementing the version if
+// incrementVersion is true) of the record with the same ID, with the column
+// values as placeholders
+func insertOrUpdateSQL(driver string, tableName string, columns []string, updateColumns []string, incrementVersion bool) string {
+	columnList := strings.Join(columns, ", ")
+
+	set := make([]string, len(updateColumns), len(updateColumns)+1)
+	switch driver {
+	case "mysql":
+		for i, column := range updateColumns {
+			set[i] = column + " = VALUES(" + column + ")"
+		}
+		if incrementVersion {
+			set = append(set, COLUMN_VERSION+" = "+COLUMN_VERSION+" + 1")
+		}
+		return "INSERT INTO " + tableName + " (" + columnList + ") VALUES (" + placeholders(len(columns)) + ")" +
+			" ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+	case "sqlserver":
+		source := make([]string, len(columns))
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			source[i] = "? AS " + column
+			values[i] = "source." + column
+		}
+		for i, column := range updateColumns {
+			set[i] = "target." + column + " = source." + column
+		}
+		if incrementVersion {
+			set = append(set, "target."+COLUMN_VERSION+" = target."+COLUMN_VERSION+" + 1")
+		}
+		return "MERGE INTO " + tableName + " AS target USING (SELECT " + strings.Join(source, ", ") + ") AS source" +
+			" ON target." + COLUMN_ID + " = source." + COLUMN_ID +
+			" WHEN MATCHED THEN UPDATE SET " + strings.Join(set, ", ") +
+			" WHEN NOT MATCHED THEN INSERT (" + columnList + ") VALUES (" + strings.Join(values, ", ") + ");"
+	default:
+		for i, column := range updateColumns {
+			set[i] = column + " = excluded." + column
+		}
+		if incrementVersion {
+			set = append(set, COLUMN_VERSION+" = "+tableName+"."+COLUMN_VERSION+" + 1")
+		}
+		return "INSERT INTO " + tableName + " (" + columnList + ") VALUES (" + placeholders(len(columns)) + ")" +
+			" ON CONFLICT (" + COLUMN_ID + ") DO UPDATE SET " + strings.Join(set, ", ")
+	}
+}
@@ -0,0 +1,88 @@
+package customstore_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dracory/customstore"
+)
+
+func TestRecordUpsert(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_upsert",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	record := customstore.NewRecord("person",
+		customstore.WithID("external-1"),
+		customstore.WithMemo("John"),
+		customstore.WithPayloadMap(map[string]any{"name": "John"}))
+	if err := store.RecordUpsert(record); err != nil {
+		t.Fatalf("RecordUpsert insert failed: %v", err)
+	}
+
+	found, err := store.RecordFindByID("external-1")
+	if err != nil || found == nil {
+		t.Fatalf("Expected the upserted record to be created, but got %v, %v", found, err)
+	}
+	createdAt := found.CreatedAt()
+	if !strings.HasPrefix(createdAt, "20") || found.IsSoftDeleted() {
+		t.Fatalf("Expected a valid created at and an active record, but got %q", createdAt)
+	}
+
+	update := customstore.NewRecord("person",
+		customstore.WithID("external-1"),
+		customstore.WithMemo("Johnny"),
+		customstore.WithPayloadMap(map[string]any{"name": "Johnny"}),
+		customstore.WithMetas(map[string]string{"source": "crm"}))
+	if err := store.RecordUpsert(update); err != nil {
+		t.Fatalf("RecordUpsert update failed: %v", err)
+	}
+
+	count, err := store.RecordCount(customstore.RecordQuery().SetType("person"))
+	if err != nil {
+		t.Fatalf("RecordCount failed: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 record after upserting twice, but got %d", count)
+	}
+
+	found, err = store.RecordFindByID("external-1")
+	if err != nil || found == nil {
+		t.Fatalf("RecordFindByID failed: %v, %v", found, err)
+	}
+	if found.Memo() != "Johnny" || found.PayloadString("name", "") != "Johnny" || found.Meta("source") != "crm" {
+		t.Fatalf("Expected the record to be updated, but got memo %q payload %q", found.Memo(), found.Payload())
+	}
+	if found.CreatedAt() != createdAt {
+		t.Fatalf("Expected created at %q to be kept, but got %q", createdAt, found.CreatedAt())
+	}
+	if found.Version() != 2 {
+		t.Fatalf("Expected the upsert update to increment the version to 2, but got %d", found.Version())
+	}
+
+	// Upserting over a soft deleted record restores it
+	if err := store.RecordSoftDeleteByID("external-1"); err != nil {
+		t.Fatalf("RecordSoftDeleteByID failed: %v", err)
+	}
+	if err := store.RecordUpsert(customstore.NewRecord("person",
+		customstore.WithID("external-1"),
+		customstore.WithMemo("Revived"))); err != nil {
+		t.Fatalf("RecordUpsert over a soft deleted record failed: %v", err)
+	}
+	found, err = store.RecordFindByID("external-1")
+	if err != nil || found == nil || found.Memo() != "Revived" || found.IsSoftDeleted() {
+		t.Fatalf("Expected the soft deleted record to be restored by the upsert, but got %v, %v", found, err)
+	}
+
+	if err := store.RecordUpsert(customstore.NewRecord("person", customstore.WithID(""))); err == nil {
+		t.Fatalf("Expected error upserting a record without ID, but got nil")
+	}
+}
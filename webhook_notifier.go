@@ -0,0 +1,424 @@
+package customstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	neatuid "github.com/dracory/neat/support/uid"
+)
+
+// ============================================================================
+// == INTERFACE
+// ============================================================================
+
+// WebhookNotifierInterface posts the record changes of a store to webhook
+// endpoints, see NewWebhookNotifier
+type WebhookNotifierInterface interface {
+	// Run subscribes to the store changes and delivers them until the
+	// context is cancelled
+	Run(ctx context.Context) error
+
+	// Stats returns the delivery counters
+	Stats() WebhookNotifierStats
+}
+
+// WebhookNotifierStats holds the delivery metrics
+type WebhookNotifierStats struct {
+	// Delivered is the number of envelopes accepted by an endpoint
+	Delivered int64
+
+	// Retried is the number of failed attempts which were retried
+	Retried int64
+
+	// Failed is the number of envelopes given up on after the last attempt,
+	// or rejected by an endpoint with a 4xx status
+	Failed int64
+
+	// Dropped is the number of envelopes not queued as the queue of the
+	// endpoint was full
+	Dropped int64
+}
+
+// WebhookEnvelope is the JSON body posted to the webhook endpoints
+type WebhookEnvelope struct {
+	// ID identifies the delivery, the same for every attempt
+	ID string `json:"id"`
+
+	// Event is the change type, i.e. "create", "update", "soft_delete" or
+	// "delete"
+	Event ChangeType `json:"event"`
+
+	// RecordID is the ID of the changed record
+	RecordID string `json:"record_id"`
+
+	// RecordType is the type of the changed record
+	RecordType string `json:"record_type"`
+
+	// OccurredAt is the time of the change (UTC, RFC 3339)
+	OccurredAt string `json:"occurred_at"`
+
+	// Old is the record before the change, nil for creates
+	Old *WebhookRecord `json:"old"`
+
+	// New is the record after the change, nil for deletes
+	New *WebhookRecord `json:"new"`
+}
+
+// WebhookRecord is a record snapshot of a WebhookEnvelope
+type WebhookRecord struct {
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	Payload       string            `json:"payload"`
+	Metas         map[string]string `json:"metas"`
+	Memo          string            `json:"memo"`
+	CreatedAt     string            `json:"created_at"`
+	UpdatedAt     string            `json:"updated_at"`
+	SoftDeletedAt string            `json:"soft_deleted_at"`
+	ExpiresAt     string            `json:"expires_at"`
+	Version       int64             `json:"version"`
+}
+
+// Webhook request headers
+const (
+	WEBHOOK_HEADER_DELIVERY  = "X-Customstore-Delivery"
+	WEBHOOK_HEADER_TIMESTAMP = "X-Customstore-Timestamp"
+	WEBHOOK_HEADER_SIGNATURE = "X-Customstore-Signature"
+)
+
+// WEBHOOK_ALL_TYPES is the WebhookNotifierOptions.Endpoints key of the
+// endpoints notified of the changes of every record type
+const WEBHOOK_ALL_TYPES = "*"
+
+// ============================================================================
+// == TYPE
+// ============================================================================
+
+var _ WebhookNotifierInterface = (*webhookNotifierImplementation)(nil)
+
+type webhookNotifierImplementation struct {
+	store   StoreInterface
+	options WebhookNotifierOptions
+
+	mu    sync.Mutex
+	stats WebhookNotifierStats
+}
+
+// webhookDelivery is an envelope queued for an endpoint
+type webhookDelivery struct {
+	id   string
+	body []byte
+}
+
+// ============================================================================
+// == CONSTRUCTOR
+// ============================================================================
+
+// WebhookNotifierOptions define the options for creating a new webhook
+// notifier
+type WebhookNotifierOptions struct {
+	// Endpoints maps record types to the URLs notified of their changes,
+	// with WEBHOOK_ALL_TYPES for the URLs notified of every change
+	Endpoints map[string][]string
+
+	// Secret signs the envelopes, see WebhookSignature
+	Secret string
+
+	// Client sends the requests, defaults to a client with a 10 seconds
+	// timeout
+	Client *http.Client
+
+	// MaxAttempts is the number of attempts per envelope, defaults to 5
+	MaxAttempts int
+
+	// Backoff is the wait before the first retry, doubled for every
+	// further retry up to MaxBackoff. Defaults to 1 second.
+	Backoff time.Duration
+
+	// MaxBackoff caps the wait between retries, defaults to 1 minute
+	MaxBackoff time.Duration
+
+	// QueueSize is the number of envelopes queued per endpoint, further
+	// envelopes are dropped while the endpoint is behind. Defaults to 1000.
+	QueueSize int
+
+	Logger *slog.Logger
+}
+
+// NewWebhookNotifier creates a notifier posting a signed WebhookEnvelope to
+// the endpoints of the record type of every change made through the store
+// (see Changes), once Run is called.
+//
+// Every endpoint is delivered to in order by its own worker, so a slow or
+// failing endpoint neither delays the others nor the store writes. Network
+// errors, 429 and 5xx responses are retried with exponential backoff.
+func NewWebhookNotifier(store StoreInterface, opts WebhookNotifierOptions) (WebhookNotifierInterface, error) {
+	if store == nil {
+		return nil, errors.New("customstore webhook notifier: store is required")
+	}
+
+	if len(opts.Endpoints) == 0 {
+		return nil, errors.New("customstore webhook notifier: endpoints are required")
+	}
+
+	if opts.Secret == "" {
+		return nil, errors.New("customstore webhook notifier: secret is required")
+	}
+
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+
+	if opts.Backoff <= 0 {
+		opts.Backoff = time.Second
+	}
+
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Minute
+	}
+
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1000
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = slog.New(slog.NewTextHandler(os.Stdout, nil))
+	}
+
+	return &webhookNotifierImplementation{
+		store:   store,
+		options: opts,
+	}, nil
+}
+
+// WebhookSignature returns the signature of a webhook request, sent in the
+// X-Customstore-Signature header as "sha256=" followed by the hex encoded
+// HMAC-SHA256 of the timestamp header, a dot and the body. Receivers
+// recompute it to verify the request.
+func WebhookSignature(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ============================================================================
+// == METHODS
+// ============================================================================
+
+func (n *webhookNotifierImplementation) Run(ctx context.Context) error {
+	queues := map[string]chan webhookDelivery{}
+	var workers sync.WaitGroup
+
+	for _, urls := range n.options.Endpoints {
+		for _, url := range urls {
+			if _, exists := queues[url]; exists {
+				continue
+			}
+
+			queue := make(chan webhookDelivery, n.options.QueueSize)
+			queues[url] = queue
+
+			workers.Add(1)
+			go func(url string) {
+				defer workers.Done()
+				for delivery := range queue {
+					n.deliver(ctx, url, delivery)
+				}
+			}(url)
+		}
+	}
+
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		workers.Wait()
+	}()
+
+	for change := range n.store.Changes(ctx, n.options.QueueSize) {
+		urls := n.endpointsFor(change)
+		if len(urls) == 0 {
+			continue
+		}
+
+		delivery, err := newWebhookDelivery(change)
+		if err != nil {
+			n.options.Logger.Error("Webhook envelope encoding failed", "id", change.RecordID, "error", err)
+			continue
+		}
+
+		for _, url := range urls {
+			select {
+			case queues[url] <- delivery:
+			default:
+				n.count(func(stats *WebhookNotifierStats) { stats.Dropped++ })
+				n.options.Logger.Error("Webhook queue full, envelope dropped", "url", url, "delivery", delivery.id)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (n *webhookNotifierImplementation) Stats() WebhookNotifierStats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.stats
+}
+
+// endpointsFor returns the URLs to notify of the change, without duplicates
+func (n *webhookNotifierImplementation) endpointsFor(change ChangeEvent) []string {
+	record := change.New
+	if record == nil {
+		record = change.Old
+	}
+	if record == nil {
+		return nil
+	}
+
+	urls := []string{}
+	seen := map[string]bool{}
+	for _, key := range []string{record.Type(), WEBHOOK_ALL_TYPES} {
+		for _, url := range n.options.Endpoints[key] {
+			if !seen[url] {
+				seen[url] = true
+				urls = append(urls, url)
+			}
+		}
+	}
+	return urls
+}
+
+// deliver posts the delivery to the URL, retrying with exponential backoff
+func (n *webhookNotifierImplementation) deliver(ctx context.Context, url string, delivery webhookDelivery) {
+	backoff := n.options.Backoff
+
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(ctx, url, delivery)
+		if err == nil {
+			n.count(func(stats *WebhookNotifierStats) { stats.Delivered++ })
+			return
+		}
+
+		if !retry || attempt >= n.options.MaxAttempts || ctx.Err() != nil {
+			n.count(func(stats *WebhookNotifierStats) { stats.Failed++ })
+			n.options.Logger.Error("Webhook delivery failed", "url", url, "delivery", delivery.id, "attempts", attempt, "error", err)
+			return
+		}
+
+		n.count(func(stats *WebhookNotifierStats) { stats.Retried++ })
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, n.options.MaxBackoff)
+	}
+}
+
+// post sends one attempt of the delivery, returning whether a failure is
+// worth retrying
+func (n *webhookNotifierImplementation) post(ctx context.Context, url string, delivery webhookDelivery) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(delivery.body))
+	if err != nil {
+		return false, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(WEBHOOK_HEADER_DELIVERY, delivery.id)
+	request.Header.Set(WEBHOOK_HEADER_TIMESTAMP, timestamp)
+	request.Header.Set(WEBHOOK_HEADER_SIGNATURE, WebhookSignature(n.options.Secret, timestamp, delivery.body))
+
+	response, err := n.options.Client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+	return retry, errors.New("webhook endpoint responded with status " + strconv.Itoa(response.StatusCode))
+}
+
+// count updates the stats under the lock
+func (n *webhookNotifierImplementation) count(fn func(stats *WebhookNotifierStats)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	fn(&n.stats)
+}
+
+// newWebhookDelivery encodes the envelope of the change
+func newWebhookDelivery(change ChangeEvent) (webhookDelivery, error) {
+	envelope := WebhookEnvelope{
+		ID:         neatuid.GenerateShortID(),
+		Event:      change.Type,
+		RecordID:   change.RecordID,
+		OccurredAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var err error
+	if envelope.Old, err = newWebhookRecord(change.Old); err != nil {
+		return webhookDelivery{}, err
+	}
+	if envelope.New, err = newWebhookRecord(change.New); err != nil {
+		return webhookDelivery{}, err
+	}
+
+	if envelope.New != nil {
+		envelope.RecordType = envelope.New.Type
+	} else if envelope.Old != nil {
+		envelope.RecordType = envelope.Old.Type
+	}
+
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return webhookDelivery{}, err
+	}
+
+	return webhookDelivery{id: envelope.ID, body: body}, nil
+}
+
+// newWebhookRecord returns the snapshot of the record, nil if nil
+func newWebhookRecord(record RecordInterface) (*WebhookRecord, error) {
+	if record == nil {
+		return nil, nil
+	}
+
+	metas, err := record.Metas()
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebhookRecord{
+		ID:            record.ID(),
+		Type:          record.Type(),
+		Payload:       record.Payload(),
+		Metas:         metas,
+		Memo:          record.Memo(),
+		CreatedAt:     record.CreatedAt(),
+		UpdatedAt:     record.UpdatedAt(),
+		SoftDeletedAt: record.SoftDeletedAt(),
+		ExpiresAt:     record.ExpiresAt(),
+		Version:       record.Version(),
+	}, nil
+}
@@ -0,0 +1,216 @@
+package customstore_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dracory/customstore"
+)
+
+func TestWebhookNotifier(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_webhooks",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	var mu sync.Mutex
+	envelopes := []customstore.WebhookEnvelope{}
+	attempts := map[string]int{}
+	received := make(chan struct{}, 10)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		signature := customstore.WebhookSignature("secret", r.Header.Get(customstore.WEBHOOK_HEADER_TIMESTAMP), body)
+		if r.Header.Get(customstore.WEBHOOK_HEADER_SIGNATURE) != signature {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		// The first attempt of every delivery fails, to be retried
+		delivery := r.Header.Get(customstore.WEBHOOK_HEADER_DELIVERY)
+		attempts[delivery]++
+		if attempts[delivery] == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		envelope := customstore.WebhookEnvelope{}
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if envelope.ID != delivery {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		envelopes = append(envelopes, envelope)
+		received <- struct{}{}
+	}))
+	defer server.Close()
+
+	notifier, err := customstore.NewWebhookNotifier(store, customstore.WebhookNotifierOptions{
+		Endpoints: map[string][]string{"person": {server.URL}},
+		Secret:    "secret",
+		Backoff:   10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- notifier.Run(ctx) }()
+
+	// Give Run the time to subscribe to the changes
+	time.Sleep(50 * time.Millisecond)
+
+	// Changes of other record types are not posted
+	if err := store.RecordCreate(customstore.NewRecord("invoice")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	person := customstore.NewRecord("person", customstore.WithPayload(`{"name":"Ada"}`))
+	if err := store.RecordCreate(person); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+	if err := store.RecordDeleteByID(person.ID()); err != nil {
+		t.Fatalf("RecordDeleteByID failed: %v", err)
+	}
+
+	for range 2 {
+		select {
+		case <-received:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected 2 envelopes, but got %d", len(envelopes))
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for notifier.Stats().Delivered < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(envelopes) != 2 {
+		t.Fatalf("Expected 2 envelopes, but got %d", len(envelopes))
+	}
+
+	created := envelopes[0]
+	if created.Event != customstore.ChangeCreate || created.RecordID != person.ID() || created.RecordType != "person" {
+		t.Fatalf("Unexpected create envelope %+v", created)
+	}
+	if created.Old != nil || created.New == nil || created.New.Payload != `{"name":"Ada"}` {
+		t.Fatalf("Expected the new record of the create envelope, but got %+v", created.New)
+	}
+
+	deleted := envelopes[1]
+	if deleted.Event != customstore.ChangeDelete || deleted.Old == nil || deleted.New != nil {
+		t.Fatalf("Unexpected delete envelope %+v", deleted)
+	}
+
+	stats := notifier.Stats()
+	if stats.Delivered != 2 || stats.Retried != 2 || stats.Failed != 0 || stats.Dropped != 0 {
+		t.Fatalf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestWebhookNotifierGivesUp(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_webhooks_failing",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	notifier, err := customstore.NewWebhookNotifier(store, customstore.WebhookNotifierOptions{
+		Endpoints: map[string][]string{customstore.WEBHOOK_ALL_TYPES: {server.URL}},
+		Secret:    "secret",
+		Backoff:   10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go notifier.Run(ctx)
+	time.Sleep(50 * time.Millisecond)
+
+	if err := store.RecordCreate(customstore.NewRecord("invoice")); err != nil {
+		t.Fatalf("RecordCreate failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for notifier.Stats().Failed == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A 4xx response is not retried
+	mu.Lock()
+	defer mu.Unlock()
+	if stats := notifier.Stats(); stats.Failed != 1 || stats.Retried != 0 || requests != 1 {
+		t.Fatalf("Expected one rejected attempt, but got %+v after %d requests", stats, requests)
+	}
+}
+
+func TestNewWebhookNotifierValidation(t *testing.T) {
+	db := InitDB()
+	defer db.Close()
+
+	store, err := customstore.NewStore(customstore.NewStoreOptions{
+		DB:                 db,
+		TableName:          "data_webhooks_validation",
+		AutomigrateEnabled: true,
+	})
+	if err != nil {
+		t.Fatalf("Store could not be created: %v", err)
+	}
+
+	if _, err := customstore.NewWebhookNotifier(store, customstore.WebhookNotifierOptions{Secret: "secret"}); err == nil {
+		t.Fatalf("Expected error for missing endpoints, but got nil")
+	}
+
+	endpoints := map[string][]string{"person": {"http://localhost"}}
+	if _, err := customstore.NewWebhookNotifier(store, customstore.WebhookNotifierOptions{Endpoints: endpoints}); err == nil {
+		t.Fatalf("Expected error for a missing secret, but got nil")
+	}
+}